@@ -14,7 +14,9 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis"
+	operatorconfig "github.com/dev4devs-com/postgresql-operator/pkg/config"
 	"github.com/dev4devs-com/postgresql-operator/pkg/controller"
+	"github.com/dev4devs-com/postgresql-operator/pkg/webhook"
 	"github.com/dev4devs-com/postgresql-operator/version"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
@@ -38,6 +40,7 @@ var (
 	metricsHost               = "0.0.0.0"
 	metricsPort         int32 = 8383
 	operatorMetricsPort int32 = 8686
+	webhookPort               = 9443
 )
 var log = logf.Log.WithName("cmd")
 
@@ -77,6 +80,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Load the default values of the CRs from the configuration file of the operator when it exists
+	configPath := operatorconfig.GetOperatorConfigPath()
+	if err := operatorconfig.LoadOperatorConfig(configPath); err != nil {
+		log.Error(err, "Failed to load the configuration file", "Path", configPath)
+		os.Exit(1)
+	}
+
 	// Get a config to talk to the apiserver
 	cfg, err := config.GetConfig()
 	if err != nil {
@@ -96,6 +106,7 @@ func main() {
 	options := manager.Options{
 		Namespace:          namespace,
 		MetricsBindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort),
+		Port:               webhookPort,
 	}
 
 	// Add support for MultiNamespace set in WATCH_NAMESPACE (e.g ns1,ns2)
@@ -128,6 +139,23 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup the mutating and validating webhooks when they are enabled since they require the certificate of the server
+	// mounted in the CertDir of the manager (/tmp/k8s-webhook-server/serving-certs)
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err := webhook.AddToManager(mgr); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
+	// Reload the configuration file when it changes
+	if err := mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		return operatorconfig.WatchOperatorConfig(configPath, stop)
+	})); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
 	// Add the Metrics Service
 	addMetrics(ctx, cfg)
 
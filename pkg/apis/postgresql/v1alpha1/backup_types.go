@@ -115,6 +115,179 @@ type BackupSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Gpg trust model:"
 	GpgTrustModel string `json:"gpgTrustModel,omitempty"`
+
+	// Value used to request an on-demand backup. Each time that it is changed a Job is created immediately from the
+	// same template of the CronJob. (E.g. "before-migration-01")
+	// Default Value: nil
+	// NOTE: The annotation postgresql.dev4devs.com/backup-trigger can be used instead of this spec
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="On-demand backup trigger"
+	Trigger string `json:"trigger,omitempty"`
+
+	// Retention policy of the dumps stored in the AWS S3 bucket. The expired dumps are deleted after each successful backup.
+	// Default Value: nil
+	// NOTE: If it be not informed then the dumps are never deleted
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Retention policy"
+	Retention *BackupRetention `json:"retention,omitempty"`
+
+	// Setup of the periodic base backups which allow the point-in-time recovery with the WAL segments shipped by the
+	// Database with the walArchiving enabled
+	// Default Value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="WAL Archiving"
+	WalArchiving *BackupWalArchiving `json:"walArchiving,omitempty"`
+
+	// Storage backend where the dumps, base backups and WAL segments are stored. (E.g. S3-compatible services as
+	// MinIO, Google Cloud Storage, Azure Blob or a PVC)
+	// Default Value: nil
+	// NOTE: If it be not informed then the AWS S3 specs above are used and the upload is done by the backup image
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Storage backend"
+	Storage *BackupStorage `json:"storage,omitempty"`
+}
+
+// BackupStorage defines the backend where the backups are stored
+// The keys of the secret with the credentials are validated according to the type:
+// - s3: AWS_S3_BUCKET_NAME, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and the optional AWS_CA_BUNDLE
+// - gcs: GCS_BUCKET_NAME, GCS_ACCESS_KEY_ID and GCS_SECRET_ACCESS_KEY (HMAC key of a service account)
+// - azure: AZURE_STORAGE_ACCOUNT, AZURE_STORAGE_CONTAINER and AZURE_STORAGE_SAS_TOKEN
+// - volume: none secret is used
+// +k8s:openapi-gen=true
+type BackupStorage struct {
+	// Type of the storage backend. Options: s3, gcs, azure or volume
+	// Default Value: s3
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Type"
+	Type string `json:"type,omitempty"`
+
+	// Name of the secret with the credentials of the storage pre-existing in the cluster
+	// Default Value: The AWS secret when the type is s3
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Secret name"
+	SecretName string `json:"secretName,omitempty"`
+
+	// Namespace of the secret with the credentials of the storage pre-existing in the cluster
+	// Default Value: The namespace of the Backup CR
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Secret namespace"
+	SecretNamespace string `json:"secretNamespace,omitempty"`
+
+	// Options of the S3-compatible services (E.g. MinIO)
+	// Default Value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="S3 options"
+	S3 *BackupStorageS3 `json:"s3,omitempty"`
+
+	// PVC where the backups are written when the type is volume
+	// Default Value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Volume"
+	Volume *BackupStorageVolume `json:"volume,omitempty"`
+
+	// Compression of the dumps written in the storage. Options: gzip or none
+	// Default Value: gzip
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Compression"
+	Compression string `json:"compression,omitempty"`
+}
+
+// BackupStorageS3 defines the options used to access S3-compatible services
+// +k8s:openapi-gen=true
+type BackupStorageS3 struct {
+	// URL of the service (E.g. https://minio.example.com:9000)
+	// Default Value: AWS S3
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Endpoint URL"
+	EndpointURL string `json:"endpointURL,omitempty"`
+
+	// Region of the bucket
+	// Default Value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Region"
+	Region string `json:"region,omitempty"`
+
+	// When true the bucket is addressed in the path of the URL instead of the host name. It is required by MinIO.
+	// Default Value: false
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Path style"
+	ForcePathStyle bool `json:"forcePathStyle,omitempty"`
+}
+
+// BackupStorageVolume defines the PVC used as storage of the backups
+// +k8s:openapi-gen=true
+type BackupStorageVolume struct {
+	// Name of the PVC pre-existing in the namespace of the Backup CR. It should be ReadWriteMany when the Jobs
+	// can run in different nodes.
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Claim name"
+	ClaimName string `json:"claimName"`
+}
+
+// BackupWalArchiving defines the base backups and the storage of the WAL segments of the Database
+// NOTE: The base backups are taken with pg_basebackup which requires a user with the REPLICATION privilege. The
+// replication user is used when the replication of the Database is enabled, otherwise the database user.
+// +k8s:openapi-gen=true
+type BackupWalArchiving struct {
+	// When true the base backups are taken and the Database can ship its WAL segments to the AWS S3 bucket
+	// Default Value: false
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Enabled"
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Schedule of the CronJob which takes the base backups
+	// Default Value: 0 0 * * 0
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Base Backup Schedule"
+	BaseBackupSchedule string `json:"baseBackupSchedule,omitempty"`
+}
+
+// BackupRetention defines which dumps of the database are kept in the AWS S3 bucket
+// NOTE: A dump is kept when it matches any of the keep rules and it is not older than the max age. When none keep rule is
+// informed only the max age is used.
+// +k8s:openapi-gen=true
+type BackupRetention struct {
+	// Quantity of the latest dumps which are kept
+	// Default Value: 0
+	KeepLast int32 `json:"keepLast,omitempty"`
+
+	// Quantity of days which the latest dump of the day is kept
+	// Default Value: 0
+	KeepDaily int32 `json:"keepDaily,omitempty"`
+
+	// Quantity of weeks which the latest dump of the week is kept
+	// Default Value: 0
+	KeepWeekly int32 `json:"keepWeekly,omitempty"`
+
+	// Quantity of months which the latest dump of the month is kept
+	// Default Value: 0
+	KeepMonthly int32 `json:"keepMonthly,omitempty"`
+
+	// Quantity of days after which the dumps are deleted even if they match a keep rule
+	// Default Value: 0 (No max age)
+	MaxAgeDays int32 `json:"maxAgeDays,omitempty"`
+}
+
+// OnDemandBackupStatus defines the observed state of the latest on-demand backup
+// +k8s:openapi-gen=true
+type OnDemandBackupStatus struct {
+	// Value of the trigger which requested the backup
+	Trigger string `json:"trigger"`
+
+	// Name of the Job object created to do the backup
+	JobName string `json:"jobName"`
+
+	// Phase of the backup. Options: Running, Succeeded or Failed
+	Phase string `json:"phase"`
+
+	// Time when the backup started
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Time when the backup finished
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message of the error when the backup failed
+	Error string `json:"error,omitempty"`
 }
 
 // BackupStatus defines the observed state of Backup
@@ -178,6 +351,121 @@ type BackupStatus struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="v1beta1.CronJobStatus"
 	CronJobStatus v1beta1.CronJobStatus `json:"cronJobStatus"`
+
+	// Outcome of the latest on-demand backup requested by the trigger
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="On-demand Backup"
+	OnDemandBackup *OnDemandBackupStatus `json:"onDemandBackup,omitempty"`
+
+	// Outcome of the latest pruning of the dumps done by the retention policy
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Retention"
+	Retention *BackupRetentionStatus `json:"retention,omitempty"`
+
+	// Outcome of the latest base backup taken for the point-in-time recovery
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="WAL Archiving"
+	WalArchiving *BackupWalArchivingStatus `json:"walArchiving,omitempty"`
+
+	// Dumps of the database found in the storage by the latest backup from the newest to the oldest
+	// NOTE: Just the 30 newest dumps are listed and it is only filled when the storage is informed or the retention is enabled
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Dumps"
+	Dumps []BackupDump `json:"dumps,omitempty"`
+
+	// Latest runs of the backup (scheduled by the CronJob or on-demand) from the newest to the oldest
+	// NOTE: Just the 10 newest runs are kept
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Runs"
+	Runs []BackupRun `json:"runs,omitempty"`
+
+	// Time when the latest successful backup finished
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Last Successful Backup Time"
+	LastSuccessfulBackupTime *metav1.Time `json:"lastSuccessfulBackupTime,omitempty"`
+
+	// Reason of the latest backup which failed
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Last Failure Reason"
+	LastFailureReason string `json:"lastFailureReason,omitempty"`
+
+	// Quantity of backups which failed since the latest successful one
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Consecutive Failures"
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+
+	// Conditions of the Backup: Ready and Failing
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Conditions"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// BackupRun defines the outcome of one Job which did the backup
+// +k8s:openapi-gen=true
+type BackupRun struct {
+	// Name of the Job
+	JobName string `json:"jobName"`
+
+	// Time when the Job started
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Time when the Job finished
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Time took by the Job to finish (E.g. 1m30s)
+	Duration string `json:"duration,omitempty"`
+
+	// Result of the Job: Running, Succeeded or Failed
+	Result string `json:"result"`
+
+	// Key of the dump uploaded to the storage when the storage is informed
+	Artifact string `json:"artifact,omitempty"`
+
+	// Size in bytes of the dump uploaded to the storage when the storage is informed
+	Size int64 `json:"size,omitempty"`
+
+	// Reason of the failure when the Job failed
+	Reason string `json:"reason,omitempty"`
+}
+
+// BackupDump defines a dump of the database found in the storage
+// +k8s:openapi-gen=true
+type BackupDump struct {
+	// Key of the dump in the storage which can be informed in the objectKey of the Restore CR
+	Key string `json:"key"`
+
+	// Date and time (UTC) when the dump was written in the storage (E.g. 2020-07-06 00:00)
+	LastModified string `json:"lastModified"`
+}
+
+// BackupWalArchivingStatus defines the observed state of the base backups
+// +k8s:openapi-gen=true
+type BackupWalArchivingStatus struct {
+	// Name of the CronJob which takes the base backups
+	BaseBackupCronJobName string `json:"baseBackupCronJobName,omitempty"`
+
+	// Key of the latest base backup uploaded to the AWS S3 bucket
+	LastBaseBackup string `json:"lastBaseBackup,omitempty"`
+
+	// Time when the latest base backup finished
+	LastBaseBackupTime *metav1.Time `json:"lastBaseBackupTime,omitempty"`
+}
+
+// BackupRetentionStatus defines the observed state of the latest pruning of the dumps
+// +k8s:openapi-gen=true
+type BackupRetentionStatus struct {
+	// Time when the latest pruning finished
+	LastPruneTime *metav1.Time `json:"lastPruneTime,omitempty"`
+
+	// Quantity of dumps kept in the latest pruning
+	KeptArtifacts int32 `json:"keptArtifacts"`
+
+	// Quantity of dumps deleted in the latest pruning
+	DeletedArtifacts int32 `json:"deletedArtifacts"`
+
+	// Error found in the result of the latest pruning
+	Error string `json:"error,omitempty"`
 }
 
 // Backup is the Schema for the backups API
@@ -186,9 +474,13 @@ type BackupStatus struct {
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=backups,scope=Namespaced
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Last Success",type="date",JSONPath=".status.lastSuccessfulBackupTime"
+// +kubebuilder:printcolumn:name="Failures",type="integer",JSONPath=".status.consecutiveFailures"
 // +kubebuilder:subresource:status
 // +operator-sdk:gen-csv:customresourcedefinitions.displayName="Database Backup"
 // +operator-sdk:gen-csv:customresourcedefinitions.resources="CronJob,v1beta1,\"A Kubernetes Deployment\""
+// +operator-sdk:gen-csv:customresourcedefinitions.resources="Job,v1,\"A Kubernetes Job\""
 // +operator-sdk:gen-csv:customresourcedefinitions.resources="Service,v1,\"A Kubernetes Service\""
 // +operator-sdk:gen-csv:customresourcedefinitions.resources="PersistentVolumeClaim,v1,\"A Kubernetes PersistentVolumeClaim\""
 type Backup struct {
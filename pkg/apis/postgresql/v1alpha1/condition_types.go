@@ -0,0 +1,45 @@
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Types of the conditions reported in the status of the CRs
+const (
+	// ConditionProvisioned is True when all objects required by the Database were created
+	ConditionProvisioned = "Provisioned"
+	// ConditionReady is True when the primary of the Database is ready to accept connections or when all objects of the
+	// Backup were created and its latest backup did not fail
+	ConditionReady = "Ready"
+	// ConditionDegraded is True when the Database is serving with less members than expected or the WAL archiving is failing
+	ConditionDegraded = "Degraded"
+	// ConditionBackupConfigured is True when a Backup CR is configured for the Database
+	ConditionBackupConfigured = "BackupConfigured"
+	// ConditionFailing is True when the latest backup failed
+	ConditionFailing = "Failing"
+	// ConditionStorageResized is True when the PersistentVolumeClaims of the Database have the storage of the spec
+	ConditionStorageResized = "StorageResized"
+)
+
+// Condition describes one aspect of the current state of the CR in the same format of the metav1.Condition
+// +k8s:openapi-gen=true
+type Condition struct {
+	// Type of the condition (E.g. Ready)
+	Type string `json:"type"`
+
+	// Status of the condition, one of True, False or Unknown
+	Status v1.ConditionStatus `json:"status"`
+
+	// Generation of the CR when the condition was set
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Last time when the condition changed from one status to another
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// Reason in CamelCase for the last transition of the condition
+	Reason string `json:"reason"`
+
+	// Human readable message with details about the last transition
+	Message string `json:"message,omitempty"`
+}
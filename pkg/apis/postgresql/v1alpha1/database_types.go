@@ -23,7 +23,9 @@ type DatabaseSpec struct {
 	DatabaseName string `json:"databaseName,omitempty"`
 
 	// Value for the Database Environment Variable (spec.databasePasswordKeyEnvVar).
-	// Default value: postgres
+	// When it, the configMapName and the credentialsSecretName are not informed the operator generates a random
+	// password in the Secret <name>-credentials.
+	// Default value: nil
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Database Password"
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:password"
@@ -60,6 +62,8 @@ type DatabaseSpec struct {
 	DatabasePort int32 `json:"databasePort,omitempty"`
 
 	// Quantity of instances
+	// When the replication is enabled it is the total of instances (primary + standbys). Otherwise, only 1 is allowed
+	// since all replicas would mount the same volume.
 	// Default value: 1
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
 	Size int32 `json:"size,omitempty"`
@@ -70,6 +74,13 @@ type DatabaseSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Image:tag"
 	Image string `json:"image,omitempty"`
 
+	// Major version of PostgreSQL run by the image (E.g. 9.6 or 12). When it is changed together with the image, the
+	// operator upgrades the data of the Database to the new major version before running the new image.
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="PostgreSQL Version"
+	PostgresVersion string `json:"postgresVersion,omitempty"`
+
 	// Name to create the Database container
 	ContainerName string `json:"containerName,omitempty"`
 
@@ -141,6 +152,370 @@ type DatabaseSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="ConfigMap User Key"
 	ConfigMapDatabaseUserKey string `json:"configMapDatabaseUserKey,omitempty"`
+
+	// Name of the Secret where the operator should looking for the values of the database name, user and password
+	// for its env vars. It has priority over the configMapName.
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Credentials Secret name"
+	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+
+	// Name of the Secret key where the operator should looking for the value for the database name for its env var
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Credentials Secret Database Key"
+	CredentialsSecretDatabaseNameKey string `json:"credentialsSecretDatabaseNameKey,omitempty"`
+
+	// Name of the Secret key where the operator should looking for the value for the database user for its env var
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Credentials Secret User Key"
+	CredentialsSecretUserKey string `json:"credentialsSecretUserKey,omitempty"`
+
+	// Name of the Secret key where the operator should looking for the value for the database password for its env var
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Credentials Secret Password Key"
+	CredentialsSecretPasswordKey string `json:"credentialsSecretPasswordKey,omitempty"`
+
+	// Setup to run the Database with streaming replication as one primary and (spec.size - 1) hot standbys
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Replication"
+	Replication *DatabaseReplication `json:"replication,omitempty"`
+
+	// Kind of the workload used to run the Database. Options: Deployment or StatefulSet
+	// When StatefulSet is used, the PVCs are created from its volumeClaimTemplates and the pods have stable names and
+	// DNS records by its headless Service (<name>-<ordinal>.<name>-headless). An existing Deployment will be migrated.
+	// Default value: Deployment
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Workload Type"
+	WorkloadType string `json:"workloadType,omitempty"`
+
+	// Setup to ship the WAL segments to the AWS S3 bucket of a Backup CR in order to allow the point-in-time recovery
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="WAL Archiving"
+	WalArchiving *DatabaseWalArchiving `json:"walArchiving,omitempty"`
+
+	// Parameters of the postgresql.conf (E.g. max_connections: "200" or work_mem: 8MB). They are stored in a ConfigMap
+	// included in the configuration of the database. The changes are applied by reload or restart according to the
+	// parameter. Only the parameters known by the operator are allowed.
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Parameters"
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// Roles created and managed by the operator in the database server. The password of each role which can log in
+	// is generated in a Secret with its credentials.
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Roles"
+	Roles []DatabaseRole `json:"roles,omitempty"`
+
+	// Databases created and managed by the operator in the database server with their owner and grants
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Databases"
+	Databases []LogicalDatabase `json:"databases,omitempty"`
+
+	// Setup to rotate periodically the passwords generated by the operator (the Secret <name>-credentials and the
+	// Secrets of the spec.roles)
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Password Rotation"
+	PasswordRotation *DatabasePasswordRotation `json:"passwordRotation,omitempty"`
+
+	// Setup to encrypt the client connections with TLS by a server certificate of an existing Secret, issued by
+	// cert-manager or generated by the operator with its own CA
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="TLS"
+	TLS *DatabaseTLS `json:"tls,omitempty"`
+
+	// Ordered rules of the pg_hba.conf which control the access to the database server. They are stored in a ConfigMap
+	// and reloaded when they change. When informed, only the local connections and the replication are allowed besides
+	// them.
+	// Default value: nil (the pg_hba.conf of the image is used)
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Host-Based Authentication"
+	Hba []DatabaseHbaRule `json:"hba,omitempty"`
+
+	// Name of the cluster-scoped DatabaseClass with the values used when they are not informed in this spec. E.g. the
+	// image, resources, storage, parameters, backup policy and scheduling constraints.
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Class Name"
+	ClassName string `json:"className,omitempty"`
+
+	// Node labels which the nodes of the pods of the Database must have
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Node Selector"
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the pods of the Database
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Tolerations"
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity of the pods of the Database
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Affinity"
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
+
+	// Policy applied to the PVCs with the data when the Database is deleted. Options: Delete, Retain, Snapshot or
+	// BackupThenDelete. Retain keeps the PVCs, Snapshot takes a VolumeSnapshot of each PVC and BackupThenDelete does an
+	// on-demand backup by the Backup CR of the Database before the PVCs are deleted.
+	// Default value: Delete
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Deletion Policy"
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// Name of the VolumeSnapshotClass of the snapshots taken when the deletionPolicy is Snapshot
+	// Default value: nil (the default VolumeSnapshotClass of the cluster is used)
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Volume Snapshot Class Name"
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+}
+
+// DatabaseHbaRule defines a rule of the pg_hba.conf
+// +k8s:openapi-gen=true
+type DatabaseHbaRule struct {
+	// Type of the connection. Options: local, host, hostssl and hostnossl
+	Type string `json:"type"`
+
+	// Databases matched by the rule separated by comma (E.g. app,+readers or sameuser)
+	// Default value: all
+	Database string `json:"database,omitempty"`
+
+	// Users matched by the rule separated by comma. The roles prefixed by + match their members
+	// Default value: all
+	User string `json:"user,omitempty"`
+
+	// Client address as CIDR (E.g. 10.0.0.0/8) or the keywords all, samehost and samenet. It is not informed for the
+	// type local
+	Address string `json:"address,omitempty"`
+
+	// Authentication method. Options: trust, reject, scram-sha-256, md5, password, gss, ident, peer (only for the type
+	// local) and pam
+	Method string `json:"method"`
+}
+
+// DatabaseTLS defines the server certificate used to encrypt the client connections
+// +k8s:openapi-gen=true
+type DatabaseTLS struct {
+	// When true the database server accepts the connections with TLS (ssl=on)
+	// Default value: false
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Enabled"
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Name of an existing Secret of the type kubernetes.io/tls (keys tls.crt and tls.key) with the server certificate
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Secret Name"
+	SecretName string `json:"secretName,omitempty"`
+
+	// Issuer of cert-manager which issues the server certificate in the Secret <name>-tls. When neither the secretName
+	// nor the certManager are informed the operator generates its own CA and the server certificate in this Secret.
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="cert-manager"
+	CertManager *DatabaseTLSCertManager `json:"certManager,omitempty"`
+
+	// When true only the connections with TLS are accepted (hostssl in the pg_hba.conf)
+	// Default value: false
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Enforce SSL"
+	EnforceSSL bool `json:"enforceSSL,omitempty"`
+
+	// Quantity of days which the server certificates issued by cert-manager or the operator are valid
+	// Default value: 365
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Validity Days"
+	ValidityDays int32 `json:"validityDays,omitempty"`
+
+	// Quantity of days before the expiry which the server certificates are renewed
+	// Default value: 30
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Renew Before Days"
+	RenewBeforeDays int32 `json:"renewBeforeDays,omitempty"`
+}
+
+// DatabaseTLSCertManager defines the issuer of cert-manager which issues the server certificate
+// +k8s:openapi-gen=true
+type DatabaseTLSCertManager struct {
+	// Name of the Issuer or ClusterIssuer
+	IssuerName string `json:"issuerName"`
+
+	// Kind of the issuer. Options: Issuer or ClusterIssuer
+	// Default value: Issuer
+	IssuerKind string `json:"issuerKind,omitempty"`
+}
+
+// DatabasePasswordRotation defines the policy to rotate the passwords generated by the operator
+// +k8s:openapi-gen=true
+type DatabasePasswordRotation struct {
+	// When true the passwords are rotated
+	// Default value: false
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Enabled"
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Quantity of days between the rotations
+	// Default value: 30
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Interval Days"
+	IntervalDays int32 `json:"intervalDays,omitempty"`
+
+	// Quantity of minutes after the rotation which the previous role of the Secret can still log in with its password
+	// Default value: 60
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Grace Period Minutes"
+	GracePeriodMinutes int32 `json:"gracePeriodMinutes,omitempty"`
+}
+
+// DatabaseRole defines a role of the database server managed by the operator
+// +k8s:openapi-gen=true
+type DatabaseRole struct {
+	// Name of the role. Only lowercase letters, digits and underscores are allowed
+	Name string `json:"name"`
+
+	// When false the role cannot log in and no Secret is generated. (E.g. a group role)
+	// Default value: true
+	Login *bool `json:"login,omitempty"`
+
+	// When true the role can create databases
+	CreateDB bool `json:"createDB,omitempty"`
+
+	// When true the role can create other roles
+	CreateRole bool `json:"createRole,omitempty"`
+
+	// Maximum of concurrent connections of the role. Default value: -1 (no limit)
+	ConnectionLimit *int32 `json:"connectionLimit,omitempty"`
+
+	// Roles which the role is member of
+	InRoles []string `json:"inRoles,omitempty"`
+}
+
+// LogicalDatabase defines a database of the database server managed by the operator
+// +k8s:openapi-gen=true
+type LogicalDatabase struct {
+	// Name of the database. Only lowercase letters, digits and underscores are allowed
+	Name string `json:"name"`
+
+	// Role which owns the database. Default value: postgres
+	Owner string `json:"owner,omitempty"`
+
+	// Privileges on the database granted to the roles
+	Grants []DatabaseGrant `json:"grants,omitempty"`
+}
+
+// DatabaseGrant defines the privileges on a database granted to a role
+// +k8s:openapi-gen=true
+type DatabaseGrant struct {
+	// Name of the role
+	Role string `json:"role"`
+
+	// Privileges granted: CONNECT, CREATE, TEMPORARY or ALL
+	Privileges []string `json:"privileges"`
+}
+
+// DatabaseWalArchiving defines the continuous archiving of the WAL segments of the Database
+// The database is started with archive_mode on and its archive_command hands over each segment to a sidecar container
+// which uploads it to the AWS S3 bucket of the Backup CR. The segment is only considered archived after the upload.
+// +k8s:openapi-gen=true
+type DatabaseWalArchiving struct {
+	// When true the WAL segments are shipped to the AWS S3 bucket
+	// Default value: false
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Enabled"
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Name of the Backup CR applied with the walArchiving enabled. Its AWS S3 bucket receives the WAL segments.
+	// Default value: backup
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Name of Backup CR"
+	BackupCRName string `json:"backupCRName,omitempty"`
+
+	// Time in seconds after which the current WAL segment is archived even if it is not full. It is the maximum data
+	// loss when the database has low activity.
+	// Default value: 60
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Archive Timeout"
+	ArchiveTimeout int32 `json:"archiveTimeout,omitempty"`
+
+	// Image:tag of the sidecar container which uploads the WAL segments. It requires s3cmd.
+	// Default value: quay.io/integreatly/backup-container:1.0.8
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Image:tag"
+	Image string `json:"image,omitempty"`
+}
+
+// DatabaseReplication defines the streaming replication setup of the Database
+// Each standby is created with its own Deployment and PersistentVolumeClaim and will stream the data from the primary
+// by using the replication user.
+// +k8s:openapi-gen=true
+type DatabaseReplication struct {
+	// When true the operator will create one primary and (spec.size - 1) hot standbys
+	// Default value: false
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Enabled"
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Value for the Environment Variable (spec.replication.replicationUserKeyEnvVar).
+	// User which will be used by the standbys to connect to the primary
+	// Default value: replicator
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Replication User"
+	ReplicationUser string `json:"replicationUser,omitempty"`
+
+	// Value for the Environment Variable (spec.replication.replicationPasswordKeyEnvVar).
+	// Password of the user which will be used by the standbys to connect to the primary
+	// It is not used when the credentials are in a Secret (spec.credentialsSecretName or the one generated
+	// by the operator) since then the password is read from it.
+	// Default value: random password generated in the Secret <database>-credentials
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Replication Password"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:password"
+	ReplicationPassword string `json:"replicationPassword,omitempty"`
+
+	// Key Value for the Environment Variable in order to inform the replication user
+	// Note that each database version/image can expected a different value for it.
+	// Default value: POSTGRESQL_MASTER_USER
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="EnvVar Key (Replication User)"
+	ReplicationUserKeyEnvVar string `json:"replicationUserKeyEnvVar,omitempty"`
+
+	// Key Value for the Environment Variable in order to inform the replication password
+	// Note that each database version/image can expected a different value for it.
+	// Default value: POSTGRESQL_MASTER_PASSWORD
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="EnvVar Key (Replication Password)"
+	ReplicationPasswordKeyEnvVar string `json:"replicationPasswordKeyEnvVar,omitempty"`
+
+	// Key Value for the Environment Variable in order to inform to the standbys the host of the primary.
+	// It is used by the image to build the primary_conninfo of the standbys.
+	// Note that each database version/image can expected a different value for it.
+	// Default value: POSTGRESQL_MASTER_SERVICE_NAME
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="EnvVar Key (Primary Service)"
+	PrimaryServiceKeyEnvVar string `json:"primaryServiceKeyEnvVar,omitempty"`
+
+	// Command of the image used to start the primary
+	// Default value: run-postgresql-master
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Primary Command"
+	PrimaryCommand string `json:"primaryCommand,omitempty"`
+
+	// Command of the image used to start the standbys
+	// Default value: run-postgresql-slave
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Standby Command"
+	StandbyCommand string `json:"standbyCommand,omitempty"`
 }
 
 // DatabaseStatus defines the observed state of Database
@@ -160,6 +535,11 @@ type DatabaseStatus struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="appsv1.DeploymentStatus"
 	DeploymentStatus appsv1.DeploymentStatus `json:"deploymentStatus"`
 
+	// Status of the Database StatefulSet created and managed by it when the spec.workloadType is StatefulSet
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="appsv1.StatefulSetStatus"
+	StatefulSetStatus appsv1.StatefulSetStatus `json:"statefulSetStatus,omitempty"`
+
 	// Status of the Database Service created and managed by it
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="v1.ServiceStatus"
@@ -169,6 +549,370 @@ type DatabaseStatus struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Database Status"
 	DatabaseStatus string `json:"databaseStatus"`
+
+	// Phase of the Database: Pending, Provisioning, Ready, Degraded or Failed
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Phase"
+	Phase string `json:"phase,omitempty"`
+
+	// Generation of the Database CR observed by the operator when the status was updated
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Observed Generation"
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions of the Database: Provisioned, Ready, Degraded, BackupConfigured and StorageResized
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Conditions"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// Name of the member (Deployment or pod of the StatefulSet) which is running as primary when the replication is enabled
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Current Primary"
+	CurrentPrimary string `json:"currentPrimary,omitempty"`
+
+	// Latest failovers performed by the operator when the primary was not available
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Failover Events"
+	FailoverEvents []FailoverEvent `json:"failoverEvents,omitempty"`
+
+	// Time since the primary is not available through its Service. A standby is only promoted after the grace period
+	PrimaryUnavailableSince *metav1.Time `json:"primaryUnavailableSince,omitempty"`
+
+	// State of the WAL archiving of the primary when the walArchiving is enabled
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="WAL Archiving"
+	WalArchiving *WalArchivingStatus `json:"walArchiving,omitempty"`
+
+	// Latest rollout done by the operator to apply the changes of the spec to the Deployments or StatefulSet
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Rollout"
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// Major version of PostgreSQL of the data of the Database when the spec.postgresVersion is informed
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="PostgreSQL Version"
+	PostgresVersion string `json:"postgresVersion,omitempty"`
+
+	// Latest upgrade of the major version of PostgreSQL done by the operator
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Upgrade"
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+
+	// Latest change of the spec.parameters applied by the operator
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Parameters"
+	Parameters *ParametersStatus `json:"parameters,omitempty"`
+
+	// State of the roles of the spec in the database server
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Roles"
+	Roles []ServerObjectStatus `json:"roles,omitempty"`
+
+	// State of the databases of the spec in the database server
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Databases"
+	Databases []ServerObjectStatus `json:"databases,omitempty"`
+
+	// Time of the latest rotation of the passwords when the passwordRotation is enabled
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Last Rotation Time"
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+
+	// State of the server certificate when the tls is enabled
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="TLS"
+	TLS *TLSStatus `json:"tls,omitempty"`
+
+	// Latest change of the pg_hba.conf done by the operator
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Host-Based Authentication"
+	Hba *HbaStatus `json:"hba,omitempty"`
+
+	// Values resolved from the DatabaseClass and the spec when the className is informed
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Class"
+	Class *ClassStatus `json:"class,omitempty"`
+
+	// State of the deletionPolicy applied by the operator when the Database is deleted
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Deletion"
+	Deletion *DeletionStatus `json:"deletion,omitempty"`
+}
+
+// DeletionStatus defines the state of the deletionPolicy applied to the PVCs when the Database is deleted
+// +k8s:openapi-gen=true
+type DeletionStatus struct {
+	// Deletion policy applied
+	Policy string `json:"policy"`
+
+	// Phase of the deletion: Retaining, Snapshotting, BackingUp, Completed or Failed
+	Phase string `json:"phase"`
+
+	// Names of the PVCs of the Database
+	PersistentVolumeClaims []string `json:"persistentVolumeClaims,omitempty"`
+
+	// Names of the VolumeSnapshots taken when the policy is Snapshot
+	Snapshots []string `json:"snapshots,omitempty"`
+
+	// Name of the Backup CR which did the backup when the policy is BackupThenDelete
+	BackupName string `json:"backupName,omitempty"`
+
+	// Name of the Job of the backup when the policy is BackupThenDelete
+	BackupJobName string `json:"backupJobName,omitempty"`
+
+	// Time when the policy started to be applied
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Time when the policy was applied and the finalizer removed
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message of the error which blocks the deletion
+	Message string `json:"message,omitempty"`
+}
+
+// ClassStatus defines the values used by the Database resolved from its DatabaseClass and spec
+// +k8s:openapi-gen=true
+type ClassStatus struct {
+	// Name of the DatabaseClass
+	Name string `json:"name"`
+
+	// Fields of the spec which use the values of the DatabaseClass since they are not informed in the Database
+	Fields []string `json:"fields,omitempty"`
+
+	// Image of the Database
+	Image string `json:"image,omitempty"`
+
+	// Limit of Memory of the database container
+	DatabaseMemoryLimit string `json:"databaseMemoryLimit,omitempty"`
+
+	// Memory requested by the database container
+	DatabaseMemoryRequest string `json:"databaseMemoryRequest,omitempty"`
+
+	// Limit of CPU of the database container
+	DatabaseCpuLimit string `json:"databaseCpuLimit,omitempty"`
+
+	// CPU requested by the database container
+	DatabaseCpu string `json:"databaseCpu,omitempty"`
+
+	// Storage requested by the PVCs
+	DatabaseStorageRequest string `json:"databaseStorageRequest,omitempty"`
+
+	// Storage Class of the PVCs
+	DatabaseStorageClassName string `json:"databaseStorageClassName,omitempty"`
+
+	// Message of the error when the DatabaseClass is not found
+	Message string `json:"message,omitempty"`
+}
+
+// HbaStatus defines the change of the pg_hba.conf done by the operator
+// +k8s:openapi-gen=true
+type HbaStatus struct {
+	// Name of the ConfigMap with the pg_hba.conf
+	ConfigMapName string `json:"configMapName"`
+
+	// Phase of the change: Reloading, Restarting or Applied
+	Phase string `json:"phase"`
+
+	// Quantity of rules of the spec applied
+	Rules int32 `json:"rules"`
+
+	// Time when the ConfigMap was changed
+	ChangeTime metav1.Time `json:"changeTime"`
+
+	// Time when the change was applied by all instances
+	AppliedTime *metav1.Time `json:"appliedTime,omitempty"`
+}
+
+// TLSStatus defines the state of the server certificate used by the Database
+// +k8s:openapi-gen=true
+type TLSStatus struct {
+	// Name of the Secret with the server certificate
+	SecretName string `json:"secretName"`
+
+	// Origin of the certificate: Secret, CertManager or SelfSigned
+	Source string `json:"source"`
+
+	// Time when the certificate expires
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// Hash of the certificate. The pods are restarted when it changes in order to load the new certificate
+	Hash string `json:"hash,omitempty"`
+
+	// Time when the certificate was issued or renewed by the operator
+	LastRenewalTime *metav1.Time `json:"lastRenewalTime,omitempty"`
+
+	// Message of the error when the certificate is not found or is invalid
+	Message string `json:"message,omitempty"`
+
+	// Spec tls applied to the pods with the certificate of the hash. It is kept in the pods while the spec tls is
+	// invalid or its certificate is not available, so they are not redeployed without TLS
+	AppliedSpec *DatabaseTLS `json:"appliedSpec,omitempty"`
+}
+
+// ServerObjectStatus defines the state of a role or database managed by the operator in the database server
+// +k8s:openapi-gen=true
+type ServerObjectStatus struct {
+	// Name of the role or database
+	Name string `json:"name"`
+
+	// Phase of the object: Pending, Ready or Failed
+	Phase string `json:"phase"`
+
+	// Name of the Secret with the credentials of the role
+	SecretName string `json:"secretName,omitempty"`
+
+	// Hash of the spec applied. It allows apply the object only when it changes
+	Hash string `json:"hash,omitempty"`
+
+	// Time when the object was applied in the database server
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+
+	// Message of the error when the object could not be applied
+	Message string `json:"message,omitempty"`
+}
+
+// ParametersStatus defines the change of the parameters of the postgresql.conf done by the operator
+// +k8s:openapi-gen=true
+type ParametersStatus struct {
+	// Name of the ConfigMap with the parameters included in the postgresql.conf
+	ConfigMapName string `json:"configMapName"`
+
+	// Phase of the change: Reloading, Restarting or Applied
+	Phase string `json:"phase"`
+
+	// Parameters changed which are applied by the reload of the configuration
+	Reload []string `json:"reload,omitempty"`
+
+	// Parameters changed which require the restart of the database
+	Restart []string `json:"restart,omitempty"`
+
+	// Time when the ConfigMap was changed
+	ChangeTime metav1.Time `json:"changeTime"`
+
+	// Time when the change was applied by all instances
+	AppliedTime *metav1.Time `json:"appliedTime,omitempty"`
+}
+
+// UpgradeStatus defines the upgrade of the data of the Database to a new major version of PostgreSQL
+// The data is dumped with the previous image and loaded with the new one. The previous data is kept in the PVC in order
+// to allow the rollback when some step fails.
+// +k8s:openapi-gen=true
+type UpgradeStatus struct {
+	// Major version of the data before the upgrade
+	FromVersion string `json:"fromVersion"`
+
+	// Major version of the data after the upgrade
+	ToVersion string `json:"toVersion"`
+
+	// Image which was running before the upgrade
+	FromImage string `json:"fromImage"`
+
+	// Image which runs after the upgrade
+	ToImage string `json:"toImage"`
+
+	// Generation of the Database CR which requested the upgrade
+	Generation int64 `json:"generation"`
+
+	// Phase of the upgrade: BackingUp, Dumping, Upgrading, Swapping, RollingBack, Succeeded, Failed or Cancelled
+	Phase string `json:"phase"`
+
+	// Time when the upgrade started
+	StartTime metav1.Time `json:"startTime"`
+
+	// Time when the upgrade finished
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message of the error when the upgrade failed
+	Message string `json:"message,omitempty"`
+
+	// Steps done by the upgrade
+	Steps []UpgradeStep `json:"steps,omitempty"`
+}
+
+// UpgradeStep defines a step of the upgrade of the Database
+// +k8s:openapi-gen=true
+type UpgradeStep struct {
+	// Name of the step: Backup, Dump, Upgrade, Swap or Rollback
+	Name string `json:"name"`
+
+	// Phase of the step: Running, Succeeded, Failed or Skipped
+	Phase string `json:"phase"`
+
+	// Time when the step started
+	StartTime metav1.Time `json:"startTime"`
+
+	// Time when the step finished
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Name of the Job which runs the step
+	JobName string `json:"jobName,omitempty"`
+
+	// Details of the result of the step
+	Message string `json:"message,omitempty"`
+}
+
+// RolloutStatus defines an update of the workloads of the Database done by the operator when the spec changed
+// +k8s:openapi-gen=true
+type RolloutStatus struct {
+	// Generation of the Database CR applied by the rollout
+	Generation int64 `json:"generation"`
+
+	// Phase of the rollout: Progressing or Complete
+	Phase string `json:"phase"`
+
+	// Time when the workloads were updated
+	StartTime metav1.Time `json:"startTime"`
+
+	// Time when all pods of the workloads were updated and available
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Names of the Deployments or StatefulSet updated
+	Workloads []string `json:"workloads"`
+
+	// Fields changed in the workloads. E.g. database.image or database.resources
+	Changes []string `json:"changes,omitempty"`
+}
+
+// WalArchivingStatus defines the observed state of the WAL archiving from the pg_stat_archiver of the primary
+// +k8s:openapi-gen=true
+type WalArchivingStatus struct {
+	// Name of the last WAL segment archived successfully
+	LastArchivedWal string `json:"lastArchivedWal,omitempty"`
+
+	// Time of the last successful archive
+	LastArchivedTime *metav1.Time `json:"lastArchivedTime,omitempty"`
+
+	// Name of the last WAL segment which failed to be archived
+	LastFailedWal string `json:"lastFailedWal,omitempty"`
+
+	// Time of the last failed archive
+	LastFailedTime *metav1.Time `json:"lastFailedTime,omitempty"`
+
+	// Name of the WAL segment which is being written
+	CurrentWal string `json:"currentWal,omitempty"`
+
+	// Quantity of WAL segments completed which were not archived yet
+	ArchiveLagSegments int64 `json:"archiveLagSegments"`
+}
+
+// FailoverEvent defines a promotion of a standby to primary performed by the operator
+// +k8s:openapi-gen=true
+type FailoverEvent struct {
+	// Time when the standby was promoted
+	Time metav1.Time `json:"time"`
+
+	// Name of the member which was the primary
+	OldPrimary string `json:"oldPrimary"`
+
+	// Name of the member promoted to primary
+	NewPrimary string `json:"newPrimary"`
+
+	// Last WAL location received by the standby promoted
+	LSN string `json:"lsn,omitempty"`
+
+	// Reason of the failover
+	Reason string `json:"reason,omitempty"`
 }
 
 // Database is the Schema for the the Database Database API
@@ -176,8 +920,12 @@ type DatabaseStatus struct {
 
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +operator-sdk:gen-csv:customresourcedefinitions.displayName="Database Database"
 // +operator-sdk:gen-csv:customresourcedefinitions.resources="Deployment,v1,\"A Kubernetes Deployment\""
+// +operator-sdk:gen-csv:customresourcedefinitions.resources="StatefulSet,v1,\"A Kubernetes StatefulSet\""
 // +operator-sdk:gen-csv:customresourcedefinitions.resources="Service,v1,\"A Kubernetes Service\""
 // +operator-sdk:gen-csv:customresourcedefinitions.resources="PersistentVolumeClaim,v1,\"A Kubernetes PersistentVolumeClaim\""
 type Database struct {
@@ -0,0 +1,121 @@
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DatabaseClassSpec defines the values shared by the Databases which inform the class in the spec className. The values
+// informed in the spec of the Database have priority over the ones of the class.
+// +k8s:openapi-gen=true
+type DatabaseClassSpec struct {
+	// Image of the Database
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Image"
+	Image string `json:"image,omitempty"`
+
+	// Limit of Memory which will be available for the database container
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Database Memory Limit"
+	DatabaseMemoryLimit string `json:"databaseMemoryLimit,omitempty"`
+
+	// Memory requested by the database container
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Database Memory Request"
+	DatabaseMemoryRequest string `json:"databaseMemoryRequest,omitempty"`
+
+	// Limit of CPU which will be available for the database container
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Database CPU Limit"
+	DatabaseCpuLimit string `json:"databaseCpuLimit,omitempty"`
+
+	// CPU requested by the database container
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Database CPU"
+	DatabaseCpu string `json:"databaseCpu,omitempty"`
+
+	// Storage requested by the PVCs of the Database
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Database Storage Request"
+	DatabaseStorageRequest string `json:"databaseStorageRequest,omitempty"`
+
+	// Storage Class of the PVCs of the Database
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Database Storage Class Name"
+	DatabaseStorageClassName string `json:"databaseStorageClassName,omitempty"`
+
+	// Parameters of the postgresql.conf. The parameters informed in the spec of the Database are added to them.
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Parameters"
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// Backup policy used by the Backup CRs of the Databases of the class
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Backup"
+	Backup *DatabaseClassBackup `json:"backup,omitempty"`
+
+	// Node labels which the nodes of the pods of the Database must have
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Node Selector"
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the pods of the Database
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Tolerations"
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity of the pods of the Database
+	// Default value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Affinity"
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
+}
+
+// DatabaseClassBackup defines the backup policy of the Databases of the class
+// +k8s:openapi-gen=true
+type DatabaseClassBackup struct {
+	// Schedule of the CronJob of the Backup CRs which do not inform it
+	// Default value: nil
+	Schedule string `json:"schedule,omitempty"`
+
+	// Retention policy of the Backup CRs which do not inform it
+	// Default value: nil
+	Retention *BackupRetention `json:"retention,omitempty"`
+}
+
+// DatabaseClass is the Schema for the databaseclasses API
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=databaseclasses,scope=Cluster
+// +operator-sdk:gen-csv:customresourcedefinitions.displayName="Database Class"
+type DatabaseClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DatabaseClassSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DatabaseClassList contains a list of DatabaseClass
+type DatabaseClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DatabaseClass `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DatabaseClass{}, &DatabaseClassList{})
+}
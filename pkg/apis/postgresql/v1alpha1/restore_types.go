@@ -0,0 +1,159 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RestoreSpec defines the desired state of Restore
+// +k8s:openapi-gen=true
+type RestoreSpec struct {
+	// Name of the Database CR applied where the dump will be loaded
+	// Default Value: "database"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Name of Database CR"
+	DatabaseCRName string `json:"databaseCRName,omitempty"`
+
+	// Name of the Backup CR applied which did the backup. Its AWS secret, productName and encryption secret will be used
+	// when they are not informed in this CR.
+	// Default Value: nil
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Name of Backup CR"
+	BackupCRName string `json:"backupCRName,omitempty"`
+
+	// Key of the dump object in the AWS S3 bucket. (E.g backups/postgresql/postgres/2020/07/06/postgresql.example-00_00_00.pg_dump.gz)
+	// Default Value: nil
+	// NOTE: If the key be not informed then the latest dump of the database found in the bucket will be used
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Object Key"
+	ObjectKey string `json:"objectKey,omitempty"`
+
+	// Used to find the directory where the dumps are stored when the objectKey is not informed
+	// Default Value: productName of the Backup CR
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="AWS tag name"
+	ProductName string `json:"productName,omitempty"`
+
+	// Image:tag used to do the restore. It requires s3cmd, gpg and psql.
+	// Default Value: <quay.io/integreatly/backup-container:1.0.8>
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Image:tag"
+	Image string `json:"image,omitempty"`
+
+	// Name of the secret with the AWS data credentials pre-existing in the cluster
+	// Default Value: AWS secret of the Backup CR
+	// See here the template: https://github.com/integr8ly/backup-container-image/blob/master/templates/openshift/sample-config/s3-secret.yaml
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="AWS Secret name:"
+	AwsSecretName string `json:"awsSecretName,omitempty"`
+
+	// Namespace of the secret with the AWS data credentials pre-existing in the cluster
+	// Default Value: nil
+	// NOTE: If the namespace be not informed then the operator will try to find it in the same namespace where it is applied
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="AWS Secret namespace:"
+	AwsSecretNamespace string `json:"awsSecretNamespace,omitempty"`
+
+	// Name of the secret with the GPG data pre-existing in the cluster to decrypt the dump. It has the same keys of
+	// the secret used to encrypt the backup (GPG_PUBLIC_KEY, GPG_RECIPIENT, GPG_TRUST_MODEL) and also the
+	// GPG_PRIVATE_KEY (base64 encoded) and the optional GPG_PASSPHRASE
+	// Default Value: nil
+	// NOTE: It is required when the Backup CR referenced encrypts the backups
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="DecryptKey Secret name:"
+	DecryptKeySecretName string `json:"decryptKeySecretName,omitempty"`
+
+	// Namespace of the secret with the GPG data pre-existing in the cluster to decrypt the dump
+	// Default Value: nil
+	// NOTE: If the namespace be not informed then the operator will try to find it in the same namespace where it is applied
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="DecryptKey Secret namespace:"
+	DecryptKeySecretNamespace string `json:"decryptKeySecretNamespace,omitempty"`
+
+	// Time until which the WAL segments are replayed in order to do the point-in-time recovery in the RFC3339 format.
+	// (E.g. 2020-07-06T15:04:05Z)
+	// Default Value: nil
+	// NOTE: When it or the targetLSN is informed the objectKey is the key of the base backup and the latest one taken
+	// before the target is used when it is not informed. It requires the walArchiving enabled in the Database and Backup.
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Target Time"
+	TargetTime string `json:"targetTime,omitempty"`
+
+	// WAL location until which the WAL segments are replayed in order to do the point-in-time recovery. (E.g. 0/3000060)
+	// Default Value: nil
+	// NOTE: It requires PostgreSQL 10 or later and cannot be informed with the targetTime. The latest base backup is used
+	// when the objectKey is not informed.
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Target LSN"
+	TargetLSN string `json:"targetLSN,omitempty"`
+
+	// Storage backend where the dumps and base backups are stored. Its secret is validated as in the Backup CR.
+	// Default Value: storage of the Backup CR
+	// NOTE: If it be not informed and none Backup CR is referenced then the AWS secret is used
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Storage backend"
+	Storage *BackupStorage `json:"storage,omitempty"`
+}
+
+// RestoreStatus defines the observed state of Restore
+// +k8s:openapi-gen=true
+type RestoreStatus struct {
+	// Phase of the restore. Options: Pending, Running, Succeeded or Failed
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Phase"
+	Phase string `json:"phase"`
+
+	// Name of the Job object created and managed by it to do the restore
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Job Name"
+	JobName string `json:"jobName,omitempty"`
+
+	// Key of the dump object restored or of the base backup used by the point-in-time recovery
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Object Key"
+	ObjectKey string `json:"objectKey,omitempty"`
+
+	// Time when the restore started
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Start Time"
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Time when the restore finished
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Completion Time"
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message of the error when the restore could not be done
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Error"
+	Error string `json:"error,omitempty"`
+}
+
+// Restore is the Schema for the restores API
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=restores,scope=Namespaced
+// +operator-sdk:gen-csv:customresourcedefinitions.displayName="Database Restore"
+// +operator-sdk:gen-csv:customresourcedefinitions.resources="Job,v1,\"A Kubernetes Job\""
+// +operator-sdk:gen-csv:customresourcedefinitions.resources="Secret,v1,\"A Kubernetes Secret\""
+type Restore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RestoreSpec   `json:"spec,omitempty"`
+	Status RestoreStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RestoreList contains a list of Restore
+type RestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Restore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Restore{}, &RestoreList{})
+}
@@ -11,12 +11,49 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
-		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.Backup":         schema_pkg_apis_postgresql_v1alpha1_Backup(ref),
-		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupSpec":     schema_pkg_apis_postgresql_v1alpha1_BackupSpec(ref),
-		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStatus":   schema_pkg_apis_postgresql_v1alpha1_BackupStatus(ref),
-		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.Database":       schema_pkg_apis_postgresql_v1alpha1_Database(ref),
-		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseSpec":   schema_pkg_apis_postgresql_v1alpha1_DatabaseSpec(ref),
-		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseStatus": schema_pkg_apis_postgresql_v1alpha1_DatabaseStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.Backup":                   schema_pkg_apis_postgresql_v1alpha1_Backup(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupDump":               schema_pkg_apis_postgresql_v1alpha1_BackupDump(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupRetention":          schema_pkg_apis_postgresql_v1alpha1_BackupRetention(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupRetentionStatus":    schema_pkg_apis_postgresql_v1alpha1_BackupRetentionStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupRun":                schema_pkg_apis_postgresql_v1alpha1_BackupRun(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupSpec":               schema_pkg_apis_postgresql_v1alpha1_BackupSpec(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStatus":             schema_pkg_apis_postgresql_v1alpha1_BackupStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStorage":            schema_pkg_apis_postgresql_v1alpha1_BackupStorage(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStorageS3":          schema_pkg_apis_postgresql_v1alpha1_BackupStorageS3(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStorageVolume":      schema_pkg_apis_postgresql_v1alpha1_BackupStorageVolume(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupWalArchiving":       schema_pkg_apis_postgresql_v1alpha1_BackupWalArchiving(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupWalArchivingStatus": schema_pkg_apis_postgresql_v1alpha1_BackupWalArchivingStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.ClassStatus":              schema_pkg_apis_postgresql_v1alpha1_ClassStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.Condition":                schema_pkg_apis_postgresql_v1alpha1_Condition(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.Database":                 schema_pkg_apis_postgresql_v1alpha1_Database(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseClass":            schema_pkg_apis_postgresql_v1alpha1_DatabaseClass(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseClassBackup":      schema_pkg_apis_postgresql_v1alpha1_DatabaseClassBackup(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseClassSpec":        schema_pkg_apis_postgresql_v1alpha1_DatabaseClassSpec(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseGrant":            schema_pkg_apis_postgresql_v1alpha1_DatabaseGrant(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseHbaRule":          schema_pkg_apis_postgresql_v1alpha1_DatabaseHbaRule(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabasePasswordRotation": schema_pkg_apis_postgresql_v1alpha1_DatabasePasswordRotation(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseReplication":      schema_pkg_apis_postgresql_v1alpha1_DatabaseReplication(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseRole":             schema_pkg_apis_postgresql_v1alpha1_DatabaseRole(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseSpec":             schema_pkg_apis_postgresql_v1alpha1_DatabaseSpec(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseStatus":           schema_pkg_apis_postgresql_v1alpha1_DatabaseStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseTLS":              schema_pkg_apis_postgresql_v1alpha1_DatabaseTLS(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseTLSCertManager":   schema_pkg_apis_postgresql_v1alpha1_DatabaseTLSCertManager(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseWalArchiving":     schema_pkg_apis_postgresql_v1alpha1_DatabaseWalArchiving(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DeletionStatus":           schema_pkg_apis_postgresql_v1alpha1_DeletionStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.FailoverEvent":            schema_pkg_apis_postgresql_v1alpha1_FailoverEvent(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.HbaStatus":                schema_pkg_apis_postgresql_v1alpha1_HbaStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.LogicalDatabase":          schema_pkg_apis_postgresql_v1alpha1_LogicalDatabase(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.OnDemandBackupStatus":     schema_pkg_apis_postgresql_v1alpha1_OnDemandBackupStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.ParametersStatus":         schema_pkg_apis_postgresql_v1alpha1_ParametersStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.Restore":                  schema_pkg_apis_postgresql_v1alpha1_Restore(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.RestoreSpec":              schema_pkg_apis_postgresql_v1alpha1_RestoreSpec(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.RestoreStatus":            schema_pkg_apis_postgresql_v1alpha1_RestoreStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.RolloutStatus":            schema_pkg_apis_postgresql_v1alpha1_RolloutStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.ServerObjectStatus":       schema_pkg_apis_postgresql_v1alpha1_ServerObjectStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.TLSStatus":                schema_pkg_apis_postgresql_v1alpha1_TLSStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.UpgradeStatus":            schema_pkg_apis_postgresql_v1alpha1_UpgradeStatus(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.UpgradeStep":              schema_pkg_apis_postgresql_v1alpha1_UpgradeStep(ref),
+		"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.WalArchivingStatus":       schema_pkg_apis_postgresql_v1alpha1_WalArchivingStatus(ref),
 	}
 }
 
@@ -63,72 +100,2403 @@ func schema_pkg_apis_postgresql_v1alpha1_Backup(ref common.ReferenceCallback) co
 	}
 }
 
+func schema_pkg_apis_postgresql_v1alpha1_BackupDump(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BackupDump defines a dump of the database found in the storage",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"key": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key of the dump in the storage which can be informed in the objectKey of the Restore CR",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"lastModified": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Date and time (UTC) when the dump was written in the storage (E.g. 2020-07-06 00:00)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"key", "lastModified"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_BackupRetention(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BackupRetention defines which dumps of the database are kept in the AWS S3 bucket NOTE: A dump is kept when it matches any of the keep rules and it is not older than the max age. When none keep rule is informed only the max age is used.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"keepLast": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of the latest dumps which are kept Default Value: 0",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"keepDaily": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of days which the latest dump of the day is kept Default Value: 0",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"keepWeekly": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of weeks which the latest dump of the week is kept Default Value: 0",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"keepMonthly": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of months which the latest dump of the month is kept Default Value: 0",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"maxAgeDays": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of days after which the dumps are deleted even if they match a keep rule Default Value: 0 (No max age)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_BackupRetentionStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BackupRetentionStatus defines the observed state of the latest pruning of the dumps",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"lastPruneTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the latest pruning finished",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"keptArtifacts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of dumps kept in the latest pruning",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"deletedArtifacts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of dumps deleted in the latest pruning",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"error": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Error found in the result of the latest pruning",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"keptArtifacts", "deletedArtifacts"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_BackupRun(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BackupRun defines the outcome of one Job which did the backup",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"jobName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Job",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"startTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the Job started",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the Job finished",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"duration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time took by the Job to finish (E.g. 1m30s)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"result": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Result of the Job: Running, Succeeded or Failed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"artifact": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key of the dump uploaded to the storage when the storage is informed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"size": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Size in bytes of the dump uploaded to the storage when the storage is informed",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reason of the failure when the Job failed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"jobName", "result"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
 func schema_pkg_apis_postgresql_v1alpha1_BackupSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "BackupSpec defines the desired state of Backup",
-				Type:        []string{"object"},
+				Description: "BackupSpec defines the desired state of Backup",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"databaseCRName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Database CR applied which this backup will work with Default Value: \"database\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"schedule": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Schedule period for the CronJob. Default Value: <0 0 * * *> daily at 00:00",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image:tag used to do the backup. Default Value: <quay.io/integreatly/backup-container:1.0.8> More Info: https://github.com/integr8ly/backup-container-image",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Database version. (E.g 9.6). Default Value: <9.6> IMPORTANT: Just the first 2 digits should be used.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"productName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Used to create the directory where the files will be stored Default Value: <postgresql>",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"awsS3BucketName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of AWS S3 storage. Default Value: nil Required to create the Secret with the AWS data to allow send the backup files to AWS S3 storage.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"awsAccessKeyId": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key ID of AWS S3 storage. Default Value: nil Required to create the Secret with the data to allow send the backup files to AWS S3 storage.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"awsSecretAccessKey": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Secret/Token of AWS S3 storage. Default Value: nil Required to create the Secret with the data to allow send the backup files to AWS S3 storage.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"awsSecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the secret with the AWS data credentials pre-existing in the cluster Default Value: nil See here the template: https://github.com/integr8ly/backup-container-image/blob/master/templates/openshift/sample-config/s3-secret.yaml",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"awsSecretNamespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace of the secret with the AWS data credentials pre-existing in the cluster Default Value: nil NOTE: If the namespace be not informed then the operator will try to find it in the same namespace where it is applied",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"encryptKeySecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the secret with the Encrypt data pre-existing in the cluster Default Value: nil See here the template: https://github.com/integr8ly/backup-container-image/blob/master/templates/openshift/sample-config/gpg-secret.yaml",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"encryptKeySecretNamespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace of the secret with the Encrypt data pre-existing in the cluster Default Value: nil NOTE: If the namespace be not informed then the operator will try to find it in the same namespace where it is applied",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"gpgPublicKey": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GPG public key to create the EncryptionKeySecret with this data Default Value: nil See here how to create this key : https://help.github.com/en/articles/generating-a-new-gpg-key",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"gpgEmail": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GPG email to create the EncryptionKeySecret with this data Default Value: nil See here how to create this key : https://help.github.com/en/articles/generating-a-new-gpg-key",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"gpgTrustModel": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GPG trust model to create the EncryptionKeySecret with this data. the default value is true when it is empty. Default Value: nil See here how to create this key : https://help.github.com/en/articles/generating-a-new-gpg-key",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"trigger": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value used to request an on-demand backup. Each time that it is changed a Job is created immediately from the same template of the CronJob. (E.g. \"before-migration-01\") Default Value: nil NOTE: The annotation postgresql.dev4devs.com/backup-trigger can be used instead of this spec",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"retention": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Retention policy of the dumps stored in the AWS S3 bucket. The expired dumps are deleted after each successful backup. Default Value: nil NOTE: If it be not informed then the dumps are never deleted",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupRetention"),
+						},
+					},
+					"walArchiving": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Setup of the periodic base backups which allow the point-in-time recovery with the WAL segments shipped by the Database with the walArchiving enabled Default Value: nil",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupWalArchiving"),
+						},
+					},
+					"storage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Storage backend where the dumps, base backups and WAL segments are stored. (E.g. S3-compatible services as MinIO, Google Cloud Storage, Azure Blob or a PVC) Default Value: nil NOTE: If it be not informed then the AWS S3 specs above are used and the upload is done by the backup image",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStorage"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupRetention", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStorage", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupWalArchiving"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_BackupStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BackupStatus defines the observed state of Backup",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"backupStatus": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Will be as \"OK when all objects are created successfully",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"cronJobName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the CronJob object created and managed by it to schedule the backup job",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"dbSecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the secret object created with the database data to allow the backup image connect to the database",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"awsSecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name  of the secret object with the Aws data to allow send the backup files to the AWS storage",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"awsCredentialsSecretNamespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace  of the secret object with the Aws data to allow send the backup files to the AWS storage",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"encryptKeySecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name  of the secret object with the Encryption GPG Key",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"encryptKeySecretNamespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace of the secret object with the Encryption GPG Key",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"hasEncryptKey": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Boolean value which has true when it has an EncryptionKey to be used to send the backup files",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"isDatabasePodFound": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Boolean value which has true when the Database Pod was found in order to create the secret with the database data to allow the backup image connect into it.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"isDatabaseServiceFound": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Boolean value which has true when the Service Database Pod was found in order to create the secret with the database data to allow the backup image connect into it.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"cronJobStatus": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status of the CronJob object",
+							Ref:         ref("k8s.io/api/batch/v1beta1.CronJobStatus"),
+						},
+					},
+					"onDemandBackup": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Outcome of the latest on-demand backup requested by the trigger",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.OnDemandBackupStatus"),
+						},
+					},
+					"retention": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Outcome of the latest pruning of the dumps done by the retention policy",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupRetentionStatus"),
+						},
+					},
+					"walArchiving": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Outcome of the latest base backup taken for the point-in-time recovery",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupWalArchivingStatus"),
+						},
+					},
+					"dumps": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Dumps of the database found in the storage by the latest backup from the newest to the oldest NOTE: Just the 30 newest dumps are listed and it is only filled when the storage is informed or the retention is enabled",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupDump"),
+									},
+								},
+							},
+						},
+					},
+					"runs": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Latest runs of the backup (scheduled by the CronJob or on-demand) from the newest to the oldest NOTE: Just the 10 newest runs are kept",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupRun"),
+									},
+								},
+							},
+						},
+					},
+					"lastSuccessfulBackupTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the latest successful backup finished",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"lastFailureReason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reason of the latest backup which failed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"consecutiveFailures": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of backups which failed since the latest successful one",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions of the Backup: Ready and Failing",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.Condition"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"backupStatus", "cronJobName", "dbSecretName", "awsSecretName", "awsCredentialsSecretNamespace", "encryptKeySecretName", "encryptKeySecretNamespace", "hasEncryptKey", "isDatabasePodFound", "isDatabaseServiceFound", "cronJobStatus"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupDump", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupRetentionStatus", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupRun", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupWalArchivingStatus", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.Condition", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.OnDemandBackupStatus", "k8s.io/api/batch/v1beta1.CronJobStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_BackupStorage(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BackupStorage defines the backend where the backups are stored The keys of the secret with the credentials are validated according to the type: - s3: AWS_S3_BUCKET_NAME, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and the optional AWS_CA_BUNDLE - gcs: GCS_BUCKET_NAME, GCS_ACCESS_KEY_ID and GCS_SECRET_ACCESS_KEY (HMAC key of a service account) - azure: AZURE_STORAGE_ACCOUNT, AZURE_STORAGE_CONTAINER and AZURE_STORAGE_SAS_TOKEN - volume: none secret is used",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of the storage backend. Options: s3, gcs, azure or volume Default Value: s3",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"secretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the secret with the credentials of the storage pre-existing in the cluster Default Value: The AWS secret when the type is s3",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"secretNamespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace of the secret with the credentials of the storage pre-existing in the cluster Default Value: The namespace of the Backup CR",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"s3": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Options of the S3-compatible services (E.g. MinIO) Default Value: nil",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStorageS3"),
+						},
+					},
+					"volume": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PVC where the backups are written when the type is volume Default Value: nil",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStorageVolume"),
+						},
+					},
+					"compression": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Compression of the dumps written in the storage. Options: gzip or none Default Value: gzip",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStorageS3", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStorageVolume"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_BackupStorageS3(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BackupStorageS3 defines the options used to access S3-compatible services",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"endpointURL": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL of the service (E.g. https://minio.example.com:9000) Default Value: AWS S3",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"region": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Region of the bucket Default Value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"forcePathStyle": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When true the bucket is addressed in the path of the URL instead of the host name. It is required by MinIO. Default Value: false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_BackupStorageVolume(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BackupStorageVolume defines the PVC used as storage of the backups",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"claimName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the PVC pre-existing in the namespace of the Backup CR. It should be ReadWriteMany when the Jobs can run in different nodes.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"claimName"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_BackupWalArchiving(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BackupWalArchiving defines the base backups and the storage of the WAL segments of the Database NOTE: The base backups are taken with pg_basebackup which requires a user with the REPLICATION privilege. The replication user is used when the replication of the Database is enabled, otherwise the database user.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"enabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When true the base backups are taken and the Database can ship its WAL segments to the AWS S3 bucket Default Value: false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"baseBackupSchedule": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Schedule of the CronJob which takes the base backups Default Value: 0 0 * * 0",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_BackupWalArchivingStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BackupWalArchivingStatus defines the observed state of the base backups",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"baseBackupCronJobName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the CronJob which takes the base backups",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"lastBaseBackup": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key of the latest base backup uploaded to the AWS S3 bucket",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"lastBaseBackupTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the latest base backup finished",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_ClassStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ClassStatus defines the values used by the Database resolved from its DatabaseClass and spec",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the DatabaseClass",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"fields": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Fields of the spec which use the values of the DatabaseClass since they are not informed in the Database",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image of the Database",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseMemoryLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Limit of Memory of the database container",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseMemoryRequest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Memory requested by the database container",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseCpuLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Limit of CPU of the database container",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseCpu": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CPU requested by the database container",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseStorageRequest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Storage requested by the PVCs",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseStorageClassName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Storage Class of the PVCs",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message of the error when the DatabaseClass is not found",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_Condition(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Condition describes one aspect of the current state of the CR in the same format of the metav1.Condition",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of the condition (E.g. Ready)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status of the condition, one of True, False or Unknown",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Generation of the CR when the condition was set",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"lastTransitionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Last time when the condition changed from one status to another",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reason in CamelCase for the last transition of the condition",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Human readable message with details about the last transition",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"type", "status", "lastTransitionTime", "reason"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_Database(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type: []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseStatus"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseSpec", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabaseClass(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type: []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseClassSpec"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseClassSpec", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabaseClassBackup(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DatabaseClassBackup defines the backup policy of the Databases of the class",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"schedule": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Schedule of the CronJob of the Backup CRs which do not inform it Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"retention": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Retention policy of the Backup CRs which do not inform it Default value: nil",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupRetention"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupRetention"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabaseClassSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DatabaseClassSpec defines the values shared by the Databases which inform the class in the spec className. The values informed in the spec of the Database have priority over the ones of the class.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image of the Database Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseMemoryLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Limit of Memory which will be available for the database container Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseMemoryRequest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Memory requested by the database container Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseCpuLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Limit of CPU which will be available for the database container Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseCpu": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CPU requested by the database container Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseStorageRequest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Storage requested by the PVCs of the Database Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseStorageClassName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Storage Class of the PVCs of the Database Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"parameters": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Parameters of the postgresql.conf. The parameters informed in the spec of the Database are added to them. Default value: nil",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"backup": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Backup policy used by the Backup CRs of the Databases of the class Default value: nil",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseClassBackup"),
+						},
+					},
+					"nodeSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Node labels which the nodes of the pods of the Database must have Default value: nil",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"tolerations": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Tolerations of the pods of the Database Default value: nil",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.Toleration"),
+									},
+								},
+							},
+						},
+					},
+					"affinity": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Affinity of the pods of the Database Default value: nil",
+							Ref:         ref("k8s.io/api/core/v1.Affinity"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseClassBackup", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Toleration"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabaseGrant(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DatabaseGrant defines the privileges on a database granted to a role",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"role": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the role",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"privileges": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Privileges granted: CONNECT, CREATE, TEMPORARY or ALL",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"role", "privileges"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabaseHbaRule(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DatabaseHbaRule defines a rule of the pg_hba.conf",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of the connection. Options: local, host, hostssl and hostnossl",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"database": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Databases matched by the rule separated by comma (E.g. app,+readers or sameuser) Default value: all",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"user": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Users matched by the rule separated by comma. The roles prefixed by + match their members Default value: all",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"address": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Client address as CIDR (E.g. 10.0.0.0/8) or the keywords all, samehost and samenet. It is not informed for the type local",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"method": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Authentication method. Options: trust, reject, scram-sha-256, md5, password, gss, ident, peer (only for the type local) and pam",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"type", "method"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabasePasswordRotation(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DatabasePasswordRotation defines the policy to rotate the passwords generated by the operator",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"enabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When true the passwords are rotated Default value: false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"intervalDays": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of days between the rotations Default value: 30",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"gracePeriodMinutes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of minutes after the rotation which the previous role of the Secret can still log in with its password Default value: 60",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabaseReplication(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DatabaseReplication defines the streaming replication setup of the Database Each standby is created with its own Deployment and PersistentVolumeClaim and will stream the data from the primary by using the replication user.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"enabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When true the operator will create one primary and (spec.size - 1) hot standbys Default value: false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"replicationUser": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value for the Environment Variable (spec.replication.replicationUserKeyEnvVar). User which will be used by the standbys to connect to the primary Default value: replicator",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"replicationPassword": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value for the Environment Variable (spec.replication.replicationPasswordKeyEnvVar). Password of the user which will be used by the standbys to connect to the primary It is not used when the credentials are in a Secret (spec.credentialsSecretName or the one generated by the operator) since then the password is read from it. Default value: random password generated in the Secret <database>-credentials",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"replicationUserKeyEnvVar": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key Value for the Environment Variable in order to inform the replication user Note that each database version/image can expected a different value for it. Default value: POSTGRESQL_MASTER_USER",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"replicationPasswordKeyEnvVar": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key Value for the Environment Variable in order to inform the replication password Note that each database version/image can expected a different value for it. Default value: POSTGRESQL_MASTER_PASSWORD",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"primaryServiceKeyEnvVar": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key Value for the Environment Variable in order to inform to the standbys the host of the primary. It is used by the image to build the primary_conninfo of the standbys. Note that each database version/image can expected a different value for it. Default value: POSTGRESQL_MASTER_SERVICE_NAME",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"primaryCommand": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Command of the image used to start the primary Default value: run-postgresql-master",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"standbyCommand": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Command of the image used to start the standbys Default value: run-postgresql-slave",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabaseRole(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DatabaseRole defines a role of the database server managed by the operator",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the role. Only lowercase letters, digits and underscores are allowed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"login": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When false the role cannot log in and no Secret is generated. (E.g. a group role) Default value: true",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"createDB": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When true the role can create databases",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"createRole": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When true the role can create other roles",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"connectionLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Maximum of concurrent connections of the role. Default value: -1 (no limit)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"inRoles": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Roles which the role is member of",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabaseSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DatabaseSpec defines the desired state of Database",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"databaseName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value for the Database Environment Variable (spec.databaseNameKeyEnvVar). Default value: example",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databasePassword": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value for the Database Environment Variable (spec.databasePasswordKeyEnvVar). When it, the configMapName and the credentialsSecretName are not informed the operator generates a random password in the Secret <name>-credentials. Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseUser": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value for the Database Environment Variable (spec.databaseUserKeyEnvVar). Default value: postgres",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseNameKeyEnvVar": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key Value for the Database Environment Variable in order to inform the database mame Note that each database version/image can expected a different value for it. Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databasePasswordKeyEnvVar": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key Value for the Database Environment Variable in order to inform the database password Note that each database version/image can expected a different value for it. Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseUserKeyEnvVar": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key Value for the Database Environment Variable in order to inform the database user Note that each database version/image can expected a different value for it. Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databasePort": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value for the Database Environment Variable in order to define the port which it should use. It will be used in its container as well",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"size": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of instances When the replication is enabled it is the total of instances (primary + standbys). Otherwise, only 1 is allowed since all replicas would mount the same volume. Default value: 1",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Database image:tag Default value: centos/postgresql-96-centos7",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"postgresVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Major version of PostgreSQL run by the image (E.g. 9.6 or 12). When it is changed together with the image, the operator upgrades the data of the Database to the new major version before running the new image. Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"containerName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name to create the Database container",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseMemoryLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Limit of Memory which will be available for the database container Default value: 512Mi",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseMemoryRequest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Limit of Memory Request which will be available for the database container Default value: 128Mi",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseStorageRequest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Limit of Storage Request which will be available for the database container Default value: 1Gi",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseCpu": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CPU resource request which will be available for the database container Default value: 10Mi",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseCpuLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Limit of CPU request which will be available for the database container Default value: 20Mi",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"containerImagePullPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Policy definition to pull the Database Image More info: https://kubernetes.io/docs/concepts/containers/images/ Default value:",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"configMapName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the ConfigMap where the operator should looking for the EnvVars keys and/or values only Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"databaseStorageClassName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name the Storage Class name of the PVC which will be created for the Database More info: https://kubernetes.io/docs/concepts/storage/persistent-volumes/#persistentvolumeclaims Default value: standard",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"configMapDatabaseNameKey": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the configMap key where the operator should looking for the value for the database name for its env var Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"configMapDatabasePasswordKey": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the configMap key where the operator should looking for the value for the database user for its env var Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"configMapDatabaseUserKey": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the configMap key where the operator should looking for the value for the database password for its env var Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"credentialsSecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Secret where the operator should looking for the values of the database name, user and password for its env vars. It has priority over the configMapName. Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"credentialsSecretDatabaseNameKey": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Secret key where the operator should looking for the value for the database name for its env var Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"credentialsSecretUserKey": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Secret key where the operator should looking for the value for the database user for its env var Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"credentialsSecretPasswordKey": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Secret key where the operator should looking for the value for the database password for its env var Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"replication": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Setup to run the Database with streaming replication as one primary and (spec.size - 1) hot standbys Default value: nil",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseReplication"),
+						},
+					},
+					"workloadType": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind of the workload used to run the Database. Options: Deployment or StatefulSet When StatefulSet is used, the PVCs are created from its volumeClaimTemplates and the pods have stable names and DNS records by its headless Service (<name>-<ordinal>.<name>-headless). An existing Deployment will be migrated. Default value: Deployment",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"walArchiving": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Setup to ship the WAL segments to the AWS S3 bucket of a Backup CR in order to allow the point-in-time recovery Default value: nil",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseWalArchiving"),
+						},
+					},
+					"parameters": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Parameters of the postgresql.conf (E.g. max_connections: \"200\" or work_mem: 8MB). They are stored in a ConfigMap included in the configuration of the database. The changes are applied by reload or restart according to the parameter. Only the parameters known by the operator are allowed. Default value: nil",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"roles": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Roles created and managed by the operator in the database server. The password of each role which can log in is generated in a Secret with its credentials. Default value: nil",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseRole"),
+									},
+								},
+							},
+						},
+					},
+					"databases": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Databases created and managed by the operator in the database server with their owner and grants Default value: nil",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.LogicalDatabase"),
+									},
+								},
+							},
+						},
+					},
+					"passwordRotation": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Setup to rotate periodically the passwords generated by the operator (the Secret <name>-credentials and the Secrets of the spec.roles) Default value: nil",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabasePasswordRotation"),
+						},
+					},
+					"tls": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Setup to encrypt the client connections with TLS by a server certificate of an existing Secret, issued by cert-manager or generated by the operator with its own CA Default value: nil",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseTLS"),
+						},
+					},
+					"hba": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Ordered rules of the pg_hba.conf which control the access to the database server. They are stored in a ConfigMap and reloaded when they change. When informed, only the local connections and the replication are allowed besides them. Default value: nil (the pg_hba.conf of the image is used)",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseHbaRule"),
+									},
+								},
+							},
+						},
+					},
+					"className": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the cluster-scoped DatabaseClass with the values used when they are not informed in this spec. E.g. the image, resources, storage, parameters, backup policy and scheduling constraints. Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"nodeSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Node labels which the nodes of the pods of the Database must have Default value: nil",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"tolerations": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Tolerations of the pods of the Database Default value: nil",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.Toleration"),
+									},
+								},
+							},
+						},
+					},
+					"affinity": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Affinity of the pods of the Database Default value: nil",
+							Ref:         ref("k8s.io/api/core/v1.Affinity"),
+						},
+					},
+					"deletionPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Policy applied to the PVCs with the data when the Database is deleted. Options: Delete, Retain, Snapshot or BackupThenDelete. Retain keeps the PVCs, Snapshot takes a VolumeSnapshot of each PVC and BackupThenDelete does an on-demand backup by the Backup CR of the Database before the PVCs are deleted. Default value: Delete",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"volumeSnapshotClassName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the VolumeSnapshotClass of the snapshots taken when the deletionPolicy is Snapshot Default value: nil (the default VolumeSnapshotClass of the cluster is used)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseHbaRule", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabasePasswordRotation", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseReplication", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseRole", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseTLS", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseWalArchiving", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.LogicalDatabase", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Toleration"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabaseStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DatabaseStatus defines the observed state of Database",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"pvcStatus": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the PersistentVolumeClaim created and managed by it",
+							Ref:         ref("k8s.io/api/core/v1.PersistentVolumeClaimStatus"),
+						},
+					},
+					"deploymentStatus": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status of the Database Deployment created and managed by it",
+							Ref:         ref("k8s.io/api/apps/v1.DeploymentStatus"),
+						},
+					},
+					"statefulSetStatus": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status of the Database StatefulSet created and managed by it when the spec.workloadType is StatefulSet",
+							Ref:         ref("k8s.io/api/apps/v1.StatefulSetStatus"),
+						},
+					},
+					"serviceStatus": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status of the Database Service created and managed by it",
+							Ref:         ref("k8s.io/api/core/v1.ServiceStatus"),
+						},
+					},
+					"databaseStatus": {
+						SchemaProps: spec.SchemaProps{
+							Description: "It will be as \"OK when all objects are created successfully",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase of the Database: Pending, Provisioning, Ready, Degraded or Failed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Generation of the Database CR observed by the operator when the status was updated",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions of the Database: Provisioned, Ready, Degraded, BackupConfigured and StorageResized",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.Condition"),
+									},
+								},
+							},
+						},
+					},
+					"currentPrimary": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the member (Deployment or pod of the StatefulSet) which is running as primary when the replication is enabled",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"failoverEvents": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Latest failovers performed by the operator when the primary was not available",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.FailoverEvent"),
+									},
+								},
+							},
+						},
+					},
+					"primaryUnavailableSince": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time since the primary is not available through its Service. A standby is only promoted after the grace period",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"walArchiving": {
+						SchemaProps: spec.SchemaProps{
+							Description: "State of the WAL archiving of the primary when the walArchiving is enabled",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.WalArchivingStatus"),
+						},
+					},
+					"rollout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Latest rollout done by the operator to apply the changes of the spec to the Deployments or StatefulSet",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.RolloutStatus"),
+						},
+					},
+					"postgresVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Major version of PostgreSQL of the data of the Database when the spec.postgresVersion is informed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"upgrade": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Latest upgrade of the major version of PostgreSQL done by the operator",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.UpgradeStatus"),
+						},
+					},
+					"parameters": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Latest change of the spec.parameters applied by the operator",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.ParametersStatus"),
+						},
+					},
+					"roles": {
+						SchemaProps: spec.SchemaProps{
+							Description: "State of the roles of the spec in the database server",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.ServerObjectStatus"),
+									},
+								},
+							},
+						},
+					},
+					"databases": {
+						SchemaProps: spec.SchemaProps{
+							Description: "State of the databases of the spec in the database server",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.ServerObjectStatus"),
+									},
+								},
+							},
+						},
+					},
+					"lastRotationTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time of the latest rotation of the passwords when the passwordRotation is enabled",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"tls": {
+						SchemaProps: spec.SchemaProps{
+							Description: "State of the server certificate when the tls is enabled",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.TLSStatus"),
+						},
+					},
+					"hba": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Latest change of the pg_hba.conf done by the operator",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.HbaStatus"),
+						},
+					},
+					"class": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Values resolved from the DatabaseClass and the spec when the className is informed",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.ClassStatus"),
+						},
+					},
+					"deletion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "State of the deletionPolicy applied by the operator when the Database is deleted",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DeletionStatus"),
+						},
+					},
+				},
+				Required: []string{"pvcStatus", "deploymentStatus", "serviceStatus", "databaseStatus"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.ClassStatus", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.Condition", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DeletionStatus", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.FailoverEvent", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.HbaStatus", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.ParametersStatus", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.RolloutStatus", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.ServerObjectStatus", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.TLSStatus", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.UpgradeStatus", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.WalArchivingStatus", "k8s.io/api/apps/v1.DeploymentStatus", "k8s.io/api/apps/v1.StatefulSetStatus", "k8s.io/api/core/v1.PersistentVolumeClaimStatus", "k8s.io/api/core/v1.ServiceStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabaseTLS(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DatabaseTLS defines the server certificate used to encrypt the client connections",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"enabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When true the database server accepts the connections with TLS (ssl=on) Default value: false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"secretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of an existing Secret of the type kubernetes.io/tls (keys tls.crt and tls.key) with the server certificate Default value: nil",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"certManager": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Issuer of cert-manager which issues the server certificate in the Secret <name>-tls. When neither the secretName nor the certManager are informed the operator generates its own CA and the server certificate in this Secret. Default value: nil",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseTLSCertManager"),
+						},
+					},
+					"enforceSSL": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When true only the connections with TLS are accepted (hostssl in the pg_hba.conf) Default value: false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"validityDays": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of days which the server certificates issued by cert-manager or the operator are valid Default value: 365",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"renewBeforeDays": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of days before the expiry which the server certificates are renewed Default value: 30",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseTLSCertManager"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabaseTLSCertManager(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DatabaseTLSCertManager defines the issuer of cert-manager which issues the server certificate",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"issuerName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Issuer or ClusterIssuer",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"issuerKind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind of the issuer. Options: Issuer or ClusterIssuer Default value: Issuer",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"issuerName"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DatabaseWalArchiving(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DatabaseWalArchiving defines the continuous archiving of the WAL segments of the Database The database is started with archive_mode on and its archive_command hands over each segment to a sidecar container which uploads it to the AWS S3 bucket of the Backup CR. The segment is only considered archived after the upload.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"enabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When true the WAL segments are shipped to the AWS S3 bucket Default value: false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"backupCRName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Backup CR applied with the walArchiving enabled. Its AWS S3 bucket receives the WAL segments. Default value: backup",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"archiveTimeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time in seconds after which the current WAL segment is archived even if it is not full. It is the maximum data loss when the database has low activity. Default value: 60",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image:tag of the sidecar container which uploads the WAL segments. It requires s3cmd. Default value: quay.io/integreatly/backup-container:1.0.8",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_DeletionStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DeletionStatus defines the state of the deletionPolicy applied to the PVCs when the Database is deleted",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"policy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Deletion policy applied",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase of the deletion: Retaining, Snapshotting, BackingUp, Completed or Failed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"persistentVolumeClaims": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Names of the PVCs of the Database",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"snapshots": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Names of the VolumeSnapshots taken when the policy is Snapshot",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"backupName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Backup CR which did the backup when the policy is BackupThenDelete",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"backupJobName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Job of the backup when the policy is BackupThenDelete",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"startTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the policy started to be applied",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the policy was applied and the finalizer removed",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message of the error which blocks the deletion",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"policy", "phase"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_FailoverEvent(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FailoverEvent defines a promotion of a standby to primary performed by the operator",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"time": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the standby was promoted",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"oldPrimary": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the member which was the primary",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"newPrimary": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the member promoted to primary",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"lsn": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Last WAL location received by the standby promoted",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reason of the failover",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"time", "oldPrimary", "newPrimary"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_HbaStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "HbaStatus defines the change of the pg_hba.conf done by the operator",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"configMapName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the ConfigMap with the pg_hba.conf",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase of the change: Reloading, Restarting or Applied",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"rules": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of rules of the spec applied",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"changeTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the ConfigMap was changed",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"appliedTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the change was applied by all instances",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+				Required: []string{"configMapName", "phase", "rules", "changeTime"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_LogicalDatabase(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "LogicalDatabase defines a database of the database server managed by the operator",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the database. Only lowercase letters, digits and underscores are allowed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"owner": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Role which owns the database. Default value: postgres",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"grants": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Privileges on the database granted to the roles",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseGrant"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseGrant"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_OnDemandBackupStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "OnDemandBackupStatus defines the observed state of the latest on-demand backup",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"trigger": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value of the trigger which requested the backup",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"jobName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Job object created to do the backup",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase of the backup. Options: Running, Succeeded or Failed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"startTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the backup started",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the backup finished",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"error": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message of the error when the backup failed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"trigger", "jobName", "phase"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_ParametersStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ParametersStatus defines the change of the parameters of the postgresql.conf done by the operator",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"configMapName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the ConfigMap with the parameters included in the postgresql.conf",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase of the change: Reloading, Restarting or Applied",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"reload": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Parameters changed which are applied by the reload of the configuration",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"restart": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Parameters changed which require the restart of the database",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"changeTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the ConfigMap was changed",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"appliedTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the change was applied by all instances",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+				Required: []string{"configMapName", "phase", "changeTime"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_Restore(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type: []string{"object"},
 				Properties: map[string]spec.Schema{
-					"databaseCRName": {
+					"kind": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name of the Database CR applied which this backup will work with Default Value: \"database\"",
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"schedule": {
+					"apiVersion": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Schedule period for the CronJob. Default Value: <0 0 * * *> daily at 00:00",
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"image": {
+					"metadata": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Image:tag used to do the backup. Default Value: <quay.io/integreatly/backup-container:1.0.8> More Info: https://github.com/integr8ly/backup-container-image",
-							Type:        []string{"string"},
-							Format:      "",
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
 						},
 					},
-					"databaseVersion": {
+					"spec": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Database version. (E.g 9.6). Default Value: <9.6> IMPORTANT: Just the first 2 digits should be used.",
+							Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.RestoreSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.RestoreStatus"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.RestoreSpec", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.RestoreStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_RestoreSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "RestoreSpec defines the desired state of Restore",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"databaseCRName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Database CR applied where the dump will be loaded Default Value: \"database\"",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"productName": {
+					"backupCRName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Used to create the directory where the files will be stored Default Value: <postgresql>",
+							Description: "Name of the Backup CR applied which did the backup. Its AWS secret, productName and encryption secret will be used when they are not informed in this CR. Default Value: nil",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"awsS3BucketName": {
+					"objectKey": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name of AWS S3 storage. Default Value: nil Required to create the Secret with the AWS data to allow send the backup files to AWS S3 storage.",
+							Description: "Key of the dump object in the AWS S3 bucket. (E.g backups/postgresql/postgres/2020/07/06/postgresql.example-00_00_00.pg_dump.gz) Default Value: nil NOTE: If the key be not informed then the latest dump of the database found in the bucket will be used",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"awsAccessKeyId": {
+					"productName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Key ID of AWS S3 storage. Default Value: nil Required to create the Secret with the data to allow send the backup files to AWS S3 storage.",
+							Description: "Used to find the directory where the dumps are stored when the objectKey is not informed Default Value: productName of the Backup CR",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"awsSecretAccessKey": {
+					"image": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Secret/Token of AWS S3 storage. Default Value: nil Required to create the Secret with the data to allow send the backup files to AWS S3 storage.",
+							Description: "Image:tag used to do the restore. It requires s3cmd, gpg and psql. Default Value: <quay.io/integreatly/backup-container:1.0.8>",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
 					"awsSecretName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name of the secret with the AWS data credentials pre-existing in the cluster Default Value: nil See here the template: https://github.com/integr8ly/backup-container-image/blob/master/templates/openshift/sample-config/s3-secret.yaml",
+							Description: "Name of the secret with the AWS data credentials pre-existing in the cluster Default Value: AWS secret of the Backup CR See here the template: https://github.com/integr8ly/backup-container-image/blob/master/templates/openshift/sample-config/s3-secret.yaml",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -140,372 +2508,491 @@ func schema_pkg_apis_postgresql_v1alpha1_BackupSpec(ref common.ReferenceCallback
 							Format:      "",
 						},
 					},
-					"encryptKeySecretName": {
+					"decryptKeySecretName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name of the secret with the Encrypt data pre-existing in the cluster Default Value: nil See here the template: https://github.com/integr8ly/backup-container-image/blob/master/templates/openshift/sample-config/gpg-secret.yaml",
+							Description: "Name of the secret with the GPG data pre-existing in the cluster to decrypt the dump. It has the same keys of the secret used to encrypt the backup (GPG_PUBLIC_KEY, GPG_RECIPIENT, GPG_TRUST_MODEL) and also the GPG_PRIVATE_KEY (base64 encoded) and the optional GPG_PASSPHRASE Default Value: nil NOTE: It is required when the Backup CR referenced encrypts the backups",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"encryptKeySecretNamespace": {
+					"decryptKeySecretNamespace": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Namespace of the secret with the Encrypt data pre-existing in the cluster Default Value: nil NOTE: If the namespace be not informed then the operator will try to find it in the same namespace where it is applied",
+							Description: "Namespace of the secret with the GPG data pre-existing in the cluster to decrypt the dump Default Value: nil NOTE: If the namespace be not informed then the operator will try to find it in the same namespace where it is applied",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"gpgPublicKey": {
+					"targetTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "GPG public key to create the EncryptionKeySecret with this data Default Value: nil See here how to create this key : https://help.github.com/en/articles/generating-a-new-gpg-key",
+							Description: "Time until which the WAL segments are replayed in order to do the point-in-time recovery in the RFC3339 format. (E.g. 2020-07-06T15:04:05Z) Default Value: nil NOTE: When it or the targetLSN is informed the objectKey is the key of the base backup and the latest one taken before the target is used when it is not informed. It requires the walArchiving enabled in the Database and Backup.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"gpgEmail": {
+					"targetLSN": {
 						SchemaProps: spec.SchemaProps{
-							Description: "GPG email to create the EncryptionKeySecret with this data Default Value: nil See here how to create this key : https://help.github.com/en/articles/generating-a-new-gpg-key",
+							Description: "WAL location until which the WAL segments are replayed in order to do the point-in-time recovery. (E.g. 0/3000060) Default Value: nil NOTE: It requires PostgreSQL 10 or later and cannot be informed with the targetTime. The latest base backup is used when the objectKey is not informed.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"gpgTrustModel": {
+					"storage": {
 						SchemaProps: spec.SchemaProps{
-							Description: "GPG trust model to create the EncryptionKeySecret with this data. the default value is true when it is empty. Default Value: nil See here how to create this key : https://help.github.com/en/articles/generating-a-new-gpg-key",
-							Type:        []string{"string"},
-							Format:      "",
+							Description: "Storage backend where the dumps and base backups are stored. Its secret is validated as in the Backup CR. Default Value: storage of the Backup CR NOTE: If it be not informed and none Backup CR is referenced then the AWS secret is used",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStorage"),
 						},
 					},
 				},
 			},
 		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.BackupStorage"},
 	}
 }
 
-func schema_pkg_apis_postgresql_v1alpha1_BackupStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_postgresql_v1alpha1_RestoreStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "BackupStatus defines the observed state of Backup",
+				Description: "RestoreStatus defines the observed state of Restore",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"backupStatus": {
+					"phase": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Will be as \"OK when all objects are created successfully",
+							Description: "Phase of the restore. Options: Pending, Running, Succeeded or Failed",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"cronJobName": {
+					"jobName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name of the CronJob object created and managed by it to schedule the backup job",
+							Description: "Name of the Job object created and managed by it to do the restore",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"dbSecretName": {
+					"objectKey": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name of the secret object created with the database data to allow the backup image connect to the database",
+							Description: "Key of the dump object restored or of the base backup used by the point-in-time recovery",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"awsSecretName": {
+					"startTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name  of the secret object with the Aws data to allow send the backup files to the AWS storage",
-							Type:        []string{"string"},
-							Format:      "",
+							Description: "Time when the restore started",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"awsCredentialsSecretNamespace": {
+					"completionTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Namespace  of the secret object with the Aws data to allow send the backup files to the AWS storage",
-							Type:        []string{"string"},
-							Format:      "",
+							Description: "Time when the restore finished",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"encryptKeySecretName": {
+					"error": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name  of the secret object with the Encryption GPG Key",
+							Description: "Message of the error when the restore could not be done",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"encryptKeySecretNamespace": {
+				},
+				Required: []string{"phase"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_RolloutStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "RolloutStatus defines an update of the workloads of the Database done by the operator when the spec changed",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"generation": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Namespace of the secret object with the Encryption GPG Key",
+							Description: "Generation of the Database CR applied by the rollout",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase of the rollout: Progressing or Complete",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"hasEncryptKey": {
+					"startTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Boolean value which has true when it has an EncryptionKey to be used to send the backup files",
-							Type:        []string{"boolean"},
-							Format:      "",
+							Description: "Time when the workloads were updated",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"isDatabasePodFound": {
+					"completionTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Boolean value which has true when the Database Pod was found in order to create the secret with the database data to allow the backup image connect into it.",
-							Type:        []string{"boolean"},
-							Format:      "",
+							Description: "Time when all pods of the workloads were updated and available",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"isDatabaseServiceFound": {
+					"workloads": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Boolean value which has true when the Service Database Pod was found in order to create the secret with the database data to allow the backup image connect into it.",
-							Type:        []string{"boolean"},
-							Format:      "",
+							Description: "Names of the Deployments or StatefulSet updated",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
 						},
 					},
-					"cronJobStatus": {
+					"changes": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Status of the CronJob object",
-							Ref:         ref("k8s.io/api/batch/v1beta1.CronJobStatus"),
+							Description: "Fields changed in the workloads. E.g. database.image or database.resources",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
 						},
 					},
 				},
-				Required: []string{"backupStatus", "cronJobName", "dbSecretName", "awsSecretName", "awsCredentialsSecretNamespace", "encryptKeySecretName", "encryptKeySecretNamespace", "hasEncryptKey", "isDatabasePodFound", "isDatabaseServiceFound", "cronJobStatus"},
+				Required: []string{"generation", "phase", "startTime", "workloads"},
 			},
 		},
 		Dependencies: []string{
-			"k8s.io/api/batch/v1beta1.CronJobStatus"},
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
-func schema_pkg_apis_postgresql_v1alpha1_Database(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_postgresql_v1alpha1_ServerObjectStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Type: []string{"object"},
+				Description: "ServerObjectStatus defines the state of a role or database managed by the operator in the database server",
+				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"kind": {
+					"name": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Description: "Name of the role or database",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"apiVersion": {
+					"phase": {
 						SchemaProps: spec.SchemaProps{
-							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Description: "Phase of the object: Pending, Ready or Failed",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"metadata": {
+					"secretName": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+							Description: "Name of the Secret with the credentials of the role",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"spec": {
+					"hash": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseSpec"),
+							Description: "Hash of the spec applied. It allows apply the object only when it changes",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"status": {
+					"lastAppliedTime": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseStatus"),
+							Description: "Time when the object was applied in the database server",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message of the error when the object could not be applied",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
 				},
+				Required: []string{"name", "phase"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseSpec", "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
-func schema_pkg_apis_postgresql_v1alpha1_DatabaseSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_postgresql_v1alpha1_TLSStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "DatabaseSpec defines the desired state of Database",
+				Description: "TLSStatus defines the state of the server certificate used by the Database",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"databaseName": {
+					"secretName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Value for the Database Environment Variable (spec.databaseNameKeyEnvVar). Default value: example",
+							Description: "Name of the Secret with the server certificate",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"databasePassword": {
+					"source": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Value for the Database Environment Variable (spec.databasePasswordKeyEnvVar). Default value: postgres",
+							Description: "Origin of the certificate: Secret, CertManager or SelfSigned",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"databaseUser": {
+					"notAfter": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Value for the Database Environment Variable (spec.databaseUserKeyEnvVar). Default value: postgres",
-							Type:        []string{"string"},
-							Format:      "",
+							Description: "Time when the certificate expires",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"databaseNameKeyEnvVar": {
+					"hash": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Key Value for the Database Environment Variable in order to inform the database mame Note that each database version/image can expected a different value for it. Default value: nil",
+							Description: "Hash of the certificate. The pods are restarted when it changes in order to load the new certificate",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"databasePasswordKeyEnvVar": {
+					"lastRenewalTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Key Value for the Database Environment Variable in order to inform the database password Note that each database version/image can expected a different value for it. Default value: nil",
-							Type:        []string{"string"},
-							Format:      "",
+							Description: "Time when the certificate was issued or renewed by the operator",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"databaseUserKeyEnvVar": {
+					"message": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Key Value for the Database Environment Variable in order to inform the database user Note that each database version/image can expected a different value for it. Default value: nil",
+							Description: "Message of the error when the certificate is not found or is invalid",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"databasePort": {
+					"appliedSpec": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Value for the Database Environment Variable in order to define the port which it should use. It will be used in its container as well",
-							Type:        []string{"integer"},
-							Format:      "int32",
+							Description: "Spec tls applied to the pods with the certificate of the hash. It is kept in the pods while the spec tls is invalid or its certificate is not available, so they are not redeployed without TLS",
+							Ref:         ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseTLS"),
 						},
 					},
-					"size": {
+				},
+				Required: []string{"secretName", "source"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.DatabaseTLS", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_UpgradeStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UpgradeStatus defines the upgrade of the data of the Database to a new major version of PostgreSQL The data is dumped with the previous image and loaded with the new one. The previous data is kept in the PVC in order to allow the rollback when some step fails.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"fromVersion": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Quantity of instances Default value: 1",
-							Type:        []string{"integer"},
-							Format:      "int32",
+							Description: "Major version of the data before the upgrade",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"image": {
+					"toVersion": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Database image:tag Default value: centos/postgresql-96-centos7",
+							Description: "Major version of the data after the upgrade",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"containerName": {
+					"fromImage": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name to create the Database container",
+							Description: "Image which was running before the upgrade",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"databaseMemoryLimit": {
+					"toImage": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Limit of Memory which will be available for the database container Default value: 512Mi",
+							Description: "Image which runs after the upgrade",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"databaseMemoryRequest": {
+					"generation": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Limit of Memory Request which will be available for the database container Default value: 128Mi",
-							Type:        []string{"string"},
-							Format:      "",
+							Description: "Generation of the Database CR which requested the upgrade",
+							Type:        []string{"integer"},
+							Format:      "int64",
 						},
 					},
-					"databaseStorageRequest": {
+					"phase": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Limit of Storage Request which will be available for the database container Default value: 1Gi",
+							Description: "Phase of the upgrade: BackingUp, Dumping, Upgrading, Swapping, RollingBack, Succeeded, Failed or Cancelled",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"databaseCpu": {
+					"startTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "CPU resource request which will be available for the database container Default value: 10Mi",
-							Type:        []string{"string"},
-							Format:      "",
+							Description: "Time when the upgrade started",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"databaseCpuLimit": {
+					"completionTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Limit of CPU request which will be available for the database container Default value: 20Mi",
-							Type:        []string{"string"},
-							Format:      "",
+							Description: "Time when the upgrade finished",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"containerImagePullPolicy": {
+					"message": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Policy definition to pull the Database Image More info: https://kubernetes.io/docs/concepts/containers/images/ Default value:",
+							Description: "Message of the error when the upgrade failed",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"configMapName": {
+					"steps": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name of the ConfigMap where the operator should looking for the EnvVars keys and/or values only Default value: nil",
+							Description: "Steps done by the upgrade",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.UpgradeStep"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"fromVersion", "toVersion", "fromImage", "toImage", "generation", "phase", "startTime"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1.UpgradeStep", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_postgresql_v1alpha1_UpgradeStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UpgradeStep defines a step of the upgrade of the Database",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the step: Backup, Dump, Upgrade, Swap or Rollback",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"configMapDatabaseNameKey": {
+					"phase": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name of the configMap key where the operator should looking for the value for the database name for its env var Default value: nil",
+							Description: "Phase of the step: Running, Succeeded, Failed or Skipped",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"configMapDatabasePasswordKey": {
+					"startTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name of the configMap key where the operator should looking for the value for the database user for its env var Default value: nil",
+							Description: "Time when the step started",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time when the step finished",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"jobName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Job which runs the step",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"configMapDatabaseUserKey": {
+					"message": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name of the configMap key where the operator should looking for the value for the database password for its env var Default value: nil",
+							Description: "Details of the result of the step",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
 				},
+				Required: []string{"name", "phase", "startTime"},
 			},
 		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
-func schema_pkg_apis_postgresql_v1alpha1_DatabaseStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_postgresql_v1alpha1_WalArchivingStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "DatabaseStatus defines the observed state of Database",
+				Description: "WalArchivingStatus defines the observed state of the WAL archiving from the pg_stat_archiver of the primary",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"pvcStatus": {
+					"lastArchivedWal": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name of the PersistentVolumeClaim created and managed by it",
-							Ref:         ref("k8s.io/api/core/v1.PersistentVolumeClaimStatus"),
+							Description: "Name of the last WAL segment archived successfully",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"deploymentStatus": {
+					"lastArchivedTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Status of the Database Deployment created and managed by it",
-							Ref:         ref("k8s.io/api/apps/v1.DeploymentStatus"),
+							Description: "Time of the last successful archive",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"serviceStatus": {
+					"lastFailedWal": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Status of the Database Service created and managed by it",
-							Ref:         ref("k8s.io/api/core/v1.ServiceStatus"),
+							Description: "Name of the last WAL segment which failed to be archived",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"databaseStatus": {
+					"lastFailedTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "It will be as \"OK when all objects are created successfully",
+							Description: "Time of the last failed archive",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"currentWal": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the WAL segment which is being written",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"archiveLagSegments": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Quantity of WAL segments completed which were not archived yet",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
 				},
-				Required: []string{"pvcStatus", "deploymentStatus", "serviceStatus", "databaseStatus"},
+				Required: []string{"archiveLagSegments"},
 			},
 		},
 		Dependencies: []string{
-			"k8s.io/api/apps/v1.DeploymentStatus", "k8s.io/api/core/v1.PersistentVolumeClaimStatus", "k8s.io/api/core/v1.ServiceStatus"},
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
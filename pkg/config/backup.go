@@ -5,20 +5,32 @@ const (
 	bakupImage      = "quay.io/integreatly/backup-container:1.0.8"
 	databaseVersion = "9.6"
 	databaseCRName  = "database"
+
+	// Weekly on Sunday at 00:00
+	baseBackupSchedule = "0 0 * * 0"
+
+	storageType = "s3"
+	compression = "gzip"
 )
 
 type DefaultBackupConfig struct {
-	Schedule        string `json:"schedule"`
-	Image           string `json:"image"`
-	DatabaseVersion string `json:"databaseVersion"`
-	DatabaseCRName  string `json:"databaseCRName"`
+	Schedule           string `json:"schedule"`
+	Image              string `json:"image"`
+	DatabaseVersion    string `json:"databaseVersion"`
+	DatabaseCRName     string `json:"databaseCRName"`
+	BaseBackupSchedule string `json:"baseBackupSchedule"`
+	StorageType        string `json:"storageType"`
+	Compression        string `json:"compression"`
 }
 
 func NewDefaultBackupConfig() *DefaultBackupConfig {
 	return &DefaultBackupConfig{
-		Schedule:        schedule,
-		Image:           bakupImage,
-		DatabaseVersion: databaseVersion,
-		DatabaseCRName:  databaseCRName,
+		Schedule:           schedule,
+		Image:              bakupImage,
+		DatabaseVersion:    databaseVersion,
+		DatabaseCRName:     databaseCRName,
+		BaseBackupSchedule: baseBackupSchedule,
+		StorageType:        storageType,
+		Compression:        compression,
 	}
 }
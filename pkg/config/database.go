@@ -3,7 +3,6 @@ package config
 const (
 	size                      = 1
 	databaseName              = "solution"
-	databasePassword          = "postgres"
 	databaseUser              = "postgres"
 	databaseNameKeyEnvVar     = "POSTGRESQL_DATABASE"
 	databasePasswordKeyEnvVar = "POSTGRESQL_PASSWORD"
@@ -17,44 +16,99 @@ const (
 	databaseStorageClassName  = "standard"
 	databaseCpuLimit          = "60m"
 	databaseCpu               = "30m"
+	workloadType              = "Deployment"
+	deletionPolicy            = "Delete"
+
+	// Replication values expected by the image centos/postgresql-96-centos7
+	// More info: https://github.com/sclorg/postgresql-container/tree/master/examples/replica
+	replicationUser              = "replicator"
+	replicationUserKeyEnvVar     = "POSTGRESQL_MASTER_USER"
+	replicationPasswordKeyEnvVar = "POSTGRESQL_MASTER_PASSWORD"
+	primaryServiceKeyEnvVar      = "POSTGRESQL_MASTER_SERVICE_NAME"
+	primaryCommand               = "run-postgresql-master"
+	standbyCommand               = "run-postgresql-slave"
+
+	// WAL archiving values. The segments are uploaded by a sidecar with the image used by the Backup
+	walArchivingBackupCRName = "backup"
+	archiveTimeout           = 60
+	walArchiverImage         = "quay.io/integreatly/backup-container:1.0.8"
+
+	// Password rotation values
+	passwordRotationIntervalDays       = 30
+	passwordRotationGracePeriodMinutes = 60
+
+	// TLS values
+	tlsValidityDays       = 365
+	tlsRenewBeforeDays    = 30
+	certManagerIssuerKind = "Issuer"
 )
 
 type DefaultDatabaseConfig struct {
-	Size                      int32  `json:"size"`
-	Image                     string `json:"image"`
-	DatabaseName              string `json:"databaseName"`
-	DatabasePassword          string `json:"databasePassword"`
-	DatabaseUser              string `json:"databaseUser"`
-	DatabaseNameKeyEnvVar     string `json:"databaseNameKeyEnvVar"`
-	DatabasePasswordKeyEnvVar string `json:"databasePasswordKeyEnvVar"`
-	DatabaseUserKeyEnvVar     string `json:"databaseUserKeyEnvVar"`
-	ContainerName             string `json:"containerName"`
-	DatabasePort              int32  `json:"databasePort"`
-	DatabaseMemoryLimit       string `json:"databaseMemoryLimit"`
-	DatabaseMemoryRequest     string `json:"databaseMemoryRequest"`
-	DatabaseCpuLimit          string `json:"databaseCpuLimit"`
-	DatabaseCpu               string `json:"databaseCpu"`
-	DatabaseStorageRequest    string `json:"databaseStorageRequest"`
-	DatabaseStorageClassName  string `json:"databaseStorageClassName"`
+	Size                         int32  `json:"size"`
+	Image                        string `json:"image"`
+	DatabaseName                 string `json:"databaseName"`
+	DatabaseUser                 string `json:"databaseUser"`
+	DatabaseNameKeyEnvVar        string `json:"databaseNameKeyEnvVar"`
+	DatabasePasswordKeyEnvVar    string `json:"databasePasswordKeyEnvVar"`
+	DatabaseUserKeyEnvVar        string `json:"databaseUserKeyEnvVar"`
+	ContainerName                string `json:"containerName"`
+	DatabasePort                 int32  `json:"databasePort"`
+	DatabaseMemoryLimit          string `json:"databaseMemoryLimit"`
+	DatabaseMemoryRequest        string `json:"databaseMemoryRequest"`
+	DatabaseCpuLimit             string `json:"databaseCpuLimit"`
+	DatabaseCpu                  string `json:"databaseCpu"`
+	DatabaseStorageRequest       string `json:"databaseStorageRequest"`
+	DatabaseStorageClassName     string `json:"databaseStorageClassName"`
+	ReplicationUser              string `json:"replicationUser"`
+	ReplicationUserKeyEnvVar     string `json:"replicationUserKeyEnvVar"`
+	ReplicationPasswordKeyEnvVar string `json:"replicationPasswordKeyEnvVar"`
+	PrimaryServiceKeyEnvVar      string `json:"primaryServiceKeyEnvVar"`
+	PrimaryCommand               string `json:"primaryCommand"`
+	StandbyCommand               string `json:"standbyCommand"`
+	WorkloadType                 string `json:"workloadType"`
+	WalArchivingBackupCRName     string `json:"walArchivingBackupCRName"`
+	ArchiveTimeout               int32  `json:"archiveTimeout"`
+	WalArchiverImage             string `json:"walArchiverImage"`
+	RotationIntervalDays         int32  `json:"rotationIntervalDays"`
+	RotationGracePeriodMinutes   int32  `json:"rotationGracePeriodMinutes"`
+	TLSValidityDays              int32  `json:"tlsValidityDays"`
+	TLSRenewBeforeDays           int32  `json:"tlsRenewBeforeDays"`
+	CertManagerIssuerKind        string `json:"certManagerIssuerKind"`
+	DeletionPolicy               string `json:"deletionPolicy"`
 }
 
 func NewDatabaseConfig() *DefaultDatabaseConfig {
 	return &DefaultDatabaseConfig{
-		Size:                      size,
-		Image:                     image,
-		DatabaseName:              databaseName,
-		DatabasePassword:          databasePassword,
-		DatabaseUser:              databaseUser,
-		DatabaseNameKeyEnvVar:     databaseNameKeyEnvVar,
-		DatabasePasswordKeyEnvVar: databasePasswordKeyEnvVar,
-		DatabaseUserKeyEnvVar:     databaseUserKeyEnvVar,
-		ContainerName:             containerName,
-		DatabasePort:              databasePort,
-		DatabaseMemoryLimit:       databaseMemoryLimit,
-		DatabaseMemoryRequest:     databaseMemoryRequest,
-		DatabaseCpu:               databaseCpu,
-		DatabaseCpuLimit:          databaseCpuLimit,
-		DatabaseStorageRequest:    databaseStorageRequest,
-		DatabaseStorageClassName:  databaseStorageClassName,
+		Size:                         size,
+		Image:                        image,
+		DatabaseName:                 databaseName,
+		DatabaseUser:                 databaseUser,
+		DatabaseNameKeyEnvVar:        databaseNameKeyEnvVar,
+		DatabasePasswordKeyEnvVar:    databasePasswordKeyEnvVar,
+		DatabaseUserKeyEnvVar:        databaseUserKeyEnvVar,
+		ContainerName:                containerName,
+		DatabasePort:                 databasePort,
+		DatabaseMemoryLimit:          databaseMemoryLimit,
+		DatabaseMemoryRequest:        databaseMemoryRequest,
+		DatabaseCpu:                  databaseCpu,
+		DatabaseCpuLimit:             databaseCpuLimit,
+		DatabaseStorageRequest:       databaseStorageRequest,
+		DatabaseStorageClassName:     databaseStorageClassName,
+		ReplicationUser:              replicationUser,
+		ReplicationUserKeyEnvVar:     replicationUserKeyEnvVar,
+		ReplicationPasswordKeyEnvVar: replicationPasswordKeyEnvVar,
+		PrimaryServiceKeyEnvVar:      primaryServiceKeyEnvVar,
+		PrimaryCommand:               primaryCommand,
+		StandbyCommand:               standbyCommand,
+		WorkloadType:                 workloadType,
+		WalArchivingBackupCRName:     walArchivingBackupCRName,
+		ArchiveTimeout:               archiveTimeout,
+		WalArchiverImage:             walArchiverImage,
+		RotationIntervalDays:         passwordRotationIntervalDays,
+		RotationGracePeriodMinutes:   passwordRotationGracePeriodMinutes,
+		TLSValidityDays:              tlsValidityDays,
+		TLSRenewBeforeDays:           tlsRenewBeforeDays,
+		CertManagerIssuerKind:        certManagerIssuerKind,
+		DeletionPolicy:               deletionPolicy,
 	}
 }
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// OperatorConfigPathEnvVar is the env var with the path of the configuration file of the operator
+	OperatorConfigPathEnvVar = "OPERATOR_CONFIG_PATH"
+	// DefaultOperatorConfigPath is the path where the ConfigMap with the configuration file is mounted
+	DefaultOperatorConfigPath = "/etc/postgresql-operator/config.yaml"
+	// OperatorConfigReloadInterval is the interval in which the changes of the configuration file are checked
+	OperatorConfigReloadInterval = 10 * time.Second
+)
+
+var log = logf.Log.WithName("config")
+
+// OperatorConfig has the default values used for the CRs of the cluster and the ones of each namespace
+type OperatorConfig struct {
+	defaults   operatorDefaults
+	namespaces map[string]operatorDefaults
+}
+
+// operatorDefaults has the default values of each CR
+type operatorDefaults struct {
+	database *DefaultDatabaseConfig
+	backup   *DefaultBackupConfig
+	restore  *DefaultRestoreConfig
+}
+
+// operatorConfigFile is the configuration file where each section only informs the values which should be changed
+type operatorConfigFile struct {
+	operatorDefaultsFile
+	Namespaces map[string]operatorDefaultsFile `json:"namespaces,omitempty"`
+}
+
+// operatorDefaultsFile has the values of each CR informed in a section of the configuration file
+type operatorDefaultsFile struct {
+	Database json.RawMessage `json:"database,omitempty"`
+	Backup   json.RawMessage `json:"backup,omitempty"`
+	Restore  json.RawMessage `json:"restore,omitempty"`
+}
+
+var (
+	mutex          sync.RWMutex
+	operatorConfig = NewOperatorConfig()
+)
+
+// NewOperatorConfig returns the configuration with the default values of the operator
+func NewOperatorConfig() *OperatorConfig {
+	return &OperatorConfig{
+		defaults: operatorDefaults{
+			database: NewDatabaseConfig(),
+			backup:   NewDefaultBackupConfig(),
+			restore:  NewDefaultRestoreConfig(),
+		},
+	}
+}
+
+// ParseOperatorConfig returns the configuration of the YAML or JSON file informed
+// NOTE: The values not informed are kept with the default values of the operator and the ones not informed for a
+// namespace are kept with the values of the cluster
+func ParseOperatorConfig(data []byte) (*OperatorConfig, error) {
+	data, err := yaml.ToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("Error: The configuration file is invalid: %v", err)
+	}
+	file := &operatorConfigFile{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(file); err != nil {
+		return nil, fmt.Errorf("Error: The configuration file is invalid: %v", err)
+	}
+
+	cfg := NewOperatorConfig()
+	if err := file.operatorDefaultsFile.apply(&cfg.defaults); err != nil {
+		return nil, err
+	}
+	cfg.namespaces = map[string]operatorDefaults{}
+	for namespace, nsFile := range file.Namespaces {
+		defaults := cfg.defaults.copy()
+		if err := nsFile.apply(&defaults); err != nil {
+			return nil, fmt.Errorf("Error: The namespace %v of the configuration file is invalid: %v", namespace, err)
+		}
+		cfg.namespaces[namespace] = defaults
+	}
+	return cfg, nil
+}
+
+// apply changes the default values with the ones informed in the section of the file
+func (f operatorDefaultsFile) apply(defaults *operatorDefaults) error {
+	sections := []struct {
+		name  string
+		data  json.RawMessage
+		value interface{}
+	}{
+		{"database", f.Database, defaults.database},
+		{"backup", f.Backup, defaults.backup},
+		{"restore", f.Restore, defaults.restore},
+	}
+	for _, section := range sections {
+		if len(section.data) == 0 {
+			continue
+		}
+		// The unknown fields are refused in order to not ignore the values with typos
+		decoder := json.NewDecoder(bytes.NewReader(section.data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(section.value); err != nil {
+			return fmt.Errorf("Error: The %v values are invalid: %v", section.name, err)
+		}
+	}
+	return nil
+}
+
+// copy returns a copy of the default values which can be changed without change the original ones
+func (d operatorDefaults) copy() operatorDefaults {
+	database := *d.database
+	backup := *d.backup
+	restore := *d.restore
+	return operatorDefaults{database: &database, backup: &backup, restore: &restore}
+}
+
+// get returns the default values of the namespace informed
+func (c *OperatorConfig) get(namespace string) operatorDefaults {
+	if defaults, ok := c.namespaces[namespace]; ok {
+		return defaults
+	}
+	return c.defaults
+}
+
+// SetOperatorConfig replaces the configuration used by the operator
+func SetOperatorConfig(cfg *OperatorConfig) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	operatorConfig = cfg
+}
+
+// getDefaults returns a copy of the default values of the namespace informed in the configuration used by the operator
+func getDefaults(namespace string) operatorDefaults {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return operatorConfig.get(namespace).copy()
+}
+
+// GetDatabaseConfig returns the default values of the Database CRs of the namespace informed
+func GetDatabaseConfig(namespace string) *DefaultDatabaseConfig {
+	return getDefaults(namespace).database
+}
+
+// GetBackupConfig returns the default values of the Backup CRs of the namespace informed
+func GetBackupConfig(namespace string) *DefaultBackupConfig {
+	return getDefaults(namespace).backup
+}
+
+// GetRestoreConfig returns the default values of the Restore CRs of the namespace informed
+func GetRestoreConfig(namespace string) *DefaultRestoreConfig {
+	return getDefaults(namespace).restore
+}
+
+// GetOperatorConfigPath returns the path of the configuration file informed by the env var or the default one
+func GetOperatorConfigPath() string {
+	if path := os.Getenv(OperatorConfigPathEnvVar); path != "" {
+		return path
+	}
+	return DefaultOperatorConfigPath
+}
+
+// LoadOperatorConfig reads the configuration file and starts to use it. The default values of the operator are used
+// when the file does not exist.
+// NOTE: The configuration used is not changed when the file is invalid
+func LoadOperatorConfig(path string) error {
+	_, err := loadOperatorConfig(path, nil)
+	return err
+}
+
+// loadOperatorConfig starts to use the configuration file when its data is not the same of the last one read and
+// returns its data, which is returned even when it is invalid in order to not try to load it again
+func loadOperatorConfig(path string, last []byte) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		data, err = nil, nil
+	}
+	if err != nil {
+		return last, err
+	}
+	if last != nil && bytes.Equal(data, last) {
+		return last, nil
+	}
+
+	cfg := NewOperatorConfig()
+	if data != nil {
+		if cfg, err = ParseOperatorConfig(data); err != nil {
+			return data, err
+		}
+	}
+	SetOperatorConfig(cfg)
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
+
+// WatchOperatorConfig reloads the configuration file when it changes until the stop channel is closed
+// NOTE: The ConfigMap mounted is updated by the kubelet, so the file is checked in an interval instead of watching it
+func WatchOperatorConfig(path string, stop <-chan struct{}) error {
+	last, err := ioutil.ReadFile(path)
+	if err != nil {
+		last = []byte{}
+	}
+	wait.Until(func() {
+		data, err := loadOperatorConfig(path, last)
+		if err != nil {
+			log.Error(err, "Failed to reload the configuration file. The last valid one is still used.", "Path", path)
+		} else if !bytes.Equal(data, last) {
+			log.Info("Configuration file reloaded", "Path", path)
+		}
+		last = data
+	}, OperatorConfigReloadInterval, stop)
+	return nil
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseOperatorConfig(t *testing.T) {
+	data := `
+database:
+  image: registry.example.com/postgresql:12
+  databaseStorageClassName: gp2
+backup:
+  schedule: "0 2 * * *"
+namespaces:
+  production:
+    database:
+      databaseMemoryLimit: 2Gi
+  staging:
+    restore:
+      image: registry.example.com/backup:1.0.0
+`
+	cfg, err := ParseOperatorConfig([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseOperatorConfig() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		got       string
+		want      string
+	}{
+		{"should use the image of the cluster", "default", cfg.get("default").database.Image, "registry.example.com/postgresql:12"},
+		{"should use the storage class of the cluster", "default", cfg.get("default").database.DatabaseStorageClassName, "gp2"},
+		{"should keep the default value not informed", "default", cfg.get("default").database.DatabaseMemoryLimit, databaseMemoryLimit},
+		{"should use the schedule of the cluster", "default", cfg.get("default").backup.Schedule, "0 2 * * *"},
+		{"should use the value of the namespace", "production", cfg.get("production").database.DatabaseMemoryLimit, "2Gi"},
+		{"should use the value of the cluster not informed for the namespace", "production", cfg.get("production").database.Image, "registry.example.com/postgresql:12"},
+		{"should use the restore image of the namespace", "staging", cfg.get("staging").restore.Image, "registry.example.com/backup:1.0.0"},
+		{"should keep the restore image of other namespaces", "production", cfg.get("production").restore.Image, bakupImage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("Value of the namespace %v got (%v), want (%v)", tt.namespace, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOperatorConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "should not accept an invalid YAML", data: "database: [image"},
+		{name: "should not accept an unknown section", data: "databases:\n  image: postgres"},
+		{name: "should not accept an unknown value", data: "database:\n  imagee: postgres"},
+		{name: "should not accept a value with the wrong type", data: "database:\n  databasePort: port"},
+		{name: "should not accept an unknown value of a namespace", data: "namespaces:\n  production:\n    backup:\n      schedules: \"@daily\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseOperatorConfig([]byte(tt.data)); err == nil {
+				t.Errorf("ParseOperatorConfig() of (%v) is expected to fail", tt.data)
+			}
+		})
+	}
+}
+
+func TestLoadOperatorConfig(t *testing.T) {
+	defer SetOperatorConfig(NewOperatorConfig())
+
+	dir, err := ioutil.TempDir("", "operator-config")
+	if err != nil {
+		t.Fatalf("temp dir: (%v)", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+
+	// The default values are used when the file does not exist
+	if err := LoadOperatorConfig(path); err != nil {
+		t.Fatalf("LoadOperatorConfig() error = %v", err)
+	}
+	if GetDatabaseConfig("default").Image != image {
+		t.Errorf("Image got (%v), want the default one (%v)", GetDatabaseConfig("default").Image, image)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("database:\n  image: postgres:12\n"), 0644); err != nil {
+		t.Fatalf("write file: (%v)", err)
+	}
+	last, err := loadOperatorConfig(path, []byte{})
+	if err != nil {
+		t.Fatalf("loadOperatorConfig() error = %v", err)
+	}
+	if GetDatabaseConfig("default").Image != "postgres:12" {
+		t.Errorf("Image got (%v), want the one of the file (postgres:12)", GetDatabaseConfig("default").Image)
+	}
+
+	// The values returned cannot change the configuration
+	GetDatabaseConfig("default").Image = "changed"
+	if GetDatabaseConfig("default").Image != "postgres:12" {
+		t.Errorf("Image got (%v), want the one of the file (postgres:12)", GetDatabaseConfig("default").Image)
+	}
+
+	// The last valid configuration is kept when the file is invalid
+	if err := ioutil.WriteFile(path, []byte("database:\n  imagee: postgres:13\n"), 0644); err != nil {
+		t.Fatalf("write file: (%v)", err)
+	}
+	if _, err := loadOperatorConfig(path, last); err == nil {
+		t.Error("loadOperatorConfig() is expected to fail with the invalid file")
+	}
+	if GetDatabaseConfig("default").Image != "postgres:12" {
+		t.Errorf("Image got (%v), want the last valid one (postgres:12)", GetDatabaseConfig("default").Image)
+	}
+
+	// The default values are used again when the file is removed
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove file: (%v)", err)
+	}
+	if _, err := loadOperatorConfig(path, last); err != nil {
+		t.Fatalf("loadOperatorConfig() error = %v", err)
+	}
+	if GetDatabaseConfig("default").Image != image {
+		t.Errorf("Image got (%v), want the default one (%v)", GetDatabaseConfig("default").Image, image)
+	}
+}
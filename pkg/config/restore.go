@@ -0,0 +1,13 @@
+package config
+
+type DefaultRestoreConfig struct {
+	Image          string `json:"image"`
+	DatabaseCRName string `json:"databaseCRName"`
+}
+
+func NewDefaultRestoreConfig() *DefaultRestoreConfig {
+	return &DefaultRestoreConfig{
+		Image:          bakupImage,
+		DatabaseCRName: databaseCRName,
+	}
+}
@@ -1,17 +1,22 @@
 package backup
 
 import (
+	"context"
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/config"
 	"github.com/dev4devs-com/postgresql-operator/pkg/service"
 	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/batch/v1beta1"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -52,6 +57,24 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch Job resource of the on-demand backups controlled and created by it
+	if err := service.Watch(c, &batchv1.Job{}, true, &v1alpha1.Backup{}); err != nil {
+		return err
+	}
+
+	// Watch Pod resources which do the backup in order to get the result of the pruning of the expired dumps
+	// NOTE: The pods of the Jobs created by the CronJob are not controlled by the Backup so they are found by its labels
+	err = c.Watch(&source.Kind{Type: &v1.Pod{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: backupLabelMapper})
+	if err != nil {
+		return err
+	}
+
+	// Watch Job resources created by the CronJob in order to record the runs of the backup
+	err = c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: backupLabelMapper})
+	if err != nil {
+		return err
+	}
+
 	// Watch Secret resource controlled and created by it
 	if err := service.Watch(c, &v1.Secret{}, true, &v1alpha1.Backup{}); err != nil {
 		return err
@@ -62,9 +85,62 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch DatabaseClass resource in order to apply its backup policy in the Backups of the Databases which refer to it
+	if err := c.Watch(&source.Kind{Type: &v1alpha1.DatabaseClass{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			return getClassBackupRequests(mgr.GetClient(), obj.Meta.GetName())
+		}),
+	}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// getClassBackupRequests returns the requests of the Backups of the Databases which refer to the DatabaseClass informed
+func getClassBackupRequests(c client.Client, className string) []reconcile.Request {
+	dbList := &v1alpha1.DatabaseList{}
+	if err := c.List(context.TODO(), dbList); err != nil {
+		logf.Log.WithName(utils.BackupControllerName).Error(err, "Failed to list the Databases of the DatabaseClass", "DatabaseClass.Name", className)
+		return nil
+	}
+	databases := map[types.NamespacedName]bool{}
+	for _, db := range dbList.Items {
+		if db.Spec.ClassName == className {
+			databases[types.NamespacedName{Name: db.Name, Namespace: db.Namespace}] = true
+		}
+	}
+	if len(databases) == 0 {
+		return nil
+	}
+
+	bkpList := &v1alpha1.BackupList{}
+	if err := c.List(context.TODO(), bkpList); err != nil {
+		logf.Log.WithName(utils.BackupControllerName).Error(err, "Failed to list the Backups of the DatabaseClass", "DatabaseClass.Name", className)
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, bkp := range bkpList.Items {
+		dbName := bkp.Spec.DatabaseCRName
+		if dbName == "" {
+			dbName = config.GetBackupConfig(bkp.Namespace).DatabaseCRName
+		}
+		if databases[types.NamespacedName{Name: dbName, Namespace: bkp.Namespace}] {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: bkp.Name, Namespace: bkp.Namespace}})
+		}
+	}
+	return requests
+}
+
+// backupLabelMapper returns the request of the Backup informed in the label of the object
+var backupLabelMapper = handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+	name, ok := obj.Meta.GetLabels()[utils.BackupLabelKey]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.Meta.GetNamespace()}}}
+})
+
 // blank assignment to verify that ReconcileBackup implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileBackup{}
 
@@ -102,8 +178,14 @@ func (r *ReconcileBackup) Reconcile(request reconcile.Request) (reconcile.Result
 		return reconcile.Result{}, err
 	}
 
-	// Add const values for mandatory specs
+	// Add the backup policy of the DatabaseClass of the Database and the const values for mandatory specs
+	// NOTE: They are stored in the CR by the defaulting webhook when it is enabled, so it only fills the CRs created
+	// without it
 	reqLogger.Info("Adding backup mandatory specs")
+	if err := service.AddBackupClassSpecs(bkp, r.client); err != nil {
+		reqLogger.Error(err, "Failed to get the DatabaseClass of the Database")
+		return reconcile.Result{}, err
+	}
 	utils.AddBackupMandatorySpecs(bkp)
 
 	// Create mandatory objects for the Backup
@@ -168,11 +250,29 @@ func (r *ReconcileBackup) createResources(bkp *v1alpha1.Backup, request reconcil
 		return err
 	}
 
+	// Check if the secret used to access the storage directly is created, if not create one
+	if err := r.createStorageSecret(bkp); err != nil {
+		reqLogger.Error(err, "Failed to create the Storage secret")
+		return err
+	}
+
 	// Check if the cronJob is created, if not create one
-	if err := r.createCronJob(bkp); err != nil {
+	if err := r.createCronJob(bkp, db); err != nil {
 		reqLogger.Error(err, "Failed to create the CronJob")
 		return err
 	}
+
+	// Check if the cronJob of the base backups is created when the WAL archiving is enabled, if not create one
+	if err := r.createBaseBackupCronJob(bkp, db); err != nil {
+		reqLogger.Error(err, "Failed to create the base backup CronJob")
+		return err
+	}
+
+	// Check if an on-demand backup was requested by the trigger, if yes create its Job
+	if err := r.createOnDemandJob(bkp, db); err != nil {
+		reqLogger.Error(err, "Failed to create the on-demand backup Job")
+		return err
+	}
 	return nil
 }
 
@@ -211,6 +311,31 @@ func (r *ReconcileBackup) createUpdateCRStatus(request reconcile.Request) error
 		return err
 	}
 
+	if err := r.updateOnDemandBackupStatus(request); err != nil {
+		reqLogger.Error(err, "Failed to create/update onDemandBackup status")
+		return err
+	}
+
+	if err := r.updateRetentionStatus(request); err != nil {
+		reqLogger.Error(err, "Failed to create/update retention status")
+		return err
+	}
+
+	if err := r.updateWalArchivingStatus(request); err != nil {
+		reqLogger.Error(err, "Failed to create/update walArchiving status")
+		return err
+	}
+
+	if err := r.updateDumpsStatus(request); err != nil {
+		reqLogger.Error(err, "Failed to create/update dumps status")
+		return err
+	}
+
+	if err := r.updateRunsStatus(request); err != nil {
+		reqLogger.Error(err, "Failed to create/update runs status")
+		return err
+	}
+
 	if err := r.updateBackupStatus(request); err != nil {
 		reqLogger.Error(err, "Failed to create/update backup status")
 		return err
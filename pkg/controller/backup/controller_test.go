@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
 	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -129,6 +130,48 @@ func TestReconcileBackup(t *testing.T) {
 			wantEncSecret: false,
 			wantCronJob:   true,
 		},
+		{
+			name: "Should work with the credentials in a Secret when it will build the db data secret",
+			fields: fields{
+				objs: []runtime.Object{
+					&bkpInstanceWithMandatorySpec,
+					&dbInstanceWithCredentialsSecret,
+					&podDatabaseCredentialsSecret,
+					&serviceDatabase,
+					&secretCredentials,
+				},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithMandatorySpec,
+			},
+			wantErr:       false,
+			wantRequeue:   false,
+			wantAwsSecret: true,
+			wantDBSecret:  true,
+			wantEncSecret: false,
+			wantCronJob:   true,
+		},
+		{
+			name: "Should fail with wrong database pwd key in the Secret when it will build the db data secret",
+			fields: fields{
+				objs: []runtime.Object{
+					&bkpInstanceWithMandatorySpec,
+					&dbInstanceWithCredentialsSecret,
+					&podDatabaseCredentialsSecret,
+					&serviceDatabase,
+					&secretCredentialsInvalidPwdKey,
+				},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithMandatorySpec,
+			},
+			wantErr:       true,
+			wantRequeue:   false,
+			wantAwsSecret: false,
+			wantDBSecret:  false,
+			wantEncSecret: false,
+			wantCronJob:   false,
+		},
 		{
 			name: "Should work with encryption secret data and create this secret",
 			fields: fields{
@@ -376,3 +419,434 @@ func TestReconcileBackup(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileBackup_OnDemand(t *testing.T) {
+	type fields struct {
+		objs []runtime.Object
+	}
+	type args struct {
+		bkpInstance v1alpha1.Backup
+		trigger     string
+	}
+	tests := []struct {
+		name            string
+		fields          fields
+		args            args
+		wantErr         bool
+		wantJob         bool
+		wantPreviousJob bool
+		wantPhase       string
+	}{
+		{
+			name: "Should create the job when the trigger is informed by the annotation",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithTriggerAnnotation, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithTriggerAnnotation,
+				trigger:     "backup-now",
+			},
+			wantErr:   false,
+			wantJob:   true,
+			wantPhase: phaseRunning,
+		},
+		{
+			name: "Should create the job and remove the previous one when the trigger changed",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithTrigger, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase, &onDemandJobPrevious},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithTrigger,
+				trigger:     "before-migration-02",
+			},
+			wantErr:         false,
+			wantJob:         true,
+			wantPreviousJob: false,
+			wantPhase:       phaseRunning,
+		},
+		{
+			name: "Should show the failure of the job",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithTrigger, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase, &onDemandJobFailed},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithTrigger,
+				trigger:     "before-migration-02",
+			},
+			wantErr:   false,
+			wantJob:   true,
+			wantPhase: phaseFailed,
+		},
+		{
+			name: "Should not create the job without trigger",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithMandatorySpec, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithMandatorySpec,
+				trigger:     "before-migration-01",
+			},
+			wantErr: false,
+			wantJob: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := buildReconcileWithFakeClientWithMocks(tt.fields.objs)
+
+			// mock request to simulate Reconcile() being called on an event for a watched resource
+			req := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      tt.args.bkpInstance.Name,
+					Namespace: tt.args.bkpInstance.Namespace,
+				},
+			}
+
+			if _, err := r.Reconcile(req); (err != nil) != tt.wantErr {
+				t.Errorf("TestReconcileBackup_OnDemand reconcile: error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			job := &batchv1.Job{}
+			err := r.client.Get(context.TODO(), types.NamespacedName{Name: utils.GetOnDemandJobName(&tt.args.bkpInstance, tt.args.trigger), Namespace: tt.args.bkpInstance.Namespace}, job)
+			if (err == nil) != tt.wantJob {
+				t.Errorf("TestReconcileBackup_OnDemand to get job error = %v, wantJob %v", err, tt.wantJob)
+				return
+			}
+
+			previous := &batchv1.Job{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: onDemandJobPrevious.Name, Namespace: onDemandJobPrevious.Namespace}, previous)
+			if (err == nil) != tt.wantPreviousJob {
+				t.Errorf("TestReconcileBackup_OnDemand to get previous job error = %v, wantPreviousJob %v", err, tt.wantPreviousJob)
+				return
+			}
+
+			bkp := &v1alpha1.Backup{}
+			if err := r.client.Get(context.TODO(), req.NamespacedName, bkp); err != nil {
+				t.Errorf("TestReconcileBackup_OnDemand to get backup error = %v", err)
+				return
+			}
+
+			if !tt.wantJob {
+				return
+			}
+			if bkp.Status.OnDemandBackup == nil || bkp.Status.OnDemandBackup.Trigger != tt.args.trigger || bkp.Status.OnDemandBackup.Phase != tt.wantPhase {
+				t.Errorf("TestReconcileBackup_OnDemand status.onDemandBackup = %+v, want trigger %v and phase %v", bkp.Status.OnDemandBackup, tt.args.trigger, tt.wantPhase)
+			}
+		})
+	}
+}
+
+func TestReconcileBackup_Retention(t *testing.T) {
+	type fields struct {
+		objs []runtime.Object
+	}
+	type args struct {
+		bkpInstance v1alpha1.Backup
+	}
+	tests := []struct {
+		name                string
+		fields              fields
+		args                args
+		wantErr             bool
+		wantRetentionSecret bool
+		wantPruneContainer  bool
+	}{
+		{
+			name: "Should create the retention secret and the cronjob with the prune container",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithRetention, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithRetention,
+			},
+			wantErr:             false,
+			wantRetentionSecret: true,
+			wantPruneContainer:  true,
+		},
+		{
+			name: "Should update the job template of the cronjob created before the retention be enabled",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithRetention, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase, &cronJobWithoutRetention},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithRetention,
+			},
+			wantErr:             false,
+			wantRetentionSecret: true,
+			wantPruneContainer:  true,
+		},
+		{
+			name: "Should not create the retention secret and the prune container without retention",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithMandatorySpec, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithMandatorySpec,
+			},
+			wantErr:             false,
+			wantRetentionSecret: false,
+			wantPruneContainer:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := buildReconcileWithFakeClientWithMocks(tt.fields.objs)
+
+			// mock request to simulate Reconcile() being called on an event for a watched resource
+			req := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      tt.args.bkpInstance.Name,
+					Namespace: tt.args.bkpInstance.Namespace,
+				},
+			}
+
+			if _, err := r.Reconcile(req); (err != nil) != tt.wantErr {
+				t.Errorf("TestReconcileBackup_Retention reconcile: error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			secret := &corev1.Secret{}
+			err := r.client.Get(context.TODO(), types.NamespacedName{Name: utils.StorageSecretPrefix + tt.args.bkpInstance.Name, Namespace: tt.args.bkpInstance.Namespace}, secret)
+			if (err == nil) != tt.wantRetentionSecret {
+				t.Errorf("TestReconcileBackup_Retention to get retention secret error = %v, wantRetentionSecret %v", err, tt.wantRetentionSecret)
+				return
+			}
+			if tt.wantRetentionSecret && string(secret.Data["STORAGE_BUCKET"]) != tt.args.bkpInstance.Spec.AwsS3BucketName {
+				t.Errorf("TestReconcileBackup_Retention retention secret STORAGE_BUCKET = %v, want %v", string(secret.Data["STORAGE_BUCKET"]), tt.args.bkpInstance.Spec.AwsS3BucketName)
+			}
+
+			cronJob := &v1beta1.CronJob{}
+			if err := r.client.Get(context.TODO(), req.NamespacedName, cronJob); err != nil {
+				t.Errorf("TestReconcileBackup_Retention to get cronjob error = %v", err)
+				return
+			}
+			podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+			hasPrune := len(podSpec.InitContainers) == 1 && len(podSpec.Containers) == 1 && podSpec.Containers[0].Name == utils.GetPruneContainerName(&tt.args.bkpInstance)
+			if hasPrune != tt.wantPruneContainer {
+				t.Errorf("TestReconcileBackup_Retention cronjob has prune container = %v, wantPruneContainer %v", hasPrune, tt.wantPruneContainer)
+			}
+		})
+	}
+}
+
+func TestReconcileBackup_WalArchiving(t *testing.T) {
+	type fields struct {
+		objs []runtime.Object
+	}
+	type args struct {
+		bkpInstance v1alpha1.Backup
+	}
+	tests := []struct {
+		name              string
+		fields            fields
+		args              args
+		wantErr           bool
+		wantStorageSecret bool
+		wantBaseBackup    bool
+	}{
+		{
+			name: "Should create the storage secret and the cronjob of the base backups",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithWalArchiving, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithWalArchiving,
+			},
+			wantErr:           false,
+			wantStorageSecret: true,
+			wantBaseBackup:    true,
+		},
+		{
+			name: "Should not create the cronjob of the base backups without wal archiving",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithMandatorySpec, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithMandatorySpec,
+			},
+			wantErr:           false,
+			wantStorageSecret: false,
+			wantBaseBackup:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := buildReconcileWithFakeClientWithMocks(tt.fields.objs)
+
+			// mock request to simulate Reconcile() being called on an event for a watched resource
+			req := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      tt.args.bkpInstance.Name,
+					Namespace: tt.args.bkpInstance.Namespace,
+				},
+			}
+
+			if _, err := r.Reconcile(req); (err != nil) != tt.wantErr {
+				t.Errorf("TestReconcileBackup_WalArchiving reconcile: error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			secret := &corev1.Secret{}
+			err := r.client.Get(context.TODO(), types.NamespacedName{Name: utils.StorageSecretPrefix + tt.args.bkpInstance.Name, Namespace: tt.args.bkpInstance.Namespace}, secret)
+			if (err == nil) != tt.wantStorageSecret {
+				t.Errorf("TestReconcileBackup_WalArchiving to get storage secret error = %v, wantStorageSecret %v", err, tt.wantStorageSecret)
+				return
+			}
+
+			cronJob := &v1beta1.CronJob{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: utils.GetBaseBackupName(&tt.args.bkpInstance), Namespace: tt.args.bkpInstance.Namespace}, cronJob)
+			if (err == nil) != tt.wantBaseBackup {
+				t.Errorf("TestReconcileBackup_WalArchiving to get base backup cronjob error = %v, wantBaseBackup %v", err, tt.wantBaseBackup)
+				return
+			}
+			if tt.wantBaseBackup && cronJob.Spec.Schedule != "0 0 * * 0" {
+				t.Errorf("TestReconcileBackup_WalArchiving base backup schedule = %v, want %v", cronJob.Spec.Schedule, "0 0 * * 0")
+			}
+		})
+	}
+}
+
+func TestReconcileBackup_Storage(t *testing.T) {
+	type fields struct {
+		objs []runtime.Object
+	}
+	type args struct {
+		bkpInstance v1alpha1.Backup
+	}
+	tests := []struct {
+		name          string
+		fields        fields
+		args          args
+		wantErr       bool
+		wantData      map[string]string
+		wantAwsSecret bool
+		wantVolume    bool
+	}{
+		{
+			name: "Should create the storage secret of the S3-compatible service and the cronjob which dumps and uploads",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithS3CompatibleStorage, &minioSecret, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithS3CompatibleStorage,
+			},
+			wantErr: false,
+			wantData: map[string]string{
+				"STORAGE_TYPE":         utils.S3Storage,
+				"STORAGE_BUCKET":       "example-bucket",
+				"STORAGE_ENDPOINT_URL": "http://minio.minio.svc:9000",
+				"STORAGE_PATH_STYLE":   "true",
+			},
+			wantAwsSecret: false,
+			wantVolume:    false,
+		},
+		{
+			name: "Should create the storage secret and mount the PVC of the volume storage",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithVolumeStorage, &pvcBackupStorage, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithVolumeStorage,
+			},
+			wantErr: false,
+			wantData: map[string]string{
+				"STORAGE_TYPE": utils.VolumeStorage,
+				"STORAGE_PATH": utils.StorageVolumePath,
+			},
+			wantAwsSecret: false,
+			wantVolume:    true,
+		},
+		{
+			name: "Should fail when the PVC of the volume storage is missing",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithVolumeStorage, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithVolumeStorage,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should fail when the secret of the GCS storage has not the bucket",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithGCSStorage, &gcsSecretWithoutBucket, &dbInstanceWithoutSpec, &podDatabase, &serviceDatabase},
+			},
+			args: args{
+				bkpInstance: bkpInstanceWithGCSStorage,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := buildReconcileWithFakeClientWithMocks(tt.fields.objs)
+
+			// mock request to simulate Reconcile() being called on an event for a watched resource
+			req := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      tt.args.bkpInstance.Name,
+					Namespace: tt.args.bkpInstance.Namespace,
+				},
+			}
+
+			if _, err := r.Reconcile(req); (err != nil) != tt.wantErr {
+				t.Errorf("TestReconcileBackup_Storage reconcile: error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			secret := &corev1.Secret{}
+			if err := r.client.Get(context.TODO(), types.NamespacedName{Name: utils.StorageSecretPrefix + tt.args.bkpInstance.Name, Namespace: tt.args.bkpInstance.Namespace}, secret); err != nil {
+				t.Errorf("TestReconcileBackup_Storage to get storage secret error = %v", err)
+				return
+			}
+
+			// The Backup should not require the AWS secret when the storage informed has not it
+			bkp := &v1alpha1.Backup{}
+			if err := r.client.Get(context.TODO(), req.NamespacedName, bkp); err != nil {
+				t.Errorf("TestReconcileBackup_Storage to get backup error = %v", err)
+				return
+			}
+			if bkp.Status.BackupStatus != statusOk {
+				t.Errorf("TestReconcileBackup_Storage status.backupStatus = %v, want %v", bkp.Status.BackupStatus, statusOk)
+			}
+			for key, value := range tt.wantData {
+				if string(secret.Data[key]) != value {
+					t.Errorf("TestReconcileBackup_Storage storage secret %v = %v, want %v", key, string(secret.Data[key]), value)
+				}
+			}
+
+			aws := &corev1.Secret{}
+			err := r.client.Get(context.TODO(), types.NamespacedName{Name: utils.GetAWSSecretName(&tt.args.bkpInstance), Namespace: tt.args.bkpInstance.Namespace}, aws)
+			if (err == nil) != tt.wantAwsSecret {
+				t.Errorf("TestReconcileBackup_Storage to get aws secret error = %v, wantAwsSecret %v", err, tt.wantAwsSecret)
+			}
+
+			cronJob := &v1beta1.CronJob{}
+			if err := r.client.Get(context.TODO(), req.NamespacedName, cronJob); err != nil {
+				t.Errorf("TestReconcileBackup_Storage to get cronjob error = %v", err)
+				return
+			}
+			podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+			if len(podSpec.InitContainers) != 1 || podSpec.InitContainers[0].Name != tt.args.bkpInstance.Name+utils.DumpContainerSuffix {
+				t.Errorf("TestReconcileBackup_Storage cronjob without the dump init container")
+			}
+			hasVolume := false
+			for _, v := range podSpec.Volumes {
+				if v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == pvcBackupStorage.Name {
+					hasVolume = true
+				}
+			}
+			if hasVolume != tt.wantVolume {
+				t.Errorf("TestReconcileBackup_Storage cronjob has the PVC of the storage = %v, wantVolume %v", hasVolume, tt.wantVolume)
+			}
+		})
+	}
+}
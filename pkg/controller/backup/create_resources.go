@@ -7,6 +7,10 @@ import (
 	"github.com/dev4devs-com/postgresql-operator/pkg/resource"
 	"github.com/dev4devs-com/postgresql-operator/pkg/service"
 	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Set in the ReconcileBackup the Pod database created by Database
@@ -36,9 +40,27 @@ func (r *ReconcileBackup) getDatabaseService(bkp *v1alpha1.Backup, db *v1alpha1.
 }
 
 // Check if the cronJob is created, if not create one
-func (r *ReconcileBackup) createCronJob(bkp *v1alpha1.Backup) error {
-	if _, err := service.FetchCronJob(bkp.Name, bkp.Namespace, r.client); err != nil {
-		if err := r.client.Create(context.TODO(), resource.NewBackupCronJob(bkp, r.scheme)); err != nil {
+// NOTE: Its job template is updated when it was changed in order to apply the changes in the Backup CR (E.g. retention)
+func (r *ReconcileBackup) createCronJob(bkp *v1alpha1.Backup, db *v1alpha1.Database) error {
+	// The image and port of the Database are required by the dump when the storage is informed
+	db = db.DeepCopy()
+	service.AddDatabaseMandatorySpecs(db, r.client)
+	cronJob, err := service.FetchCronJob(bkp.Name, bkp.Namespace, r.client)
+	if err != nil {
+		if err := r.client.Create(context.TODO(), resource.NewBackupCronJob(bkp, db, r.scheme)); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	desired := resource.NewBackupCronJob(bkp, db, r.scheme)
+	if !isTemplateHashEqual(cronJob, desired) {
+		if cronJob.Annotations == nil {
+			cronJob.Annotations = map[string]string{}
+		}
+		cronJob.Annotations[utils.TemplateHashAnnotation] = desired.Annotations[utils.TemplateHashAnnotation]
+		cronJob.Spec.JobTemplate = desired.Spec.JobTemplate
+		if err := r.client.Update(context.TODO(), cronJob); err != nil {
 			return err
 		}
 	}
@@ -66,8 +88,12 @@ func (r *ReconcileBackup) createEncryptionKey(bkp *v1alpha1.Backup) error {
 }
 
 // createAwsSecret checks if the secret with the aws data is created, if not create one
-// NOTE: The user can config in the CR to use a pre-existing one by informing the name
+// NOTE: The user can config in the CR to use a pre-existing one by informing the name. It is not required when the
+// storage informed has another secret.
 func (r *ReconcileBackup) createAwsSecret(bkp *v1alpha1.Backup) error {
+	if !utils.IsAwsSecretUsed(bkp) {
+		return nil
+	}
 	if _, err := service.FetchSecret(utils.GetAwsSecretNamespace(bkp), utils.GetAWSSecretName(bkp), r.client); err != nil {
 		// The user can just inform the name of the Secret which is already applied in the cluster
 		if !utils.IsAwsKeySetupByName(bkp) {
@@ -96,3 +122,100 @@ func (r *ReconcileBackup) createDatabaseSecret(bkp *v1alpha1.Backup, db *v1alpha
 	}
 	return nil
 }
+
+// createOnDemandJob checks if the Job of the on-demand backup requested by the trigger is created, if not create one
+// NOTE: Just the Job of the latest on-demand backup is kept
+func (r *ReconcileBackup) createOnDemandJob(bkp *v1alpha1.Backup, db *v1alpha1.Database) error {
+	if !utils.IsOnDemandBackupRequested(bkp) {
+		return nil
+	}
+
+	db = db.DeepCopy()
+	service.AddDatabaseMandatorySpecs(db, r.client)
+
+	name := utils.GetOnDemandJobName(bkp, utils.GetBackupTrigger(bkp))
+	if _, err := service.FetchJob(name, bkp.Namespace, r.client); err != nil {
+		if err := r.deletePreviousOnDemandJob(bkp, name); err != nil {
+			return err
+		}
+		if err := r.client.Create(context.TODO(), resource.NewBackupJob(bkp, db, name, r.scheme)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deletePreviousOnDemandJob will remove the Job of the previous on-demand backup when a new one is requested
+func (r *ReconcileBackup) deletePreviousOnDemandJob(bkp *v1alpha1.Backup, name string) error {
+	if bkp.Status.OnDemandBackup == nil || bkp.Status.OnDemandBackup.JobName == name {
+		return nil
+	}
+	job, err := service.FetchJob(bkp.Status.OnDemandBackup.JobName, bkp.Namespace, r.client)
+	if err != nil {
+		return nil
+	}
+	if err := r.client.Delete(context.TODO(), job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// createStorageSecret checks if the secret with the storage data used by the upload, the retention and the WAL archiving is created, if not create one
+// NOTE: The data is built from the secret of the storage since it can be in another namespace and it is kept in sync with it
+func (r *ReconcileBackup) createStorageSecret(bkp *v1alpha1.Backup) error {
+	if !utils.IsStorageSecretRequired(bkp) {
+		return nil
+	}
+
+	secretData, err := r.buildStorageSecretData(bkp)
+	if err != nil {
+		return err
+	}
+
+	secret, err := service.FetchSecret(bkp.Namespace, utils.StorageSecretPrefix+bkp.Name, r.client)
+	if err != nil {
+		return r.client.Create(context.TODO(), resource.NewBackupSecret(bkp, utils.StorageSecretPrefix, secretData, nil, r.scheme))
+	}
+
+	if !reflect.DeepEqual(secret.Data, secretData) {
+		secret.Data = secretData
+		if err := r.client.Update(context.TODO(), secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createBaseBackupCronJob checks if the cronJob which takes the base backups is created, if not create one
+// NOTE: Its spec is updated when it was changed and it is removed when the WAL archiving is disabled
+func (r *ReconcileBackup) createBaseBackupCronJob(bkp *v1alpha1.Backup, db *v1alpha1.Database) error {
+	cronJob, err := service.FetchCronJob(utils.GetBaseBackupName(bkp), bkp.Namespace, r.client)
+	if !utils.IsBackupWalArchivingEnabled(bkp) {
+		if err == nil {
+			if err := r.client.Delete(context.TODO(), cronJob, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The connection data of the primary is required by pg_basebackup
+	db = db.DeepCopy()
+	service.AddDatabaseMandatorySpecs(db, r.client)
+	desired := resource.NewBaseBackupCronJob(bkp, db, r.scheme)
+	if err != nil {
+		return r.client.Create(context.TODO(), desired)
+	}
+
+	if !isTemplateHashEqual(cronJob, desired) {
+		if cronJob.Annotations == nil {
+			cronJob.Annotations = map[string]string{}
+		}
+		cronJob.Annotations[utils.TemplateHashAnnotation] = desired.Annotations[utils.TemplateHashAnnotation]
+		cronJob.Spec = desired.Spec
+		if err := r.client.Update(context.TODO(), cronJob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
 	"github.com/dev4devs-com/postgresql-operator/pkg/service"
 	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	"k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // DbSecret keep the data which will be used in the DB secret
@@ -24,12 +26,15 @@ type HelperDbSecret struct {
 	cfgName      string
 	cfgKey       string
 	cfgNamespace string
+	secretName   string
+	secretKey    string
 }
 
 // buildDBSecretData will returns the data required to create the database secret according to the configuration
 // NOTE: The user can:
 // - Customize the environment variables keys as values that should be used with
 // - Inform the name and namespace of an Config Map as the keys which has the values which should be used (E.g. user, password and database name already setup for another application )
+// - Inform the name of a Secret as the keys which has the values which should be used (E.g. the password generated by the operator)
 func (r *ReconcileBackup) buildDBSecretData(bkp *v1alpha1.Backup, db *v1alpha1.Database) (map[string][]byte, error) {
 
 	dbSecret := r.newDBSecret(bkp)
@@ -64,6 +69,12 @@ func (r *ReconcileBackup) buildDBSecretData(bkp *v1alpha1.Backup, db *v1alpha1.D
 // getEnvVarValue will return the value that should be used for the Key informed
 func (r *ReconcileBackup) getEnvVarValue(value string, dbSecret *DbSecret, helper *HelperDbSecret) (string, error) {
 	value = helper.envVarValue
+	if value == "" && helper.secretName != "" {
+		value = r.getKeyValueFromSecret(helper)
+		if value == "" {
+			return "", helper.newErrorUnableToGetKeyFromSecret()
+		}
+	}
 	if value == "" {
 		value = r.getKeyValueFromConfigMap(helper)
 		if value == "" {
@@ -79,9 +90,15 @@ func (r *ReconcileBackup) newHelperDbSecret(i int, bkp *v1alpha1.Backup) *Helper
 	dt.envVarName = r.dbPod.Spec.Containers[0].Env[i].Name
 	dt.envVarValue = r.dbPod.Spec.Containers[0].Env[i].Value
 	dt.cfgNamespace = bkp.Namespace
-	if r.dbPod.Spec.Containers[0].Env[i].ValueFrom != nil {
-		dt.cfgName = r.dbPod.Spec.Containers[0].Env[i].ValueFrom.ConfigMapKeyRef.Name
-		dt.cfgKey = r.dbPod.Spec.Containers[0].Env[i].ValueFrom.ConfigMapKeyRef.Key
+	if valueFrom := r.dbPod.Spec.Containers[0].Env[i].ValueFrom; valueFrom != nil {
+		if valueFrom.ConfigMapKeyRef != nil {
+			dt.cfgName = valueFrom.ConfigMapKeyRef.Name
+			dt.cfgKey = valueFrom.ConfigMapKeyRef.Key
+		}
+		if valueFrom.SecretKeyRef != nil {
+			dt.secretName = valueFrom.SecretKeyRef.Name
+			dt.secretKey = valueFrom.SecretKeyRef.Key
+		}
 	}
 	return dt
 }
@@ -102,6 +119,22 @@ func (dt *HelperDbSecret) newErrorUnableToGetKeyFrom() error {
 		dt.cfgKey, dt.cfgName, dt.cfgNamespace)
 }
 
+// newErrorUnableToGetKeyFromSecret returns an error when is not possible find the key into the Secret and namespace in
+// order to create the mandatory envvar for the database
+func (dt *HelperDbSecret) newErrorUnableToGetKeyFromSecret() error {
+	return fmt.Errorf("Unable to get the key (%v) in the secret (%v) in the namespace (%v) to create the secret",
+		dt.secretKey, dt.secretName, dt.cfgNamespace)
+}
+
+// getKeyValueFromSecret returns the value of some key defined in the Secret
+func (r *ReconcileBackup) getKeyValueFromSecret(dt *HelperDbSecret) string {
+	secret, err := service.FetchSecret(dt.cfgNamespace, dt.secretName, r.client)
+	if err != nil {
+		return ""
+	}
+	return string(secret.Data[dt.secretKey])
+}
+
 // getKeyValueFromConfigMap returns the value of some key defined in the ConfigMap
 func (r *ReconcileBackup) getKeyValueFromConfigMap(dt *HelperDbSecret) string {
 	// search for ConfigMap
@@ -134,6 +167,51 @@ func createAwsDataByteMap(bkp *v1alpha1.Backup) map[string][]byte {
 	return dataByte
 }
 
+// buildStorageSecretData returns the data required by the containers which access the storage directly
+// (E.g. the upload of the dumps, the prune container and the base backup)
+// NOTE: The secret of the storage is validated according to its type and the GPG public key is added when the dumps
+// are uploaded with the storage spec in order to allow encrypt them.
+func (r *ReconcileBackup) buildStorageSecretData(bkp *v1alpha1.Backup) (map[string][]byte, error) {
+	storage := utils.GetBackupStorage(bkp)
+	if err := utils.ValidateStorage(storage); err != nil {
+		return nil, err
+	}
+	if utils.IsVolumeStorage(storage) && utils.IsBackupWalArchivingEnabled(bkp) {
+		return nil, fmt.Errorf("Error: The WAL archiving requires an object storage and it is not supported by the storage %v. (name:%v,namespace:%v)",
+			storage.Type, bkp.Name, bkp.Namespace)
+	}
+
+	secret := &corev1.Secret{}
+	if utils.IsVolumeStorage(storage) {
+		if _, err := service.FetchPersistentVolumeClaim(storage.Volume.ClaimName, bkp.Namespace, r.client); err != nil {
+			return nil, fmt.Errorf("Error: The PVC of the storage %v is missing. (name:%v,namespace:%v)", storage.Type, storage.Volume.ClaimName, bkp.Namespace)
+		}
+	} else if storage.SecretName != "" {
+		var err error
+		if secret, err = service.FetchSecret(storage.SecretNamespace, storage.SecretName, r.client); err != nil {
+			return nil, err
+		}
+	}
+
+	dataByte, err := utils.BuildStorageData(storage, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if utils.IsStorageInformed(bkp) && utils.IsEncryptionKeyOptionConfig(bkp) {
+		enc, err := service.FetchSecret(utils.GetEncSecretNamespace(bkp), utils.GetEncSecretName(bkp), r.client)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range []string{"GPG_PUBLIC_KEY", "GPG_RECIPIENT", "GPG_TRUST_MODEL"} {
+			if value, ok := enc.Data[key]; ok {
+				dataByte[key] = value
+			}
+		}
+	}
+	return dataByte, nil
+}
+
 func createEncDataMaps(bkp *v1alpha1.Backup) (map[string][]byte, map[string]string) {
 	dataByte := map[string][]byte{
 		"GPG_PUBLIC_KEY": []byte(bkp.Spec.GpgPublicKey),
@@ -145,3 +223,8 @@ func createEncDataMaps(bkp *v1alpha1.Backup) (map[string][]byte, map[string]stri
 	}
 	return dataByte, dataString
 }
+
+// isTemplateHashEqual returns true when the hash of the template of the CronJob in the cluster is the same of the desired one
+func isTemplateHashEqual(cronJob, desired *v1beta1.CronJob) bool {
+	return cronJob.Annotations[utils.TemplateHashAnnotation] == desired.Annotations[utils.TemplateHashAnnotation]
+}
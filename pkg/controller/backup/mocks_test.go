@@ -1,11 +1,21 @@
 package backup
 
 import (
+	"fmt"
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
 	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+var (
+	errCronJobMissing    = fmt.Errorf("Error: CronJob is missing")
+	controllerRef        = true
+	startTimeBackup      = metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	completionTimeBackup = metav1.NewTime(time.Now().Add(-time.Hour + 90*time.Second).Truncate(time.Second))
 )
 
 // Centralized mock objects for use in tests
@@ -103,6 +113,345 @@ var (
 		},
 	}
 
+	/**
+	BKP CR to test the on-demand backups
+	*/
+
+	bkpInstanceWithTrigger = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.BackupSpec{
+			Trigger: "before-migration-02",
+		},
+		Status: v1alpha1.BackupStatus{
+			OnDemandBackup: &v1alpha1.OnDemandBackupStatus{
+				Trigger: "before-migration-01",
+				JobName: utils.GetOnDemandJobName(&bkpInstanceWithMandatorySpec, "before-migration-01"),
+				Phase:   phaseSucceeded,
+			},
+		},
+	}
+
+	bkpInstanceWithTriggerAnnotation = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "backup",
+			Namespace:   "postgresql-operator",
+			Annotations: map[string]string{utils.BackupTriggerAnnotation: "backup-now"},
+		},
+	}
+
+	onDemandJobPrevious = batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.GetOnDemandJobName(&bkpInstanceWithMandatorySpec, "before-migration-01"),
+			Namespace: bkpInstanceWithMandatorySpec.Namespace,
+		},
+		Status: batchv1.JobStatus{
+			Succeeded: 1,
+		},
+	}
+
+	onDemandJobFailed = batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.GetOnDemandJobName(&bkpInstanceWithMandatorySpec, "before-migration-02"),
+			Namespace: bkpInstanceWithMandatorySpec.Namespace,
+		},
+		Status: batchv1.JobStatus{
+			Failed: 1,
+			Conditions: []batchv1.JobCondition{
+				{
+					Type:    batchv1.JobFailed,
+					Status:  corev1.ConditionTrue,
+					Message: "Job has reached the specified backoff limit",
+				},
+			},
+		},
+	}
+
+	/**
+	BKP CR to test the retention policy
+	*/
+
+	bkpInstanceWithRetention = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.BackupSpec{
+			AwsS3BucketName:    "example-awsS3BucketName",
+			AwsAccessKeyId:     "example-awsAccessKeyId",
+			AwsSecretAccessKey: "example-awsSecretAccessKey",
+			Retention: &v1alpha1.BackupRetention{
+				KeepLast:   3,
+				KeepDaily:  7,
+				MaxAgeDays: 30,
+			},
+		},
+	}
+
+	cronJobWithoutRetention = v1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bkpInstanceWithRetention.Name,
+			Namespace: bkpInstanceWithRetention.Namespace,
+		},
+		Spec: v1beta1.CronJobSpec{
+			JobTemplate: v1beta1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: bkpInstanceWithRetention.Name}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	podPruneOld = buildPrunePod("backup-1561588320-abcde", "kept=5 deleted=0", time.Now().Add(-time.Hour))
+
+	podPruneLatest = buildPrunePod("backup-1561588380-abcde", "kept=3 deleted=2\n"+
+		"2019-06-26 22:33 backups/postgresql/postgres/2019/06/26/database.example-22_33_00.pg_dump.gz\n", time.Now())
+
+	podPruneInvalid = buildPrunePod("backup-1561588380-fghij", "Unable to list the dumps\n", time.Now())
+
+	/**
+	BKP CR with the base backups of the point-in-time recovery
+	*/
+
+	bkpInstanceWithWalArchiving = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.BackupSpec{
+			AwsS3BucketName:    "example-awsS3BucketName",
+			AwsAccessKeyId:     "example-awsAccessKeyId",
+			AwsSecretAccessKey: "example-awsSecretAccessKey",
+			WalArchiving: &v1alpha1.BackupWalArchiving{
+				Enabled: true,
+			},
+		},
+	}
+
+	podBaseBackup = corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup-basebackup-1561588380-abcde",
+			Namespace: bkpInstanceWithWalArchiving.Namespace,
+			Labels:    utils.GetBackupPodLabels(&bkpInstanceWithWalArchiving),
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: utils.GetBaseBackupName(&bkpInstanceWithWalArchiving),
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							Message:    "wal/postgresql-operator/database/base/20190626T222600Z/base.tar.gz",
+							FinishedAt: metav1.NewTime(time.Now().Truncate(time.Second)),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	/**
+	BKP CRs with the storage backends
+	*/
+
+	bkpInstanceWithS3CompatibleStorage = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.BackupSpec{
+			Storage: &v1alpha1.BackupStorage{
+				Type:       utils.S3Storage,
+				SecretName: "minio",
+				S3: &v1alpha1.BackupStorageS3{
+					EndpointURL:    "http://minio.minio.svc:9000",
+					ForcePathStyle: true,
+				},
+			},
+		},
+	}
+
+	minioSecret = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "minio",
+			Namespace: bkpInstanceWithS3CompatibleStorage.Namespace,
+		},
+		Data: map[string][]byte{
+			"AWS_S3_BUCKET_NAME":    []byte("example-bucket"),
+			"AWS_ACCESS_KEY_ID":     []byte("example-accessKeyId"),
+			"AWS_SECRET_ACCESS_KEY": []byte("example-secretAccessKey"),
+		},
+	}
+
+	bkpInstanceWithGCSStorage = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.BackupSpec{
+			Storage: &v1alpha1.BackupStorage{
+				Type:       utils.GCSStorage,
+				SecretName: "gcs",
+			},
+		},
+	}
+
+	gcsSecretWithoutBucket = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gcs",
+			Namespace: bkpInstanceWithGCSStorage.Namespace,
+		},
+		Data: map[string][]byte{
+			"GCS_ACCESS_KEY_ID":     []byte("example-accessKeyId"),
+			"GCS_SECRET_ACCESS_KEY": []byte("example-secretAccessKey"),
+		},
+	}
+
+	bkpInstanceWithVolumeStorage = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.BackupSpec{
+			Storage: &v1alpha1.BackupStorage{
+				Type: utils.VolumeStorage,
+				Volume: &v1alpha1.BackupStorageVolume{
+					ClaimName: "backups",
+				},
+			},
+		},
+	}
+
+	podUploadVolumeStorage = corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup-1561588380-fghij",
+			Namespace: bkpInstanceWithVolumeStorage.Namespace,
+			Labels:    map[string]string{utils.BackupLabelKey: bkpInstanceWithVolumeStorage.Name, "job-name": "backup-1561588380"},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: bkpInstanceWithVolumeStorage.Name,
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							Message: "backups/postgresql/postgres/2019/06/26/database.example-22_33_00.pg_dump.gz 2048\n" +
+								"2019-06-26 22:33 backups/postgresql/postgres/2019/06/26/database.example-22_33_00.pg_dump.gz\n" +
+								"2019-06-25 22:33 backups/postgresql/postgres/2019/06/25/database.example-22_33_00.pg_dump.gz\n",
+							FinishedAt: metav1.NewTime(time.Now().Truncate(time.Second)),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	pvcBackupStorage = corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backups",
+			Namespace: bkpInstanceWithVolumeStorage.Namespace,
+		},
+	}
+
+	/**
+	Mock of the runs of the backup
+	*/
+
+	jobBackupSucceeded = batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "backup-1561588380",
+			Namespace:         bkpInstanceWithVolumeStorage.Namespace,
+			Labels:            utils.GetBackupJobLabels(&bkpInstanceWithVolumeStorage),
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+			OwnerReferences:   []metav1.OwnerReference{{Kind: "CronJob", Name: "backup", Controller: &controllerRef}},
+		},
+		Status: batchv1.JobStatus{
+			Succeeded:      1,
+			StartTime:      &startTimeBackup,
+			CompletionTime: &completionTimeBackup,
+		},
+	}
+
+	jobBackupFailed = batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "backup-1561591980",
+			Namespace:         bkpInstanceWithVolumeStorage.Namespace,
+			Labels:            utils.GetBackupJobLabels(&bkpInstanceWithVolumeStorage),
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+			OwnerReferences:   []metav1.OwnerReference{{Kind: "CronJob", Name: "backup", Controller: &controllerRef}},
+		},
+		Status: batchv1.JobStatus{
+			Failed:    1,
+			StartTime: &startTimeBackup,
+			Conditions: []batchv1.JobCondition{
+				{
+					Type:               batchv1.JobFailed,
+					Status:             corev1.ConditionTrue,
+					Reason:             "BackoffLimitExceeded",
+					Message:            "Job has reached the specified backoff limit",
+					LastTransitionTime: completionTimeBackup,
+				},
+			},
+		},
+	}
+
+	podBackupFailed = corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup-1561591980-klmno",
+			Namespace: bkpInstanceWithVolumeStorage.Namespace,
+			Labels:    map[string]string{utils.BackupLabelKey: bkpInstanceWithVolumeStorage.Name, "job-name": "backup-1561591980"},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: bkpInstanceWithVolumeStorage.Name + utils.DumpContainerSuffix,
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							ExitCode:   1,
+							Reason:     "Error",
+							Message:    "pg_dump: could not connect to server\n",
+							FinishedAt: completionTimeBackup,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	jobBaseBackup = batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "backup-basebackup-1561588380",
+			Namespace:       bkpInstanceWithVolumeStorage.Namespace,
+			Labels:          utils.GetBackupPodLabels(&bkpInstanceWithVolumeStorage),
+			OwnerReferences: []metav1.OwnerReference{{Kind: "CronJob", Name: "backup-basebackup", Controller: &controllerRef}},
+		},
+		Status: batchv1.JobStatus{
+			Succeeded: 1,
+		},
+	}
+
+	bkpInstanceWithFailures = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "postgresql-operator",
+		},
+		Spec: bkpInstanceWithVolumeStorage.Spec,
+		Status: v1alpha1.BackupStatus{
+			ConsecutiveFailures: 1,
+			LastFailureReason:   "Job has reached the specified backoff limit",
+			Runs: []v1alpha1.BackupRun{
+				{
+					JobName: "backup-1561584780",
+					Result:  "Failed",
+				},
+			},
+		},
+	}
+
 	/**
 	Mock of Database resource
 	*/
@@ -334,6 +683,63 @@ var (
 		},
 	}
 
+	dbInstanceWithCredentialsSecret = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			CredentialsSecretName:        "database-credentials",
+			CredentialsSecretPasswordKey: "password",
+			DatabaseNameKeyEnvVar:        "POSTGRESQL_DATABASE",
+			DatabasePasswordKeyEnvVar:    "POSTGRESQL_PASSWORD",
+			DatabaseUserKeyEnvVar:        "POSTGRESQL_USER",
+		},
+	}
+
+	podDatabaseCredentialsSecret = corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database-test",
+			Namespace: "postgresql-operator",
+			Labels:    utils.GetLabels(dbInstanceWithCredentialsSecret.Name),
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Image: "postgresql",
+				Name:  "postgresql",
+				Env: []corev1.EnvVar{
+					utils.BuildDatabaseNameEnvVar(&dbInstanceWithCredentialsSecret),
+					utils.BuildDatabaseUserEnvVar(&dbInstanceWithCredentialsSecret),
+					utils.BuildDatabasePasswordEnvVar(&dbInstanceWithCredentialsSecret),
+				},
+			}},
+		},
+	}
+
+	secretCredentials = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database-credentials",
+			Namespace: "postgresql-operator",
+		},
+		Data: map[string][]byte{
+			"POSTGRESQL_DATABASE": []byte("solution"),
+			"POSTGRESQL_USER":     []byte("postgres"),
+			"password":            []byte("generated"),
+		},
+	}
+
+	secretCredentialsInvalidPwdKey = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database-credentials",
+			Namespace: "postgresql-operator",
+		},
+		Data: map[string][]byte{
+			"POSTGRESQL_DATABASE": []byte("solution"),
+			"POSTGRESQL_USER":     []byte("postgres"),
+			"invalid":             []byte("generated"),
+		},
+	}
+
 	dbInstanceWithConfigMapAndCustomizeKeys = v1alpha1.Database{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "database",
@@ -347,3 +753,27 @@ var (
 		},
 	}
 )
+
+// buildPrunePod returns the pod of the backup Job with the prune container terminated with the message informed
+func buildPrunePod(name, msg string, finishedAt time.Time) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: bkpInstanceWithRetention.Namespace,
+			Labels:    utils.GetBackupPodLabels(&bkpInstanceWithRetention),
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: utils.GetPruneContainerName(&bkpInstanceWithRetention),
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							Message:    msg,
+							FinishedAt: metav1.NewTime(finishedAt.Truncate(time.Second)),
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,183 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// maxBackupRuns is the quantity of runs kept in the status
+const maxBackupRuns = 10
+
+// updateRunsStatus returns error when was not possible update the status with the runs of the Jobs which do the backup
+// NOTE: The Jobs are removed by the history limits of the CronJob so each run is recorded when it is found and the
+// counters are only changed when the run finishes
+func (r *ReconcileBackup) updateRunsStatus(request reconcile.Request) error {
+	bkp, err := service.FetchBackupCR(request.Name, request.Namespace, r.client)
+	if err != nil {
+		return err
+	}
+
+	jobList := &batchv1.JobList{}
+	listOps := &client.ListOptions{Namespace: bkp.Namespace, LabelSelector: labels.SelectorFromSet(utils.GetBackupPodLabels(bkp))}
+	if err := r.client.List(context.TODO(), jobList, listOps); err != nil {
+		return err
+	}
+
+	// The Jobs of the base backups have the same labels of their pods
+	jobs := []batchv1.Job{}
+	for _, job := range jobList.Items {
+		if owner := metav1.GetControllerOf(&job); owner == nil || owner.Name != utils.GetBaseBackupName(bkp) {
+			jobs = append(jobs, job)
+		}
+	}
+
+	// The Jobs are processed from the oldest to the newest in order to keep the result of the latest one
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreationTimestamp.Before(&jobs[j].CreationTimestamp)
+	})
+
+	status := bkp.Status.DeepCopy()
+	for i := range jobs {
+		job := &jobs[i]
+		previous := findBackupRun(status.Runs, job.Name)
+		if previous != nil && previous.Result != phaseRunning {
+			continue
+		}
+
+		run, err := r.buildBackupRun(bkp, job)
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			*previous = *run
+		} else {
+			status.Runs = append([]v1alpha1.BackupRun{*run}, status.Runs...)
+		}
+
+		switch run.Result {
+		case phaseSucceeded:
+			status.LastSuccessfulBackupTime = run.CompletionTime
+			status.ConsecutiveFailures = 0
+		case phaseFailed:
+			status.LastFailureReason = run.Reason
+			status.ConsecutiveFailures++
+		}
+	}
+	if len(status.Runs) > maxBackupRuns {
+		status.Runs = status.Runs[:maxBackupRuns]
+	}
+
+	// Check if the runs changed, if yes update the status
+	if !reflect.DeepEqual(*status, bkp.Status) {
+		bkp.Status = *status
+		if err := r.client.Status().Update(context.TODO(), bkp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildBackupRun returns the run of the backup according to the state of its Job
+func (r *ReconcileBackup) buildBackupRun(bkp *v1alpha1.Backup, job *batchv1.Job) (*v1alpha1.BackupRun, error) {
+	run := &v1alpha1.BackupRun{
+		JobName:   job.Name,
+		StartTime: job.Status.StartTime,
+		Result:    phaseRunning,
+	}
+
+	if job.Status.Succeeded > 0 {
+		run.Result = phaseSucceeded
+		run.CompletionTime = job.Status.CompletionTime
+		run.Duration = utils.GetDuration(run.StartTime, run.CompletionTime)
+
+		// The container which uploads the dump writes its key and size in the termination message
+		if utils.IsStorageInformed(bkp) {
+			_, upload, err := r.fetchJobContainerState(job, bkp.Name, true)
+			if err != nil {
+				return nil, err
+			}
+			if upload != nil {
+				run.Artifact, run.Size = utils.ParseUploadMessage(upload.Message)
+			}
+		}
+		return run, nil
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Type != batchv1.JobFailed || c.Status != corev1.ConditionTrue {
+			continue
+		}
+		run.Result = phaseFailed
+		run.CompletionTime = c.LastTransitionTime.DeepCopy()
+		run.Duration = utils.GetDuration(run.StartTime, run.CompletionTime)
+		run.Reason = c.Message
+
+		// The reason of the Job (E.g. BackoffLimitExceeded) is completed with the container which failed
+		name, failed, err := r.fetchJobContainerState(job, "", false)
+		if err != nil {
+			return nil, err
+		}
+		if failed != nil {
+			run.Reason = strings.TrimSpace(fmt.Sprintf("Container %v exited with code %v (%v): %v",
+				name, failed.ExitCode, failed.Reason, strings.TrimSpace(failed.Message)))
+			if c.Message != "" {
+				run.Reason = c.Message + ". " + run.Reason
+			}
+		}
+	}
+	return run, nil
+}
+
+// fetchJobContainerState returns the name and the latest state of the container of the pods of the Job which terminated
+// successfully or with failure. When the name is empty any container is considered.
+// NOTE: The last termination is also checked since the containers of the backup are restarted when they fail
+func (r *ReconcileBackup) fetchJobContainerState(job *batchv1.Job, name string, succeeded bool) (string, *corev1.ContainerStateTerminated, error) {
+	podList := &corev1.PodList{}
+	listOps := &client.ListOptions{Namespace: job.Namespace, LabelSelector: labels.SelectorFromSet(map[string]string{"job-name": job.Name})}
+	if err := r.client.List(context.TODO(), podList, listOps); err != nil {
+		return "", nil, err
+	}
+
+	latestName := ""
+	var latest *corev1.ContainerStateTerminated
+	for i := range podList.Items {
+		statuses := append(podList.Items[i].Status.InitContainerStatuses, podList.Items[i].Status.ContainerStatuses...)
+		for _, c := range statuses {
+			if name != "" && c.Name != name {
+				continue
+			}
+			for _, t := range []*corev1.ContainerStateTerminated{c.State.Terminated, c.LastTerminationState.Terminated} {
+				if t == nil || (t.ExitCode == 0) != succeeded {
+					continue
+				}
+				if latest == nil || latest.FinishedAt.Before(&t.FinishedAt) {
+					latestName, latest = c.Name, t
+				}
+			}
+		}
+	}
+	return latestName, latest, nil
+}
+
+// findBackupRun returns the run of the Job with the name informed or nil when it is not found
+func findBackupRun(runs []v1alpha1.BackupRun, jobName string) *v1alpha1.BackupRun {
+	for i := range runs {
+		if runs[i].JobName == jobName {
+			return &runs[i]
+		}
+	}
+	return nil
+}
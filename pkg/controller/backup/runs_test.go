@@ -0,0 +1,170 @@
+package backup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestUpdateRunsStatus(t *testing.T) {
+	type fields struct {
+		objs []runtime.Object
+	}
+	tests := []struct {
+		name                    string
+		fields                  fields
+		wantRuns                []v1alpha1.BackupRun
+		wantConsecutiveFailures int32
+		wantLastSuccessful      bool
+		wantLastFailureReason   string
+	}{
+		{
+			name: "Should record the successful run with the dump uploaded",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithVolumeStorage, &jobBackupSucceeded, &podUploadVolumeStorage, &jobBaseBackup},
+			},
+			wantRuns: []v1alpha1.BackupRun{
+				{
+					JobName:        jobBackupSucceeded.Name,
+					StartTime:      &startTimeBackup,
+					CompletionTime: &completionTimeBackup,
+					Duration:       "1m30s",
+					Result:         phaseSucceeded,
+					Artifact:       "backups/postgresql/postgres/2019/06/26/database.example-22_33_00.pg_dump.gz",
+					Size:           2048,
+				},
+			},
+			wantLastSuccessful: true,
+		},
+		{
+			name: "Should record the failed run with the container which failed and count the consecutive failures",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithFailures, &jobBackupFailed, &podBackupFailed},
+			},
+			wantRuns: []v1alpha1.BackupRun{
+				{
+					JobName:        jobBackupFailed.Name,
+					StartTime:      &startTimeBackup,
+					CompletionTime: &completionTimeBackup,
+					Duration:       "1m30s",
+					Result:         phaseFailed,
+					Reason: "Job has reached the specified backoff limit. Container backup-dump exited with code 1 (Error): " +
+						"pg_dump: could not connect to server",
+				},
+				bkpInstanceWithFailures.Status.Runs[0],
+			},
+			wantConsecutiveFailures: 2,
+			wantLastFailureReason: "Job has reached the specified backoff limit. Container backup-dump exited with code 1 (Error): " +
+				"pg_dump: could not connect to server",
+		},
+		{
+			name: "Should reset the consecutive failures after a successful run",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithFailures, &jobBackupSucceeded, &podUploadVolumeStorage},
+			},
+			wantRuns: []v1alpha1.BackupRun{
+				{
+					JobName:        jobBackupSucceeded.Name,
+					StartTime:      &startTimeBackup,
+					CompletionTime: &completionTimeBackup,
+					Duration:       "1m30s",
+					Result:         phaseSucceeded,
+					Artifact:       "backups/postgresql/postgres/2019/06/26/database.example-22_33_00.pg_dump.gz",
+					Size:           2048,
+				},
+				bkpInstanceWithFailures.Status.Runs[0],
+			},
+			wantLastSuccessful:    true,
+			wantLastFailureReason: bkpInstanceWithFailures.Status.LastFailureReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := buildReconcileWithFakeClientWithMocks(tt.fields.objs)
+
+			request := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      bkpInstanceWithVolumeStorage.Name,
+					Namespace: bkpInstanceWithVolumeStorage.Namespace,
+				},
+			}
+			if err := r.updateRunsStatus(request); err != nil {
+				t.Fatalf("TestUpdateRunsStatus error = %v", err)
+			}
+
+			bkp := &v1alpha1.Backup{}
+			if err := r.client.Get(context.TODO(), request.NamespacedName, bkp); err != nil {
+				t.Fatalf("TestUpdateRunsStatus error fetching the Backup = %v", err)
+			}
+			if len(bkp.Status.Runs) != len(tt.wantRuns) {
+				t.Fatalf("TestUpdateRunsStatus runs = %+v, want %+v", bkp.Status.Runs, tt.wantRuns)
+			}
+			for i, run := range bkp.Status.Runs {
+				want := tt.wantRuns[i]
+				if run.JobName != want.JobName || run.Result != want.Result || run.Duration != want.Duration ||
+					run.Artifact != want.Artifact || run.Size != want.Size || run.Reason != want.Reason {
+					t.Errorf("TestUpdateRunsStatus run = %+v, want %+v", run, want)
+				}
+			}
+			if bkp.Status.ConsecutiveFailures != tt.wantConsecutiveFailures {
+				t.Errorf("TestUpdateRunsStatus consecutiveFailures = %v, want %v", bkp.Status.ConsecutiveFailures, tt.wantConsecutiveFailures)
+			}
+			if (bkp.Status.LastSuccessfulBackupTime != nil) != tt.wantLastSuccessful {
+				t.Errorf("TestUpdateRunsStatus lastSuccessfulBackupTime = %v, want it informed %v", bkp.Status.LastSuccessfulBackupTime, tt.wantLastSuccessful)
+			}
+			if bkp.Status.LastFailureReason != tt.wantLastFailureReason {
+				t.Errorf("TestUpdateRunsStatus lastFailureReason = %v, want %v", bkp.Status.LastFailureReason, tt.wantLastFailureReason)
+			}
+		})
+	}
+}
+
+func TestBuildBackupConditions(t *testing.T) {
+	tests := []struct {
+		name        string
+		bkp         *v1alpha1.Backup
+		createdErr  error
+		wantReady   corev1.ConditionStatus
+		wantFailing corev1.ConditionStatus
+	}{
+		{
+			name:        "Should be ready when none backup failed",
+			bkp:         &bkpInstanceWithVolumeStorage,
+			wantReady:   corev1.ConditionTrue,
+			wantFailing: corev1.ConditionFalse,
+		},
+		{
+			name:        "Should be failing when the latest backup failed",
+			bkp:         &bkpInstanceWithFailures,
+			wantReady:   corev1.ConditionFalse,
+			wantFailing: corev1.ConditionTrue,
+		},
+		{
+			name:        "Should not be ready when some object is missing",
+			bkp:         &bkpInstanceWithVolumeStorage,
+			createdErr:  errCronJobMissing,
+			wantReady:   corev1.ConditionFalse,
+			wantFailing: corev1.ConditionFalse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.bkp.Status.DeepCopy()
+			buildBackupConditions(tt.bkp, status, tt.createdErr)
+
+			if c := utils.FindCondition(status.Conditions, v1alpha1.ConditionReady); c == nil || c.Status != tt.wantReady {
+				t.Errorf("TestBuildBackupConditions Ready = %+v, want %v", c, tt.wantReady)
+			}
+			if c := utils.FindCondition(status.Conditions, v1alpha1.ConditionFailing); c == nil || c.Status != tt.wantFailing {
+				t.Errorf("TestBuildBackupConditions Failing = %+v, want %v", c, tt.wantFailing)
+			}
+		})
+	}
+}
@@ -6,13 +6,24 @@ import (
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
 	"github.com/dev4devs-com/postgresql-operator/pkg/service"
 	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strings"
+	"time"
 )
 
-const statusOk = "OK"
+const (
+	statusOk       = "OK"
+	phaseRunning   = "Running"
+	phaseSucceeded = "Succeeded"
+	phaseFailed    = "Failed"
+)
 
 //updateAppStatus returns error when status regards  all required resource could not be updated with OK
 func (r *ReconcileBackup) updateBackupStatus(request reconcile.Request) error {
@@ -23,21 +34,26 @@ func (r *ReconcileBackup) updateBackupStatus(request reconcile.Request) error {
 
 	statusMsgUpdate := statusOk
 	// Check if all required resource were created and found
-	if err := r.isAllCreated(bkp); err != nil {
-		statusMsgUpdate = err.Error()
+	createdErr := r.isAllCreated(bkp)
+	if createdErr != nil {
+		statusMsgUpdate = createdErr.Error()
 	}
 
+	status := bkp.Status.DeepCopy()
+	status.BackupStatus = statusMsgUpdate
+	buildBackupConditions(bkp, status, createdErr)
+
 	// Check if BackupStatus was changed, if yes update it
-	if err := r.insertUpdateBackupStatus(bkp, statusMsgUpdate); err != nil {
+	if err := r.insertUpdateBackupStatus(bkp, status); err != nil {
 		return err
 	}
 	return nil
 }
 
 // Check if BackupStatus was changed, if yes update it
-func (r *ReconcileBackup) insertUpdateBackupStatus(bkp *v1alpha1.Backup, statusMsgUpdate string) error {
-	if statusMsgUpdate != bkp.Status.BackupStatus {
-		bkp.Status.BackupStatus = statusMsgUpdate
+func (r *ReconcileBackup) insertUpdateBackupStatus(bkp *v1alpha1.Backup, status *v1alpha1.BackupStatus) error {
+	if !reflect.DeepEqual(*status, bkp.Status) {
+		bkp.Status = *status
 		if err := r.client.Status().Update(context.TODO(), bkp); err != nil {
 			return err
 		}
@@ -45,6 +61,27 @@ func (r *ReconcileBackup) insertUpdateBackupStatus(bkp *v1alpha1.Backup, statusM
 	return nil
 }
 
+// buildBackupConditions sets the conditions Ready and Failing according to the objects created and the latest runs
+func buildBackupConditions(bkp *v1alpha1.Backup, status *v1alpha1.BackupStatus, createdErr error) {
+	failing := utils.NewCondition(v1alpha1.ConditionFailing, false, "NoFailures", "", bkp.Generation)
+	if status.ConsecutiveFailures > 0 {
+		failing = utils.NewCondition(v1alpha1.ConditionFailing, true, "BackupFailed",
+			fmt.Sprintf("%v consecutive backups failed: %v", status.ConsecutiveFailures, status.LastFailureReason), bkp.Generation)
+	}
+	utils.SetCondition(&status.Conditions, failing)
+
+	ready := utils.NewCondition(v1alpha1.ConditionReady, true, "Scheduled", "None backup finished yet", bkp.Generation)
+	if createdErr != nil {
+		ready = utils.NewCondition(v1alpha1.ConditionReady, false, "ResourcesMissing", createdErr.Error(), bkp.Generation)
+	} else if status.ConsecutiveFailures > 0 {
+		ready = utils.NewCondition(v1alpha1.ConditionReady, false, "BackupFailed", failing.Message, bkp.Generation)
+	} else if status.LastSuccessfulBackupTime != nil {
+		ready = utils.NewCondition(v1alpha1.ConditionReady, true, "BackupSucceeded",
+			fmt.Sprintf("Latest backup finished at %v", status.LastSuccessfulBackupTime.UTC().Format(time.RFC3339)), bkp.Generation)
+	}
+	utils.SetCondition(&status.Conditions, ready)
+}
+
 // updateCronJobStatus returns error when was not possible update the CronJob status successfully
 func (r *ReconcileBackup) updateCronJobStatus(request reconcile.Request) error {
 	bkp, err := service.FetchBackupCR(request.Name, request.Namespace, r.client)
@@ -86,6 +123,11 @@ func (r *ReconcileBackup) updateAWSSecretStatus(request reconcile.Request) error
 		return err
 	}
 
+	// The AWS secret is not used when the storage informed has another secret
+	if !utils.IsAwsSecretUsed(bkp) {
+		return nil
+	}
+
 	aws, err := service.FetchSecret(utils.GetAwsSecretNamespace(bkp), utils.GetAWSSecretName(bkp), r.client)
 	if err != nil {
 		return err
@@ -281,13 +323,24 @@ func (r *ReconcileBackup) isAllCreated(bkp *v1alpha1.Backup) error {
 		return err
 	}
 
-	// Check if AWS secret was created
-	awsSecretName := utils.GetAWSSecretName(bkp)
-	awsSecretNamespace := utils.GetAwsSecretNamespace(bkp)
-	_, err = service.FetchSecret(awsSecretNamespace, awsSecretName, r.client)
-	if err != nil {
-		err := fmt.Errorf("Error: AWS Secret is missing. (name:%v,namespace:%v)", awsSecretName, awsSecretNamespace)
-		return err
+	// Check if AWS secret was created (if it is used by the storage)
+	if utils.IsAwsSecretUsed(bkp) {
+		awsSecretName := utils.GetAWSSecretName(bkp)
+		awsSecretNamespace := utils.GetAwsSecretNamespace(bkp)
+		_, err = service.FetchSecret(awsSecretNamespace, awsSecretName, r.client)
+		if err != nil {
+			err := fmt.Errorf("Error: AWS Secret is missing. (name:%v,namespace:%v)", awsSecretName, awsSecretNamespace)
+			return err
+		}
+	}
+
+	// Check if the secret used to access the storage directly was created (if it is required)
+	if utils.IsStorageSecretRequired(bkp) {
+		storageSecretName := utils.StorageSecretPrefix + bkp.Name
+		if _, err := service.FetchSecret(bkp.Namespace, storageSecretName, r.client); err != nil {
+			err := fmt.Errorf("Error: Storage Secret is missing. (name:%v,namespace:%v)", storageSecretName, bkp.Namespace)
+			return err
+		}
 	}
 
 	// Check if Enc secret was created (if was configured to be used)
@@ -310,3 +363,210 @@ func (r *ReconcileBackup) isAllCreated(bkp *v1alpha1.Backup) error {
 
 	return nil
 }
+
+// updateOnDemandBackupStatus returns error when was not possible update the status of the latest on-demand backup
+func (r *ReconcileBackup) updateOnDemandBackupStatus(request reconcile.Request) error {
+	bkp, err := service.FetchBackupCR(request.Name, request.Namespace, r.client)
+	if err != nil {
+		return err
+	}
+
+	// The outcome of the latest on-demand backup is kept when the trigger is removed
+	trigger := utils.GetBackupTrigger(bkp)
+	if trigger == "" {
+		return nil
+	}
+
+	// The last status recorded is kept when the Job is not found. E.g. it was removed after the backup or it is
+	// created in this reconcile and is not in the cache yet
+	job, err := service.FetchJob(utils.GetOnDemandJobName(bkp, trigger), bkp.Namespace, r.client)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	// Check if the Job changed, if yes update its status
+	if err := r.insertUpdateOnDemandBackupStatus(bkp, buildOnDemandBackupStatus(trigger, job)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// insertUpdateOnDemandBackupStatus will check if the on-demand backup status was changed, if yes update it
+func (r *ReconcileBackup) insertUpdateOnDemandBackupStatus(bkp *v1alpha1.Backup, status *v1alpha1.OnDemandBackupStatus) error {
+	if !reflect.DeepEqual(status, bkp.Status.OnDemandBackup) {
+		bkp.Status.OnDemandBackup = status
+		if err := r.client.Status().Update(context.TODO(), bkp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildOnDemandBackupStatus returns the status of the on-demand backup according to the state of its Job
+func buildOnDemandBackupStatus(trigger string, job *batchv1.Job) *v1alpha1.OnDemandBackupStatus {
+	status := &v1alpha1.OnDemandBackupStatus{
+		Trigger:   trigger,
+		JobName:   job.Name,
+		Phase:     phaseRunning,
+		StartTime: job.Status.StartTime,
+	}
+
+	if job.Status.Succeeded > 0 {
+		status.Phase = phaseSucceeded
+		status.CompletionTime = job.Status.CompletionTime
+		return status
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			status.Phase = phaseFailed
+			status.CompletionTime = c.LastTransitionTime.DeepCopy()
+			status.Error = c.Message
+		}
+	}
+	return status
+}
+
+// updateRetentionStatus returns error when was not possible update the status with the result of the latest pruning
+func (r *ReconcileBackup) updateRetentionStatus(request reconcile.Request) error {
+	bkp, err := service.FetchBackupCR(request.Name, request.Namespace, r.client)
+	if err != nil {
+		return err
+	}
+
+	if !utils.IsRetentionEnabled(bkp) {
+		return nil
+	}
+
+	status, err := r.fetchLatestPruneStatus(bkp)
+	if err != nil || status == nil {
+		return err
+	}
+
+	// Check if the result of the pruning changed, if yes update its status
+	if !reflect.DeepEqual(status, bkp.Status.Retention) {
+		bkp.Status.Retention = status
+		if err := r.client.Status().Update(context.TODO(), bkp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchLatestPruneStatus returns the result written by the prune container which finished successfully last
+// NOTE: It returns nil when none pruning finished yet. The result which cannot be parsed is shown as the error of the
+// pruning, so the other status of the Backup are still updated.
+func (r *ReconcileBackup) fetchLatestPruneStatus(bkp *v1alpha1.Backup) (*v1alpha1.BackupRetentionStatus, error) {
+	latest, err := r.fetchLatestTerminatedContainer(bkp, utils.GetPruneContainerName(bkp))
+	if err != nil || latest == nil {
+		return nil, err
+	}
+
+	status := &v1alpha1.BackupRetentionStatus{LastPruneTime: latest.FinishedAt.DeepCopy()}
+	kept, deleted, err := utils.ParsePruneMessage(latest.Message)
+	if err != nil {
+		status.Error = err.Error()
+		return status, nil
+	}
+	status.KeptArtifacts = kept
+	status.DeletedArtifacts = deleted
+	return status, nil
+}
+
+// updateWalArchivingStatus returns error when was not possible update the status with the latest base backup
+func (r *ReconcileBackup) updateWalArchivingStatus(request reconcile.Request) error {
+	bkp, err := service.FetchBackupCR(request.Name, request.Namespace, r.client)
+	if err != nil {
+		return err
+	}
+
+	var status *v1alpha1.BackupWalArchivingStatus
+	if utils.IsBackupWalArchivingEnabled(bkp) {
+		status = &v1alpha1.BackupWalArchivingStatus{BaseBackupCronJobName: utils.GetBaseBackupName(bkp)}
+		latest, err := r.fetchLatestTerminatedContainer(bkp, utils.GetBaseBackupName(bkp))
+		if err != nil {
+			return err
+		}
+		if latest != nil {
+			status.LastBaseBackup = strings.TrimSpace(latest.Message)
+			status.LastBaseBackupTime = latest.FinishedAt.DeepCopy()
+		}
+	}
+
+	// Check if the state of the base backups changed, if yes update its status
+	if !reflect.DeepEqual(status, bkp.Status.WalArchiving) {
+		bkp.Status.WalArchiving = status
+		if err := r.client.Status().Update(context.TODO(), bkp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateDumpsStatus returns error when was not possible update the status with the dumps found in the storage
+// NOTE: The dumps are listed by the container which uploads the dump when the storage is informed and by the prune
+// container when the retention is enabled. The list of the one which finished last is used.
+func (r *ReconcileBackup) updateDumpsStatus(request reconcile.Request) error {
+	bkp, err := service.FetchBackupCR(request.Name, request.Namespace, r.client)
+	if err != nil {
+		return err
+	}
+
+	var latest *corev1.ContainerStateTerminated
+	if utils.IsStorageInformed(bkp) {
+		if latest, err = r.fetchLatestTerminatedContainer(bkp, bkp.Name); err != nil {
+			return err
+		}
+	}
+	if utils.IsRetentionEnabled(bkp) {
+		prune, err := r.fetchLatestTerminatedContainer(bkp, utils.GetPruneContainerName(bkp))
+		if err != nil {
+			return err
+		}
+		if prune != nil && (latest == nil || !prune.FinishedAt.Before(&latest.FinishedAt)) {
+			latest = prune
+		}
+	}
+	if latest == nil {
+		return nil
+	}
+
+	// Check if the dumps found changed, if yes update its status
+	dumps := utils.ParseDumpsMessage(latest.Message)
+	if !reflect.DeepEqual(dumps, bkp.Status.Dumps) {
+		bkp.Status.Dumps = dumps
+		if err := r.client.Status().Update(context.TODO(), bkp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchLatestTerminatedContainer returns the state of the container or init container with the name informed which
+// finished successfully last in the pods of the Jobs of the Backup
+// NOTE: It returns nil when none container finished successfully yet
+func (r *ReconcileBackup) fetchLatestTerminatedContainer(bkp *v1alpha1.Backup, name string) (*corev1.ContainerStateTerminated, error) {
+	podList := &corev1.PodList{}
+	listOps := &client.ListOptions{Namespace: bkp.Namespace, LabelSelector: labels.SelectorFromSet(utils.GetBackupPodLabels(bkp))}
+	if err := r.client.List(context.TODO(), podList, listOps); err != nil {
+		return nil, err
+	}
+
+	var latest *corev1.ContainerStateTerminated
+	for i := range podList.Items {
+		statuses := append(podList.Items[i].Status.InitContainerStatuses, podList.Items[i].Status.ContainerStatuses...)
+		for _, c := range statuses {
+			t := c.State.Terminated
+			if c.Name != name || t == nil || t.ExitCode != 0 {
+				continue
+			}
+			if latest == nil || latest.FinishedAt.Before(&t.FinishedAt) {
+				latest = t
+			}
+		}
+	}
+	return latest, nil
+}
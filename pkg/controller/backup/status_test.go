@@ -1,6 +1,8 @@
 package backup
 
 import (
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
 	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
 	"k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
@@ -538,3 +540,340 @@ func TestUpdateServiceDatabaseFoundStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateOnDemandBackupStatus(t *testing.T) {
+	type fields struct {
+		objs []runtime.Object
+	}
+	type args struct {
+		request reconcile.Request
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		wantErr   bool
+		wantPhase string
+	}{
+		{
+			name: "Should not update the status without trigger",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithMandatorySpec},
+			},
+			args: args{
+				request: reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      bkpInstanceWithMandatorySpec.Name,
+						Namespace: bkpInstanceWithMandatorySpec.Namespace,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Should keep the last status when the job of the trigger was not found",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithTrigger},
+			},
+			args: args{
+				request: reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      bkpInstanceWithTrigger.Name,
+						Namespace: bkpInstanceWithTrigger.Namespace,
+					},
+				},
+			},
+			wantErr:   false,
+			wantPhase: phaseSucceeded,
+		},
+		{
+			name: "Should update the status with the job of the trigger",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithTrigger, &onDemandJobFailed},
+			},
+			args: args{
+				request: reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      bkpInstanceWithTrigger.Name,
+						Namespace: bkpInstanceWithTrigger.Namespace,
+					},
+				},
+			},
+			wantErr:   false,
+			wantPhase: phaseFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := buildReconcileWithFakeClientWithMocks(tt.fields.objs)
+
+			if err := r.updateOnDemandBackupStatus(tt.args.request); (err != nil) != tt.wantErr {
+				t.Errorf("TestUpdateOnDemandBackupStatus error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantPhase == "" {
+				return
+			}
+			bkp, err := service.FetchBackupCR(tt.args.request.Name, tt.args.request.Namespace, r.client)
+			if err != nil {
+				t.Fatalf("get backup: (%v)", err)
+			}
+			if bkp.Status.OnDemandBackup == nil || bkp.Status.OnDemandBackup.Phase != tt.wantPhase {
+				t.Errorf("TestUpdateOnDemandBackupStatus on-demand backup got (%v), when is expected the phase (%v)", bkp.Status.OnDemandBackup, tt.wantPhase)
+			}
+		})
+	}
+}
+
+func TestUpdateRetentionStatus(t *testing.T) {
+	type fields struct {
+		objs []runtime.Object
+	}
+	type args struct {
+		request reconcile.Request
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		args        args
+		wantErr     bool
+		wantStatus  bool
+		wantKept    int32
+		wantDeleted int32
+		wantError   bool
+	}{
+		{
+			name: "Should not update the status without retention",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithMandatorySpec, &podPruneLatest},
+			},
+			args: args{
+				request: reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      bkpInstanceWithMandatorySpec.Name,
+						Namespace: bkpInstanceWithMandatorySpec.Namespace,
+					},
+				},
+			},
+			wantErr:    false,
+			wantStatus: false,
+		},
+		{
+			name: "Should not update the status when none pruning finished",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithRetention},
+			},
+			args: args{
+				request: reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      bkpInstanceWithRetention.Name,
+						Namespace: bkpInstanceWithRetention.Namespace,
+					},
+				},
+			},
+			wantErr:    false,
+			wantStatus: false,
+		},
+		{
+			name: "Should update the status with the latest pruning",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithRetention, &podPruneOld, &podPruneLatest},
+			},
+			args: args{
+				request: reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      bkpInstanceWithRetention.Name,
+						Namespace: bkpInstanceWithRetention.Namespace,
+					},
+				},
+			},
+			wantErr:     false,
+			wantStatus:  true,
+			wantKept:    3,
+			wantDeleted: 2,
+		},
+		{
+			name: "Should update the status with the error when the result of the pruning cannot be parsed",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithRetention, &podPruneInvalid},
+			},
+			args: args{
+				request: reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      bkpInstanceWithRetention.Name,
+						Namespace: bkpInstanceWithRetention.Namespace,
+					},
+				},
+			},
+			wantErr:    false,
+			wantStatus: true,
+			wantError:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := buildReconcileWithFakeClientWithMocks(tt.fields.objs)
+
+			if err := r.updateRetentionStatus(tt.args.request); (err != nil) != tt.wantErr {
+				t.Errorf("TestUpdateRetentionStatus error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			bkp, err := service.FetchBackupCR(tt.args.request.Name, tt.args.request.Namespace, r.client)
+			if err != nil {
+				t.Errorf("TestUpdateRetentionStatus to get backup error = %v", err)
+				return
+			}
+
+			if (bkp.Status.Retention != nil) != tt.wantStatus {
+				t.Errorf("TestUpdateRetentionStatus status.retention = %+v, wantStatus %v", bkp.Status.Retention, tt.wantStatus)
+				return
+			}
+			if tt.wantStatus && (bkp.Status.Retention.KeptArtifacts != tt.wantKept || bkp.Status.Retention.DeletedArtifacts != tt.wantDeleted) {
+				t.Errorf("TestUpdateRetentionStatus status.retention = %+v, wantKept %v, wantDeleted %v", bkp.Status.Retention, tt.wantKept, tt.wantDeleted)
+			}
+			if tt.wantStatus && (bkp.Status.Retention.Error != "") != tt.wantError {
+				t.Errorf("TestUpdateRetentionStatus status.retention.error = %v, wantError %v", bkp.Status.Retention.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestUpdateDumpsStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		objs      []runtime.Object
+		bkp       v1alpha1.Backup
+		wantDumps []string
+	}{
+		{
+			name:      "Should not update the status without storage and retention",
+			objs:      []runtime.Object{&bkpInstanceWithMandatorySpec, &podPruneLatest},
+			bkp:       bkpInstanceWithMandatorySpec,
+			wantDumps: nil,
+		},
+		{
+			name:      "Should update the status with the dumps listed by the latest pruning",
+			objs:      []runtime.Object{&bkpInstanceWithRetention, &podPruneOld, &podPruneLatest},
+			bkp:       bkpInstanceWithRetention,
+			wantDumps: []string{"backups/postgresql/postgres/2019/06/26/database.example-22_33_00.pg_dump.gz"},
+		},
+		{
+			name: "Should update the status with the dumps listed by the upload to the volume storage",
+			objs: []runtime.Object{&bkpInstanceWithVolumeStorage, &podUploadVolumeStorage},
+			bkp:  bkpInstanceWithVolumeStorage,
+			wantDumps: []string{
+				"backups/postgresql/postgres/2019/06/26/database.example-22_33_00.pg_dump.gz",
+				"backups/postgresql/postgres/2019/06/25/database.example-22_33_00.pg_dump.gz",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := buildReconcileWithFakeClientWithMocks(tt.objs)
+
+			request := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      tt.bkp.Name,
+					Namespace: tt.bkp.Namespace,
+				},
+			}
+			if err := r.updateDumpsStatus(request); err != nil {
+				t.Errorf("TestUpdateDumpsStatus error = %v", err)
+				return
+			}
+
+			bkp, err := service.FetchBackupCR(tt.bkp.Name, tt.bkp.Namespace, r.client)
+			if err != nil {
+				t.Errorf("TestUpdateDumpsStatus to get backup error = %v", err)
+				return
+			}
+
+			var keys []string
+			for _, dump := range bkp.Status.Dumps {
+				keys = append(keys, dump.Key)
+			}
+			if !reflect.DeepEqual(keys, tt.wantDumps) {
+				t.Errorf("TestUpdateDumpsStatus status.dumps = %v, want %v", keys, tt.wantDumps)
+			}
+		})
+	}
+}
+
+func TestUpdateWalArchivingStatus(t *testing.T) {
+	type fields struct {
+		objs []runtime.Object
+	}
+	type args struct {
+		request reconcile.Request
+	}
+	tests := []struct {
+		name           string
+		fields         fields
+		args           args
+		wantErr        bool
+		wantStatus     bool
+		wantBaseBackup string
+	}{
+		{
+			name: "Should not update the status without wal archiving",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithMandatorySpec},
+			},
+			args: args{
+				request: reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      bkpInstanceWithMandatorySpec.Name,
+						Namespace: bkpInstanceWithMandatorySpec.Namespace,
+					},
+				},
+			},
+			wantErr:    false,
+			wantStatus: false,
+		},
+		{
+			name: "Should update the status with the latest base backup",
+			fields: fields{
+				objs: []runtime.Object{&bkpInstanceWithWalArchiving, &podBaseBackup},
+			},
+			args: args{
+				request: reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      bkpInstanceWithWalArchiving.Name,
+						Namespace: bkpInstanceWithWalArchiving.Namespace,
+					},
+				},
+			},
+			wantErr:        false,
+			wantStatus:     true,
+			wantBaseBackup: "wal/postgresql-operator/database/base/20190626T222600Z/base.tar.gz",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := buildReconcileWithFakeClientWithMocks(tt.fields.objs)
+
+			if err := r.updateWalArchivingStatus(tt.args.request); (err != nil) != tt.wantErr {
+				t.Errorf("TestUpdateWalArchivingStatus error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			bkp, err := service.FetchBackupCR(tt.args.request.Name, tt.args.request.Namespace, r.client)
+			if err != nil {
+				t.Errorf("TestUpdateWalArchivingStatus to get backup error = %v", err)
+				return
+			}
+
+			if (bkp.Status.WalArchiving != nil) != tt.wantStatus {
+				t.Errorf("TestUpdateWalArchivingStatus status.walArchiving = %+v, wantStatus %v", bkp.Status.WalArchiving, tt.wantStatus)
+				return
+			}
+			if tt.wantStatus && bkp.Status.WalArchiving.LastBaseBackup != tt.wantBaseBackup {
+				t.Errorf("TestUpdateWalArchivingStatus status.walArchiving.lastBaseBackup = %v, want %v", bkp.Status.WalArchiving.LastBaseBackup, tt.wantBaseBackup)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileDatabase_Class(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		dbInstanceWithClass.DeepCopy(),
+		databaseClassSmall.DeepCopy(),
+	}
+
+	r := buildReconcileWithStatusSubresource(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithClass.Name,
+			Namespace: dbInstanceWithClass.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	container := dep.Spec.Template.Spec.Containers[0]
+	if container.Image != databaseClassSmall.Spec.Image {
+		t.Errorf("Deployment got the image (%v), when is expected the one of the class (%v)", container.Image, databaseClassSmall.Spec.Image)
+	}
+	// The values informed in the spec of the Database have priority over the ones of the class
+	if limit := container.Resources.Limits[corev1.ResourceMemory]; limit.String() != "2Gi" {
+		t.Errorf("Deployment got the memory limit (%v), when is expected the one of the spec (2Gi)", limit.String())
+	}
+	if request := container.Resources.Requests[corev1.ResourceMemory]; request.String() != "512Mi" {
+		t.Errorf("Deployment got the memory request (%v), when is expected the one of the class (512Mi)", request.String())
+	}
+	if !reflect.DeepEqual(dep.Spec.Template.Spec.NodeSelector, databaseClassSmall.Spec.NodeSelector) {
+		t.Errorf("Deployment got the nodeSelector (%v), when is expected the one of the class (%v)",
+			dep.Spec.Template.Spec.NodeSelector, databaseClassSmall.Spec.NodeSelector)
+	}
+
+	cm, err := service.FetchConfigMap(req.Name+utils.ParametersSuffix, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get configmap: (%v)", err)
+	}
+	if conf := cm.Data[utils.ParametersConfKey]; !strings.Contains(conf, "work_mem = '8MB'\n") {
+		t.Errorf("ConfigMap got (%v), when is expected the parameters of the class", conf)
+	}
+
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	class := db.Status.Class
+	if class == nil || class.Name != "small" || class.Image != databaseClassSmall.Spec.Image ||
+		class.DatabaseMemoryLimit != "2Gi" || class.DatabaseMemoryRequest != "512Mi" {
+		t.Fatalf("Class status got (%v), when is expected the values resolved with the class", class)
+	}
+	// The values of the class and the default values are not stored in the CR
+	if db.Spec.Image != "" || db.Spec.DatabaseMemoryRequest != "" || len(db.Spec.NodeSelector) != 0 || len(db.Spec.Parameters) != 0 {
+		t.Errorf("Database spec got (%v), when is expected to not store the values of the class", db.Spec)
+	}
+}
+
+func TestReconcileDatabase_ClassNotFound(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		dbInstanceWithClass.DeepCopy(),
+	}
+
+	r := buildReconcileWithStatusSubresource(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithClass.Name,
+			Namespace: dbInstanceWithClass.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	// The workloads are not created with the default values instead of the ones of the class
+	if _, err := service.FetchDeployment(req.Name, req.Namespace, r.client); err == nil {
+		t.Error("Deployment was created without the DatabaseClass")
+	}
+
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if !strings.Contains(db.Status.DatabaseStatus, "DatabaseClass small was not found") {
+		t.Errorf("DatabaseStatus got (%v), when is expected the error of the class not found", db.Status.DatabaseStatus)
+	}
+	if db.Status.Class == nil || db.Status.Class.Message == "" {
+		t.Errorf("Class status got (%v), when is expected the message of the class not found", db.Status.Class)
+	}
+}
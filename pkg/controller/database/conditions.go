@@ -0,0 +1,181 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/config"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Phases of the Database shown in the status
+const (
+	phasePending      = "Pending"
+	phaseProvisioning = "Provisioning"
+	phaseReady        = "Ready"
+	phaseDegraded     = "Degraded"
+	phaseFailed       = "Failed"
+)
+
+// buildConditions sets the conditions and the phase in the status according to the objects and pods found in the cluster
+// NOTE: The validationErr is the error of the spec and the createdErr the error of the objects which are missing
+func (r *ReconcileDatabase) buildConditions(db *v1alpha1.Database, status *v1alpha1.DatabaseStatus, validationErr, createdErr error) error {
+	generation := db.Generation
+	wasReady := status.Phase == phaseReady || status.Phase == phaseDegraded
+
+	// Provisioned
+	provisioned := utils.NewCondition(v1alpha1.ConditionProvisioned, true, "ResourcesCreated", "", generation)
+	if validationErr != nil {
+		provisioned = utils.NewCondition(v1alpha1.ConditionProvisioned, false, "InvalidSpec", validationErr.Error(), generation)
+	} else if createdErr != nil {
+		provisioned = utils.NewCondition(v1alpha1.ConditionProvisioned, false, "ResourcesMissing", createdErr.Error(), generation)
+	}
+	utils.SetCondition(&status.Conditions, provisioned)
+
+	// Ready
+	primaryPod, readyMembers, err := r.fetchPrimaryPodAndReadyMembers(db)
+	if err != nil {
+		return err
+	}
+	ready := utils.NewCondition(v1alpha1.ConditionReady, false, "PrimaryNotFound", "None pod of the primary was found", generation)
+	if primaryPod != nil && isPodReady(primaryPod) {
+		ready = utils.NewCondition(v1alpha1.ConditionReady, true, "PrimaryReady",
+			fmt.Sprintf("Primary %v is ready to accept connections", primaryPod.Name), generation)
+	} else if primaryPod != nil {
+		ready = utils.NewCondition(v1alpha1.ConditionReady, false, "PrimaryNotReady",
+			fmt.Sprintf("Primary %v is not ready", primaryPod.Name), generation)
+	}
+	utils.SetCondition(&status.Conditions, ready)
+	isReady := ready.Status == corev1.ConditionTrue
+
+	// Degraded
+	expectedMembers := getExpectedMembers(db)
+	degraded := utils.NewCondition(v1alpha1.ConditionDegraded, false, "AsExpected",
+		fmt.Sprintf("%v of %v members are ready", readyMembers, expectedMembers), generation)
+	if !isReady && wasReady {
+		degraded = utils.NewCondition(v1alpha1.ConditionDegraded, true, "PrimaryNotReady", ready.Message, generation)
+	} else if isReady && readyMembers < expectedMembers {
+		degraded = utils.NewCondition(v1alpha1.ConditionDegraded, true, "MembersNotReady",
+			fmt.Sprintf("%v of %v members are ready", readyMembers, expectedMembers), generation)
+	} else if isWalArchivingFailing(db, status) {
+		degraded = utils.NewCondition(v1alpha1.ConditionDegraded, true, "WalArchivingFailing",
+			fmt.Sprintf("The archiving of the WAL segment %v failed", status.WalArchiving.LastFailedWal), generation)
+	}
+	utils.SetCondition(&status.Conditions, degraded)
+
+	// BackupConfigured
+	bkp, err := r.fetchDatabaseBackup(db)
+	if err != nil {
+		return err
+	}
+	backupConfigured := utils.NewCondition(v1alpha1.ConditionBackupConfigured, false, "BackupNotFound",
+		"None Backup CR refers to the Database", generation)
+	if bkp != nil {
+		backupConfigured = utils.NewCondition(v1alpha1.ConditionBackupConfigured, true, "BackupFound",
+			fmt.Sprintf("Backup %v refers to the Database", bkp.Name), generation)
+	}
+	utils.SetCondition(&status.Conditions, backupConfigured)
+
+	// StorageResized
+	storageResized, err := r.buildStorageCondition(db)
+	if err != nil {
+		return err
+	}
+	utils.SetCondition(&status.Conditions, storageResized)
+
+	status.Phase = getPhase(validationErr, createdErr, isReady, wasReady, degraded.Status == corev1.ConditionTrue)
+	return nil
+}
+
+// getPhase returns the phase of the Database according to its conditions
+func getPhase(validationErr, createdErr error, isReady, wasReady, isDegraded bool) string {
+	switch {
+	case validationErr != nil:
+		return phaseFailed
+	case createdErr != nil:
+		return phasePending
+	case !isReady && wasReady:
+		return phaseDegraded
+	case !isReady:
+		return phaseProvisioning
+	case isDegraded:
+		return phaseDegraded
+	}
+	return phaseReady
+}
+
+// fetchPrimaryPodAndReadyMembers returns the pod of the primary and the quantity of members (primary and standbys) which are ready
+func (r *ReconcileDatabase) fetchPrimaryPodAndReadyMembers(db *v1alpha1.Database) (*corev1.Pod, int32, error) {
+	podList := &corev1.PodList{}
+	listOps := &client.ListOptions{Namespace: db.Namespace, LabelSelector: labels.SelectorFromSet(utils.GetLabels(db.Name))}
+	if err := r.client.List(context.TODO(), podList, listOps); err != nil {
+		return nil, 0, err
+	}
+
+	// Without the replication and the StatefulSet the only pod is the one of the Deployment
+	primary := ""
+	if utils.IsReplicationEnabled(db) || utils.IsStatefulSet(db) {
+		primary = utils.GetPrimaryMember(db)
+	}
+
+	var primaryPod *corev1.Pod
+	var readyMembers int32
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		member := getPodMember(db, pod)
+		if pod.DeletionTimestamp != nil || (primary != "" && member == "") {
+			continue
+		}
+		if isPodReady(pod) {
+			readyMembers++
+		}
+		// The ready pod is preferred when more than one is found. (E.g. during a rollout)
+		if (primary == "" || member == primary) && (primaryPod == nil || !isPodReady(primaryPod)) {
+			primaryPod = pod
+		}
+	}
+	return primaryPod, readyMembers, nil
+}
+
+// getExpectedMembers returns the quantity of members (primary and standbys) which should be ready
+func getExpectedMembers(db *v1alpha1.Database) int32 {
+	if utils.IsStatefulSet(db) {
+		return utils.GetStatefulSetReplicas(db)
+	}
+	return int32(1 + utils.GetStandbySize(db))
+}
+
+// isWalArchivingFailing returns true when the last archiving of a WAL segment failed after the last one archived
+func isWalArchivingFailing(db *v1alpha1.Database, status *v1alpha1.DatabaseStatus) bool {
+	if !utils.IsWalArchivingEnabled(db) || status.WalArchiving == nil || status.WalArchiving.LastFailedTime == nil {
+		return false
+	}
+	return status.WalArchiving.LastArchivedTime == nil ||
+		status.WalArchiving.LastFailedTime.After(status.WalArchiving.LastArchivedTime.Time)
+}
+
+// fetchDatabaseBackup returns the first Backup CR in the namespace which refers to the Database or nil when none is found
+func (r *ReconcileDatabase) fetchDatabaseBackup(db *v1alpha1.Database) (*v1alpha1.Backup, error) {
+	bkpList := &v1alpha1.BackupList{}
+	if err := r.client.List(context.TODO(), bkpList, &client.ListOptions{Namespace: db.Namespace}); err != nil {
+		return nil, err
+	}
+	for i := range bkpList.Items {
+		if getBackupDatabaseCRName(&bkpList.Items[i]) == db.Name {
+			return &bkpList.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// getBackupDatabaseCRName returns the name of the Database CR of the Backup with the default value when it is not informed
+func getBackupDatabaseCRName(bkp *v1alpha1.Backup) string {
+	if bkp.Spec.DatabaseCRName == "" {
+		return config.GetBackupConfig(bkp.Namespace).DatabaseCRName
+	}
+	return bkp.Spec.DatabaseCRName
+}
@@ -1,21 +1,31 @@
 package database
 
 import (
+	"context"
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
 	"github.com/dev4devs-com/postgresql-operator/pkg/service"
 	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
 	"k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
+	"time"
 )
 
+// walArchivingCheckInterval is the interval used to update the state of the WAL archiving in the status
+const walArchivingCheckInterval = 30 * time.Second
+
 // Add creates a new Database Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
@@ -24,7 +34,14 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileDatabase{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	r := &ReconcileDatabase{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor(utils.DatabaseControllerName)}
+	executor, err := service.NewPodExecutor(mgr.GetConfig())
+	if err != nil {
+		logf.Log.WithName(utils.DatabaseControllerName).Error(err, "Failed to create the SQL executor. The failover will not be performed.")
+		return r
+	}
+	r.executor = executor
+	return r
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -47,19 +64,91 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch StatefulSet resource controlled and created by it
+	if err := service.Watch(c, &v1.StatefulSet{}, true, &v1alpha1.Database{}); err != nil {
+		return err
+	}
+
+	// Watch ConfigMap resource controlled and created by it with the spec.parameters
+	if err := service.Watch(c, &corev1.ConfigMap{}, true, &v1alpha1.Database{}); err != nil {
+		return err
+	}
+
+	// Watch Secret resource controlled and created by it with the credentials of the roles
+	if err := service.Watch(c, &corev1.Secret{}, true, &v1alpha1.Database{}); err != nil {
+		return err
+	}
+
+	// Watch Job resource controlled and created by it in order to migrate the Deployment to StatefulSet
+	if err := service.Watch(c, &batchv1.Job{}, true, &v1alpha1.Database{}); err != nil {
+		return err
+	}
+
 	// Watch PersistenceVolumeClaim resource controlled and created by it
 	if err := service.Watch(c, &corev1.PersistentVolumeClaim{}, true, &v1alpha1.Database{}); err != nil {
 		return err
 	}
 
+	// Watch PersistenceVolumeClaim resource created by the volumeClaimTemplates of the StatefulSet in order to follow
+	// their expansion. They have the labels of the Database but are not controlled by it
+	if err := c.Watch(&source.Kind{Type: &corev1.PersistentVolumeClaim{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			name := obj.Meta.GetLabels()["cr"]
+			if name == "" || metav1.GetControllerOf(obj.Meta) != nil {
+				return nil
+			}
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.Meta.GetNamespace()}}}
+		}),
+	}); err != nil {
+		return err
+	}
+
 	// Watch Service resource controlled and created by it
 	if err := service.Watch(c, &corev1.Service{}, true, &v1alpha1.Database{}); err != nil {
 		return err
 	}
 
+	// Watch Backup resource in order to update the condition BackupConfigured of the Database which it refers to
+	if err := c.Watch(&source.Kind{Type: &v1alpha1.Backup{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			bkp, ok := obj.Object.(*v1alpha1.Backup)
+			if !ok {
+				return nil
+			}
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: getBackupDatabaseCRName(bkp), Namespace: bkp.Namespace}}}
+		}),
+	}); err != nil {
+		return err
+	}
+
+	// Watch DatabaseClass resource in order to apply its changes in the Databases which refer to it
+	if err := c.Watch(&source.Kind{Type: &v1alpha1.DatabaseClass{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			return getClassDatabaseRequests(mgr.GetClient(), obj.Meta.GetName())
+		}),
+	}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// getClassDatabaseRequests returns the requests of the Databases which refer to the DatabaseClass informed
+func getClassDatabaseRequests(c client.Client, className string) []reconcile.Request {
+	dbList := &v1alpha1.DatabaseList{}
+	if err := c.List(context.TODO(), dbList); err != nil {
+		logf.Log.WithName(utils.DatabaseControllerName).Error(err, "Failed to list the Databases of the DatabaseClass", "DatabaseClass.Name", className)
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, db := range dbList.Items {
+		if db.Spec.ClassName == className {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: db.Name, Namespace: db.Namespace}})
+		}
+	}
+	return requests
+}
+
 // blank assignment to verify that ReconcileDatabase implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileDatabase{}
 
@@ -69,6 +158,10 @@ type ReconcileDatabase struct {
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
 	scheme *runtime.Scheme
+	// executor runs the health checks and SQL statements in the Database pods. (E.g. to promote a standby)
+	executor service.SQLExecutor
+	// recorder publishes the events of the Database. (E.g. failover)
+	recorder record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a Database object and makes changes based on the state read
@@ -94,25 +187,122 @@ func (r *ReconcileDatabase) Reconcile(request reconcile.Request) (reconcile.Resu
 		return reconcile.Result{}, err
 	}
 
-	// Add const values for mandatory specs
-	utils.AddDatabaseMandatorySpecs(db)
+	// Apply the deletionPolicy to the PVCs when the Database is being deleted
+	if db.DeletionTimestamp != nil {
+		return r.manageDeletion(db)
+	}
+
+	// Add or remove the finalizer which applies the deletionPolicy according to it
+	if err := r.manageDeletionFinalizer(db); err != nil {
+		reqLogger.Error(err, "Failed to manage the finalizer of the Database")
+		return reconcile.Result{}, err
+	}
+
+	// Add the values of the DatabaseClass and the const values for mandatory specs
+	// NOTE: They are stored in the CR by the defaulting webhook when it is enabled, so it only fills the CRs created
+	// without it. The values managed by the class are never stored.
+	if _, err := service.AddDatabaseMandatorySpecs(db, r.client); err != nil {
+		// The workloads are not changed with the default values when the class is not found. The Database is
+		// reconciled again when the DatabaseClass is created
+		if errors.IsNotFound(err) {
+			reqLogger.Error(err, "DatabaseClass of the Database not found")
+			return reconcile.Result{}, r.updateDBStatus(request)
+		}
+		reqLogger.Error(err, "Failed to get the DatabaseClass of the Database")
+		return reconcile.Result{}, err
+	}
+
+	// The workloads cannot be built with invalid quantities. They are rejected by the validating webhook, but it can be
+	// disabled or the CR applied before it be enabled
+	if err := utils.ValidateQuantities(db); err != nil {
+		reqLogger.Error(err, "Invalid quantities in the Database spec")
+		return reconcile.Result{}, r.updateDBStatus(request)
+	}
+
+	if err := r.manageUpgrade(db); err != nil {
+		reqLogger.Error(err, "Failed to manage the upgrade of the Database major version")
+		return reconcile.Result{}, err
+	}
+
+	if err := r.manageTLS(db); err != nil {
+		reqLogger.Error(err, "Failed to manage the TLS of the Database")
+		return reconcile.Result{}, err
+	}
+
+	if err := r.manageHba(db); err != nil {
+		reqLogger.Error(err, "Failed to manage the pg_hba.conf of the Database")
+		return reconcile.Result{}, err
+	}
+
+	if err := r.manageParameters(db); err != nil {
+		reqLogger.Error(err, "Failed to manage the parameters of the Database")
+		return reconcile.Result{}, err
+	}
 
 	if err := r.createResources(db, request); err != nil {
 		reqLogger.Error(err, "Failed to create the secondary resource required for the Database CR")
 		return reconcile.Result{}, err
 	}
 
+	if err := r.manageFailover(db); err != nil {
+		reqLogger.Error(err, "Failed to manage the failover of the Database primary")
+		return reconcile.Result{}, err
+	}
+
 	if err := r.manageResources(db); err != nil {
 		reqLogger.Error(err, "Failed to manage resource required for the Database CR")
 		return reconcile.Result{}, err
 	}
 
+	if err := r.manageRoles(db); err != nil {
+		reqLogger.Error(err, "Failed to manage the roles and databases of the Database")
+		return reconcile.Result{}, err
+	}
+
+	if err := r.managePasswordRotation(db); err != nil {
+		reqLogger.Error(err, "Failed to rotate the passwords of the Database")
+		return reconcile.Result{}, err
+	}
+
 	if err := r.createUpdateCRStatus(request); err != nil {
 		reqLogger.Error(err, "Failed to create and update the status in the Database CR")
 		return reconcile.Result{}, err
 	}
 
 	reqLogger.Info("Stop Reconciling Database ...")
+	// The steps of the upgrade are checked periodically in order to roll back when the swap times out
+	if isUpgradeInProgress(db) {
+		return reconcile.Result{RequeueAfter: upgradeCheckInterval}, nil
+	}
+	// The pods are checked periodically until they have the file of the parameters or pg_hba.conf changed in order to
+	// reload them
+	if isParametersChanging(db) || isHbaChanging(db) {
+		return reconcile.Result{RequeueAfter: parametersCheckInterval}, nil
+	}
+	// The roles and databases are applied again until the primary be ready or the error be fixed
+	if isServerObjectsPending(db) {
+		return reconcile.Result{RequeueAfter: serverObjectsCheckInterval}, nil
+	}
+	// The health of the primary should be checked periodically when the replication is enabled
+	if utils.IsReplicationEnabled(db) && r.executor != nil {
+		return reconcile.Result{RequeueAfter: failoverCheckInterval}, nil
+	}
+	// The state of the WAL archiving is only available in the database so it should be checked periodically
+	if utils.IsWalArchivingEnabled(db) && r.executor != nil {
+		return reconcile.Result{RequeueAfter: walArchivingCheckInterval}, nil
+	}
+	// The Secret with the server certificate is checked until it be available and valid
+	if isTLSPending(db) {
+		return reconcile.Result{RequeueAfter: tlsRetryInterval}, nil
+	}
+	// The passwords are rotated when the interval elapses and the previous login roles expired after the grace period
+	if utils.IsPasswordRotationEnabled(db) && utils.ValidatePasswordRotation(db) == nil {
+		return reconcile.Result{RequeueAfter: getPasswordRotationRequeue(db)}, nil
+	}
+	// The server certificate is checked periodically in order to renew it before the expiry
+	if db.Status.TLS != nil {
+		return reconcile.Result{RequeueAfter: tlsCheckInterval}, nil
+	}
 	return reconcile.Result{}, nil
 }
 
@@ -121,22 +311,59 @@ func (r *ReconcileDatabase) createResources(db *v1alpha1.Database, request recon
 	reqLogger := utils.GetLoggerByRequestAndController(request, utils.DatabaseControllerName)
 	reqLogger.Info("Creating secondary Database resources ...")
 
-	// Check if deployment for the app exist, if not create one
-	if err := r.createDeployment(db); err != nil {
-		reqLogger.Error(err, "Failed to create Deployment")
+	// Check if the Secret with the generated password exist, if not create one
+	if err := r.createCredentialsSecret(db); err != nil {
+		reqLogger.Error(err, "Failed to create the credentials Secret")
 		return err
 	}
 
+	if utils.IsStatefulSet(db) {
+		// Check if the headless service for the StatefulSet exist, if not create one
+		if err := r.createHeadlessService(db); err != nil {
+			reqLogger.Error(err, "Failed to create the headless Service")
+			return err
+		}
+
+		// Check if StatefulSet for the app exist, if not create one (migrating the Deployment when it exist)
+		if err := r.createStatefulSet(db); err != nil {
+			reqLogger.Error(err, "Failed to create StatefulSet")
+			return err
+		}
+	} else {
+		// Check if deployment for the app exist, if not create one
+		if err := r.createDeployment(db); err != nil {
+			reqLogger.Error(err, "Failed to create Deployment")
+			return err
+		}
+
+		// Check if PersistentVolumeClaim for the app exist, if not create one
+		if err := r.createPvc(db); err != nil {
+			reqLogger.Error(err, "Failed to create PVC")
+			return err
+		}
+	}
+
 	// Check if service for the app exist, if not create one
 	if err := r.createService(db); err != nil {
 		reqLogger.Error(err, "Failed to create Service")
 		return err
 	}
 
-	// Check if PersistentVolumeClaim for the app exist, if not create one
-	if err := r.createPvc(db); err != nil {
-		reqLogger.Error(err, "Failed to create PVC")
-		return err
+	if utils.IsReplicationEnabled(db) {
+		// Check if the read-only service for the standbys exist, if not create one
+		if err := r.createReadOnlyService(db); err != nil {
+			reqLogger.Error(err, "Failed to create the read-only Service")
+			return err
+		}
+
+		// Check if the PVC and Deployment of each standby exist, if not create them
+		// NOTE: With the StatefulSet the standbys are its pods
+		if !utils.IsStatefulSet(db) {
+			if err := r.createStandbys(db); err != nil {
+				reqLogger.Error(err, "Failed to create the standbys")
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -162,6 +389,26 @@ func (r *ReconcileDatabase) createUpdateCRStatus(request reconcile.Request) erro
 		return err
 	}
 
+	if err := r.updateWalArchivingStatus(request); err != nil {
+		reqLogger.Error(err, "Failed to create WAL Archiving Status")
+		return err
+	}
+
+	if err := r.updateRolloutStatus(request); err != nil {
+		reqLogger.Error(err, "Failed to create Rollout Status")
+		return err
+	}
+
+	if err := r.updateParametersStatus(request); err != nil {
+		reqLogger.Error(err, "Failed to create Parameters Status")
+		return err
+	}
+
+	if err := r.updateHbaStatus(request); err != nil {
+		reqLogger.Error(err, "Failed to create Hba Status")
+		return err
+	}
+
 	if err := r.updateDBStatus(request); err != nil {
 		reqLogger.Error(err, "Failed to create DB Status")
 		return err
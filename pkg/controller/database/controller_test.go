@@ -4,10 +4,15 @@ import (
 	"context"
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
 	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	"strings"
 	"testing"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -175,3 +180,658 @@ func TestReconcileDatabase_EnsureReplicasSizeInstance(t *testing.T) {
 		t.Error("did not expect request to requeue")
 	}
 }
+
+func TestReconcileDatabase_Replication(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		&dbInstanceWithReplication,
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithReplication.Name,
+			Namespace: dbInstanceWithReplication.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dep, err := service.FetchDeployment(dbInstanceWithReplication.Name, dbInstanceWithReplication.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get primary deployment: (%v)", err)
+	}
+	if *dep.Spec.Replicas != 1 {
+		t.Errorf("Primary replicas got (%v), when is expected (%v)", *dep.Spec.Replicas, 1)
+	}
+	if dep.Spec.Template.Labels[utils.RoleLabelKey] != utils.PrimaryRole {
+		t.Errorf("Primary pod role got (%v), when is expected (%v)", dep.Spec.Template.Labels[utils.RoleLabelKey], utils.PrimaryRole)
+	}
+
+	for _, name := range []string{"database-standby-0", "database-standby-1"} {
+		standby, err := service.FetchDeployment(name, dbInstanceWithReplication.Namespace, r.client)
+		if err != nil {
+			t.Fatalf("get standby deployment %v: (%v)", name, err)
+		}
+		if standby.Spec.Template.Labels[utils.RoleLabelKey] != utils.StandbyRole {
+			t.Errorf("Standby pod role got (%v), when is expected (%v)", standby.Spec.Template.Labels[utils.RoleLabelKey], utils.StandbyRole)
+		}
+		if labels.SelectorFromSet(dep.Spec.Selector.MatchLabels).Matches(labels.Set(standby.Spec.Template.Labels)) {
+			t.Errorf("Primary selector (%v) should not match the pods of the standby %v", dep.Spec.Selector.MatchLabels, name)
+		}
+		if _, err := service.FetchPersistentVolumeClaim(name, dbInstanceWithReplication.Namespace, r.client); err != nil {
+			t.Fatalf("get standby pvc %v: (%v)", name, err)
+		}
+	}
+
+	ser, err := service.FetchService(dbInstanceWithReplication.Name, dbInstanceWithReplication.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get service: (%v)", err)
+	}
+	if ser.Spec.Selector[utils.RoleLabelKey] != utils.PrimaryRole {
+		t.Errorf("Service selector role got (%v), when is expected (%v)", ser.Spec.Selector[utils.RoleLabelKey], utils.PrimaryRole)
+	}
+
+	roSer, err := service.FetchService("database-ro", dbInstanceWithReplication.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get read-only service: (%v)", err)
+	}
+	if roSer.Spec.Selector[utils.RoleLabelKey] != utils.StandbyRole {
+		t.Errorf("Read-only service selector role got (%v), when is expected (%v)", roSer.Spec.Selector[utils.RoleLabelKey], utils.StandbyRole)
+	}
+
+	// Decrease the size to keep only one standby
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	db.Spec.Size = 2
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database size: (%v)", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	if _, err := service.FetchDeployment("database-standby-0", dbInstanceWithReplication.Namespace, r.client); err != nil {
+		t.Errorf("get standby deployment database-standby-0: (%v)", err)
+	}
+	if _, err := service.FetchDeployment("database-standby-1", dbInstanceWithReplication.Namespace, r.client); err == nil {
+		t.Error("standby deployment database-standby-1 should be removed")
+	}
+	if _, err := service.FetchPersistentVolumeClaim("database-standby-1", dbInstanceWithReplication.Namespace, r.client); err == nil {
+		t.Error("standby pvc database-standby-1 should be removed")
+	}
+}
+
+func TestReconcileDatabase_ReplicationDisabledWithStandbyPrimary(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		dbInstanceWithReplication.DeepCopy(),
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithReplication.Name,
+			Namespace: dbInstanceWithReplication.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	// Simulate the promotion of the first standby by a failover and then disable the replication
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	db.Status.CurrentPrimary = "database-standby-0"
+	db.Spec.Replication.Enabled = false
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	primary, err := service.FetchDeployment("database-standby-0", req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get promoted standby deployment: (%v)", err)
+	}
+	if primary.Spec.Template.Labels[utils.RoleLabelKey] != utils.PrimaryRole {
+		t.Errorf("Promoted standby pod role got (%v), when is expected (%v)", primary.Spec.Template.Labels[utils.RoleLabelKey], utils.PrimaryRole)
+	}
+	if _, err := service.FetchPersistentVolumeClaim("database-standby-0", req.Namespace, r.client); err != nil {
+		t.Errorf("get promoted standby pvc: (%v)", err)
+	}
+	if _, err := service.FetchService("database-ro", req.Namespace, r.client); err != nil {
+		t.Errorf("get read-only service: (%v)", err)
+	}
+
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database deployment: (%v)", err)
+	}
+	if dep.Spec.Template.Labels[utils.RoleLabelKey] != utils.StandbyRole {
+		t.Errorf("Database pod role got (%v), when is expected (%v)", dep.Spec.Template.Labels[utils.RoleLabelKey], utils.StandbyRole)
+	}
+
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if !strings.Contains(db.Status.DatabaseStatus, "cannot be disabled") {
+		t.Errorf("Database status got (%v), when is expected the error of the replication disabled", db.Status.DatabaseStatus)
+	}
+
+	// The replication is also kept when it is removed from the spec
+	db.Spec.Replication = nil
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	if _, err := service.FetchDeployment("database-standby-0", req.Namespace, r.client); err != nil {
+		t.Errorf("get promoted standby deployment: (%v)", err)
+	}
+	if _, err := service.FetchPersistentVolumeClaim("database-standby-0", req.Namespace, r.client); err != nil {
+		t.Errorf("get promoted standby pvc: (%v)", err)
+	}
+}
+
+func TestReconcileDatabase_StatefulSet(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		&dbInstanceWithStatefulSet,
+		&podStatefulSetPrimary,
+		&podStatefulSetStandby,
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithStatefulSet.Name,
+			Namespace: dbInstanceWithStatefulSet.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	sts, err := service.FetchStatefulSet(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get statefulset: (%v)", err)
+	}
+	if *sts.Spec.Replicas != 2 {
+		t.Errorf("StatefulSet replicas got (%v), when is expected (%v)", *sts.Spec.Replicas, 2)
+	}
+	if sts.Spec.ServiceName != "database-headless" {
+		t.Errorf("StatefulSet service name got (%v), when is expected (%v)", sts.Spec.ServiceName, "database-headless")
+	}
+	if len(sts.Spec.VolumeClaimTemplates) != 1 {
+		t.Errorf("StatefulSet volumeClaimTemplates got (%v), when is expected (%v)", len(sts.Spec.VolumeClaimTemplates), 1)
+	}
+
+	headless, err := service.FetchService("database-headless", req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get headless service: (%v)", err)
+	}
+	if headless.Spec.ClusterIP != corev1.ClusterIPNone {
+		t.Errorf("Headless service cluster ip got (%v), when is expected (%v)", headless.Spec.ClusterIP, corev1.ClusterIPNone)
+	}
+
+	if _, err := service.FetchDeployment(req.Name, req.Namespace, r.client); err == nil {
+		t.Error("deployment should not be created when the workload type is StatefulSet")
+	}
+
+	wantRoles := map[string]string{"database-0": utils.PrimaryRole, "database-1": utils.StandbyRole}
+	for name, role := range wantRoles {
+		pod := &corev1.Pod{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: req.Namespace}, pod); err != nil {
+			t.Fatalf("get pod %v: (%v)", name, err)
+		}
+		if pod.Labels[utils.RoleLabelKey] != role {
+			t.Errorf("Pod %v role got (%v), when is expected (%v)", name, pod.Labels[utils.RoleLabelKey], role)
+		}
+	}
+}
+
+func TestReconcileDatabase_MigrateDeploymentToStatefulSet(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		&dbInstanceWithoutSpec,
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithoutSpec.Name,
+			Namespace: dbInstanceWithoutSpec.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	db.Spec.WorkloadType = utils.StatefulSetWorkload
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database workload type: (%v)", err)
+	}
+
+	// The deployment should be scaled down
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if *dep.Spec.Replicas != 0 {
+		t.Errorf("Deployment replicas got (%v), when is expected (%v)", *dep.Spec.Replicas, 0)
+	}
+
+	// The data should be copied by the migration job
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	job, err := service.FetchJob("database-migration", req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get migration job: (%v)", err)
+	}
+	if _, err := service.FetchPersistentVolumeClaim("database-database-0", req.Namespace, r.client); err != nil {
+		t.Fatalf("get statefulset pvc: (%v)", err)
+	}
+	if _, err := service.FetchStatefulSet(req.Name, req.Namespace, r.client); err == nil {
+		t.Error("statefulset should not be created before the migration be finished")
+	}
+
+	// Mock the job finished
+	job.Status = batchv1.JobStatus{Succeeded: 1}
+	if err := r.client.Status().Update(context.TODO(), job); err != nil {
+		t.Fatalf("fails when try to update the job status: (%v)", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if _, err := service.FetchStatefulSet(req.Name, req.Namespace, r.client); err != nil {
+		t.Errorf("get statefulset: (%v)", err)
+	}
+	if _, err := service.FetchDeployment(req.Name, req.Namespace, r.client); err == nil {
+		t.Error("deployment should be removed after the migration")
+	}
+	if _, err := service.FetchPersistentVolumeClaim(req.Name, req.Namespace, r.client); err != nil {
+		t.Errorf("the pvc of the deployment should be kept: (%v)", err)
+	}
+}
+
+func TestReconcileDatabase_WalArchiving(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		&dbInstanceWithoutSpec,
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithoutSpec.Name,
+			Namespace: dbInstanceWithoutSpec.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	// Enable the WAL archiving in the Database already created
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	db.Spec.WalArchiving = dbInstanceWithWalArchiving.Spec.WalArchiving.DeepCopy()
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database wal archiving: (%v)", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	containers := dep.Spec.Template.Spec.Containers
+	if len(containers) != 2 {
+		t.Fatalf("Deployment containers got (%v), when is expected (%v)", len(containers), 2)
+	}
+	if containers[1].Name != utils.WalArchiverName {
+		t.Errorf("Deployment sidecar got (%v), when is expected (%v)", containers[1].Name, utils.WalArchiverName)
+	}
+	found := false
+	for _, arg := range containers[0].Args {
+		if arg == "archive_mode=on" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Database container args got (%v), when is expected to have (%v)", containers[0].Args, "archive_mode=on")
+	}
+
+	// Disable the WAL archiving
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	db.Spec.WalArchiving.Enabled = false
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database wal archiving: (%v)", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dep, err = service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if len(dep.Spec.Template.Spec.Containers) != 1 {
+		t.Errorf("Deployment containers got (%v), when is expected (%v)", len(dep.Spec.Template.Spec.Containers), 1)
+	}
+}
+
+func TestReconcileDatabase_ReplicationCredentials(t *testing.T) {
+	objs := []runtime.Object{
+		dbInstanceWithReplication.DeepCopy(),
+	}
+	r := buildReconcileWithFakeClientWithMocks(objs)
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithReplication.Name,
+			Namespace: dbInstanceWithReplication.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	// The replication password is generated in the Secret of the credentials
+	db := dbInstanceWithReplication.DeepCopy()
+	utils.AddDatabaseMandatorySpecs(db)
+	secret, err := service.FetchSecret(req.Namespace, utils.GetGeneratedCredentialsSecretName(db), r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	password := string(secret.Data[utils.GetReplicationPasswordKey(db)])
+	if len(password) != utils.RolePasswordLength || string(secret.Data[utils.GetReplicationUserKey(db)]) != db.Spec.Replication.ReplicationUser {
+		t.Errorf("Secret data got (%v), when is expected the replication user (%v) and a generated password", secret.Data, db.Spec.Replication.ReplicationUser)
+	}
+
+	for _, name := range []string{req.Name, "database-standby-0"} {
+		dep, err := service.FetchDeployment(name, req.Namespace, r.client)
+		if err != nil {
+			t.Fatalf("get deployment %v: (%v)", name, err)
+		}
+		for _, env := range dep.Spec.Template.Spec.Containers[0].Env {
+			if env.Name != db.Spec.Replication.ReplicationUserKeyEnvVar && env.Name != db.Spec.Replication.ReplicationPasswordKeyEnvVar {
+				continue
+			}
+			if env.Value != "" || env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil || env.ValueFrom.SecretKeyRef.Name != secret.Name {
+				t.Errorf("Env var (%v) of %v got (%v), when is expected the reference to the Secret (%v)", env.Name, name, env.ValueFrom, secret.Name)
+			}
+		}
+	}
+
+	// The replication password is kept by the next reconciles
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	secret, err = service.FetchSecret(req.Namespace, secret.Name, r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	if got := string(secret.Data[utils.GetReplicationPasswordKey(db)]); got != password {
+		t.Errorf("Replication password got (%v), when is expected (%v)", got, password)
+	}
+}
+
+func TestReconcileDatabase_ReplicationCredentialsWithSpecPassword(t *testing.T) {
+	db := dbInstanceWithReplication.DeepCopy()
+	db.Spec.DatabasePassword = "secret"
+	r := buildReconcileWithFakeClientWithMocks([]runtime.Object{db})
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      db.Name,
+			Namespace: db.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	// Only the replication password is generated in the Secret since the password of the database is in the spec
+	utils.AddDatabaseMandatorySpecs(db)
+	secret, err := service.FetchSecret(req.Namespace, utils.GetGeneratedCredentialsSecretName(db), r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	if _, found := secret.Data[utils.GetCredentialsPasswordKey(db)]; found || len(secret.Data[utils.GetReplicationPasswordKey(db)]) != utils.RolePasswordLength {
+		t.Errorf("Secret data got (%v), when is expected only the replication user and a generated password", secret.Data)
+	}
+	dep, err := service.FetchDeployment("database-standby-0", req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	for _, env := range dep.Spec.Template.Spec.Containers[0].Env {
+		switch env.Name {
+		case db.Spec.DatabasePasswordKeyEnvVar:
+			if env.Value != "secret" {
+				t.Errorf("Env var (%v) got (%v), when is expected the password of the spec", env.Name, env.Value)
+			}
+		case db.Spec.Replication.ReplicationPasswordKeyEnvVar:
+			if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil || env.ValueFrom.SecretKeyRef.Name != secret.Name {
+				t.Errorf("Env var (%v) got (%v), when is expected the reference to the Secret (%v)", env.Name, env.ValueFrom, secret.Name)
+			}
+		}
+	}
+}
+
+func TestReconcileDatabase_CredentialsMigrated(t *testing.T) {
+	db := dbInstanceWithReplication.DeepCopy()
+	utils.AddDatabaseMandatorySpecs(db)
+
+	// The workload created by a previous version of the operator has the default passwords in the env vars
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: db.Name, Namespace: db.Namespace},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: db.Spec.ContainerName,
+						Env: []corev1.EnvVar{
+							{Name: db.Spec.DatabasePasswordKeyEnvVar, Value: "postgres"},
+							{Name: db.Spec.Replication.ReplicationPasswordKeyEnvVar, Value: "replicator"},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	r := buildReconcileWithFakeClientWithMocks([]runtime.Object{dbInstanceWithReplication.DeepCopy(), dep})
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      db.Name,
+			Namespace: db.Namespace,
+		},
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	secret, err := service.FetchSecret(req.Namespace, utils.GetGeneratedCredentialsSecretName(db), r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	if string(secret.Data[utils.GetCredentialsPasswordKey(db)]) != "postgres" || string(secret.Data[utils.GetReplicationPasswordKey(db)]) != "replicator" {
+		t.Errorf("Secret data got (%v), when is expected the passwords of the existing workload", secret.Data)
+	}
+}
+
+func TestReconcileDatabase_Credentials(t *testing.T) {
+	objs := []runtime.Object{
+		dbInstanceWithoutSpec.DeepCopy(),
+	}
+	r := buildReconcileWithFakeClientWithMocks(objs)
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithoutSpec.Name,
+			Namespace: dbInstanceWithoutSpec.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	// The password is generated in a Secret when it is not informed
+	db := dbInstanceWithoutSpec.DeepCopy()
+	utils.AddDatabaseMandatorySpecs(db)
+	secret, err := service.FetchSecret(req.Namespace, utils.GetGeneratedCredentialsSecretName(db), r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	password := string(secret.Data[db.Spec.DatabasePasswordKeyEnvVar])
+	if len(password) != utils.RolePasswordLength || string(secret.Data[db.Spec.DatabaseUserKeyEnvVar]) != db.Spec.DatabaseUser {
+		t.Errorf("Secret data got (%v), when is expected the user (%v) and a generated password", secret.Data, db.Spec.DatabaseUser)
+	}
+
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	for _, env := range dep.Spec.Template.Spec.Containers[0].Env[:3] {
+		if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil || env.ValueFrom.SecretKeyRef.Name != secret.Name {
+			t.Errorf("Env var (%v) got (%v), when is expected the reference to the Secret (%v)", env.Name, env.ValueFrom, secret.Name)
+		}
+	}
+
+	// The user of the spec is kept in the Secret without change the password
+	cr, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	cr.Spec.DatabaseUser = "app"
+	if err := r.client.Update(context.TODO(), cr); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	secret, err = service.FetchSecret(req.Namespace, secret.Name, r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	if string(secret.Data[db.Spec.DatabaseUserKeyEnvVar]) != "app" || string(secret.Data[db.Spec.DatabasePasswordKeyEnvVar]) != password {
+		t.Errorf("Secret data got (%v), when is expected the user app and the same password", secret.Data)
+	}
+}
+
+func TestReconcileDatabase_CredentialsSecret(t *testing.T) {
+	objs := []runtime.Object{
+		dbInstanceWithCredentialsSecret.DeepCopy(),
+	}
+	r := buildReconcileWithFakeClientWithMocks(objs)
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithCredentialsSecret.Name,
+			Namespace: dbInstanceWithCredentialsSecret.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	// The Secret informed is used with the keys customized and no password is generated
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	expected := map[string]string{
+		"POSTGRESQL_DATABASE": "POSTGRESQL_DATABASE",
+		"POSTGRESQL_USER":     "POSTGRESQL_USER",
+		"POSTGRESQL_PASSWORD": "password",
+	}
+	for _, env := range dep.Spec.Template.Spec.Containers[0].Env[:3] {
+		ref := env.ValueFrom.SecretKeyRef
+		if ref == nil || ref.Name != dbInstanceWithCredentialsSecret.Spec.CredentialsSecretName || ref.Key != expected[env.Name] {
+			t.Errorf("Env var (%v) got (%v), when is expected the key (%v) of the Secret", env.Name, env.ValueFrom, expected[env.Name])
+		}
+	}
+	if _, err := service.FetchSecret(req.Namespace, utils.GetGeneratedCredentialsSecretName(&dbInstanceWithCredentialsSecret), r.client); err == nil {
+		t.Errorf("Secret with the generated password was found, when is expected not be created")
+	}
+}
+
+func TestReconcileDatabase_InvalidQuantities(t *testing.T) {
+	db := dbInstanceWithoutSpec.DeepCopy()
+	db.Spec.DatabaseMemoryLimit = "512MB"
+	r := buildReconcileWithFakeClientWithMocks([]runtime.Object{db})
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      db.Name,
+			Namespace: db.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	// The workloads are not created with the invalid quantity
+	if _, err := service.FetchDeployment(req.Name, req.Namespace, r.client); err == nil {
+		t.Error("Deployment was created with the invalid quantity")
+	}
+
+	cr, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if !strings.Contains(cr.Status.DatabaseStatus, "databaseMemoryLimit") {
+		t.Errorf("DatabaseStatus got (%v), when is expected the error of the databaseMemoryLimit", cr.Status.DatabaseStatus)
+	}
+}
@@ -2,11 +2,129 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
 	"github.com/dev4devs-com/postgresql-operator/pkg/resource"
 	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	"k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// Check if the Secret with the generated passwords exist, if not create one
+// NOTE: The database name and user of the spec, and the replication user, are kept in the Secret when it is owned by the
+// Database. The Secret informed in the spec.credentialsSecretName is never changed. It only has the replication user
+// and password when the credentials are informed by the spec or the ConfigMap without the replicationPassword.
+func (r *ReconcileDatabase) createCredentialsSecret(db *v1alpha1.Database) error {
+	name := utils.GetGeneratedCredentialsSecretName(db)
+	credentials := db.Spec.CredentialsSecretName == name
+	replication := utils.IsReplicationEnabled(db) && utils.GetReplicationSecretName(db) == name
+	if !credentials && !replication {
+		return nil
+	}
+	secret, err := service.FetchSecret(db.Namespace, name, r.client)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+	if found && !metav1.IsControlledBy(secret, db) {
+		return nil
+	}
+
+	password, replicationPassword := "", ""
+	if found && credentials {
+		password = string(secret.Data[utils.GetCredentialsPasswordKey(db)])
+	}
+	if found && replication {
+		replicationPassword = string(secret.Data[utils.GetReplicationPasswordKey(db)])
+	}
+
+	// The passwords of the workloads created before the Secret (E.g. the default ones of the previous versions of the
+	// operator) are migrated to it, so the upgrade of the operator does not change the passwords used by the clients
+	if (credentials && password == "") || (replication && replicationPassword == "") {
+		env, err := r.fetchPrimaryWorkloadEnvValues(db)
+		if err != nil {
+			return err
+		}
+		if credentials && password == "" {
+			password = env[db.Spec.DatabasePasswordKeyEnvVar]
+		}
+		if replication && replicationPassword == "" {
+			replicationPassword = env[db.Spec.Replication.ReplicationPasswordKeyEnvVar]
+		}
+	}
+	if password == "" && credentials {
+		if password, err = utils.GeneratePassword(utils.RolePasswordLength); err != nil {
+			return err
+		}
+	}
+	if replicationPassword == "" && replication {
+		if replicationPassword, err = utils.GeneratePassword(utils.RolePasswordLength); err != nil {
+			return err
+		}
+	}
+	desired := resource.NewDatabaseCredentialsSecret(db, password, replicationPassword, r.scheme)
+	if !found {
+		return r.client.Create(context.TODO(), desired)
+	}
+	// The user is the alternate role of the databaseUser when it was set by the password rotation
+	if user := secret.Data[utils.GetCredentialsUserKey(db)]; credentials && string(user) == utils.GetAlternateRole(db.Spec.DatabaseUser) {
+		desired.Data[utils.GetCredentialsUserKey(db)] = user
+	}
+
+	// The other keys (E.g. the replication password when the replication is disabled) are preserved
+	changed := false
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for key, value := range desired.Data {
+		if !reflect.DeepEqual(secret.Data[key], value) {
+			secret.Data[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return r.client.Update(context.TODO(), secret)
+}
+
+// fetchPrimaryWorkloadEnvValues returns the values informed directly in the env vars of the database container of the
+// primary workload, or none when it was not created yet
+func (r *ReconcileDatabase) fetchPrimaryWorkloadEnvValues(db *v1alpha1.Database) (map[string]string, error) {
+	var containers []corev1.Container
+	if utils.IsStatefulSet(db) {
+		sts, err := service.FetchStatefulSet(db.Name, db.Namespace, r.client)
+		if err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+		containers = sts.Spec.Template.Spec.Containers
+	} else {
+		dep, err := service.FetchDeployment(db.Name, db.Namespace, r.client)
+		if err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+		containers = dep.Spec.Template.Spec.Containers
+	}
+	values := map[string]string{}
+	for _, container := range containers {
+		if container.Name != db.Spec.ContainerName {
+			continue
+		}
+		for _, env := range container.Env {
+			if env.Value != "" {
+				values[env.Name] = env.Value
+			}
+		}
+	}
+	return values, nil
+}
+
 // Check if PersistentVolumeClaim for the app exist, if not create one
 func (r *ReconcileDatabase) createPvc(db *v1alpha1.Database) error {
 	if _, err := service.FetchPersistentVolumeClaim(db.Name, db.Namespace, r.client); err != nil {
@@ -28,12 +146,120 @@ func (r *ReconcileDatabase) createService(db *v1alpha1.Database) error {
 }
 
 // Check if Deployment for the app exist, if not create one
+// NOTE: The selector is immutable so the Deployment created without the member label in it, which also selects the pods
+// of the standbys, is replaced. It is deleted orphaning its pods, once they have the member label, in order to be
+// adopted by the new Deployment without restarting the database.
 func (r *ReconcileDatabase) createDeployment(db *v1alpha1.Database) error {
-	_, err := service.FetchDeployment(db.Name, db.Namespace, r.client)
+	desired := resource.NewDatabaseDeployment(db, r.scheme)
+	dep, err := service.FetchDeployment(db.Name, db.Namespace, r.client)
 	if err != nil {
-		if err := r.client.Create(context.TODO(), resource.NewDatabaseDeployment(db, r.scheme)); err != nil {
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	selector := labels.SelectorFromSet(desired.Spec.Selector.MatchLabels)
+	if reflect.DeepEqual(dep.Spec.Selector, desired.Spec.Selector) || !selector.Matches(labels.Set(dep.Spec.Template.Labels)) {
+		return nil
+	}
+	if err := r.client.Delete(context.TODO(), dep, client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return r.client.Create(context.TODO(), desired)
+}
+
+// Check if the read-only Service for the standbys exist, if not create one
+func (r *ReconcileDatabase) createReadOnlyService(db *v1alpha1.Database) error {
+	if _, err := service.FetchService(utils.GetReadOnlyServiceName(db), db.Namespace, r.client); err != nil {
+		if err := r.client.Create(context.TODO(), resource.NewDatabaseReadOnlyService(db, r.scheme)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check if the PersistentVolumeClaim and Deployment of each standby exist, if not create them
+func (r *ReconcileDatabase) createStandbys(db *v1alpha1.Database) error {
+	for i := 0; i < utils.GetStandbySize(db); i++ {
+		name := utils.GetStandbyName(db, i)
+		if _, err := service.FetchPersistentVolumeClaim(name, db.Namespace, r.client); err != nil {
+			if err := r.client.Create(context.TODO(), resource.NewDatabaseStandbyPvc(db, i, r.scheme)); err != nil {
+				return err
+			}
+		}
+		if _, err := service.FetchDeployment(name, db.Namespace, r.client); err != nil {
+			if err := r.client.Create(context.TODO(), resource.NewDatabaseStandbyDeployment(db, i, r.scheme)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Check if the headless Service of the StatefulSet exist, if not create one
+func (r *ReconcileDatabase) createHeadlessService(db *v1alpha1.Database) error {
+	if _, err := service.FetchService(utils.GetHeadlessServiceName(db), db.Namespace, r.client); err != nil {
+		if err := r.client.Create(context.TODO(), resource.NewDatabaseHeadlessService(db, r.scheme)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// Check if StatefulSet for the app exist, if not create one
+// NOTE: When the Deployment of the app exist its data will be migrated before
+func (r *ReconcileDatabase) createStatefulSet(db *v1alpha1.Database) error {
+	if _, err := service.FetchStatefulSet(db.Name, db.Namespace, r.client); err == nil {
+		return nil
+	}
+
+	if dep, err := service.FetchDeployment(db.Name, db.Namespace, r.client); err == nil {
+		migrated, err := r.migrateDeployment(db, dep)
+		if err != nil || !migrated {
+			return err
+		}
+	}
+
+	return r.client.Create(context.TODO(), resource.NewDatabaseStatefulSet(db, r.scheme))
+}
+
+// migrateDeployment will copy the data from the PVC of the Deployment to the PVC which will be used by the first pod of
+// the StatefulSet and then remove the Deployment. It returns true when the migration is finished
+// NOTE: The PVC of the Deployment is not removed in order to allow recover the data if required
+func (r *ReconcileDatabase) migrateDeployment(db *v1alpha1.Database, dep *v1.Deployment) (bool, error) {
+	// Stop the database in order to have consistent data and release the volume
+	if dep.Spec.Replicas == nil || *dep.Spec.Replicas != 0 {
+		size := int32(0)
+		dep.Spec.Replicas = &size
+		return false, r.client.Update(context.TODO(), dep)
+	}
+	if dep.Status.Replicas != 0 {
+		return false, nil
+	}
+
+	if _, err := service.FetchPersistentVolumeClaim(utils.GetStatefulSetPvcName(db, 0), db.Namespace, r.client); err != nil {
+		if err := r.client.Create(context.TODO(), resource.NewDatabaseStatefulSetPvc(db)); err != nil {
+			return false, err
+		}
+	}
+
+	job, err := service.FetchJob(utils.GetMigrationJobName(db), db.Namespace, r.client)
+	if err != nil {
+		return false, r.client.Create(context.TODO(), resource.NewDatabaseMigrationJob(db, r.scheme))
+	}
+	if job.Status.Succeeded == 0 {
+		if job.Status.Failed > *job.Spec.BackoffLimit {
+			return false, fmt.Errorf("Error: Unable to migrate the data from the Deployment %v. Check the logs of the Job %v.", dep.Name, job.Name)
+		}
+		return false, nil
+	}
+
+	if err := r.client.Delete(context.TODO(), dep); err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if err := r.client.Delete(context.TODO(), job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,227 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/resource"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Phases of the deletion shown in the status
+const (
+	deletionRetaining    = "Retaining"
+	deletionSnapshotting = "Snapshotting"
+	deletionBackingUp    = "BackingUp"
+	deletionCompleted    = "Completed"
+	deletionFailed       = "Failed"
+)
+
+// deletionCheckInterval is the interval used to check the snapshots and the backup taken before the deletion
+const deletionCheckInterval = 10 * time.Second
+
+// manageDeletionFinalizer adds the finalizer which applies the deletionPolicy when the Database is deleted or removes
+// it when the policy is Delete, since then the PVCs are only deleted by the garbage collector
+// NOTE: The finalizer is kept when the policy is invalid, so the deletion waits until it be fixed
+// NOTE: The Database informed must not have the default values since it is updated
+func (r *ReconcileDatabase) manageDeletionFinalizer(db *v1alpha1.Database) error {
+	dbWithSpecs := db.DeepCopy()
+	utils.AddDatabaseMandatorySpecs(dbWithSpecs)
+
+	required := utils.IsDeletionFinalizerRequired(dbWithSpecs)
+	if required == utils.HasDeletionFinalizer(db) {
+		return nil
+	}
+	if required {
+		db.Finalizers = append(db.Finalizers, utils.DeletionFinalizer)
+	} else {
+		utils.RemoveDeletionFinalizer(db)
+	}
+	return r.client.Update(context.TODO(), db)
+}
+
+// manageDeletion applies the deletionPolicy to the PVCs of the Database being deleted and removes the finalizer when
+// it is done, so the garbage collector deletes the objects of the Database
+// NOTE: The finalizer is kept when the policy fails in order to not lose the data. The error is shown in the status
+// deletion and the policy can be changed to Delete or Retain to finish the deletion.
+func (r *ReconcileDatabase) manageDeletion(db *v1alpha1.Database) (reconcile.Result, error) {
+	if !utils.HasDeletionFinalizer(db) {
+		return reconcile.Result{}, nil
+	}
+	dbWithSpecs := db.DeepCopy()
+	utils.AddDatabaseMandatorySpecs(dbWithSpecs)
+	policy := dbWithSpecs.Spec.DeletionPolicy
+
+	deletion := db.Status.Deletion
+	if deletion == nil || deletion.Policy != policy {
+		now := metav1.Now()
+		deletion = &v1alpha1.DeletionStatus{Policy: policy, StartTime: &now}
+		db.Status.Deletion = deletion
+	}
+	deletion.Message = ""
+
+	pvcs, err := r.fetchDatabasePvcs(dbWithSpecs)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	deletion.PersistentVolumeClaims = nil
+	for _, pvc := range pvcs {
+		deletion.PersistentVolumeClaims = append(deletion.PersistentVolumeClaims, pvc.Name)
+	}
+
+	done := false
+	switch policy {
+	case utils.DeletePolicy:
+		done = true
+	case utils.RetainPolicy:
+		deletion.Phase = deletionRetaining
+		done, err = r.retainPvcs(db, pvcs)
+	case utils.SnapshotPolicy:
+		deletion.Phase = deletionSnapshotting
+		done, err = r.snapshotPvcs(dbWithSpecs, pvcs, deletion)
+	case utils.BackupThenDeletePolicy:
+		deletion.Phase = deletionBackingUp
+		done, err = r.backupBeforeDeletion(db, deletion)
+	default:
+		deletion.Phase = deletionFailed
+		deletion.Message = utils.ValidateDeletionPolicy(dbWithSpecs).Error()
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if !done {
+		if err := r.updateStatus(db); err != nil {
+			return reconcile.Result{}, err
+		}
+		if deletion.Phase == deletionFailed {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{RequeueAfter: deletionCheckInterval}, nil
+	}
+
+	now := metav1.Now()
+	deletion.Phase = deletionCompleted
+	deletion.CompletionTime = &now
+	if err := r.updateStatus(db); err != nil {
+		return reconcile.Result{}, err
+	}
+	utils.RemoveDeletionFinalizer(db)
+	return reconcile.Result{}, r.client.Update(context.TODO(), db)
+}
+
+// retainPvcs removes the owner reference of the Database from its PVCs, so they are not deleted by the garbage
+// collector, and adds the annotation with the name of the Database to them
+// NOTE: A Database created with the same name in the namespace uses the PVCs retained
+func (r *ReconcileDatabase) retainPvcs(db *v1alpha1.Database, pvcs []*corev1.PersistentVolumeClaim) (bool, error) {
+	for _, pvc := range pvcs {
+		var refs []metav1.OwnerReference
+		for _, ref := range pvc.OwnerReferences {
+			if ref.UID != db.UID {
+				refs = append(refs, ref)
+			}
+		}
+		if len(refs) == len(pvc.OwnerReferences) && pvc.Annotations[utils.RetainedFromAnnotation] == db.Name {
+			continue
+		}
+		pvc.OwnerReferences = refs
+		if pvc.Annotations == nil {
+			pvc.Annotations = map[string]string{}
+		}
+		pvc.Annotations[utils.RetainedFromAnnotation] = db.Name
+		if err := r.client.Update(context.TODO(), pvc); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// snapshotPvcs creates the VolumeSnapshot of each PVC of the Database and returns true when all of them are ready to
+// use, so the PVCs can be deleted
+func (r *ReconcileDatabase) snapshotPvcs(db *v1alpha1.Database, pvcs []*corev1.PersistentVolumeClaim, deletion *v1alpha1.DeletionStatus) (bool, error) {
+	deletion.Snapshots = nil
+	ready := true
+	for _, pvc := range pvcs {
+		desired := resource.NewDatabaseVolumeSnapshot(db, pvc.Name)
+		deletion.Snapshots = append(deletion.Snapshots, desired.GetName())
+
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(desired.GroupVersionKind())
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: desired.GetName(), Namespace: desired.GetNamespace()}, snapshot)
+		switch {
+		case meta.IsNoMatchError(err):
+			deletion.Phase = deletionFailed
+			deletion.Message = fmt.Sprintf("Error: The VolumeSnapshot (%v) is not supported by the cluster.", utils.VolumeSnapshotAPIVersion)
+			return false, nil
+		case errors.IsNotFound(err):
+			if err := r.client.Create(context.TODO(), desired); err != nil {
+				return false, err
+			}
+			ready = false
+			continue
+		case err != nil:
+			return false, err
+		}
+
+		if msg, found, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); found && msg != "" {
+			deletion.Phase = deletionFailed
+			deletion.Message = fmt.Sprintf("Error: The VolumeSnapshot %v failed: %v", snapshot.GetName(), msg)
+			return false, nil
+		}
+		if readyToUse, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse"); !readyToUse {
+			ready = false
+		}
+	}
+	return ready, nil
+}
+
+// backupBeforeDeletion requests an on-demand backup to the Backup CR of the Database and returns true when it succeeded
+// NOTE: The deletion fails when none Backup CR refers to the Database since the backup cannot be done
+func (r *ReconcileDatabase) backupBeforeDeletion(db *v1alpha1.Database, deletion *v1alpha1.DeletionStatus) (bool, error) {
+	bkp, err := r.fetchDatabaseBackup(db)
+	if err != nil {
+		return false, err
+	}
+	if bkp == nil || bkp.Spec.Trigger != "" {
+		deletion.Phase = deletionFailed
+		deletion.Message = "Error: None Backup CR refers to the Database. Create it or change the deletionPolicy."
+		if bkp != nil {
+			deletion.Message = fmt.Sprintf("Error: The spec trigger of the Backup %v has precedence over the annotation %v. Remove it or change the deletionPolicy.",
+				bkp.Name, utils.BackupTriggerAnnotation)
+		}
+		return false, nil
+	}
+	deletion.BackupName = bkp.Name
+
+	trigger := utils.GetDeletionBackupTrigger(db)
+	if bkp.Annotations[utils.BackupTriggerAnnotation] != trigger {
+		if bkp.Annotations == nil {
+			bkp.Annotations = map[string]string{}
+		}
+		bkp.Annotations[utils.BackupTriggerAnnotation] = trigger
+		return false, r.client.Update(context.TODO(), bkp)
+	}
+
+	backup := bkp.Status.OnDemandBackup
+	if backup == nil || backup.Trigger != trigger {
+		return false, nil
+	}
+	deletion.BackupJobName = backup.JobName
+	switch backup.Phase {
+	case stepSucceeded:
+		return true, nil
+	case stepFailed:
+		deletion.Phase = deletionFailed
+		deletion.Message = fmt.Sprintf("Error: The backup before the deletion failed. Check the logs of the Job %v.", backup.JobName)
+	}
+	return false, nil
+}
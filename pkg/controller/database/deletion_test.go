@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// requestDeletion reconciles the Database created with the deletionPolicy informed and marks it as deleted
+// NOTE: The fake client removes the objects deleted even when they have finalizers, so the deletion is simulated
+func requestDeletion(t *testing.T, policy string, objs ...runtime.Object) (*ReconcileDatabase, reconcile.Request) {
+	db := dbInstanceWithDeletionPolicy.DeepCopy()
+	db.Spec.DeletionPolicy = policy
+	r := buildReconcileWithFakeClientWithMocks(append(objs, db))
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      db.Name,
+			Namespace: db.Namespace,
+		},
+	}
+
+	reconcileUpgrade(t, r, req)
+	db = fetchUpgradeDatabase(t, r, req)
+	if utils.HasDeletionFinalizer(db) != (policy != utils.DeletePolicy) {
+		t.Fatalf("Finalizers got (%v), when is expected (%v)", db.Finalizers, utils.DeletionFinalizer)
+	}
+
+	now := metav1.Now()
+	db.DeletionTimestamp = &now
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	return r, req
+}
+
+// checkDeletionPhase reconciles the Database and checks the phase of the deletion and if the finalizer was removed
+func checkDeletionPhase(t *testing.T, r *ReconcileDatabase, req reconcile.Request, phase string) *v1alpha1.Database {
+	reconcileUpgrade(t, r, req)
+	db := fetchUpgradeDatabase(t, r, req)
+	if db.Status.Deletion == nil || db.Status.Deletion.Phase != phase {
+		t.Fatalf("Deletion got (%v), when is expected the phase (%v)", db.Status.Deletion, phase)
+	}
+	if utils.HasDeletionFinalizer(db) != (phase != deletionCompleted) {
+		t.Fatalf("Finalizers got (%v) in the phase (%v)", db.Finalizers, phase)
+	}
+	return db
+}
+
+func TestReconcileDatabase_DeletionFinalizer(t *testing.T) {
+	r, req := requestDeletion(t, utils.DeletePolicy)
+
+	// The Database without the finalizer is kept as it is to be deleted by the API
+	reconcileUpgrade(t, r, req)
+	if db := fetchUpgradeDatabase(t, r, req); db.Status.Deletion != nil {
+		t.Errorf("Deletion got (%v), when the policy Delete does not require the finalizer", db.Status.Deletion)
+	}
+
+	// The finalizer is removed when the policy is changed to Delete
+	r, req = requestDeletion(t, utils.RetainPolicy)
+	db := fetchUpgradeDatabase(t, r, req)
+	db.DeletionTimestamp = nil
+	db.Spec.DeletionPolicy = utils.DeletePolicy
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	reconcileUpgrade(t, r, req)
+	if db = fetchUpgradeDatabase(t, r, req); utils.HasDeletionFinalizer(db) {
+		t.Errorf("Finalizers got (%v), when the policy was changed to Delete", db.Finalizers)
+	}
+}
+
+func TestReconcileDatabase_DeletionInvalidPolicy(t *testing.T) {
+	r, req := requestDeletion(t, "Retian")
+
+	// The PVCs are kept until the policy be fixed
+	db := checkDeletionPhase(t, r, req, deletionFailed)
+	if !strings.Contains(db.Status.Deletion.Message, "Retian") {
+		t.Errorf("Deletion message got (%v), when is expected the error of the policy", db.Status.Deletion.Message)
+	}
+	if _, err := service.FetchPersistentVolumeClaim(req.Name, req.Namespace, r.client); err != nil {
+		t.Fatalf("get pvc: (%v)", err)
+	}
+
+	db.Spec.DeletionPolicy = utils.RetainPolicy
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	checkDeletionPhase(t, r, req, deletionCompleted)
+}
+
+func TestReconcileDatabase_DeletionRetain(t *testing.T) {
+	r, req := requestDeletion(t, utils.RetainPolicy)
+
+	pvc, err := service.FetchPersistentVolumeClaim(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get pvc: (%v)", err)
+	}
+	if len(pvc.OwnerReferences) == 0 {
+		t.Fatal("PVC got none owner reference, when is expected the one of the Database")
+	}
+
+	db := checkDeletionPhase(t, r, req, deletionCompleted)
+	if len(db.Status.Deletion.PersistentVolumeClaims) != 1 || db.Status.Deletion.PersistentVolumeClaims[0] != pvc.Name {
+		t.Errorf("Deletion got the PVCs (%v), when is expected (%v)", db.Status.Deletion.PersistentVolumeClaims, pvc.Name)
+	}
+
+	pvc, err = service.FetchPersistentVolumeClaim(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get pvc: (%v)", err)
+	}
+	if len(pvc.OwnerReferences) != 0 || pvc.Annotations[utils.RetainedFromAnnotation] != req.Name {
+		t.Errorf("PVC got the owner references (%v) and annotations (%v), when is expected it orphaned with the annotation (%v)",
+			pvc.OwnerReferences, pvc.Annotations, utils.RetainedFromAnnotation)
+	}
+}
+
+func TestReconcileDatabase_DeletionSnapshot(t *testing.T) {
+	r, req := requestDeletion(t, utils.SnapshotPolicy)
+
+	db := checkDeletionPhase(t, r, req, deletionSnapshotting)
+	name := utils.GetDeletionSnapshotName(db, req.Name)
+	if len(db.Status.Deletion.Snapshots) != 1 || db.Status.Deletion.Snapshots[0] != name {
+		t.Fatalf("Deletion got the snapshots (%v), when is expected (%v)", db.Status.Deletion.Snapshots, name)
+	}
+
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetAPIVersion(utils.VolumeSnapshotAPIVersion)
+	snapshot.SetKind("VolumeSnapshot")
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: req.Namespace}, snapshot); err != nil {
+		t.Fatalf("get volumesnapshot: (%v)", err)
+	}
+	if pvcName, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName"); pvcName != req.Name {
+		t.Errorf("VolumeSnapshot got the source (%v), when is expected the PVC (%v)", pvcName, req.Name)
+	}
+
+	// The finalizer is kept until the snapshot is ready to use
+	checkDeletionPhase(t, r, req, deletionSnapshotting)
+	if err := unstructured.SetNestedField(snapshot.Object, true, "status", "readyToUse"); err != nil {
+		t.Fatalf("set readyToUse: (%v)", err)
+	}
+	if err := r.client.Update(context.TODO(), snapshot); err != nil {
+		t.Fatalf("fails when try to update the volumesnapshot: (%v)", err)
+	}
+	checkDeletionPhase(t, r, req, deletionCompleted)
+}
+
+func TestReconcileDatabase_DeletionBackupThenDelete(t *testing.T) {
+	r, req := requestDeletion(t, utils.BackupThenDeletePolicy)
+
+	// The deletion is blocked when none Backup CR refers to the Database
+	db := checkDeletionPhase(t, r, req, deletionFailed)
+	if !strings.Contains(db.Status.Deletion.Message, "None Backup CR") {
+		t.Errorf("Deletion got the message (%v), when is expected the error of the Backup not found", db.Status.Deletion.Message)
+	}
+
+	bkp := bkpInstanceDatabase.DeepCopy()
+	if err := r.client.Create(context.TODO(), bkp); err != nil {
+		t.Fatalf("fails when try to create the backup: (%v)", err)
+	}
+	db = checkDeletionPhase(t, r, req, deletionBackingUp)
+	trigger := utils.GetDeletionBackupTrigger(db)
+
+	bkp, err := service.FetchBackupCR(bkp.Name, bkp.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get backup: (%v)", err)
+	}
+	if bkp.Annotations[utils.BackupTriggerAnnotation] != trigger {
+		t.Fatalf("Backup got the annotations (%v), when is expected the trigger (%v)", bkp.Annotations, trigger)
+	}
+
+	bkp.Status.OnDemandBackup = &v1alpha1.OnDemandBackupStatus{Trigger: trigger, JobName: "backup-ondemand-1", Phase: stepSucceeded}
+	if err := r.client.Status().Update(context.TODO(), bkp); err != nil {
+		t.Fatalf("fails when try to update the backup status: (%v)", err)
+	}
+	db = checkDeletionPhase(t, r, req, deletionCompleted)
+	if db.Status.Deletion.BackupName != bkp.Name || db.Status.Deletion.BackupJobName != "backup-ondemand-1" {
+		t.Errorf("Deletion got the backup (%v) and job (%v), when is expected (%v) and (backup-ondemand-1)",
+			db.Status.Deletion.BackupName, db.Status.Deletion.BackupJobName, bkp.Name)
+	}
+}
@@ -0,0 +1,271 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// failoverCheckInterval is the interval used to check the health of the primary when the replication is enabled
+	failoverCheckInterval = 10 * time.Second
+	// failoverGracePeriod is the time which the primary pod can be not ready before a standby be promoted
+	failoverGracePeriod = 30 * time.Second
+	// maxFailoverEvents is the quantity of failovers kept in the status
+	maxFailoverEvents = 10
+)
+
+// manageFailover will promote the most up-to-date standby when the primary is not available through its Service
+// NOTE: The failover does not run while the workloads are rolled out, since the primary is restarted by them
+func (r *ReconcileDatabase) manageFailover(db *v1alpha1.Database) error {
+	if !utils.IsReplicationEnabled(db) || r.executor == nil || isUpgradeInProgress(db) || isRolloutInProgress(db) {
+		return nil
+	}
+	updating, err := r.isPrimaryWorkloadUpdating(db)
+	if err != nil || updating {
+		return err
+	}
+
+	primary := utils.GetPrimaryMember(db)
+	primaryPod, standbyPods, err := r.fetchMemberPods(db, primary)
+	if err != nil {
+		return err
+	}
+
+	// The health check runs from a standby since the operator may not be able to reach the Service network
+	if len(standbyPods) == 0 {
+		return nil
+	}
+	if err := service.IsHostReady(r.executor, standbyPods[0], db.Spec.ContainerName, db.Name, db.Spec.DatabasePort); err == nil {
+		return r.insertUpdateCurrentPrimary(db, primary)
+	}
+
+	// Confirm by the time and the pod state that the primary is not just unreachable for a moment. The pod missing,
+	// terminating or just created is also waited since it may be restarted
+	if db.Status.PrimaryUnavailableSince == nil {
+		now := metav1.Now()
+		db.Status.PrimaryUnavailableSince = &now
+		return r.updateStatus(db)
+	}
+	if time.Since(db.Status.PrimaryUnavailableSince.Time) <= failoverGracePeriod ||
+		(primaryPod != nil && !isNotReadySince(primaryPod, failoverGracePeriod)) {
+		return nil
+	}
+
+	candidate, lsn, err := r.fetchMostUpToDateStandby(db, standbyPods)
+	if err != nil {
+		return err
+	}
+
+	if err := service.Promote(r.executor, candidate, db.Spec.ContainerName); err != nil {
+		return err
+	}
+	newPrimary := getPodMember(db, candidate)
+
+	// Ensure that the read-write Service selects only the new primary
+	candidate.Labels[utils.RoleLabelKey] = utils.PrimaryRole
+	if err := r.client.Update(context.TODO(), candidate); err != nil {
+		return err
+	}
+
+	// The new primary is stored before the old primary pod be removed, so it is recreated as standby even when the
+	// reconcile fails before the end
+	reason := fmt.Sprintf("Primary %v is not available through the Service %v", primary, db.Name)
+	if err := r.insertFailoverEvent(db, v1alpha1.FailoverEvent{
+		Time:       metav1.Now(),
+		OldPrimary: primary,
+		NewPrimary: newPrimary,
+		LSN:        lsn,
+		Reason:     reason,
+	}); err != nil {
+		return err
+	}
+	if r.recorder != nil {
+		r.recorder.Eventf(db, corev1.EventTypeWarning, "Failover", "%v. Standby %v promoted to primary.", reason, newPrimary)
+	}
+
+	// The templates of the workloads are switched to the new roles before the old primary pod be removed, otherwise
+	// it would be recreated as primary with the template of the previous role
+	if err := r.switchMemberRoles(db); err != nil {
+		return err
+	}
+
+	// The old primary pod is removed in order to be recreated as standby and avoid two primaries
+	if primaryPod != nil && primaryPod.DeletionTimestamp == nil {
+		if err := r.client.Delete(context.TODO(), primaryPod); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// switchMemberRoles updates the templates of the Deployments or StatefulSet with the role of each member according to
+// the current primary of the status
+func (r *ReconcileDatabase) switchMemberRoles(db *v1alpha1.Database) error {
+	if utils.IsStatefulSet(db) {
+		sts, err := service.FetchStatefulSet(db.Name, db.Namespace, r.client)
+		if err != nil {
+			return err
+		}
+		changes, err := r.ensureStatefulSetSpec(db, sts)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			if err := r.recordRollout(db, []string{sts.Name}, changes); err != nil {
+				return err
+			}
+		}
+		return r.ensurePodRoles(db)
+	}
+
+	updated, changes, err := r.ensureDeploymentsSpec(db)
+	if err != nil {
+		return err
+	}
+	if len(updated) > 0 {
+		return r.recordRollout(db, updated, changes)
+	}
+	return nil
+}
+
+// isPrimaryWorkloadUpdating returns true when the Deployment of the primary or the StatefulSet has pods which were not
+// updated to its template yet
+func (r *ReconcileDatabase) isPrimaryWorkloadUpdating(db *v1alpha1.Database) (bool, error) {
+	if utils.IsStatefulSet(db) {
+		sts, err := service.FetchStatefulSet(db.Name, db.Namespace, r.client)
+		if err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		return sts.Status.ObservedGeneration < sts.Generation ||
+			(sts.Status.UpdateRevision != "" && sts.Status.CurrentRevision != sts.Status.UpdateRevision), nil
+	}
+	dep, err := service.FetchDeployment(utils.GetPrimaryMember(db), db.Namespace, r.client)
+	if err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return dep.Status.ObservedGeneration < dep.Generation || dep.Status.UpdatedReplicas < dep.Status.Replicas, nil
+}
+
+// fetchMemberPods returns the pod of the primary and the ready pods of the standbys
+// NOTE: The terminating pod of the primary is only returned when it has no other pod
+func (r *ReconcileDatabase) fetchMemberPods(db *v1alpha1.Database, primary string) (*corev1.Pod, []*corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	listOps := &client.ListOptions{Namespace: db.Namespace, LabelSelector: labels.SelectorFromSet(utils.GetLabels(db.Name))}
+	if err := r.client.List(context.TODO(), podList, listOps); err != nil {
+		return nil, nil, err
+	}
+
+	var primaryPod *corev1.Pod
+	var standbyPods []*corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		member := getPodMember(db, pod)
+		if member == "" {
+			continue
+		}
+		if member == primary {
+			if primaryPod == nil || primaryPod.DeletionTimestamp != nil {
+				primaryPod = pod
+			}
+		} else if pod.DeletionTimestamp == nil && isPodReady(pod) {
+			standbyPods = append(standbyPods, pod)
+		}
+	}
+	return primaryPod, standbyPods, nil
+}
+
+// fetchMostUpToDateStandby returns the standby which received the latest WAL location and its location
+func (r *ReconcileDatabase) fetchMostUpToDateStandby(db *v1alpha1.Database, standbyPods []*corev1.Pod) (*corev1.Pod, string, error) {
+	var candidate *corev1.Pod
+	var candidateLSN string
+	var max uint64
+	for _, pod := range standbyPods {
+		lsn, err := service.FetchReceiveLSN(r.executor, pod, db.Spec.ContainerName)
+		if err != nil {
+			continue
+		}
+		value, err := utils.ParseLSN(lsn)
+		if err != nil {
+			continue
+		}
+		if candidate == nil || value > max {
+			candidate, candidateLSN, max = pod, lsn, value
+		}
+	}
+
+	if candidate == nil {
+		return nil, "", fmt.Errorf("Error: None standby of the Database %v is available to be promoted.", db.Name)
+	}
+	return candidate, candidateLSN, nil
+}
+
+// insertUpdateCurrentPrimary will check if the current primary in the status changed, if yes update it
+func (r *ReconcileDatabase) insertUpdateCurrentPrimary(db *v1alpha1.Database, primary string) error {
+	if db.Status.CurrentPrimary != primary || db.Status.PrimaryUnavailableSince != nil {
+		db.Status.CurrentPrimary = primary
+		db.Status.PrimaryUnavailableSince = nil
+		if err := r.updateStatus(db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertFailoverEvent will store the failover and the new primary in the status
+func (r *ReconcileDatabase) insertFailoverEvent(db *v1alpha1.Database, event v1alpha1.FailoverEvent) error {
+	db.Status.CurrentPrimary = event.NewPrimary
+	db.Status.PrimaryUnavailableSince = nil
+	db.Status.FailoverEvents = append(db.Status.FailoverEvents, event)
+	if len(db.Status.FailoverEvents) > maxFailoverEvents {
+		db.Status.FailoverEvents = db.Status.FailoverEvents[len(db.Status.FailoverEvents)-maxFailoverEvents:]
+	}
+	return r.updateStatus(db)
+}
+
+// getPodMember returns the name of the member (Deployment or pod of the StatefulSet) of the pod
+func getPodMember(db *v1alpha1.Database, pod *corev1.Pod) string {
+	if utils.IsStatefulSet(db) {
+		if utils.GetStatefulSetOrdinal(db, pod.Name) < 0 {
+			return ""
+		}
+		return pod.Name
+	}
+	return pod.Labels[utils.MemberLabelKey]
+}
+
+// isPodReady returns true when the pod is running and its condition Ready is true
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isNotReadySince returns true when the pod is not ready for longer than the period informed
+func isNotReadySince(pod *corev1.Pod, period time.Duration) bool {
+	if isPodReady(pod) {
+		return false
+	}
+	since := pod.CreationTimestamp.Time
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady && !c.LastTransitionTime.IsZero() {
+			since = c.LastTransitionTime.Time
+		}
+	}
+	return time.Since(since) > period
+}
@@ -0,0 +1,239 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileDatabase_Failover(t *testing.T) {
+	justCreated := podPrimaryNotReady.DeepCopy()
+	justCreated.CreationTimestamp = metav1.Now()
+	justCreated.Status.Conditions = nil
+
+	tests := []struct {
+		name             string
+		serviceReady     bool
+		unavailableSince *metav1.Time
+		rollout          *v1alpha1.RolloutStatus
+		primaryPod       *corev1.Pod
+		wantPrimary      string
+		wantPromoted     string
+		wantEvents       int
+		wantOldPodExists bool
+		wantUnavailable  bool
+	}{
+		{
+			name:             "Should promote the most up-to-date standby when the primary is not available",
+			serviceReady:     false,
+			unavailableSince: &metav1.Time{Time: time.Now().Add(-time.Minute)},
+			primaryPod:       podPrimaryNotReady.DeepCopy(),
+			wantPrimary:      "database-standby-1",
+			wantPromoted:     podStandby1.Name,
+			wantEvents:       1,
+			wantOldPodExists: false,
+		},
+		{
+			name:             "Should not promote a standby when the primary is available through the service",
+			serviceReady:     true,
+			primaryPod:       podPrimaryNotReady.DeepCopy(),
+			wantPrimary:      "database",
+			wantEvents:       0,
+			wantOldPodExists: true,
+		},
+		{
+			name:             "Should only store the time when the primary is not available for the first time",
+			serviceReady:     false,
+			primaryPod:       podPrimaryNotReady.DeepCopy(),
+			wantEvents:       0,
+			wantOldPodExists: true,
+			wantUnavailable:  true,
+		},
+		{
+			name:             "Should not promote a standby while the pod of the primary was just created",
+			serviceReady:     false,
+			unavailableSince: &metav1.Time{Time: time.Now().Add(-time.Minute)},
+			primaryPod:       justCreated,
+			wantEvents:       0,
+			wantOldPodExists: true,
+			wantUnavailable:  true,
+		},
+		{
+			name:             "Should not promote a standby while the workloads are rolled out",
+			serviceReady:     false,
+			unavailableSince: &metav1.Time{Time: time.Now().Add(-time.Minute)},
+			rollout:          &v1alpha1.RolloutStatus{Phase: rolloutProgressing, Workloads: []string{"database"}},
+			primaryPod:       podPrimaryNotReady.DeepCopy(),
+			wantEvents:       0,
+			wantOldPodExists: true,
+			wantUnavailable:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbInstanceWithReplication.DeepCopy()
+			db.Status.PrimaryUnavailableSince = tt.unavailableSince
+			db.Status.Rollout = tt.rollout
+			objs := []runtime.Object{
+				db,
+				tt.primaryPod,
+				podStandby0.DeepCopy(),
+				podStandby1.DeepCopy(),
+			}
+			r := buildReconcileWithFakeClientWithMocks(objs)
+			executor := &fakeSQLExecutor{
+				serviceReady: tt.serviceReady,
+				lsn: map[string]string{
+					podStandby0.Name: "0/3000060",
+					podStandby1.Name: "0/3000108",
+				},
+			}
+			r.executor = executor
+
+			// mock request to simulate Reconcile() being called on an event for a watched resource
+			req := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      dbInstanceWithReplication.Name,
+					Namespace: dbInstanceWithReplication.Namespace,
+				},
+			}
+
+			res, err := r.Reconcile(req)
+			if err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			if res.RequeueAfter != failoverCheckInterval {
+				t.Errorf("RequeueAfter got (%v), when is expected (%v)", res.RequeueAfter, failoverCheckInterval)
+			}
+
+			db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+			if err != nil {
+				t.Fatalf("get database: (%v)", err)
+			}
+			if db.Status.CurrentPrimary != tt.wantPrimary {
+				t.Errorf("Current primary got (%v), when is expected (%v)", db.Status.CurrentPrimary, tt.wantPrimary)
+			}
+			if (db.Status.PrimaryUnavailableSince != nil) != tt.wantUnavailable {
+				t.Errorf("Primary unavailable since got (%v), when is expected to be stored (%v)", db.Status.PrimaryUnavailableSince, tt.wantUnavailable)
+			}
+			if len(db.Status.FailoverEvents) != tt.wantEvents {
+				t.Errorf("Failover events got (%v), when is expected (%v)", len(db.Status.FailoverEvents), tt.wantEvents)
+			}
+
+			if tt.wantPromoted != "" {
+				if len(executor.promoted) != 1 || executor.promoted[0] != tt.wantPromoted {
+					t.Errorf("Promoted got (%v), when is expected (%v)", executor.promoted, tt.wantPromoted)
+				}
+
+				pod := &corev1.Pod{}
+				if err := r.client.Get(context.TODO(), types.NamespacedName{Name: tt.wantPromoted, Namespace: req.Namespace}, pod); err != nil {
+					t.Fatalf("get promoted pod: (%v)", err)
+				}
+				if pod.Labels[utils.RoleLabelKey] != utils.PrimaryRole {
+					t.Errorf("Promoted pod role got (%v), when is expected (%v)", pod.Labels[utils.RoleLabelKey], utils.PrimaryRole)
+				}
+
+				promoted, err := service.FetchDeployment(tt.wantPrimary, req.Namespace, r.client)
+				if err != nil {
+					t.Fatalf("get promoted deployment: (%v)", err)
+				}
+				if promoted.Spec.Template.Labels[utils.RoleLabelKey] != utils.PrimaryRole {
+					t.Errorf("Promoted deployment role got (%v), when is expected (%v)", promoted.Spec.Template.Labels[utils.RoleLabelKey], utils.PrimaryRole)
+				}
+
+				old, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+				if err != nil {
+					t.Fatalf("get old primary deployment: (%v)", err)
+				}
+				if old.Spec.Template.Labels[utils.RoleLabelKey] != utils.StandbyRole {
+					t.Errorf("Old primary deployment role got (%v), when is expected (%v)", old.Spec.Template.Labels[utils.RoleLabelKey], utils.StandbyRole)
+				}
+			} else if len(executor.promoted) != 0 {
+				t.Errorf("None standby should be promoted, got (%v)", executor.promoted)
+			}
+
+			oldPod := &corev1.Pod{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: podPrimaryNotReady.Name, Namespace: req.Namespace}, oldPod)
+			if (err == nil) != tt.wantOldPodExists {
+				t.Errorf("Old primary pod exists got (%v), when is expected (%v)", err == nil, tt.wantOldPodExists)
+			}
+		})
+	}
+}
+
+// recreatePodClient is the fake client which recreates the pod deleted from the template of its Deployment at the time
+// of the deletion, as the ReplicaSet does
+type recreatePodClient struct {
+	client.Client
+	recreated *corev1.Pod
+}
+
+func (c *recreatePodClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if err := c.Client.Delete(ctx, obj, opts...); err != nil {
+		return err
+	}
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+	dep, err := service.FetchDeployment(pod.Labels[utils.MemberLabelKey], pod.Namespace, c.Client)
+	if err != nil {
+		return err
+	}
+	c.recreated = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name + "-recreated",
+			Namespace: pod.Namespace,
+			Labels:    dep.Spec.Template.Labels,
+		},
+		Spec: dep.Spec.Template.Spec,
+	}
+	return c.Client.Create(ctx, c.recreated)
+}
+
+func TestReconcileDatabase_FailoverRecreatesOldPrimaryAsStandby(t *testing.T) {
+	db := dbInstanceWithReplication.DeepCopy()
+	db.Status.PrimaryUnavailableSince = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+	objs := []runtime.Object{
+		db,
+		podPrimaryNotReady.DeepCopy(),
+		podStandby0.DeepCopy(),
+		podStandby1.DeepCopy(),
+	}
+	r := buildReconcileWithStatusSubresource(objs)
+	r.executor = &fakeSQLExecutor{
+		lsn: map[string]string{
+			podStandby0.Name: "0/3000060",
+			podStandby1.Name: "0/3000108",
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithReplication.Name,
+			Namespace: dbInstanceWithReplication.Namespace,
+		},
+	}
+
+	cl := &recreatePodClient{Client: r.client}
+	r.client = cl
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if cl.recreated == nil {
+		t.Fatal("Old primary pod was not deleted")
+	}
+	if role := cl.recreated.Labels[utils.RoleLabelKey]; role != utils.StandbyRole {
+		t.Errorf("Recreated pod of the old primary role got (%v), when is expected (%v)", role, utils.StandbyRole)
+	}
+}
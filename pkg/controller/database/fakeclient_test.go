@@ -1,10 +1,15 @@
 package database
 
 import (
+	"context"
+
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -13,10 +18,57 @@ func buildReconcileWithFakeClientWithMocks(objs []runtime.Object) *ReconcileData
 	s := scheme.Scheme
 
 	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.Database{})
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.Backup{}, &v1alpha1.BackupList{})
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.DatabaseClass{})
 
 	// create a fake client to mock API calls with the mock objects
 	cl := fake.NewFakeClientWithScheme(s, objs...)
 
 	// create a Database object with the scheme and fake client
-	return &ReconcileDatabase{client: cl, scheme: s}
+	return &ReconcileDatabase{client: cl, scheme: s, recorder: record.NewFakeRecorder(10)}
+}
+
+//buildReconcileWithStatusSubresource return reconcile with the fake client whose status update of the Database works as
+// the status subresource of the API
+func buildReconcileWithStatusSubresource(objs []runtime.Object) *ReconcileDatabase {
+	r := buildReconcileWithFakeClientWithMocks(objs)
+	r.client = statusSubresourceClient{r.client}
+	return r
+}
+
+// statusSubresourceClient is the fake client whose status update of the Database only stores its status and
+// overwrites the Database informed with the one stored, as the API does
+type statusSubresourceClient struct {
+	client.Client
+}
+
+func (c statusSubresourceClient) Status() client.StatusWriter {
+	return statusSubresourceWriter{c.Client}
+}
+
+type statusSubresourceWriter struct {
+	client client.Client
+}
+
+func (w statusSubresourceWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	db, ok := obj.(*v1alpha1.Database)
+	if !ok {
+		return w.client.Status().Update(ctx, obj, opts...)
+	}
+	key := types.NamespacedName{Name: db.Name, Namespace: db.Namespace}
+	stored := &v1alpha1.Database{}
+	if err := w.client.Get(ctx, key, stored); err != nil {
+		return err
+	}
+	stored.Status = db.Status
+	if err := w.client.Status().Update(ctx, stored, opts...); err != nil {
+		return err
+	}
+	// The fake client decodes the object stored into the one informed without removing the fields which are not stored
+	*db = v1alpha1.Database{}
+	return w.client.Get(ctx, key, db)
+}
+
+func (w statusSubresourceWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return w.client.Status().Patch(ctx, obj, patch, opts...)
 }
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/resource"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// manageHba will ensure that the ConfigMap with the pg_hba.conf has the rules of the spec.hba and stores the change
+// in the status
+// NOTE: The invalid rules are reported in the status and the ConfigMap keeps the last ones which were valid, so they
+// replace the spec.hba in the Database informed which is used to create and manage the workloads. The phases of the
+// change are the ones of the parameters: the pods are restarted when the pg_hba.conf of the ConfigMap starts to be
+// used, otherwise the changes are reloaded by updateHbaStatus.
+func (r *ReconcileDatabase) manageHba(db *v1alpha1.Database) error {
+	desired := resource.NewDatabaseHbaConfigMap(db, r.scheme)
+	cm, err := service.FetchConfigMap(desired.Name, desired.Namespace, r.client)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	created := errors.IsNotFound(err)
+
+	if utils.ValidateHba(db) != nil {
+		db.Spec.Hba = nil
+		if !created {
+			db.Spec.Hba = utils.ParseHbaConf(cm.Data[utils.HbaConfKey])
+		}
+		return nil
+	}
+
+	if !utils.IsHbaManaged(db) {
+		// The pod template stops using the ConfigMap
+		if db.Status.Hba == nil {
+			return nil
+		}
+		db.Status.Hba = nil
+		return r.updateStatus(db)
+	}
+	if !created && cm.Data[utils.HbaConfKey] == desired.Data[utils.HbaConfKey] && db.Status.Hba != nil {
+		return nil
+	}
+
+	if created {
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return err
+		}
+	} else if cm.Data[utils.HbaConfKey] != desired.Data[utils.HbaConfKey] {
+		cm.Data = desired.Data
+		if err := r.client.Update(context.TODO(), cm); err != nil {
+			return err
+		}
+	}
+
+	workloadFound, err := r.isPrimaryWorkloadFound(db)
+	if err != nil {
+		return err
+	}
+
+	status := &v1alpha1.HbaStatus{
+		ConfigMapName: desired.Name,
+		Phase:         parametersReloading,
+		Rules:         int32(len(db.Spec.Hba)),
+		ChangeTime:    metav1.Now(),
+	}
+	switch {
+	case !workloadFound:
+		// The pods are created with the pg_hba.conf
+		status.Phase = parametersApplied
+		status.AppliedTime = &status.ChangeTime
+	case db.Status.Hba == nil:
+		// The ConfigMap is mounted by the pod template
+		status.Phase = parametersRestarting
+	}
+
+	if r.recorder != nil && workloadFound {
+		r.recorder.Eventf(db, corev1.EventTypeNormal, "HbaChanged", "The pg_hba.conf changed with %v rules of the spec.", status.Rules)
+	}
+	db.Status.Hba = status
+	return r.updateStatus(db)
+}
+
+//updateHbaStatus returns error when status regards the change of the pg_hba.conf could not be updated
+//NOTE: The pg_hba.conf is reloaded when all pods have the file of the ConfigMap updated
+func (r *ReconcileDatabase) updateHbaStatus(request reconcile.Request) error {
+	db, err := service.FetchDatabaseCR(request.Name, request.Namespace, r.client)
+	if err != nil {
+		return err
+	}
+	if db.Status.Hba == nil || db.Status.Hba.Phase == parametersApplied {
+		return nil
+	}
+	service.AddDatabaseMandatorySpecs(db, r.client)
+
+	switch db.Status.Hba.Phase {
+	case parametersReloading:
+		if r.executor == nil {
+			return nil
+		}
+		cm, err := service.FetchConfigMap(db.Status.Hba.ConfigMapName, db.Namespace, r.client)
+		if err != nil {
+			return err
+		}
+		conf := strings.TrimSpace(cm.Data[utils.HbaConfKey])
+		reloaded, err := r.reloadConfiguration(db, utils.HbaConfPath+"/"+utils.HbaConfKey, conf)
+		if err != nil || !reloaded {
+			return err
+		}
+		if r.recorder != nil {
+			r.recorder.Eventf(db, corev1.EventTypeNormal, "HbaReloaded", "Configuration reloaded to apply the pg_hba.conf.")
+		}
+	case parametersRestarting:
+		for _, name := range getMemberWorkloads(db) {
+			complete, err := r.isWorkloadRolledOut(db, name)
+			if err != nil || !complete {
+				return err
+			}
+		}
+	}
+
+	now := metav1.Now()
+	db.Status.Hba.Phase = parametersApplied
+	db.Status.Hba.AppliedTime = &now
+	return r.updateStatus(db)
+}
+
+// isHbaChanging returns true when the change of the pg_hba.conf was not applied yet
+func isHbaChanging(db *v1alpha1.Database) bool {
+	return db.Status.Hba != nil && db.Status.Hba.Phase != parametersApplied
+}
@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileDatabase_Hba(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		dbInstanceWithHba.DeepCopy(),
+		podPrimaryReady.DeepCopy(),
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+	executor := &fakeSQLExecutor{files: map[string]string{}}
+	r.executor = executor
+	confPath := utils.HbaConfPath + "/" + utils.HbaConfKey
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithHba.Name,
+			Namespace: dbInstanceWithHba.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	cm, err := service.FetchConfigMap(req.Name+utils.HbaSuffix, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get configmap: (%v)", err)
+	}
+	conf := cm.Data[utils.HbaConfKey]
+	first := strings.Index(conf, "host all all 10.0.0.0/8 scram-sha-256\n")
+	second := strings.Index(conf, "host appdb +readers all md5\n")
+	if first < 0 || second < first || !strings.Contains(conf, "local all all trust\n") ||
+		strings.Contains(conf, "host all all all md5\n") {
+		t.Errorf("ConfigMap got (%v), when is expected the rules of the spec in order", conf)
+	}
+	if !reflect.DeepEqual(utils.ParseHbaConf(conf), []v1alpha1.DatabaseHbaRule{
+		{Type: "host", Database: "all", User: "all", Address: "10.0.0.0/8", Method: "scram-sha-256"},
+		{Type: "host", Database: "appdb", User: "+readers", Address: "all", Method: "md5"},
+	}) {
+		t.Errorf("ParseHbaConf got (%v), when is expected the rules of the spec", utils.ParseHbaConf(conf))
+	}
+
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if !strings.Contains(strings.Join(dep.Spec.Template.Spec.Containers[0].Args, " "), "hba_file="+confPath) ||
+		!hasVolume(dep.Spec.Template.Spec.Volumes, cm.Name) {
+		t.Fatalf("Deployment got the args (%v) and volumes (%v), when is expected the pg_hba.conf of the ConfigMap",
+			dep.Spec.Template.Spec.Containers[0].Args, dep.Spec.Template.Spec.Volumes)
+	}
+
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.Hba == nil || db.Status.Hba.Phase != parametersApplied || db.Status.Hba.Rules != 2 {
+		t.Fatalf("Hba got (%v), when is expected the phase (%v) with 2 rules", db.Status.Hba, parametersApplied)
+	}
+
+	// Change a rule which is applied by reload
+	db.Spec.Hba[0].Address = "192.168.0.0/16"
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.Hba.Phase != parametersReloading || len(executor.reloaded) != 0 {
+		t.Fatalf("Hba got (%v) and reloaded (%v), when is expected the phase (%v) until the pod has the file updated",
+			db.Status.Hba, executor.reloaded, parametersReloading)
+	}
+
+	// Simulate the file of the ConfigMap updated in the pod
+	cm, err = service.FetchConfigMap(req.Name+utils.HbaSuffix, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get configmap: (%v)", err)
+	}
+	executor.files[confPath] = strings.TrimSpace(cm.Data[utils.HbaConfKey])
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.Hba.Phase != parametersApplied || !reflect.DeepEqual(executor.reloaded, []string{podPrimaryReady.Name}) {
+		t.Fatalf("Hba got (%v) and reloaded (%v), when is expected the phase (%v) with the pod reloaded",
+			db.Status.Hba, executor.reloaded, parametersApplied)
+	}
+
+	// An invalid rule is not applied and the ConfigMap keeps the last valid ones
+	db.Spec.Hba[0].Address = "192.168.0.0/33"
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	got, err := service.FetchConfigMap(req.Name+utils.HbaSuffix, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get configmap: (%v)", err)
+	}
+	if got.Data[utils.HbaConfKey] != cm.Data[utils.HbaConfKey] {
+		t.Errorf("ConfigMap got (%v), when is expected the last valid rules (%v)", got.Data[utils.HbaConfKey], cm.Data[utils.HbaConfKey])
+	}
+	dep, err = service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if !hasVolume(dep.Spec.Template.Spec.Volumes, cm.Name) {
+		t.Errorf("Deployment volumes got (%v), when is expected the pg_hba.conf of the ConfigMap kept", dep.Spec.Template.Spec.Volumes)
+	}
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if !strings.Contains(db.Status.DatabaseStatus, "192.168.0.0/33") {
+		t.Errorf("DatabaseStatus got (%v), when is expected the error of the invalid address", db.Status.DatabaseStatus)
+	}
+}
+
+func TestValidateHba(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    v1alpha1.DatabaseHbaRule
+		wantErr bool
+	}{
+		{
+			name: "should accept a local rule",
+			rule: v1alpha1.DatabaseHbaRule{Type: "local", Method: "peer"},
+		},
+		{
+			name: "should accept a hostssl rule with IPv6 CIDR",
+			rule: v1alpha1.DatabaseHbaRule{Type: "hostssl", Database: "app,other", User: "+readers", Address: "fd00::/8", Method: "scram-sha-256"},
+		},
+		{
+			name: "should accept the address keywords",
+			rule: v1alpha1.DatabaseHbaRule{Type: "host", Address: "samenet", Method: "reject"},
+		},
+		{
+			name:    "should not accept an unknown type",
+			rule:    v1alpha1.DatabaseHbaRule{Type: "remote", Address: "all", Method: "md5"},
+			wantErr: true,
+		},
+		{
+			name:    "should not accept an unknown method",
+			rule:    v1alpha1.DatabaseHbaRule{Type: "host", Address: "all", Method: "scram"},
+			wantErr: true,
+		},
+		{
+			name:    "should not accept a method which requires options",
+			rule:    v1alpha1.DatabaseHbaRule{Type: "host", Address: "all", Method: "ldap"},
+			wantErr: true,
+		},
+		{
+			name:    "should not accept the type hostgssenc",
+			rule:    v1alpha1.DatabaseHbaRule{Type: "hostgssenc", Address: "all", Method: "md5"},
+			wantErr: true,
+		},
+		{
+			name:    "should not accept an invalid CIDR",
+			rule:    v1alpha1.DatabaseHbaRule{Type: "host", Address: "10.0.0.300/8", Method: "md5"},
+			wantErr: true,
+		},
+		{
+			name:    "should not accept the host without address",
+			rule:    v1alpha1.DatabaseHbaRule{Type: "host", Method: "md5"},
+			wantErr: true,
+		},
+		{
+			name:    "should not accept the address with the type local",
+			rule:    v1alpha1.DatabaseHbaRule{Type: "local", Address: "all", Method: "md5"},
+			wantErr: true,
+		},
+		{
+			name:    "should not accept the peer method with the type host",
+			rule:    v1alpha1.DatabaseHbaRule{Type: "host", Address: "all", Method: "peer"},
+			wantErr: true,
+		},
+		{
+			name:    "should not accept a user with spaces",
+			rule:    v1alpha1.DatabaseHbaRule{Type: "host", User: "app all", Address: "all", Method: "md5"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbInstanceWithoutSpec.DeepCopy()
+			db.Spec.Hba = []v1alpha1.DatabaseHbaRule{tt.rule}
+			if err := utils.ValidateHba(db); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateHba() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,28 +2,108 @@ package database
 
 import (
 	"context"
+
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
 	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
 	"k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // manageResources will ensure that the resource are with the expected values in the cluster
 func (r *ReconcileDatabase) manageResources(db *v1alpha1.Database) error {
-	// get the latest version of db deployment
-	dep, err := service.FetchDeployment(db.Name, db.Namespace, r.client)
-	if err != nil {
+	if utils.IsStatefulSet(db) {
+		// get the latest version of db statefulset
+		sts, err := service.FetchStatefulSet(db.Name, db.Namespace, r.client)
+		if err != nil {
+			// The StatefulSet is not created until the migration of the Deployment be finished
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		// Ensure the statefulset size is the same as the spec
+		if err := r.ensureStatefulSetSize(db, sts); err != nil {
+			return err
+		}
+
+		// Ensure that the template of the statefulset is the one generated from the spec. E.g. image, resources,
+		// WAL archiving setup and the pod which should run as primary
+		changes, err := r.ensureStatefulSetSpec(db, sts)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			if err := r.recordRollout(db, []string{sts.Name}, changes); err != nil {
+				return err
+			}
+		}
+
+		// Ensure that the pods are labeled with their role in order to be selected by the services
+		if err := r.ensurePodRoles(db); err != nil {
+			return err
+		}
+	} else {
+		// get the latest version of db deployment
+		dep, err := service.FetchDeployment(db.Name, db.Namespace, r.client)
+		if err != nil {
+			return err
+		}
+
+		// Ensure the deployment size is the same as the spec
+		if err := r.ensureDepSize(db, dep); err != nil {
+			return err
+		}
+
+		// Ensure that the deployments of the primary and standbys have the spec generated from the CR. E.g. image,
+		// resources, WAL archiving setup and the role of their member
+		updated, changes, err := r.ensureDeploymentsSpec(db)
+		if err != nil {
+			return err
+		}
+		if len(updated) > 0 {
+			if err := r.recordRollout(db, updated, changes); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Ensure that the services have the ports of the spec and select the primary when the replication is enabled
+	if err := r.ensureServicesSpec(db); err != nil {
+		return err
+	}
+
+	// Ensure that the persistent volume claims have the labels of the spec
+	if err := r.ensurePvcsSpec(db); err != nil {
+		return err
+	}
+
+	// Ensure that the persistent volume claims are expanded when the storage request of the spec grows
+	if err := r.ensurePvcsStorage(db); err != nil {
 		return err
 	}
 
-	// Ensure the deployment size is the same as the spec
-	r.ensureDepSize(db, dep)
+	// Ensure that only the standbys required by the spec exist
+	if err := r.removeStandbys(db); err != nil {
+		return err
+	}
 	return nil
 }
 
 // ensureDepSize will ensure that the quanity of instances in the cluster for the Database deployment is the same defined in the CR
+// NOTE: The Deployment of the Database is always the single primary instance. The others (spec.size - 1) are standbys
+// with their own Deployment and PersistentVolumeClaim.
 func (r *ReconcileDatabase) ensureDepSize(db *v1alpha1.Database, dep *v1.Deployment) error {
-	size := db.Spec.Size
-	if *dep.Spec.Replicas != size {
+	size := int32(1)
+	// The database should not be running while its data is changed by the upgrade
+	if isDatabaseStoppedByUpgrade(db) {
+		size = 0
+	}
+	if dep.Spec.Replicas == nil || *dep.Spec.Replicas != size {
 		// Set the number of Replicas spec in the CR
 		dep.Spec.Replicas = &size
 		if err := r.client.Update(context.TODO(), dep); err != nil {
@@ -32,3 +112,110 @@ func (r *ReconcileDatabase) ensureDepSize(db *v1alpha1.Database, dep *v1.Deploym
 	}
 	return nil
 }
+
+// ensureStatefulSetSize will ensure that the quantity of pods of the StatefulSet is the same defined in the CR
+// NOTE: The pod of the current primary is never removed when the size is decreased
+func (r *ReconcileDatabase) ensureStatefulSetSize(db *v1alpha1.Database, sts *v1.StatefulSet) error {
+	size := utils.GetStatefulSetReplicas(db)
+	if ordinal := int32(utils.GetStatefulSetOrdinal(db, utils.GetPrimaryPodName(db))); ordinal >= size {
+		size = ordinal + 1
+	}
+	if sts.Spec.Replicas == nil || *sts.Spec.Replicas != size {
+		sts.Spec.Replicas = &size
+		if err := r.client.Update(context.TODO(), sts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensurePodRoles will label the pods of the StatefulSet with the role (primary or standby) when the replication is enabled
+// NOTE: All pods of the StatefulSet share the same template so their role labels are managed by the operator
+func (r *ReconcileDatabase) ensurePodRoles(db *v1alpha1.Database) error {
+	if !utils.IsReplicationEnabled(db) {
+		return nil
+	}
+
+	podList := &corev1.PodList{}
+	listOps := &client.ListOptions{Namespace: db.Namespace, LabelSelector: labels.SelectorFromSet(utils.GetLabels(db.Name))}
+	if err := r.client.List(context.TODO(), podList, listOps); err != nil {
+		return err
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if utils.GetStatefulSetOrdinal(db, pod.Name) < 0 {
+			continue
+		}
+
+		role := utils.StandbyRole
+		if pod.Name == utils.GetPrimaryPodName(db) {
+			role = utils.PrimaryRole
+		}
+
+		if pod.Labels[utils.RoleLabelKey] != role {
+			if pod.Labels == nil {
+				pod.Labels = map[string]string{}
+			}
+			pod.Labels[utils.RoleLabelKey] = role
+			if err := r.client.Update(context.TODO(), pod); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// removeStandbys will delete the Deployments, PersistentVolumeClaims and the read-only Service of the standbys
+// which are no longer required. E.g when the spec.size was decreased or the replication was disabled
+func (r *ReconcileDatabase) removeStandbys(db *v1alpha1.Database) error {
+	listOps := &client.ListOptions{Namespace: db.Namespace, LabelSelector: labels.SelectorFromSet(utils.GetLabels(db.Name))}
+
+	depList := &v1.DeploymentList{}
+	if err := r.client.List(context.TODO(), depList, listOps); err != nil {
+		return err
+	}
+	for i := range depList.Items {
+		if r.isStandbyNoLongerRequired(db, depList.Items[i].Name) {
+			if err := r.client.Delete(context.TODO(), &depList.Items[i]); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	if err := r.client.List(context.TODO(), pvcList, listOps); err != nil {
+		return err
+	}
+	for i := range pvcList.Items {
+		if r.isStandbyNoLongerRequired(db, pvcList.Items[i].Name) {
+			if err := r.client.Delete(context.TODO(), &pvcList.Items[i]); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	if !utils.IsReplicationEnabled(db) {
+		ser, err := service.FetchService(utils.GetReadOnlyServiceName(db), db.Namespace, r.client)
+		if err == nil {
+			if err := r.client.Delete(context.TODO(), ser); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isStandbyNoLongerRequired returns true when the name is from a standby with an index out of the expected size
+// NOTE: With the StatefulSet the standbys are its pods so none Deployment or PVC of standbys is required. Also, the
+// current primary is never removed
+func (r *ReconcileDatabase) isStandbyNoLongerRequired(db *v1alpha1.Database, name string) bool {
+	index := utils.GetStandbyIndex(db, name)
+	if !utils.IsStatefulSet(db) && name == utils.GetPrimaryMember(db) {
+		return false
+	}
+	if index >= 0 && utils.IsStatefulSet(db) {
+		return true
+	}
+	return index >= 0 && index >= utils.GetStandbySize(db)
+}
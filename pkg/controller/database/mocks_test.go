@@ -1,11 +1,23 @@
 package database
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	v1alpha1 "github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// allowVolumeExpansion is referenced by the StorageClass mocks
+var allowVolumeExpansion = true
+
+var noLogin = false
+
 // Centralized mock objects for use in tests
 var (
 	dbInstanceWithoutSpec = v1alpha1.Database{
@@ -38,6 +50,78 @@ var (
 		},
 	}
 
+	dbInstanceWithCredentialsSecret = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			CredentialsSecretName:        "app-credentials",
+			CredentialsSecretPasswordKey: "password",
+		},
+	}
+
+	dbInstanceWithReplication = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			Size: 3,
+			Replication: &v1alpha1.DatabaseReplication{
+				Enabled: true,
+			},
+		},
+	}
+
+	dbInstanceWithStatefulSet = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			Size:         2,
+			WorkloadType: "StatefulSet",
+			Replication: &v1alpha1.DatabaseReplication{
+				Enabled: true,
+			},
+		},
+	}
+
+	dbInstanceWithWalArchiving = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			WalArchiving: &v1alpha1.DatabaseWalArchiving{
+				Enabled: true,
+			},
+		},
+	}
+
+	podStatefulSetPrimary = corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database-0",
+			Namespace: "postgresql-operator",
+			Labels: map[string]string{
+				"owner": "postgresqloperator",
+				"cr":    "database",
+			},
+		},
+	}
+
+	podStatefulSetStandby = corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database-1",
+			Namespace: "postgresql-operator",
+			Labels: map[string]string{
+				"owner": "postgresqloperator",
+				"cr":    "database",
+			},
+		},
+	}
+
 	configMapOtherKeyValues = corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "config-otherkeys",
@@ -62,3 +146,506 @@ var (
 		},
 	}
 )
+
+// Mock objects for the failover
+var (
+	podPrimaryNotReady = corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "database-6c8f9d7b5-x2x4l",
+			Namespace:         "postgresql-operator",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+			Labels: map[string]string{
+				"owner":  "postgresqloperator",
+				"cr":     "database",
+				"member": "database",
+				"role":   "primary",
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{
+					Type:               corev1.PodReady,
+					Status:             corev1.ConditionFalse,
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Minute)),
+				},
+			},
+		},
+	}
+
+	podStandby0 = corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database-standby-0-7d9c4b6f8-k8r2p",
+			Namespace: "postgresql-operator",
+			Labels: map[string]string{
+				"owner":  "postgresqloperator",
+				"cr":     "database",
+				"member": "database-standby-0",
+				"role":   "standby",
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{
+					Type:   corev1.PodReady,
+					Status: corev1.ConditionTrue,
+				},
+			},
+		},
+	}
+
+	podStandby1 = corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database-standby-1-5f7b8c9d6-m4n7q",
+			Namespace: "postgresql-operator",
+			Labels: map[string]string{
+				"owner":  "postgresqloperator",
+				"cr":     "database",
+				"member": "database-standby-1",
+				"role":   "standby",
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{
+					Type:   corev1.PodReady,
+					Status: corev1.ConditionTrue,
+				},
+			},
+		},
+	}
+)
+
+// Mock objects for the WAL archiving
+var (
+	podPrimaryReady = corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database-6c8f9d7b5-x2x4l",
+			Namespace: "postgresql-operator",
+			Labels: map[string]string{
+				"owner":  "postgresqloperator",
+				"cr":     "database",
+				"member": "database",
+				"role":   "primary",
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{
+					Type:   corev1.PodReady,
+					Status: corev1.ConditionTrue,
+				},
+			},
+		},
+	}
+)
+
+// Mock objects for the conditions
+var (
+	dbInstanceWithGeneration = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "database",
+			Namespace:  "postgresql-operator",
+			Generation: 2,
+		},
+	}
+
+	dbInstanceWasReady = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Status: v1alpha1.DatabaseStatus{
+			Phase: "Ready",
+		},
+	}
+
+	dbInstanceInvalidWorkloadType = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			WorkloadType: "DaemonSet",
+		},
+	}
+
+	deploymentDatabase = appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+	}
+
+	pvcDatabase = corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+	}
+
+	serviceDatabase = corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+	}
+
+	bkpInstanceDatabase = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "postgresql-operator",
+		},
+	}
+
+	dbInstanceWithPasswordRotation = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			Roles: []v1alpha1.DatabaseRole{
+				{
+					Name: "app",
+				},
+			},
+			PasswordRotation: &v1alpha1.DatabasePasswordRotation{
+				Enabled: true,
+			},
+		},
+		Status: v1alpha1.DatabaseStatus{
+			LastRotationTime: &metav1.Time{Time: time.Now().Add(-31 * 24 * time.Hour)},
+		},
+	}
+
+	secretBackupDatabase = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-backup",
+			Namespace: "postgresql-operator",
+		},
+		Data: map[string][]byte{
+			"POSTGRES_USERNAME": []byte("postgres"),
+			"POSTGRES_PASSWORD": []byte("previous"),
+		},
+	}
+
+	dbInstanceWithTLS = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			TLS: &v1alpha1.DatabaseTLS{
+				Enabled:    true,
+				EnforceSSL: true,
+			},
+		},
+	}
+
+	dbInstanceWithTLSSecret = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			TLS: &v1alpha1.DatabaseTLS{
+				Enabled:    true,
+				SecretName: "database-certificate",
+			},
+		},
+	}
+
+	dbInstanceWithTLSCertManager = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			TLS: &v1alpha1.DatabaseTLS{
+				Enabled: true,
+				CertManager: &v1alpha1.DatabaseTLSCertManager{
+					IssuerName: "ca-issuer",
+				},
+			},
+		},
+	}
+
+	dbInstanceWithHba = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			Hba: []v1alpha1.DatabaseHbaRule{
+				{
+					Type:    "host",
+					Address: "10.0.0.0/8",
+					Method:  "scram-sha-256",
+				},
+				{
+					Type:     "host",
+					Database: "appdb",
+					User:     "+readers",
+					Address:  "all",
+					Method:   "md5",
+				},
+			},
+		},
+	}
+
+	storageClassExpandable = storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "standard",
+		},
+		AllowVolumeExpansion: &allowVolumeExpansion,
+	}
+
+	storageClassNotExpandable = storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "standard",
+		},
+	}
+
+	dbInstanceWithStorageRequest = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "database",
+			Namespace:  "postgresql-operator",
+			Generation: 3,
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			DatabaseStorageRequest:   "2Gi",
+			DatabaseStorageClassName: "standard",
+		},
+	}
+
+	dbInstanceWithPostgresVersion = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			Image:           "centos/postgresql-96-centos7",
+			PostgresVersion: "9.6",
+		},
+	}
+
+	dbInstanceWithParameters = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			Parameters: map[string]string{
+				"max_connections": "100",
+				"work_mem":        "4MB",
+			},
+		},
+	}
+
+	dbInstanceWithRoles = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			Roles: []v1alpha1.DatabaseRole{
+				{
+					Name:    "app",
+					InRoles: []string{"readers"},
+				},
+				{
+					Name:  "readers",
+					Login: &noLogin,
+				},
+			},
+			Databases: []v1alpha1.LogicalDatabase{
+				{
+					Name:  "appdb",
+					Owner: "app",
+					Grants: []v1alpha1.DatabaseGrant{
+						{
+							Role:       "readers",
+							Privileges: []string{"connect"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	pvcDatabaseBound = corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse("1Gi"),
+				},
+			},
+			StorageClassName: &storageClassExpandable.Name,
+		},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Phase: corev1.ClaimBound,
+			Capacity: corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse("1Gi"),
+			},
+		},
+	}
+
+	pvcDatabaseLarger = corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse("5Gi"),
+				},
+			},
+			StorageClassName: &storageClassExpandable.Name,
+		},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Phase: corev1.ClaimBound,
+			Capacity: corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse("5Gi"),
+			},
+		},
+	}
+
+	pvcDatabaseResizePending = corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse("2Gi"),
+				},
+			},
+			StorageClassName: &storageClassExpandable.Name,
+		},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Phase: corev1.ClaimBound,
+			Capacity: corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse("1Gi"),
+			},
+			Conditions: []corev1.PersistentVolumeClaimCondition{
+				{
+					Type:   corev1.PersistentVolumeClaimFileSystemResizePending,
+					Status: corev1.ConditionTrue,
+				},
+			},
+		},
+	}
+
+	databaseClassSmall = v1alpha1.DatabaseClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "small",
+		},
+		Spec: v1alpha1.DatabaseClassSpec{
+			Image:                 "centos/postgresql-10-centos7",
+			DatabaseMemoryLimit:   "1Gi",
+			DatabaseMemoryRequest: "512Mi",
+			Parameters: map[string]string{
+				"work_mem": "8MB",
+			},
+			NodeSelector: map[string]string{
+				"disktype": "ssd",
+			},
+		},
+	}
+
+	dbInstanceWithDeletionPolicy = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			DeletionPolicy: "Retain",
+		},
+	}
+
+	dbInstanceWithClass = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.DatabaseSpec{
+			ClassName:           "small",
+			DatabaseMemoryLimit: "2Gi",
+		},
+	}
+)
+
+// fakeSQLExecutor mocks the service.SQLExecutor since the fake client cannot exec in pods
+type fakeSQLExecutor struct {
+	// serviceReady is the result of the health check through the Service
+	serviceReady bool
+	// lsn is the last WAL location received by each standby pod
+	lsn map[string]string
+	// promoted has the name of the pods promoted
+	promoted []string
+	// archiver is the row of the pg_stat_archiver returned to the operator
+	archiver string
+	// files has the content of the files found in the pods by the path
+	files map[string]string
+	// reloaded has the name of the pods where the configuration was reloaded
+	reloaded []string
+	// statements has the SQL statements which changed the roles and databases
+	statements []string
+	// failSQL makes fail the statements which contain it
+	failSQL string
+}
+
+func (e *fakeSQLExecutor) Exec(pod *corev1.Pod, container string, command []string) (string, error) {
+	switch command[0] {
+	case "pg_isready":
+		if !e.serviceReady {
+			return "", fmt.Errorf("no response")
+		}
+		return "accepting connections", nil
+	case "pg_ctl":
+		e.promoted = append(e.promoted, pod.Name)
+		return "server promoting", nil
+	case "cat":
+		if content, ok := e.files[command[1]]; ok {
+			return content, nil
+		}
+		return "", fmt.Errorf("cat: %v: No such file or directory", command[1])
+	case "psql":
+		switch command[2] {
+		case "SHOW server_version_num":
+			return "90624", nil
+		case "SHOW data_directory":
+			return "/var/lib/pgsql/data/userdata", nil
+		case "SELECT pg_last_xlog_receive_location()":
+			return e.lsn[pod.Name], nil
+		case "SELECT pg_reload_conf()":
+			e.reloaded = append(e.reloaded, pod.Name)
+			return "t", nil
+		}
+		if strings.HasSuffix(command[2], "FROM pg_stat_archiver") {
+			return e.archiver, nil
+		}
+		if strings.HasPrefix(command[2], "SELECT 1 FROM pg_database") {
+			return "", nil
+		}
+		for _, prefix := range []string{"DO $$", "GRANT", "CREATE DATABASE", "ALTER DATABASE", "ALTER ROLE"} {
+			if strings.HasPrefix(command[2], prefix) {
+				if e.failSQL != "" && strings.Contains(command[2], e.failSQL) {
+					return "", fmt.Errorf("ERROR: permission denied")
+				}
+				e.statements = append(e.statements, command[2])
+				return "", nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unexpected command %v", command)
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/resource"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Phases of the change of the parameters shown in the status
+const (
+	parametersReloading  = "Reloading"
+	parametersRestarting = "Restarting"
+	parametersApplied    = "Applied"
+)
+
+// parametersCheckInterval is the interval used to check if the pods have the parameters changed in order to reload them
+// NOTE: The kubelet takes some time to update the files of the ConfigMap mounted in the pods
+const parametersCheckInterval = 10 * time.Second
+
+// manageParameters will ensure that the ConfigMap included in the postgresql.conf has the spec.parameters and
+// stores the change in the status
+// NOTE: The invalid parameters are reported in the status and the ConfigMap keeps the last ones which were valid, so
+// they replace the spec.parameters in the Database informed which is used to create and manage the workloads.
+// The parameters which require the restart change the hash in the pod template so the workloads are restarted by the
+// ensureDeploymentsSpec and ensureStatefulSetSpec. The others are reloaded by updateParametersStatus.
+func (r *ReconcileDatabase) manageParameters(db *v1alpha1.Database) error {
+	desired := resource.NewDatabaseParametersConfigMap(db, r.scheme)
+	cm, err := service.FetchConfigMap(desired.Name, desired.Namespace, r.client)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	// The ConfigMap is only created when some parameter is informed
+	created := errors.IsNotFound(err)
+
+	if utils.ValidateParameters(db) != nil {
+		db.Spec.Parameters = nil
+		if !created {
+			db.Spec.Parameters = utils.ParseParametersConf(cm.Data[utils.ParametersConfKey])
+		}
+		return nil
+	}
+
+	if created && !utils.HasParameters(db) {
+		return nil
+	}
+	if !created && cm.Data[utils.ParametersConfKey] == desired.Data[utils.ParametersConfKey] {
+		return nil
+	}
+
+	var current map[string]string
+	if created {
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return err
+		}
+	} else {
+		current = utils.ParseParametersConf(cm.Data[utils.ParametersConfKey])
+		cm.Data = desired.Data
+		if err := r.client.Update(context.TODO(), cm); err != nil {
+			return err
+		}
+	}
+
+	workloadFound, err := r.isPrimaryWorkloadFound(db)
+	if err != nil {
+		return err
+	}
+
+	reload, restart := utils.GetChangedParameters(current, db.Spec.Parameters)
+	status := &v1alpha1.ParametersStatus{
+		ConfigMapName: desired.Name,
+		Phase:         parametersReloading,
+		Reload:        reload,
+		Restart:       restart,
+		ChangeTime:    metav1.Now(),
+	}
+	switch {
+	case !workloadFound:
+		// The pods are created with the parameters
+		status.Phase = parametersApplied
+		status.AppliedTime = &status.ChangeTime
+	case len(restart) > 0 || created || !utils.HasParameters(db):
+		// The ConfigMap is mounted or unmounted by the pod template
+		status.Phase = parametersRestarting
+	}
+
+	if r.recorder != nil && workloadFound {
+		r.recorder.Eventf(db, corev1.EventTypeNormal, "ParametersChanged", "Parameters changed %v applied by reload and %v by restart.", reload, restart)
+	}
+	db.Status.Parameters = status
+	return r.updateStatus(db)
+}
+
+//updateParametersStatus returns error when status regards the change of the parameters could not be updated
+//NOTE: The parameters are reloaded when all pods have the file of the ConfigMap updated
+func (r *ReconcileDatabase) updateParametersStatus(request reconcile.Request) error {
+	db, err := service.FetchDatabaseCR(request.Name, request.Namespace, r.client)
+	if err != nil {
+		return err
+	}
+	if db.Status.Parameters == nil || db.Status.Parameters.Phase == parametersApplied {
+		return nil
+	}
+	service.AddDatabaseMandatorySpecs(db, r.client)
+
+	switch db.Status.Parameters.Phase {
+	case parametersReloading:
+		if r.executor == nil {
+			return nil
+		}
+		conf := strings.TrimSpace(utils.BuildParametersConf(db.Spec.Parameters))
+		reloaded, err := r.reloadConfiguration(db, utils.ParametersConfPath+"/"+utils.ParametersConfKey, conf)
+		if err != nil || !reloaded {
+			return err
+		}
+		if r.recorder != nil {
+			r.recorder.Eventf(db, corev1.EventTypeNormal, "ParametersReloaded", "Configuration reloaded to apply the parameters %v.", db.Status.Parameters.Reload)
+		}
+	case parametersRestarting:
+		for _, name := range getMemberWorkloads(db) {
+			complete, err := r.isWorkloadRolledOut(db, name)
+			if err != nil || !complete {
+				return err
+			}
+		}
+		if r.recorder != nil {
+			r.recorder.Eventf(db, corev1.EventTypeNormal, "ParametersRestarted", "Database restarted to apply the parameters %v.", db.Status.Parameters.Restart)
+		}
+	}
+
+	now := metav1.Now()
+	db.Status.Parameters.Phase = parametersApplied
+	db.Status.Parameters.AppliedTime = &now
+	return r.updateStatus(db)
+}
+
+// reloadConfiguration runs the pg_reload_conf in the pods of the Database and returns false when some of them is not
+// ready or does not have the file of the ConfigMap with the content informed yet
+func (r *ReconcileDatabase) reloadConfiguration(db *v1alpha1.Database, file, conf string) (bool, error) {
+	podList := &corev1.PodList{}
+	listOps := &client.ListOptions{Namespace: db.Namespace, LabelSelector: labels.SelectorFromSet(utils.GetLabels(db.Name))}
+	if err := r.client.List(context.TODO(), podList, listOps); err != nil {
+		return false, err
+	}
+
+	var pods []*corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp != nil || getPodMember(db, pod) == "" && (utils.IsReplicationEnabled(db) || utils.IsStatefulSet(db)) {
+			continue
+		}
+		if !isPodReady(pod) {
+			return false, nil
+		}
+		out, err := r.executor.Exec(pod, db.Spec.ContainerName, []string{"cat", file})
+		if err != nil || out != conf {
+			return false, nil
+		}
+		pods = append(pods, pod)
+	}
+	if len(pods) == 0 {
+		return false, nil
+	}
+
+	for _, pod := range pods {
+		if _, err := service.Query(r.executor, pod, db.Spec.ContainerName, "SELECT pg_reload_conf()"); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// isPrimaryWorkloadFound returns true when the Deployment or StatefulSet of the Database was created
+func (r *ReconcileDatabase) isPrimaryWorkloadFound(db *v1alpha1.Database) (bool, error) {
+	var err error
+	if utils.IsStatefulSet(db) {
+		_, err = service.FetchStatefulSet(db.Name, db.Namespace, r.client)
+	} else {
+		_, err = service.FetchDeployment(db.Name, db.Namespace, r.client)
+	}
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// getMemberWorkloads returns the names of the Deployments of the primary and standbys or the name of the StatefulSet
+func getMemberWorkloads(db *v1alpha1.Database) []string {
+	names := []string{db.Name}
+	if utils.IsStatefulSet(db) {
+		return names
+	}
+	for i := 0; i < utils.GetStandbySize(db); i++ {
+		names = append(names, utils.GetStandbyName(db, i))
+	}
+	return names
+}
+
+// isParametersChanging returns true when the change of the parameters was not applied yet
+func isParametersChanging(db *v1alpha1.Database) bool {
+	return db.Status.Parameters != nil && db.Status.Parameters.Phase != parametersApplied
+}
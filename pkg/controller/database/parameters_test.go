@@ -0,0 +1,231 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileDatabase_Parameters(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		dbInstanceWithParameters.DeepCopy(),
+		podPrimaryReady.DeepCopy(),
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+	executor := &fakeSQLExecutor{files: map[string]string{}}
+	r.executor = executor
+	confPath := utils.ParametersConfPath + "/" + utils.ParametersConfKey
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithParameters.Name,
+			Namespace: dbInstanceWithParameters.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	cm, err := service.FetchConfigMap(req.Name+utils.ParametersSuffix, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get configmap: (%v)", err)
+	}
+	conf := cm.Data[utils.ParametersConfKey]
+	if !strings.Contains(conf, "max_connections = '100'\n") || !strings.Contains(conf, "work_mem = '4MB'\n") {
+		t.Errorf("ConfigMap got (%v), when is expected the parameters of the spec", conf)
+	}
+
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	hash := dep.Spec.Template.Annotations[utils.RestartHashAnnotation]
+	if hash == "" || len(dep.Spec.Template.Spec.Volumes) != 2 || len(dep.Spec.Template.Spec.Containers[0].VolumeMounts) != 2 {
+		t.Fatalf("Deployment got the annotations (%v) and volumes (%v), when is expected the ConfigMap mounted",
+			dep.Spec.Template.Annotations, dep.Spec.Template.Spec.Volumes)
+	}
+
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.Parameters == nil || db.Status.Parameters.Phase != parametersApplied {
+		t.Fatalf("Parameters got (%v), when is expected the phase (%v)", db.Status.Parameters, parametersApplied)
+	}
+
+	// Change a parameter applied by reload
+	db.Spec.Parameters["work_mem"] = "8MB"
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.Parameters.Phase != parametersReloading || !reflect.DeepEqual(db.Status.Parameters.Reload, []string{"work_mem"}) {
+		t.Fatalf("Parameters got (%v), when is expected the phase (%v) with (%v)", db.Status.Parameters, parametersReloading, "work_mem")
+	}
+	dep, err = service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if got := dep.Spec.Template.Annotations[utils.RestartHashAnnotation]; got != hash {
+		t.Errorf("Deployment restart hash got (%v), when is expected (%v) since none parameter requires the restart", got, hash)
+	}
+	if len(executor.reloaded) != 0 {
+		t.Errorf("Reloaded got (%v), when is expected none since the pod does not have the file updated", executor.reloaded)
+	}
+
+	// Simulate the file of the ConfigMap updated in the pod
+	cm, err = service.FetchConfigMap(req.Name+utils.ParametersSuffix, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get configmap: (%v)", err)
+	}
+	executor.files[confPath] = strings.TrimSpace(cm.Data[utils.ParametersConfKey])
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.Parameters.Phase != parametersApplied || !reflect.DeepEqual(executor.reloaded, []string{podPrimaryReady.Name}) {
+		t.Fatalf("Parameters got (%v) and reloaded (%v), when is expected the phase (%v) with the pod reloaded",
+			db.Status.Parameters, executor.reloaded, parametersApplied)
+	}
+
+	// Change a parameter which requires the restart
+	db.Spec.Parameters["max_connections"] = "200"
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.Parameters.Phase != parametersRestarting || !reflect.DeepEqual(db.Status.Parameters.Restart, []string{"max_connections"}) {
+		t.Fatalf("Parameters got (%v), when is expected the phase (%v) with (%v)", db.Status.Parameters, parametersRestarting, "max_connections")
+	}
+	dep, err = service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if got := dep.Spec.Template.Annotations[utils.RestartHashAnnotation]; got == hash {
+		t.Errorf("Deployment restart hash got (%v), when is expected a new one in order to restart the pods", got)
+	}
+	hash = dep.Spec.Template.Annotations[utils.RestartHashAnnotation]
+
+	// Simulate the pods of the Deployment restarted
+	dep.Status.Replicas = 1
+	dep.Status.UpdatedReplicas = 1
+	dep.Status.AvailableReplicas = 1
+	if err := r.client.Status().Update(context.TODO(), dep); err != nil {
+		t.Fatalf("fails when try to update the deployment status: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.Parameters.Phase != parametersApplied || db.Status.Parameters.AppliedTime == nil {
+		t.Errorf("Parameters got (%v), when is expected the phase (%v)", db.Status.Parameters, parametersApplied)
+	}
+
+	// The invalid parameters are refused and the ConfigMap keeps the last ones which were valid
+	db.Spec.Parameters["port"] = "5433"
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.Phase != phaseFailed || !strings.Contains(db.Status.DatabaseStatus, "(port)") {
+		t.Errorf("Status got (%v, %v), when is expected the phase (%v) with the parameter refused", db.Status.Phase, db.Status.DatabaseStatus, phaseFailed)
+	}
+	cm, err = service.FetchConfigMap(req.Name+utils.ParametersSuffix, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get configmap: (%v)", err)
+	}
+	if strings.Contains(cm.Data[utils.ParametersConfKey], "port") {
+		t.Errorf("ConfigMap got (%v), when is expected the parameters which were valid", cm.Data[utils.ParametersConfKey])
+	}
+	dep, err = service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if got := dep.Spec.Template.Annotations[utils.RestartHashAnnotation]; got != hash {
+		t.Errorf("Deployment restart hash got (%v), when is expected the one of the parameters which were valid", got)
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "should accept the known parameters with valid values",
+			parameters: map[string]string{"shared_buffers": "128MB", "statement_timeout": "30s", "log_statement": "ddl", "autovacuum": "on"},
+			wantErr:    false,
+		},
+		{
+			name:       "should refuse the parameters managed by the operator",
+			parameters: map[string]string{"archive_command": "/bin/true"},
+			wantErr:    true,
+		},
+		{
+			name:       "should refuse the unknown parameters",
+			parameters: map[string]string{"unknown_parameter": "1"},
+			wantErr:    true,
+		},
+		{
+			name:       "should refuse the invalid values",
+			parameters: map[string]string{"max_connections": "many"},
+			wantErr:    true,
+		},
+		{
+			name:       "should refuse the memory without a valid unit",
+			parameters: map[string]string{"work_mem": "8MiB"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbInstanceWithParameters.DeepCopy()
+			db.Spec.Parameters = tt.parameters
+			if err := utils.ValidateParameters(db); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateParameters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/resource"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Phases of the roles and databases shown in the status
+const (
+	objectPending = "Pending"
+	objectReady   = "Ready"
+	objectFailed  = "Failed"
+)
+
+// serverObjectsCheckInterval is the interval used to apply again the roles and databases which are pending or failed
+const serverObjectsCheckInterval = 30 * time.Second
+
+// manageRoles will ensure that the roles and databases of the spec exist in the database server with the attributes,
+// owner and grants informed and stores their state in the status
+// NOTE: They are applied in the primary only when their spec (or the Secret of the role) changed since the last time.
+// The roles and databases removed from the spec are not dropped in order to keep the data.
+func (r *ReconcileDatabase) manageRoles(db *v1alpha1.Database) error {
+	if utils.ValidateRoles(db) != nil || isUpgradeInProgress(db) {
+		return nil
+	}
+
+	pod, _, err := r.fetchPrimaryPodAndReadyMembers(db)
+	if err != nil {
+		return err
+	}
+	if pod != nil && (!isPodReady(pod) || r.executor == nil) {
+		pod = nil
+	}
+
+	roles, err := r.applyRoles(db, pod)
+	if err != nil {
+		return err
+	}
+	databases := r.applyDatabases(db, pod)
+
+	// Check if the state of the roles and databases changed, if yes update it
+	if reflect.DeepEqual(roles, db.Status.Roles) && reflect.DeepEqual(databases, db.Status.Databases) {
+		return nil
+	}
+	db.Status.Roles = roles
+	db.Status.Databases = databases
+	return r.updateStatus(db)
+}
+
+// applyRoles creates or updates the roles in the primary and returns their state
+// NOTE: The memberships are granted after all roles be created since they may refer to each other
+func (r *ReconcileDatabase) applyRoles(db *v1alpha1.Database, pod *corev1.Pod) ([]v1alpha1.ServerObjectStatus, error) {
+	var statuses []v1alpha1.ServerObjectStatus
+	var applied []int
+	for _, role := range db.Spec.Roles {
+		status := v1alpha1.ServerObjectStatus{Name: role.Name, Phase: objectPending}
+		password, version := "", ""
+		if utils.IsRoleLogin(role) {
+			secret, err := r.ensureRoleSecret(db, role.Name)
+			if err != nil {
+				return nil, err
+			}
+			status.SecretName = secret.Name
+			password, version = string(secret.Data["password"]), secret.ResourceVersion
+			// The password of the Secret belongs to the alternate role of the rotation, so the role keeps its own
+			if user := string(secret.Data["username"]); user != "" && user != role.Name {
+				password = ""
+			}
+		}
+		status.Hash = utils.GetHash([]interface{}{role, version})
+
+		if previous := findServerObjectStatus(db.Status.Roles, role.Name); previous != nil && previous.Hash == status.Hash && previous.Phase == objectReady {
+			statuses = append(statuses, *previous)
+			continue
+		}
+		if pod == nil {
+			status.Message = "Waiting for the primary to be ready"
+			statuses = append(statuses, status)
+			continue
+		}
+
+		if _, err := service.Query(r.executor, pod, db.Spec.ContainerName, utils.BuildRoleSQL(role, password)); err != nil {
+			r.failServerObject(db, &status, "role", err)
+		} else {
+			applied = append(applied, len(statuses))
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, i := range applied {
+		role := db.Spec.Roles[i]
+		if len(role.InRoles) > 0 {
+			if _, err := service.Query(r.executor, pod, db.Spec.ContainerName, utils.BuildRoleMembershipSQL(role)); err != nil {
+				r.failServerObject(db, &statuses[i], "role", err)
+				continue
+			}
+		}
+		now := metav1.Now()
+		statuses[i].Phase = objectReady
+		statuses[i].LastAppliedTime = &now
+	}
+	return statuses, nil
+}
+
+// applyDatabases creates or updates the databases in the primary and returns their state
+func (r *ReconcileDatabase) applyDatabases(db *v1alpha1.Database, pod *corev1.Pod) []v1alpha1.ServerObjectStatus {
+	var statuses []v1alpha1.ServerObjectStatus
+	for _, database := range db.Spec.Databases {
+		status := v1alpha1.ServerObjectStatus{Name: database.Name, Phase: objectPending, Hash: utils.GetHash(database)}
+		if previous := findServerObjectStatus(db.Status.Databases, database.Name); previous != nil && previous.Hash == status.Hash && previous.Phase == objectReady {
+			statuses = append(statuses, *previous)
+			continue
+		}
+		if pod == nil {
+			status.Message = "Waiting for the primary to be ready"
+			statuses = append(statuses, status)
+			continue
+		}
+
+		if err := r.applyDatabase(db, pod, database); err != nil {
+			r.failServerObject(db, &status, "database", err)
+		} else {
+			now := metav1.Now()
+			status.Phase = objectReady
+			status.LastAppliedTime = &now
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// applyDatabase creates the database when it does not exist and sets its owner and grants
+func (r *ReconcileDatabase) applyDatabase(db *v1alpha1.Database, pod *corev1.Pod, database v1alpha1.LogicalDatabase) error {
+	exists, err := service.Query(r.executor, pod, db.Spec.ContainerName, utils.BuildDatabaseExistsSQL(database))
+	if err != nil {
+		return err
+	}
+	if exists != "1" {
+		if _, err := service.Query(r.executor, pod, db.Spec.ContainerName, utils.BuildCreateDatabaseSQL(database)); err != nil {
+			return err
+		}
+	}
+	_, err = service.Query(r.executor, pod, db.Spec.ContainerName, utils.BuildDatabaseSQL(database))
+	return err
+}
+
+// ensureRoleSecret returns the Secret with the credentials of the role and creates it with a new password when it is not found
+func (r *ReconcileDatabase) ensureRoleSecret(db *v1alpha1.Database, role string) (*corev1.Secret, error) {
+	secret, err := service.FetchSecret(db.Namespace, utils.GetRoleSecretName(db, role), r.client)
+	if err == nil && len(secret.Data["password"]) > 0 {
+		return secret, nil
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	password, err := utils.GeneratePassword(utils.RolePasswordLength)
+	if err != nil {
+		return nil, err
+	}
+	desired := resource.NewDatabaseRoleSecret(db, role, password, r.scheme)
+	if secret != nil && secret.Name != "" {
+		// The Secret was found without the password
+		secret.Data = desired.Data
+		return secret, r.client.Update(context.TODO(), secret)
+	}
+	return desired, r.client.Create(context.TODO(), desired)
+}
+
+// failServerObject sets the error in the state of the role or database and publishes it as an event
+func (r *ReconcileDatabase) failServerObject(db *v1alpha1.Database, status *v1alpha1.ServerObjectStatus, kind string, err error) {
+	status.Phase = objectFailed
+	status.Message = fmt.Sprintf("Error: Unable to apply the %v: %v", kind, err)
+	if r.recorder != nil {
+		r.recorder.Eventf(db, corev1.EventTypeWarning, "ServerObjectFailed", "Unable to apply the %v %v: %v", kind, status.Name, err)
+	}
+}
+
+// findServerObjectStatus returns the state of the role or database with the name informed
+func findServerObjectStatus(statuses []v1alpha1.ServerObjectStatus, name string) *v1alpha1.ServerObjectStatus {
+	for i := range statuses {
+		if statuses[i].Name == name {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// isServerObjectsPending returns true when some role or database was not applied yet
+func isServerObjectsPending(db *v1alpha1.Database) bool {
+	for _, s := range append(append([]v1alpha1.ServerObjectStatus{}, db.Status.Roles...), db.Status.Databases...) {
+		if s.Phase != objectReady {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileDatabase_Roles(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		dbInstanceWithRoles.DeepCopy(),
+		podPrimaryReady.DeepCopy(),
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+	executor := &fakeSQLExecutor{}
+	r.executor = executor
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithRoles.Name,
+			Namespace: dbInstanceWithRoles.Namespace,
+		},
+	}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if res.RequeueAfter != 0 {
+		t.Errorf("Reconcile requeue got (%v), when is expected none since all objects are ready", res.RequeueAfter)
+	}
+
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	for _, s := range append(append([]v1alpha1.ServerObjectStatus{}, db.Status.Roles...), db.Status.Databases...) {
+		if s.Phase != objectReady {
+			t.Errorf("Status of (%v) got the phase (%v), when is expected (%v): %v", s.Name, s.Phase, objectReady, s.Message)
+		}
+	}
+
+	// The statements are applied in the order of the spec with the memberships after the roles
+	expected := []string{`CREATE ROLE "app"`, `CREATE ROLE "readers"`, `GRANT "readers" TO "app";`,
+		`CREATE DATABASE "appdb" OWNER "app"`, `GRANT CONNECT ON DATABASE "appdb" TO "readers";`}
+	if len(executor.statements) != len(expected) {
+		t.Fatalf("Statements got (%v), when is expected (%v)", executor.statements, expected)
+	}
+	for i, statement := range expected {
+		if !strings.Contains(executor.statements[i], statement) {
+			t.Errorf("Statement got (%v), when is expected to contain (%v)", executor.statements[i], statement)
+		}
+	}
+
+	// Only the role which can log in has a Secret with its credentials
+	secret, err := service.FetchSecret(req.Namespace, utils.GetRoleSecretName(db, "app"), r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	password := string(secret.Data["password"])
+	if len(password) != utils.RolePasswordLength || !strings.Contains(executor.statements[0], "PASSWORD '"+password+"'") {
+		t.Errorf("Secret password got (%v), when is expected the one applied in the role", password)
+	}
+	if db.Status.Roles[0].SecretName != secret.Name {
+		t.Errorf("Status secretName got (%v), when is expected (%v)", db.Status.Roles[0].SecretName, secret.Name)
+	}
+	if _, err := service.FetchSecret(req.Namespace, utils.GetRoleSecretName(db, "readers"), r.client); !errors.IsNotFound(err) {
+		t.Errorf("Secret of the role without login got the error (%v), when is expected not found", err)
+	}
+
+	// Nothing is applied again when the spec and the Secret did not change
+	executor.statements = nil
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if len(executor.statements) != 0 {
+		t.Errorf("Statements got (%v), when is expected none", executor.statements)
+	}
+
+	// The password changed in the Secret is applied in the role
+	secret.Data["password"] = []byte("changed")
+	if err := r.client.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("fails when try to update the secret: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if len(executor.statements) != 2 || !strings.Contains(executor.statements[0], "PASSWORD 'changed'") {
+		t.Errorf("Statements got (%v), when is expected the role app with the new password", executor.statements)
+	}
+
+	// The failure is shown in the status of the object
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	db.Spec.Databases[0].Owner = "readers"
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	executor.failSQL = `OWNER TO "readers"`
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if s := db.Status.Databases[0]; s.Phase != objectFailed || !strings.Contains(s.Message, "permission denied") {
+		t.Errorf("Status of the database got (%v), when is expected the phase (%v) with the error", s, objectFailed)
+	}
+	if res.RequeueAfter != serverObjectsCheckInterval {
+		t.Errorf("Reconcile requeue got (%v), when is expected (%v)", res.RequeueAfter, serverObjectsCheckInterval)
+	}
+}
+
+func TestReconcileDatabase_RolesPrimaryNotReady(t *testing.T) {
+	objs := []runtime.Object{
+		dbInstanceWithRoles.DeepCopy(),
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+	r.executor = &fakeSQLExecutor{}
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithRoles.Name,
+			Namespace: dbInstanceWithRoles.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if len(db.Status.Roles) != 2 || db.Status.Roles[0].Phase != objectPending {
+		t.Errorf("Status roles got (%v), when is expected the phase (%v)", db.Status.Roles, objectPending)
+	}
+	// The Secret is created before the role in order to allow the applications use it
+	if _, err := service.FetchSecret(req.Namespace, utils.GetRoleSecretName(db, "app"), r.client); err != nil {
+		t.Errorf("get secret: (%v)", err)
+	}
+}
+
+func TestValidateRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    v1alpha1.DatabaseSpec
+		wantErr bool
+	}{
+		{
+			name:    "should accept the roles and databases of the mock",
+			spec:    dbInstanceWithRoles.Spec,
+			wantErr: false,
+		},
+		{
+			name:    "should refuse the names which require to be escaped",
+			spec:    v1alpha1.DatabaseSpec{Roles: []v1alpha1.DatabaseRole{{Name: `app"; DROP ROLE postgres; --`}}},
+			wantErr: true,
+		},
+		{
+			name:    "should refuse the role created by the image",
+			spec:    v1alpha1.DatabaseSpec{DatabaseUser: "admin", Roles: []v1alpha1.DatabaseRole{{Name: "admin"}}},
+			wantErr: true,
+		},
+		{
+			name:    "should refuse the duplicated databases",
+			spec:    v1alpha1.DatabaseSpec{Databases: []v1alpha1.LogicalDatabase{{Name: "appdb"}, {Name: "appdb"}}},
+			wantErr: true,
+		},
+		{
+			name: "should refuse the invalid privileges",
+			spec: v1alpha1.DatabaseSpec{Databases: []v1alpha1.LogicalDatabase{
+				{Name: "appdb", Grants: []v1alpha1.DatabaseGrant{{Role: "app", Privileges: []string{"SUPERUSER"}}}},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &v1alpha1.Database{Spec: tt.spec}
+			if err := utils.ValidateRoles(db); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRoles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
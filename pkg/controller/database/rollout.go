@@ -0,0 +1,373 @@
+package database
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/resource"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	"k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Phases of the rollout shown in the status
+const (
+	rolloutProgressing = "Progressing"
+	rolloutComplete    = "Complete"
+)
+
+// ensureDeploymentsSpec will ensure that the Deployments of the primary and standbys have the spec generated from the CR
+// and returns the names of the Deployments updated with the fields changed
+// NOTE: Only the fields generated by the operator are compared so the defaults set by the cluster are not a drift. The
+// fields removed from the template are found by its hash stored in the annotation. The replicas are managed by
+// ensureDepSize and the selector is immutable.
+func (r *ReconcileDatabase) ensureDeploymentsSpec(db *v1alpha1.Database) ([]string, []string, error) {
+	desired := []*v1.Deployment{resource.NewDatabaseDeployment(db, r.scheme)}
+	for i := 0; i < utils.GetStandbySize(db); i++ {
+		desired = append(desired, resource.NewDatabaseStandbyDeployment(db, i, r.scheme))
+	}
+
+	var updated, changes []string
+	for _, d := range desired {
+		dep, err := service.FetchDeployment(d.Name, d.Namespace, r.client)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, nil, err
+		}
+
+		templateChanges := getTemplateChanges(dep.Spec.Template, d.Spec.Template)
+		templateChanges = appendTemplateHashChange(templateChanges, dep.Annotations, d.Annotations)
+		isStrategyApplied := equality.Semantic.DeepDerivative(d.Spec.Strategy, dep.Spec.Strategy)
+		if len(templateChanges) == 0 && isStrategyApplied && isLabelsApplied(dep.Labels, d.Labels) &&
+			isLabelsApplied(dep.Annotations, d.Annotations) {
+			continue
+		}
+
+		dep.Spec.Template = d.Spec.Template
+		dep.Spec.Strategy = d.Spec.Strategy
+		dep.Labels = mergeLabels(dep.Labels, d.Labels)
+		dep.Annotations = mergeLabels(dep.Annotations, d.Annotations)
+		if err := r.client.Update(context.TODO(), dep); err != nil {
+			return nil, nil, err
+		}
+		if len(templateChanges) > 0 {
+			updated = append(updated, dep.Name)
+			changes = appendMissing(changes, templateChanges...)
+		}
+	}
+	return updated, changes, nil
+}
+
+// ensureStatefulSetSpec will ensure that the template of the StatefulSet is the one generated from the CR and returns
+// the fields changed when it was updated
+// NOTE: The volumeClaimTemplates of the StatefulSet are immutable
+func (r *ReconcileDatabase) ensureStatefulSetSpec(db *v1alpha1.Database, sts *v1.StatefulSet) ([]string, error) {
+	desired := resource.NewDatabaseStatefulSet(db, r.scheme)
+	changes := getTemplateChanges(sts.Spec.Template, desired.Spec.Template)
+	changes = appendTemplateHashChange(changes, sts.Annotations, desired.Annotations)
+	if len(changes) == 0 && isLabelsApplied(sts.Labels, desired.Labels) && isLabelsApplied(sts.Annotations, desired.Annotations) {
+		return nil, nil
+	}
+
+	sts.Spec.Template = desired.Spec.Template
+	sts.Labels = mergeLabels(sts.Labels, desired.Labels)
+	sts.Annotations = mergeLabels(sts.Annotations, desired.Annotations)
+	if err := r.client.Update(context.TODO(), sts); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// ensureServicesSpec will ensure that the Services of the Database have the ports, type and selector generated from the CR
+// NOTE: The clusterIP allocated by the cluster is kept
+func (r *ReconcileDatabase) ensureServicesSpec(db *v1alpha1.Database) error {
+	desired := []*corev1.Service{resource.NewDatabaseService(db, r.scheme)}
+	if utils.IsReplicationEnabled(db) {
+		desired = append(desired, resource.NewDatabaseReadOnlyService(db, r.scheme))
+	}
+	if utils.IsStatefulSet(db) {
+		desired = append(desired, resource.NewDatabaseHeadlessService(db, r.scheme))
+	}
+
+	for _, d := range desired {
+		ser, err := service.FetchService(d.Name, d.Namespace, r.client)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		if reflect.DeepEqual(ser.Spec.Selector, d.Spec.Selector) &&
+			equality.Semantic.DeepDerivative(d.Spec, ser.Spec) &&
+			isLabelsApplied(ser.Labels, d.Labels) {
+			continue
+		}
+
+		ser.Spec.Selector = d.Spec.Selector
+		ser.Spec.Ports = d.Spec.Ports
+		ser.Spec.Type = d.Spec.Type
+		ser.Spec.PublishNotReadyAddresses = d.Spec.PublishNotReadyAddresses
+		ser.Labels = mergeLabels(ser.Labels, d.Labels)
+		if err := r.client.Update(context.TODO(), ser); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensurePvcsSpec will ensure that the PersistentVolumeClaims of the Database have the labels generated from the CR
+// NOTE: The storage request is expanded by ensurePvcsStorage and the other fields of the spec are immutable
+func (r *ReconcileDatabase) ensurePvcsSpec(db *v1alpha1.Database) error {
+	if utils.IsStatefulSet(db) {
+		return nil
+	}
+
+	desired := []*corev1.PersistentVolumeClaim{resource.NewDatabasePvc(db, r.scheme)}
+	for i := 0; i < utils.GetStandbySize(db); i++ {
+		desired = append(desired, resource.NewDatabaseStandbyPvc(db, i, r.scheme))
+	}
+
+	for _, d := range desired {
+		pvc, err := service.FetchPersistentVolumeClaim(d.Name, d.Namespace, r.client)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if isLabelsApplied(pvc.Labels, d.Labels) {
+			continue
+		}
+		pvc.Labels = mergeLabels(pvc.Labels, d.Labels)
+		if err := r.client.Update(context.TODO(), pvc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getTemplateChanges returns the fields of the desired pod template which are not applied in the current one
+// E.g. database.image when the image of the container database changed
+func getTemplateChanges(current, desired corev1.PodTemplateSpec) []string {
+	var changes []string
+	if !reflect.DeepEqual(current.Labels, desired.Labels) {
+		changes = append(changes, "labels")
+	}
+	// The annotations added by others are not a drift. E.g. kubectl rollout restart
+	if !isLabelsApplied(current.Annotations, desired.Annotations) {
+		changes = append(changes, "annotations")
+	}
+
+	if len(current.Spec.Containers) != len(desired.Spec.Containers) {
+		changes = append(changes, "containers")
+	} else {
+		for i, d := range desired.Spec.Containers {
+			c := current.Spec.Containers[i]
+			fields := []struct {
+				name             string
+				desired, current interface{}
+			}{
+				{"name", d.Name, c.Name},
+				{"image", d.Image, c.Image},
+				{"imagePullPolicy", d.ImagePullPolicy, c.ImagePullPolicy},
+				{"command", d.Command, c.Command},
+				{"args", d.Args, c.Args},
+				{"ports", d.Ports, c.Ports},
+				{"env", d.Env, c.Env},
+				{"envFrom", d.EnvFrom, c.EnvFrom},
+				{"resources", d.Resources, c.Resources},
+				{"volumeMounts", d.VolumeMounts, c.VolumeMounts},
+				{"livenessProbe", d.LivenessProbe, c.LivenessProbe},
+				{"readinessProbe", d.ReadinessProbe, c.ReadinessProbe},
+			}
+			for _, f := range fields {
+				if !isFieldApplied(f.desired, f.current) {
+					changes = append(changes, d.Name+"."+f.name)
+				}
+			}
+		}
+	}
+
+	if !isFieldApplied(desired.Spec.Volumes, current.Spec.Volumes) {
+		changes = append(changes, "volumes")
+	}
+
+	// The scheduling constraints removed from the spec or the DatabaseClass are a drift as well
+	if !equality.Semantic.DeepEqual(desired.Spec.NodeSelector, current.Spec.NodeSelector) ||
+		!equality.Semantic.DeepEqual(desired.Spec.Tolerations, current.Spec.Tolerations) ||
+		!equality.Semantic.DeepEqual(desired.Spec.Affinity, current.Spec.Affinity) {
+		changes = append(changes, "scheduling")
+	}
+
+	// Any other field of the pod generated by the operator. E.g. the restartPolicy
+	if len(changes) == 0 && !equality.Semantic.DeepDerivative(desired.Spec, current.Spec) {
+		changes = append(changes, "template")
+	}
+	return changes
+}
+
+// isFieldApplied returns true when the desired value of the field is found in the current one
+// NOTE: The defaults set by the cluster are not a drift, but the items of the lists and the objects removed are
+func isFieldApplied(desired, current interface{}) bool {
+	d, c := reflect.ValueOf(desired), reflect.ValueOf(current)
+	switch d.Kind() {
+	case reflect.Slice:
+		if d.Len() != c.Len() {
+			return false
+		}
+	case reflect.Ptr:
+		if d.IsNil() != c.IsNil() {
+			return false
+		}
+	}
+	return equality.Semantic.DeepDerivative(desired, current)
+}
+
+// appendTemplateHashChange returns the changes with the template when its hash stored in the annotation of the
+// workload is not the desired one and none field changed was found. E.g. an optional field removed from the template
+// NOTE: The workloads created before the annotation do not have the hash, so it is only stored
+func appendTemplateHashChange(changes []string, current, desired map[string]string) []string {
+	hash := current[utils.TemplateHashAnnotation]
+	if len(changes) > 0 || hash == "" || hash == desired[utils.TemplateHashAnnotation] {
+		return changes
+	}
+	return append(changes, "template")
+}
+
+// isLabelsApplied returns true when all desired labels are found with the same value
+// NOTE: The labels added by others are not a drift
+func isLabelsApplied(current, desired map[string]string) bool {
+	for k, v := range desired {
+		if current[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeLabels returns the current labels with the desired ones
+func mergeLabels(current, desired map[string]string) map[string]string {
+	if current == nil {
+		current = map[string]string{}
+	}
+	for k, v := range desired {
+		current[k] = v
+	}
+	return current
+}
+
+// appendMissing returns the values appended when they are not found in the slice
+func appendMissing(values []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, v := range values {
+			if v == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// recordRollout stores in the status the rollout started by the update of the workloads
+// NOTE: When the previous rollout is still in progress its workloads and changes are kept until all of them be complete
+func (r *ReconcileDatabase) recordRollout(db *v1alpha1.Database, workloads, changes []string) error {
+	rollout := &v1alpha1.RolloutStatus{
+		Generation: db.Generation,
+		Phase:      rolloutProgressing,
+		StartTime:  metav1.Now(),
+		Workloads:  workloads,
+		Changes:    changes,
+	}
+	if previous := db.Status.Rollout; previous != nil && previous.Phase == rolloutProgressing {
+		rollout.Workloads = appendMissing(append([]string{}, previous.Workloads...), workloads...)
+		rollout.Changes = appendMissing(append([]string{}, previous.Changes...), changes...)
+	}
+
+	if r.recorder != nil {
+		r.recorder.Eventf(db, corev1.EventTypeNormal, "RolloutStarted", "Workloads %v updated to apply the changes %v.", workloads, changes)
+	}
+	db.Status.Rollout = rollout
+	return r.updateStatus(db)
+}
+
+//updateRolloutStatus returns error when status regards the rollout of the workloads could not be updated
+func (r *ReconcileDatabase) updateRolloutStatus(request reconcile.Request) error {
+	db, err := service.FetchDatabaseCR(request.Name, request.Namespace, r.client)
+	if err != nil {
+		return err
+	}
+	if db.Status.Rollout == nil || db.Status.Rollout.Phase != rolloutProgressing {
+		return nil
+	}
+
+	for _, name := range db.Status.Rollout.Workloads {
+		complete, err := r.isWorkloadRolledOut(db, name)
+		if err != nil || !complete {
+			return err
+		}
+	}
+
+	now := metav1.Now()
+	db.Status.Rollout.Phase = rolloutComplete
+	db.Status.Rollout.CompletionTime = &now
+	if r.recorder != nil {
+		r.recorder.Eventf(db, corev1.EventTypeNormal, "RolloutComplete", "Workloads %v updated and available.", db.Status.Rollout.Workloads)
+	}
+	return r.updateStatus(db)
+}
+
+// isWorkloadRolledOut returns true when all pods of the Deployment or StatefulSet are updated and available
+// NOTE: The workload which no longer exists is considered complete. E.g. a standby removed
+func (r *ReconcileDatabase) isWorkloadRolledOut(db *v1alpha1.Database, name string) (bool, error) {
+	if utils.IsStatefulSet(db) {
+		sts, err := service.FetchStatefulSet(name, db.Namespace, r.client)
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if sts.Spec.Replicas != nil {
+			replicas = *sts.Spec.Replicas
+		}
+		return sts.Status.ObservedGeneration >= sts.Generation &&
+			sts.Status.UpdatedReplicas == replicas &&
+			sts.Status.ReadyReplicas == replicas, nil
+	}
+
+	dep, err := service.FetchDeployment(name, db.Namespace, r.client)
+	if errors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	replicas := int32(1)
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+	return dep.Status.ObservedGeneration >= dep.Generation &&
+		dep.Status.UpdatedReplicas == replicas &&
+		dep.Status.AvailableReplicas == replicas &&
+		dep.Status.Replicas == replicas, nil
+}
+
+// isRolloutInProgress returns true when the workloads updated by the operator are not available yet
+func isRolloutInProgress(db *v1alpha1.Database) bool {
+	return db.Status.Rollout != nil && db.Status.Rollout.Phase == rolloutProgressing
+}
@@ -0,0 +1,419 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileDatabase_SpecDrift(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		&dbInstanceWithoutSpec,
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithoutSpec.Name,
+			Namespace: dbInstanceWithoutSpec.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.Rollout != nil {
+		t.Fatalf("Rollout got (%v), when is expected none since the objects were just created", db.Status.Rollout)
+	}
+
+	// Change the spec of the Database already created
+	db.Spec.Image = "centos/postgresql-12-centos7"
+	db.Spec.DatabaseMemoryLimit = "1Gi"
+	db.Spec.DatabasePort = 5433
+	db.Spec.ContainerImagePullPolicy = corev1.PullIfNotPresent
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	container := dep.Spec.Template.Spec.Containers[0]
+	if container.Image != db.Spec.Image {
+		t.Errorf("Deployment image got (%v), when is expected (%v)", container.Image, db.Spec.Image)
+	}
+	if container.ImagePullPolicy != db.Spec.ContainerImagePullPolicy {
+		t.Errorf("Deployment image pull policy got (%v), when is expected (%v)", container.ImagePullPolicy, db.Spec.ContainerImagePullPolicy)
+	}
+	if limit := container.Resources.Limits[corev1.ResourceMemory]; limit.Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Errorf("Deployment memory limit got (%v), when is expected (%v)", limit.String(), "1Gi")
+	}
+	if port := container.Ports[0].ContainerPort; port != db.Spec.DatabasePort {
+		t.Errorf("Deployment port got (%v), when is expected (%v)", port, db.Spec.DatabasePort)
+	}
+
+	ser, err := service.FetchService(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get service: (%v)", err)
+	}
+	if port := ser.Spec.Ports[0].Port; port != db.Spec.DatabasePort {
+		t.Errorf("Service port got (%v), when is expected (%v)", port, db.Spec.DatabasePort)
+	}
+
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	rollout := db.Status.Rollout
+	if rollout == nil || rollout.Phase != rolloutProgressing {
+		t.Fatalf("Rollout got (%v), when is expected the phase (%v)", rollout, rolloutProgressing)
+	}
+	if !reflect.DeepEqual(rollout.Workloads, []string{dep.Name}) {
+		t.Errorf("Rollout workloads got (%v), when is expected (%v)", rollout.Workloads, []string{dep.Name})
+	}
+	expectedChanges := []string{"database.image", "database.imagePullPolicy", "database.ports", "database.resources"}
+	if !reflect.DeepEqual(rollout.Changes, expectedChanges) {
+		t.Errorf("Rollout changes got (%v), when is expected (%v)", rollout.Changes, expectedChanges)
+	}
+
+	// Simulate the pods of the Deployment updated and available
+	dep.Status.Replicas = 1
+	dep.Status.UpdatedReplicas = 1
+	dep.Status.AvailableReplicas = 1
+	if err := r.client.Status().Update(context.TODO(), dep); err != nil {
+		t.Fatalf("fails when try to update the deployment status: (%v)", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.Rollout.Phase != rolloutComplete || db.Status.Rollout.CompletionTime == nil {
+		t.Errorf("Rollout got (%v), when is expected the phase (%v) with the completion time", db.Status.Rollout, rolloutComplete)
+	}
+}
+
+func TestReconcileDatabase_PrimarySelectorMigrated(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		&dbInstanceWithReplication,
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithReplication.Name,
+			Namespace: dbInstanceWithReplication.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	// Simulate the primary Deployment created with the selector which matches the pods of the standbys as well. Its pods
+	// have the member label, so it is replaced in the same reconcile
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	dep.Spec.Selector = &metav1.LabelSelector{MatchLabels: utils.GetLabels(req.Name)}
+	if err := r.client.Update(context.TODO(), dep); err != nil {
+		t.Fatalf("fails when try to update the deployment: (%v)", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	dep, err = service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	want := utils.GetMemberLabels(req.Name, req.Name)
+	if !reflect.DeepEqual(dep.Spec.Selector.MatchLabels, want) {
+		t.Errorf("Deployment selector got (%v), when is expected (%v)", dep.Spec.Selector.MatchLabels, want)
+	}
+}
+
+func TestGetTemplateChanges(t *testing.T) {
+	current := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "database", Image: "centos/postgresql-96-centos7", TerminationMessagePolicy: corev1.TerminationMessageReadFile},
+			},
+			RestartPolicy: corev1.RestartPolicyAlways,
+			DNSPolicy:     corev1.DNSClusterFirst,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		desired corev1.PodTemplateSpec
+		want    []string
+	}{
+		{
+			name: "should ignore the fields defaulted by the cluster",
+			desired: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "database", Image: "centos/postgresql-96-centos7"},
+					},
+				},
+			},
+			want: nil,
+		},
+		{
+			name: "should return the field of the container changed",
+			desired: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "database", Image: "centos/postgresql-12-centos7"},
+					},
+				},
+			},
+			want: []string{"database.image"},
+		},
+		{
+			name: "should return the containers when a sidecar is added",
+			desired: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "database", Image: "centos/postgresql-96-centos7"},
+						{Name: "wal-archiver"},
+					},
+				},
+			},
+			want: []string{"containers"},
+		},
+		{
+			name: "should return the template when other field of the pod changed",
+			desired: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "database", Image: "centos/postgresql-96-centos7"},
+					},
+					RestartPolicy: corev1.RestartPolicyOnFailure,
+				},
+			},
+			want: []string{"template"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getTemplateChanges(current, tt.desired); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getTemplateChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTemplateChanges_Removed(t *testing.T) {
+	current := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "database",
+					Image: "centos/postgresql-96-centos7",
+					Args:  []string{utils.RunPostgresqlCommand, "-c", "ssl=on", "-c", "hba_file=/opt/app-root/hba/pg_hba.conf"},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "database", MountPath: "/var/lib/pgsql/data"},
+						{Name: "database-parameters", MountPath: utils.ParametersConfPath, ReadOnly: true},
+					},
+					Ports:         []corev1.ContainerPort{{ContainerPort: 5432, Protocol: corev1.ProtocolTCP}},
+					LivenessProbe: &corev1.Probe{InitialDelaySeconds: 30},
+				},
+				{Name: utils.WalArchiverName},
+			},
+			Volumes: []corev1.Volume{
+				{Name: "database"},
+				{Name: "database-parameters"},
+			},
+		},
+	}
+	base := func() corev1.PodTemplateSpec {
+		desired := *current.DeepCopy()
+		desired.Spec.Containers[0].Ports = []corev1.ContainerPort{{ContainerPort: 5432}}
+		return desired
+	}
+
+	tests := []struct {
+		name    string
+		desired func() corev1.PodTemplateSpec
+		want    []string
+	}{
+		{
+			name:    "should ignore the fields defaulted by the cluster",
+			desired: base,
+			want:    nil,
+		},
+		{
+			name: "should return the args when the TLS and pg_hba.conf args are removed",
+			desired: func() corev1.PodTemplateSpec {
+				desired := base()
+				desired.Spec.Containers[0].Args = nil
+				return desired
+			},
+			want: []string{"database.args"},
+		},
+		{
+			name: "should return the volumes when the parameters mount is removed",
+			desired: func() corev1.PodTemplateSpec {
+				desired := base()
+				desired.Spec.Containers[0].VolumeMounts = desired.Spec.Containers[0].VolumeMounts[:1]
+				desired.Spec.Volumes = desired.Spec.Volumes[:1]
+				return desired
+			},
+			want: []string{"database.volumeMounts", "volumes"},
+		},
+		{
+			name: "should return the containers when the WAL archiver sidecar is removed",
+			desired: func() corev1.PodTemplateSpec {
+				desired := base()
+				desired.Spec.Containers = desired.Spec.Containers[:1]
+				return desired
+			},
+			want: []string{"containers"},
+		},
+		{
+			name: "should return the probe when it is removed",
+			desired: func() corev1.PodTemplateSpec {
+				desired := base()
+				desired.Spec.Containers[0].LivenessProbe = nil
+				return desired
+			},
+			want: []string{"database.livenessProbe"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getTemplateChanges(current, tt.desired()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getTemplateChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendTemplateHashChange(t *testing.T) {
+	desired := map[string]string{utils.TemplateHashAnnotation: "2b1d5f3a"}
+	tests := []struct {
+		name    string
+		changes []string
+		current map[string]string
+		want    []string
+	}{
+		{
+			name:    "should return the template when the hash changed",
+			current: map[string]string{utils.TemplateHashAnnotation: "9e0c7a41"},
+			want:    []string{"template"},
+		},
+		{
+			name:    "should keep the fields changed found",
+			changes: []string{"database.args"},
+			current: map[string]string{utils.TemplateHashAnnotation: "9e0c7a41"},
+			want:    []string{"database.args"},
+		},
+		{
+			name:    "should not return the template when the workload has not the hash",
+			current: nil,
+			want:    nil,
+		},
+		{
+			name:    "should not return the template when the hash is the same",
+			current: desired,
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendTemplateHashChange(tt.changes, tt.current, desired); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("appendTemplateHashChange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileDatabase_ParametersRemoved(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		dbInstanceWithParameters.DeepCopy(),
+	}
+
+	r := buildReconcileWithStatusSubresource(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithParameters.Name,
+			Namespace: dbInstanceWithParameters.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	db.Spec.Parameters = nil
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	for _, m := range dep.Spec.Template.Spec.Containers[0].VolumeMounts {
+		if m.MountPath == utils.ParametersConfPath {
+			t.Errorf("Deployment got the mount of the parameters (%v), when is expected it removed", m.Name)
+		}
+	}
+	if len(dep.Spec.Template.Spec.Volumes) != 1 {
+		t.Errorf("Deployment volumes got (%v), when is expected only the data volume", dep.Spec.Template.Spec.Volumes)
+	}
+
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.Rollout == nil || !reflect.DeepEqual(db.Status.Rollout.Changes, []string{"database.volumeMounts", "volumes"}) {
+		t.Errorf("Rollout got (%v), when is expected the changes of the parameters removed", db.Status.Rollout)
+	}
+}
@@ -0,0 +1,250 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Intervals used to check if the passwords should be rotated or the previous login roles expired
+const (
+	rotationCheckInterval = time.Hour
+	rotationRetryInterval = time.Minute
+)
+
+// rotatedPassword is a password generated by the operator in a Secret which is rotated
+type rotatedPassword struct {
+	role    string
+	secret  *corev1.Secret
+	userKey string
+	key     string
+}
+
+// managePasswordRotation will rotate the passwords generated by the operator when the interval since the latest
+// rotation elapsed and remove the password of the previous login roles after the grace period
+// NOTE: A role has a single password, so each rotation applies the new password in the login role which is not in use
+// by the Secret (the role or its alternate role) before storing both in the Secret. The previous login role keeps its
+// password until the end of the grace period, so the clients which did not read the Secret again keep working. The pods
+// of the Database are not restarted since the image only sets the password of the env var when the container starts,
+// and the connections opened keep working.
+func (r *ReconcileDatabase) managePasswordRotation(db *v1alpha1.Database) error {
+	if !utils.IsPasswordRotationEnabled(db) || utils.ValidatePasswordRotation(db) != nil || isUpgradeInProgress(db) {
+		return nil
+	}
+
+	passwords, err := r.fetchRotatedPasswords(db)
+	if err != nil {
+		return err
+	}
+
+	due := getLastRotationTime(db).Add(utils.GetPasswordRotationInterval(db))
+	expired := getExpiredPreviousRoles(db, passwords)
+	if time.Now().Before(due) && len(expired) == 0 {
+		return nil
+	}
+
+	pod, _, err := r.fetchPrimaryPodAndReadyMembers(db)
+	if err != nil || pod == nil || !isPodReady(pod) || r.executor == nil {
+		return err
+	}
+
+	for _, p := range expired {
+		if err := r.expirePreviousRole(db, pod, p); err != nil {
+			return err
+		}
+	}
+	if time.Now().Before(due) {
+		return nil
+	}
+
+	// The passwords rotated before a failure of the same rotation are not rotated again when it is retried
+	var roles []string
+	failed := false
+	for _, p := range passwords {
+		if isRotatedSince(p.secret, due) {
+			continue
+		}
+		login, password, err := r.rotatePassword(db, pod, p)
+		if err != nil {
+			if r.recorder != nil {
+				r.recorder.Eventf(db, corev1.EventTypeWarning, "PasswordRotationFailed", "Unable to rotate the password of the role %v: %v", p.role, err)
+			}
+			failed = true
+			continue
+		}
+		if p.role == db.Spec.DatabaseUser {
+			if err := r.updateBackupPasswords(db, login, password); err != nil {
+				return err
+			}
+		}
+		roles = append(roles, p.role)
+	}
+
+	if r.recorder != nil && len(roles) > 0 {
+		r.recorder.Eventf(db, corev1.EventTypeNormal, "PasswordRotated", "Passwords of the roles %v rotated.", roles)
+	}
+	if failed {
+		return nil
+	}
+	now := metav1.Now()
+	db.Status.LastRotationTime = &now
+	return r.updateStatus(db)
+}
+
+// fetchRotatedPasswords returns the Secrets owned by the Database with the passwords which are rotated
+// NOTE: The roles of the spec.roles are only rotated after they be created in the database server
+func (r *ReconcileDatabase) fetchRotatedPasswords(db *v1alpha1.Database) ([]rotatedPassword, error) {
+	var passwords []rotatedPassword
+	if db.Spec.CredentialsSecretName == utils.GetGeneratedCredentialsSecretName(db) {
+		secret, err := service.FetchSecret(db.Namespace, db.Spec.CredentialsSecretName, r.client)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if err == nil && metav1.IsControlledBy(secret, db) {
+			passwords = append(passwords, rotatedPassword{role: db.Spec.DatabaseUser, secret: secret,
+				userKey: utils.GetCredentialsUserKey(db), key: utils.GetCredentialsPasswordKey(db)})
+		}
+	}
+
+	for _, role := range db.Spec.Roles {
+		status := findServerObjectStatus(db.Status.Roles, role.Name)
+		if !utils.IsRoleLogin(role) || status == nil || status.Phase != objectReady {
+			continue
+		}
+		secret, err := service.FetchSecret(db.Namespace, utils.GetRoleSecretName(db, role.Name), r.client)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if metav1.IsControlledBy(secret, db) {
+			passwords = append(passwords, rotatedPassword{role: role.Name, secret: secret, userKey: "username", key: "password"})
+		}
+	}
+	return passwords, nil
+}
+
+// rotatePassword applies a new password in the login role which is not in use by the Secret and then stores both in
+// the Secret, with the time of the rotation and the previous login role
+// NOTE: When the ALTER ROLE fails the Secret is not changed, so the clients keep the login role in use.
+func (r *ReconcileDatabase) rotatePassword(db *v1alpha1.Database, pod *corev1.Pod, p rotatedPassword) (string, string, error) {
+	password, err := utils.GeneratePassword(utils.RolePasswordLength)
+	if err != nil {
+		return "", "", err
+	}
+	current := string(p.secret.Data[p.userKey])
+	if current == "" {
+		current = p.role
+	}
+	login := utils.GetNextLoginRole(p.role, current)
+	if _, err := service.Query(r.executor, pod, db.Spec.ContainerName, utils.BuildLoginRoleSQL(p.role, login, password)); err != nil {
+		return "", "", err
+	}
+
+	if p.secret.Annotations == nil {
+		p.secret.Annotations = map[string]string{}
+	}
+	p.secret.Data[p.userKey] = []byte(login)
+	p.secret.Data[p.key] = []byte(password)
+	p.secret.Annotations[utils.RotatedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	p.secret.Annotations[utils.PreviousRoleAnnotation] = current
+	if err := r.client.Update(context.TODO(), p.secret); err != nil {
+		return "", "", err
+	}
+	return login, password, nil
+}
+
+// expirePreviousRole removes the password of the previous login role of the Secret after the grace period
+func (r *ReconcileDatabase) expirePreviousRole(db *v1alpha1.Database, pod *corev1.Pod, p rotatedPassword) error {
+	previous := p.secret.Annotations[utils.PreviousRoleAnnotation]
+	if _, err := service.Query(r.executor, pod, db.Spec.ContainerName, utils.BuildExpiredPasswordSQL(previous)); err != nil {
+		if r.recorder != nil {
+			r.recorder.Eventf(db, corev1.EventTypeWarning, "PasswordRotationFailed", "Unable to remove the password of the previous role %v: %v", previous, err)
+		}
+		return nil
+	}
+	delete(p.secret.Annotations, utils.PreviousRoleAnnotation)
+	return r.client.Update(context.TODO(), p.secret)
+}
+
+// updateBackupPasswords changes the user and password in the database Secrets of the Backups of the Database
+func (r *ReconcileDatabase) updateBackupPasswords(db *v1alpha1.Database, user, password string) error {
+	bkpList := &v1alpha1.BackupList{}
+	if err := r.client.List(context.TODO(), bkpList, &client.ListOptions{Namespace: db.Namespace}); err != nil {
+		return err
+	}
+	for i := range bkpList.Items {
+		bkp := &bkpList.Items[i]
+		if getBackupDatabaseCRName(bkp) != db.Name {
+			continue
+		}
+		secret, err := service.FetchSecret(bkp.Namespace, utils.DbSecretPrefix+bkp.Name, r.client)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		secret.Data["POSTGRES_USERNAME"] = []byte(user)
+		secret.Data["POSTGRES_PASSWORD"] = []byte(password)
+		if err := r.client.Update(context.TODO(), secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isRotatedSince returns true when the password of the Secret was rotated after the time informed
+func isRotatedSince(secret *corev1.Secret, since time.Time) bool {
+	rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[utils.RotatedAtAnnotation])
+	return err == nil && !rotatedAt.Before(since)
+}
+
+// getExpiredPreviousRoles returns the passwords whose previous login role can still log in after the grace period
+func getExpiredPreviousRoles(db *v1alpha1.Database, passwords []rotatedPassword) []rotatedPassword {
+	var expired []rotatedPassword
+	for _, p := range passwords {
+		if p.secret.Annotations[utils.PreviousRoleAnnotation] == "" ||
+			p.secret.Annotations[utils.PreviousRoleAnnotation] == string(p.secret.Data[p.userKey]) {
+			continue
+		}
+		rotatedAt, err := time.Parse(time.RFC3339, p.secret.Annotations[utils.RotatedAtAnnotation])
+		if err != nil || time.Since(rotatedAt) >= utils.GetPasswordRotationGracePeriod(db) {
+			expired = append(expired, p)
+		}
+	}
+	return expired
+}
+
+// getLastRotationTime returns the time of the latest rotation or the creation of the Database when the passwords
+// were never rotated
+func getLastRotationTime(db *v1alpha1.Database) time.Time {
+	if db.Status.LastRotationTime != nil {
+		return db.Status.LastRotationTime.Time
+	}
+	return db.CreationTimestamp.Time
+}
+
+// getPasswordRotationRequeue returns the interval until the next rotation or the end of the grace period
+func getPasswordRotationRequeue(db *v1alpha1.Database) time.Duration {
+	last := getLastRotationTime(db)
+	next := time.Until(last.Add(utils.GetPasswordRotationInterval(db)))
+	if grace := time.Until(last.Add(utils.GetPasswordRotationGracePeriod(db))); grace > 0 && grace < next {
+		next = grace
+	}
+	switch {
+	case next <= 0:
+		return rotationRetryInterval
+	case next > rotationCheckInterval:
+		return rotationCheckInterval
+	}
+	return next
+}
@@ -0,0 +1,266 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileDatabase_PasswordRotation(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		dbInstanceWithPasswordRotation.DeepCopy(),
+		podPrimaryReady.DeepCopy(),
+		bkpInstanceDatabase.DeepCopy(),
+		secretBackupDatabase.DeepCopy(),
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+	executor := &fakeSQLExecutor{}
+	r.executor = executor
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithPasswordRotation.Name,
+			Namespace: dbInstanceWithPasswordRotation.Namespace,
+		},
+	}
+
+	// The interval since the latest rotation elapsed so the passwords are rotated
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if res.RequeueAfter <= 0 || res.RequeueAfter > rotationCheckInterval {
+		t.Errorf("Reconcile requeue got (%v), when is expected until the next rotation", res.RequeueAfter)
+	}
+
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.LastRotationTime == nil || time.Since(db.Status.LastRotationTime.Time) > time.Minute {
+		t.Errorf("Status lastRotationTime got (%v), when is expected now", db.Status.LastRotationTime)
+	}
+
+	utils.AddDatabaseMandatorySpecs(db)
+	credentials, err := service.FetchSecret(req.Namespace, db.Spec.CredentialsSecretName, r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	password := string(credentials.Data[utils.GetCredentialsPasswordKey(db)])
+	if _, found := credentials.Annotations[utils.RotatedAtAnnotation]; !found {
+		t.Errorf("Secret annotations got (%v), when is expected the time of the rotation", credentials.Annotations)
+	}
+	if string(credentials.Data[utils.GetCredentialsUserKey(db)]) != "postgres_alt" || credentials.Annotations[utils.PreviousRoleAnnotation] != "postgres" {
+		t.Errorf("Secret got data (%v) and annotations (%v), when is expected the alternate role with the previous role",
+			credentials.Data, credentials.Annotations)
+	}
+	if !containsStatement(executor.statements, utils.BuildLoginRoleSQL(db.Spec.DatabaseUser, "postgres_alt", password)) {
+		t.Errorf("Statements got (%v), when is expected the new password applied in the alternate role of (%v)", executor.statements, db.Spec.DatabaseUser)
+	}
+
+	role, err := service.FetchSecret(req.Namespace, utils.GetRoleSecretName(db, "app"), r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	if string(role.Data["username"]) != "app_alt" || !containsStatement(executor.statements, utils.BuildLoginRoleSQL("app", "app_alt", string(role.Data["password"]))) {
+		t.Errorf("Statements got (%v), when is expected the new password applied in the role app_alt", executor.statements)
+	}
+
+	// The Secret used by the backup receives the new user and password
+	bkpSecret, err := service.FetchSecret(req.Namespace, secretBackupDatabase.Name, r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	if string(bkpSecret.Data["POSTGRES_USERNAME"]) != "postgres_alt" || string(bkpSecret.Data["POSTGRES_PASSWORD"]) != password {
+		t.Errorf("Backup Secret got (%v), when is expected the user postgres_alt with the password (%v)", bkpSecret.Data, password)
+	}
+
+	// The passwords are not rotated again before the interval and the previous roles keep their password during the
+	// grace period
+	db.Status.LastRotationTime = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+	if err := r.client.Status().Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	executor.statements = nil
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if containsStatement(executor.statements, "PASSWORD") {
+		t.Errorf("Statements got (%v), when is expected no rotation and the previous passwords kept", executor.statements)
+	}
+	credentials, err = service.FetchSecret(req.Namespace, db.Spec.CredentialsSecretName, r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	if string(credentials.Data[utils.GetCredentialsPasswordKey(db)]) != password {
+		t.Errorf("Secret data got (%v), when is expected the same password", credentials.Data)
+	}
+
+	// The password of the previous roles is removed after the grace period
+	for _, secret := range []*corev1.Secret{credentials, role} {
+		secret, err := service.FetchSecret(req.Namespace, secret.Name, r.client)
+		if err != nil {
+			t.Fatalf("get secret: (%v)", err)
+		}
+		secret.Annotations[utils.RotatedAtAnnotation] = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+		if err := r.client.Update(context.TODO(), secret); err != nil {
+			t.Fatalf("fails when try to update the secret: (%v)", err)
+		}
+	}
+	executor.statements = nil
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if !containsStatement(executor.statements, utils.BuildExpiredPasswordSQL("postgres")) || !containsStatement(executor.statements, utils.BuildExpiredPasswordSQL("app")) {
+		t.Errorf("Statements got (%v), when is expected the password of the previous roles removed", executor.statements)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if containsStatement(executor.statements, `ALTER ROLE "app" WITH LOGIN NOCREATEDB NOCREATEROLE CONNECTION LIMIT -1 PASSWORD`) {
+		t.Errorf("Statements got (%v), when is expected the role app applied without the password of the alternate role", executor.statements)
+	}
+	credentials, err = service.FetchSecret(req.Namespace, db.Spec.CredentialsSecretName, r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	if _, found := credentials.Annotations[utils.PreviousRoleAnnotation]; found {
+		t.Errorf("Secret annotations got (%v), when is expected no previous role", credentials.Annotations)
+	}
+
+	// The Secret is not changed when the password cannot be applied in the role
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	last := metav1.Time{Time: time.Now().Add(-31 * 24 * time.Hour).Truncate(time.Second)}
+	db.Status.LastRotationTime = &last
+	if err := r.client.Status().Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	for _, secret := range []*corev1.Secret{credentials, role} {
+		secret, err := service.FetchSecret(req.Namespace, secret.Name, r.client)
+		if err != nil {
+			t.Fatalf("get secret: (%v)", err)
+		}
+		secret.Annotations[utils.RotatedAtAnnotation] = last.UTC().Format(time.RFC3339)
+		if err := r.client.Update(context.TODO(), secret); err != nil {
+			t.Fatalf("fails when try to update the secret: (%v)", err)
+		}
+	}
+	executor.failSQL = `ALTER ROLE "postgres"`
+	executor.statements = nil
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if res.RequeueAfter != rotationRetryInterval {
+		t.Errorf("Reconcile requeue got (%v), when is expected (%v)", res.RequeueAfter, rotationRetryInterval)
+	}
+	credentials, err = service.FetchSecret(req.Namespace, credentials.Name, r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	if string(credentials.Data[utils.GetCredentialsPasswordKey(db)]) != password || string(credentials.Data[utils.GetCredentialsUserKey(db)]) != "postgres_alt" {
+		t.Errorf("Secret data got (%v), when is expected the login role and password unchanged", credentials.Data)
+	}
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if !db.Status.LastRotationTime.Equal(&last) {
+		t.Errorf("Status lastRotationTime got (%v), when is expected (%v)", db.Status.LastRotationTime, last)
+	}
+
+	// The role app was rotated back to its own login role by the rotation which failed, so it is not rotated again by
+	// the retry
+	role, err = service.FetchSecret(req.Namespace, role.Name, r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	rolePassword := string(role.Data["password"])
+	if string(role.Data["username"]) != "app" || !containsStatement(executor.statements, utils.BuildLoginRoleSQL("app", "app", rolePassword)) {
+		t.Errorf("Statements got (%v), when is expected the new password applied in the role app", executor.statements)
+	}
+	executor.failSQL = ""
+	executor.statements = nil
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if containsStatement(executor.statements, `ALTER ROLE "app" WITH PASSWORD`) {
+		t.Errorf("Statements got (%v), when is expected the role app not rotated again", executor.statements)
+	}
+	if !containsStatement(executor.statements, `ALTER ROLE "`+db.Spec.DatabaseUser+`" WITH PASSWORD`) {
+		t.Errorf("Statements got (%v), when is expected the rotation of the role (%v) retried", executor.statements, db.Spec.DatabaseUser)
+	}
+	role, err = service.FetchSecret(req.Namespace, role.Name, r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	if string(role.Data["password"]) != rolePassword {
+		t.Errorf("Secret password of the role app got (%v), when is expected (%v)", string(role.Data["password"]), rolePassword)
+	}
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.LastRotationTime.Equal(&last) {
+		t.Errorf("Status lastRotationTime got (%v), when is expected the time of the retry", db.Status.LastRotationTime)
+	}
+}
+
+func TestValidatePasswordRotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval int32
+		grace    int32
+		role     string
+		wantErr  bool
+	}{
+		{name: "should accept the default values", wantErr: false},
+		{name: "should accept the interval and grace period informed", interval: 1, grace: 120, wantErr: false},
+		{name: "should refuse the negative interval", interval: -1, wantErr: true},
+		{name: "should refuse the negative grace period", grace: -1, wantErr: true},
+		{name: "should refuse the grace period longer than the interval", interval: 1, grace: 1440, wantErr: true},
+		{name: "should refuse the role reserved as alternate role", role: "app_alt", wantErr: true},
+		{name: "should refuse the role too long for the alternate role", role: strings.Repeat("a", 60), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbInstanceWithPasswordRotation.DeepCopy()
+			db.Spec.PasswordRotation.IntervalDays = tt.interval
+			db.Spec.PasswordRotation.GracePeriodMinutes = tt.grace
+			if tt.role != "" {
+				db.Spec.Roles = append(db.Spec.Roles, v1alpha1.DatabaseRole{Name: tt.role})
+			}
+			utils.AddDatabaseMandatorySpecs(db)
+			if err := utils.ValidatePasswordRotation(db); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePasswordRotation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// containsStatement returns true when some statement contains the text informed
+func containsStatement(statements []string, text string) bool {
+	for _, statement := range statements {
+		if strings.Contains(statement, text) {
+			return true
+		}
+	}
+	return false
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
 	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
 	"k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -20,23 +23,114 @@ func (r *ReconcileDatabase) updateDBStatus(request reconcile.Request) error {
 		return err
 	}
 
+	// The default values are used to check the objects but they are only stored in the CR by the defaulting webhook
+	dbWithSpecs := db.DeepCopy()
+	classFields, classErr := service.AddDatabaseMandatorySpecs(dbWithSpecs, r.client)
+	if classErr != nil && !errors.IsNotFound(classErr) {
+		return classErr
+	}
+
 	statusMsgUpdate := statusOk
 	// Check if all required resource were created and found
-	if err := r.isAllCreated(db); err != nil {
-		statusMsgUpdate = err.Error()
+	createdErr := r.isAllCreated(dbWithSpecs)
+	if createdErr != nil {
+		statusMsgUpdate = createdErr.Error()
+	}
+
+	// Check if the size informed can be respected
+	validationErr := utils.ValidateSize(db)
+
+	// Check if the replication can be disabled
+	if err := utils.ValidateReplication(db); err != nil {
+		validationErr = err
+	}
+
+	// Check if the quantities of the resources informed or used from the DatabaseClass are valid
+	if err := utils.ValidateQuantities(dbWithSpecs); err != nil {
+		validationErr = err
+	}
+
+	// Check if the workload type informed is supported
+	if err := utils.ValidateWorkloadType(db); err != nil {
+		validationErr = err
+	}
+
+	// Check if the postgresVersion informed is valid
+	if err := utils.ValidatePostgresVersion(db); err != nil {
+		validationErr = err
+	}
+
+	// Check if the parameters informed or used from the DatabaseClass are supported
+	if err := utils.ValidateParameters(dbWithSpecs); err != nil {
+		validationErr = err
+	}
+
+	// Check if the roles and databases informed can be managed
+	if err := utils.ValidateRoles(dbWithSpecs); err != nil {
+		validationErr = err
+	}
+
+	// Check if the password rotation informed is valid
+	if err := utils.ValidatePasswordRotation(dbWithSpecs); err != nil {
+		validationErr = err
+	}
+
+	// Check if the tls informed is valid
+	if err := utils.ValidateTLS(dbWithSpecs); err != nil {
+		validationErr = err
+	}
+
+	// Check if the rules of the pg_hba.conf informed are valid
+	if err := utils.ValidateHba(db); err != nil {
+		validationErr = err
+	}
+
+	// Check if the deletionPolicy informed is supported
+	if err := utils.ValidateDeletionPolicy(db); err != nil {
+		validationErr = err
+	}
+
+	// Check if the DatabaseClass informed exists
+	if classErr != nil {
+		validationErr = fmt.Errorf("Error: The DatabaseClass %v was not found.", db.Spec.ClassName)
+	}
+	if validationErr != nil {
+		statusMsgUpdate = validationErr.Error()
 	}
 
-	// Check if BackupStatus was changed, if yes update it
-	if err := r.insertUpdateDatabaseStatus(db, statusMsgUpdate); err != nil {
+	status := db.Status.DeepCopy()
+	status.DatabaseStatus = statusMsgUpdate
+	status.ObservedGeneration = db.Generation
+	status.Class = buildClassStatus(dbWithSpecs, classFields, classErr)
+	if err := r.buildConditions(dbWithSpecs, status, validationErr, createdErr); err != nil {
+		return err
+	}
+
+	// Check if DatabaseStatus was changed, if yes update it
+	if err := r.insertUpdateDatabaseStatus(db, status); err != nil {
 		return err
 	}
 	return nil
 }
 
+// buildClassStatus returns the status of the DatabaseClass of the Database or nil when it does not inform the className
+func buildClassStatus(dbWithSpecs *v1alpha1.Database, fields []string, classErr error) *v1alpha1.ClassStatus {
+	if dbWithSpecs.Spec.ClassName == "" {
+		return nil
+	}
+	if classErr != nil {
+		return &v1alpha1.ClassStatus{
+			Name:    dbWithSpecs.Spec.ClassName,
+			Message: fmt.Sprintf("The DatabaseClass %v was not found.", dbWithSpecs.Spec.ClassName),
+		}
+	}
+	return utils.BuildClassStatus(dbWithSpecs, fields)
+}
+
 // Check if DatabaseStatus was changed, if yes update it
-func (r *ReconcileDatabase) insertUpdateDatabaseStatus(db *v1alpha1.Database, statusMsgUpdate string) error {
-	if statusMsgUpdate != db.Status.DatabaseStatus {
-		db.Status.DatabaseStatus = statusMsgUpdate
+func (r *ReconcileDatabase) insertUpdateDatabaseStatus(db *v1alpha1.Database, status *v1alpha1.DatabaseStatus) error {
+	if !reflect.DeepEqual(*status, db.Status) {
+		db.Status = *status
 		if err := r.client.Status().Update(context.TODO(), db); err != nil {
 			return err
 		}
@@ -44,6 +138,19 @@ func (r *ReconcileDatabase) insertUpdateDatabaseStatus(db *v1alpha1.Database, st
 	return nil
 }
 
+// updateStatus updates the status of the Database used in the reconcile without changing its spec
+// NOTE: The API returns the CR stored, without the values of the DatabaseClass and the default values added to the
+// Database in the reconcile, so only its status and resourceVersion are copied back
+func (r *ReconcileDatabase) updateStatus(db *v1alpha1.Database) error {
+	updated := db.DeepCopy()
+	if err := r.client.Status().Update(context.TODO(), updated); err != nil {
+		return err
+	}
+	db.Status = updated.Status
+	db.ResourceVersion = updated.ResourceVersion
+	return nil
+}
+
 //updateDeploymentStatus returns error when status regards the deployment resource could not be updated
 func (r *ReconcileDatabase) updateDeploymentStatus(request reconcile.Request) error {
 	db, err := service.FetchDatabaseCR(request.Name, request.Namespace, r.client)
@@ -51,6 +158,11 @@ func (r *ReconcileDatabase) updateDeploymentStatus(request reconcile.Request) er
 		return err
 	}
 
+	// The StatefulSet is used instead of the Deployment
+	if utils.IsStatefulSet(db) {
+		return r.updateStatefulSetStatus(db)
+	}
+
 	dep, err := service.FetchDeployment(db.Name, db.Namespace, r.client)
 	if err != nil {
 		return err
@@ -75,6 +187,27 @@ func (r *ReconcileDatabase) insertUpdateDeploymentStatus(deploymentStatus *v1.De
 	return nil
 }
 
+//updateStatefulSetStatus returns error when status regards the statefulset resource could not be updated
+func (r *ReconcileDatabase) updateStatefulSetStatus(db *v1alpha1.Database) error {
+	sts, err := service.FetchStatefulSet(db.Name, db.Namespace, r.client)
+	if err != nil {
+		// The StatefulSet is not created until the migration of the Deployment be finished
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	// Check if StatefulSet Status was changed, if yes update it
+	if !reflect.DeepEqual(sts.Status, db.Status.StatefulSetStatus) {
+		db.Status.StatefulSetStatus = sts.Status
+		if err := r.client.Status().Update(context.TODO(), db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 //updateServiceStatus returns error when status regards the service resource could not be updated
 func (r *ReconcileDatabase) updateServiceStatus(request reconcile.Request) error {
 	db, err := service.FetchDatabaseCR(request.Name, request.Namespace, r.client)
@@ -113,8 +246,12 @@ func (r *ReconcileDatabase) updatePvcStatus(request reconcile.Request) error {
 		return err
 	}
 
-	pvc, err := service.FetchPersistentVolumeClaim(db.Name, db.Namespace, r.client)
+	pvc, err := service.FetchPersistentVolumeClaim(utils.GetDatabasePvcName(db), db.Namespace, r.client)
 	if err != nil {
+		// The PVCs of the StatefulSet are created by its volumeClaimTemplates when its pods are scheduled
+		if utils.IsStatefulSet(db) && errors.IsNotFound(err) {
+			return nil
+		}
 		return err
 	}
 
@@ -133,26 +270,96 @@ func (r *ReconcileDatabase) insertUpdatePvcStatus(pvc *corev1.PersistentVolumeCl
 	return nil
 }
 
-//validateBackup returns error when some requirement is missing
+//isAllCreated returns error when some required resource is missing
 func (r *ReconcileDatabase) isAllCreated(db *v1alpha1.Database) error {
+	if utils.IsStatefulSet(db) {
+		// Check if the StatefulSet was created
+		// NOTE: It is not created until the migration of the Deployment be finished and its PVCs are created by the pods
+		if _, err := service.FetchStatefulSet(db.Name, db.Namespace, r.client); err != nil {
+			return fmt.Errorf("Error: StatefulSet is missing.")
+		}
+
+		// Check if the headless Service was created
+		if _, err := service.FetchService(utils.GetHeadlessServiceName(db), db.Namespace, r.client); err != nil {
+			return fmt.Errorf("Error: Headless Service is missing.")
+		}
+	} else {
+		// Check if the PersistentVolumeClaim was created
+		if _, err := service.FetchPersistentVolumeClaim(utils.GetDatabasePvcName(db), db.Namespace, r.client); err != nil {
+			return fmt.Errorf("Error: PersistentVolumeClaim is missing.")
+		}
+
+		// Check if the Deployment was created
+		if _, err := service.FetchDeployment(db.Name, db.Namespace, r.client); err != nil {
+			return fmt.Errorf("Error: Deployment is missing.")
+		}
+	}
+
+	// Check if the Service was created
+	if _, err := service.FetchService(db.Name, db.Namespace, r.client); err != nil {
+		return fmt.Errorf("Error: Service is missing.")
+	}
+
+	return nil
+}
 
-	// Check if the PersistentVolumeClaim was created
-	_, err := service.FetchPersistentVolumeClaim(db.Name, db.Namespace, r.client)
+// updateWalArchivingStatus returns error when the state of the WAL archiving of the primary could not be updated
+// NOTE: It is fetched from the pg_stat_archiver of the primary so the pod should be running
+func (r *ReconcileDatabase) updateWalArchivingStatus(request reconcile.Request) error {
+	db, err := service.FetchDatabaseCR(request.Name, request.Namespace, r.client)
 	if err != nil {
-		err = fmt.Errorf("Error: PersistentVolumeClaim is missing.")
+		return err
 	}
 
-	// Check if the Deployment was created
-	_, err = service.FetchDeployment(db.Name, db.Namespace, r.client)
+	if !utils.IsWalArchivingEnabled(db) || r.executor == nil {
+		return nil
+	}
+	service.AddDatabaseMandatorySpecs(db, r.client)
+
+	pod, err := service.FetchDatabasePod(nil, db, r.client)
+	if err != nil || pod == nil || !isPodReady(pod) {
+		return err
+	}
+
+	stats, err := service.FetchArchiverStats(r.executor, pod, db.Spec.ContainerName)
 	if err != nil {
-		err = fmt.Errorf("Error: Deployment is missing.")
+		return err
 	}
 
-	// Check if the Service was created
-	_, err = service.FetchService(db.Name, db.Namespace, r.client)
+	status, err := buildWalArchivingStatus(stats)
 	if err != nil {
-		err = fmt.Errorf("Error: Service is missing.")
+		return err
 	}
 
+	// Check if the state of the WAL archiving changed, if yes update it
+	if !reflect.DeepEqual(status, db.Status.WalArchiving) {
+		db.Status.WalArchiving = status
+		if err := r.client.Status().Update(context.TODO(), db); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// buildWalArchivingStatus returns the status of the WAL archiving with the archive lag according to the pg_stat_archiver
+func buildWalArchivingStatus(stats *service.ArchiverStats) (*v1alpha1.WalArchivingStatus, error) {
+	lag, err := utils.GetWalSegmentLag(stats.CurrentWal, stats.LastArchivedWal, stats.WalSegmentSize)
+	if err != nil {
+		return nil, err
+	}
+	status := &v1alpha1.WalArchivingStatus{
+		LastArchivedWal:    stats.LastArchivedWal,
+		LastFailedWal:      stats.LastFailedWal,
+		CurrentWal:         stats.CurrentWal,
+		ArchiveLagSegments: lag,
+	}
+	if stats.LastArchivedTime > 0 {
+		t := metav1.Unix(stats.LastArchivedTime, 0)
+		status.LastArchivedTime = &t
+	}
+	if stats.LastFailedTime > 0 {
+		t := metav1.Unix(stats.LastFailedTime, 0)
+		status.LastFailedTime = &t
+	}
+	return status, nil
+}
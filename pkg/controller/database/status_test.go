@@ -1,9 +1,12 @@
 package database
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -257,3 +260,184 @@ func TestUpdatePVCStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateWalArchivingStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		archiver     string
+		wantErr      bool
+		wantArchived string
+		wantLag      int64
+	}{
+		{
+			name:         "Should update the state of the WAL archiving",
+			archiver:     "000000010000000000000003|1594339200||0|000000010000000000000006|16MB",
+			wantArchived: "000000010000000000000003",
+			wantLag:      2,
+		},
+		{
+			name:     "Should update the state when none segment was archived yet",
+			archiver: "|0||0|000000010000000000000001|16MB",
+			wantLag:  0,
+		},
+		{
+			name:     "Should return error when the pg_stat_archiver cannot be parsed",
+			archiver: "invalid",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbInstanceWithWalArchiving.DeepCopy()
+			r := buildReconcileWithFakeClientWithMocks([]runtime.Object{db, podPrimaryReady.DeepCopy()})
+			r.executor = &fakeSQLExecutor{archiver: tt.archiver}
+
+			request := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      db.Name,
+					Namespace: db.Namespace,
+				},
+			}
+			if err := r.updateWalArchivingStatus(request); (err != nil) != tt.wantErr {
+				t.Fatalf("TestUpdateWalArchivingStatus error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := &v1alpha1.Database{}
+			if err := r.client.Get(context.TODO(), request.NamespacedName, got); err != nil {
+				t.Fatalf("get database: (%v)", err)
+			}
+			if got.Status.WalArchiving == nil {
+				t.Fatal("TestUpdateWalArchivingStatus the status of the WAL archiving was not updated")
+			}
+			if got.Status.WalArchiving.LastArchivedWal != tt.wantArchived {
+				t.Errorf("TestUpdateWalArchivingStatus last archived got (%v), when is expected (%v)", got.Status.WalArchiving.LastArchivedWal, tt.wantArchived)
+			}
+			if got.Status.WalArchiving.ArchiveLagSegments != tt.wantLag {
+				t.Errorf("TestUpdateWalArchivingStatus lag got (%v), when is expected (%v)", got.Status.WalArchiving.ArchiveLagSegments, tt.wantLag)
+			}
+		})
+	}
+}
+
+func TestUpdateDBStatus_Conditions(t *testing.T) {
+	type fields struct {
+		objs []runtime.Object
+	}
+	tests := []struct {
+		name                   string
+		fields                 fields
+		wantPhase              string
+		wantConditions         map[string]corev1.ConditionStatus
+		wantObservedGeneration int64
+	}{
+		{
+			name: "Should be pending when the objects are missing",
+			fields: fields{
+				objs: []runtime.Object{&dbInstanceWithGeneration},
+			},
+			wantPhase: phasePending,
+			wantConditions: map[string]corev1.ConditionStatus{
+				v1alpha1.ConditionProvisioned:      corev1.ConditionFalse,
+				v1alpha1.ConditionReady:            corev1.ConditionFalse,
+				v1alpha1.ConditionDegraded:         corev1.ConditionFalse,
+				v1alpha1.ConditionBackupConfigured: corev1.ConditionFalse,
+			},
+			wantObservedGeneration: 2,
+		},
+		{
+			name: "Should be provisioning when the primary is not ready yet",
+			fields: fields{
+				objs: []runtime.Object{&dbInstanceWithGeneration, &deploymentDatabase, &pvcDatabase, &serviceDatabase, &podPrimaryNotReady},
+			},
+			wantPhase: phaseProvisioning,
+			wantConditions: map[string]corev1.ConditionStatus{
+				v1alpha1.ConditionProvisioned: corev1.ConditionTrue,
+				v1alpha1.ConditionReady:       corev1.ConditionFalse,
+				v1alpha1.ConditionDegraded:    corev1.ConditionFalse,
+			},
+			wantObservedGeneration: 2,
+		},
+		{
+			name: "Should be ready when the primary is ready",
+			fields: fields{
+				objs: []runtime.Object{&dbInstanceWithGeneration, &deploymentDatabase, &pvcDatabase, &serviceDatabase, &podPrimaryReady, &bkpInstanceDatabase},
+			},
+			wantPhase: phaseReady,
+			wantConditions: map[string]corev1.ConditionStatus{
+				v1alpha1.ConditionProvisioned:      corev1.ConditionTrue,
+				v1alpha1.ConditionReady:            corev1.ConditionTrue,
+				v1alpha1.ConditionDegraded:         corev1.ConditionFalse,
+				v1alpha1.ConditionBackupConfigured: corev1.ConditionTrue,
+			},
+			wantObservedGeneration: 2,
+		},
+		{
+			name: "Should be degraded when a standby is not ready",
+			fields: fields{
+				objs: []runtime.Object{&dbInstanceWithReplication, &deploymentDatabase, &pvcDatabase, &serviceDatabase, &podPrimaryReady, &podStandby0},
+			},
+			wantPhase: phaseDegraded,
+			wantConditions: map[string]corev1.ConditionStatus{
+				v1alpha1.ConditionReady:    corev1.ConditionTrue,
+				v1alpha1.ConditionDegraded: corev1.ConditionTrue,
+			},
+		},
+		{
+			name: "Should be degraded when the primary is not ready after it was ready",
+			fields: fields{
+				objs: []runtime.Object{&dbInstanceWasReady, &deploymentDatabase, &pvcDatabase, &serviceDatabase, &podPrimaryNotReady},
+			},
+			wantPhase: phaseDegraded,
+			wantConditions: map[string]corev1.ConditionStatus{
+				v1alpha1.ConditionReady:    corev1.ConditionFalse,
+				v1alpha1.ConditionDegraded: corev1.ConditionTrue,
+			},
+		},
+		{
+			name: "Should be failed when the spec is invalid",
+			fields: fields{
+				objs: []runtime.Object{&dbInstanceInvalidWorkloadType},
+			},
+			wantPhase: phaseFailed,
+			wantConditions: map[string]corev1.ConditionStatus{
+				v1alpha1.ConditionProvisioned: corev1.ConditionFalse,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := buildReconcileWithFakeClientWithMocks(tt.fields.objs)
+
+			request := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      dbInstanceWithoutSpec.Name,
+					Namespace: dbInstanceWithoutSpec.Namespace,
+				},
+			}
+			if err := r.updateDBStatus(request); err != nil {
+				t.Fatalf("TestUpdateDBStatus_Conditions error = %v", err)
+			}
+
+			db := &v1alpha1.Database{}
+			if err := r.client.Get(context.TODO(), request.NamespacedName, db); err != nil {
+				t.Fatalf("TestUpdateDBStatus_Conditions error fetching the Database = %v", err)
+			}
+			if db.Status.Phase != tt.wantPhase {
+				t.Errorf("TestUpdateDBStatus_Conditions phase = %v, want %v", db.Status.Phase, tt.wantPhase)
+			}
+			if db.Status.ObservedGeneration != tt.wantObservedGeneration {
+				t.Errorf("TestUpdateDBStatus_Conditions observedGeneration = %v, want %v", db.Status.ObservedGeneration, tt.wantObservedGeneration)
+			}
+			for conditionType, want := range tt.wantConditions {
+				c := utils.FindCondition(db.Status.Conditions, conditionType)
+				if c == nil || c.Status != want {
+					t.Errorf("TestUpdateDBStatus_Conditions condition %v = %+v, want status %v", conditionType, c, want)
+				}
+			}
+		})
+	}
+}
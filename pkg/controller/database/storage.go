@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ensurePvcsStorage will ensure that the PersistentVolumeClaims of the Database request the storage of the spec
+// NOTE: The request is only increased when the claim is bound and its StorageClass allows the volume expansion. The
+// shrinking is not supported by the cluster so it is refused and reported in the condition StorageResized.
+func (r *ReconcileDatabase) ensurePvcsStorage(db *v1alpha1.Database) error {
+	desired, err := resource.ParseQuantity(db.Spec.DatabaseStorageRequest)
+	if err != nil {
+		return err
+	}
+
+	pvcs, err := r.fetchDatabasePvcs(db)
+	if err != nil {
+		return err
+	}
+
+	for _, pvc := range pvcs {
+		requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if desired.Cmp(requested) <= 0 || pvc.Status.Phase != corev1.ClaimBound {
+			continue
+		}
+
+		allowed, err := r.isVolumeExpansionAllowed(pvc)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			continue
+		}
+
+		if pvc.Spec.Resources.Requests == nil {
+			pvc.Spec.Resources.Requests = corev1.ResourceList{}
+		}
+		pvc.Spec.Resources.Requests[corev1.ResourceStorage] = desired
+		if err := r.client.Update(context.TODO(), pvc); err != nil {
+			return err
+		}
+		if r.recorder != nil {
+			r.recorder.Eventf(db, corev1.EventTypeNormal, "StorageExpansion", "PersistentVolumeClaim %v expanded from %v to %v.",
+				pvc.Name, requested.String(), desired.String())
+		}
+	}
+	return nil
+}
+
+// buildStorageCondition returns the condition StorageResized according to the PersistentVolumeClaims of the Database
+// NOTE: The errors (E.g. shrinking) are reported before the expansions in progress
+func (r *ReconcileDatabase) buildStorageCondition(db *v1alpha1.Database) (v1alpha1.Condition, error) {
+	generation := db.Generation
+	desired, err := resource.ParseQuantity(db.Spec.DatabaseStorageRequest)
+	if err != nil {
+		return utils.NewCondition(v1alpha1.ConditionStorageResized, false, "InvalidStorageRequest",
+			fmt.Sprintf("Error: The databaseStorageRequest %v is invalid: %v", db.Spec.DatabaseStorageRequest, err), generation), nil
+	}
+
+	pvcs, err := r.fetchDatabasePvcs(db)
+	if err != nil {
+		return v1alpha1.Condition{}, err
+	}
+
+	var progress *v1alpha1.Condition
+	for _, pvc := range pvcs {
+		requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if desired.Cmp(requested) < 0 {
+			return utils.NewCondition(v1alpha1.ConditionStorageResized, false, "ShrinkNotSupported",
+				fmt.Sprintf("Error: The databaseStorageRequest %v is smaller than the %v requested by the PersistentVolumeClaim %v. Shrinking a PersistentVolumeClaim is not supported.",
+					desired.String(), requested.String(), pvc.Name), generation), nil
+		}
+
+		if desired.Cmp(requested) > 0 && pvc.Status.Phase == corev1.ClaimBound {
+			allowed, err := r.isVolumeExpansionAllowed(pvc)
+			if err != nil {
+				return v1alpha1.Condition{}, err
+			}
+			if !allowed {
+				return utils.NewCondition(v1alpha1.ConditionStorageResized, false, "ExpansionNotAllowed",
+					fmt.Sprintf("Error: The StorageClass of the PersistentVolumeClaim %v does not allow the volume expansion to %v.",
+						pvc.Name, desired.String()), generation), nil
+			}
+		}
+
+		capacity, found := pvc.Status.Capacity[corev1.ResourceStorage]
+		if progress != nil || !found || capacity.Cmp(requested) >= 0 {
+			continue
+		}
+		condition := utils.NewCondition(v1alpha1.ConditionStorageResized, false, "Resizing",
+			fmt.Sprintf("PersistentVolumeClaim %v is being expanded from %v to %v", pvc.Name, capacity.String(), requested.String()), generation)
+		if isPvcConditionTrue(pvc, corev1.PersistentVolumeClaimFileSystemResizePending) {
+			condition = utils.NewCondition(v1alpha1.ConditionStorageResized, false, "FileSystemResizePending",
+				fmt.Sprintf("The volume of the PersistentVolumeClaim %v was expanded to %v and its file system is waiting to be resized by the node",
+					pvc.Name, requested.String()), generation)
+		}
+		progress = &condition
+	}
+
+	if progress != nil {
+		return *progress, nil
+	}
+	return utils.NewCondition(v1alpha1.ConditionStorageResized, true, "AsExpected",
+		fmt.Sprintf("The PersistentVolumeClaims request %v", desired.String()), generation), nil
+}
+
+// fetchDatabasePvcs returns the PersistentVolumeClaims of the primary and standbys which were found
+// NOTE: With the StatefulSet they are the claims created by its volumeClaimTemplates for each pod
+func (r *ReconcileDatabase) fetchDatabasePvcs(db *v1alpha1.Database) ([]*corev1.PersistentVolumeClaim, error) {
+	var names []string
+	if utils.IsStatefulSet(db) {
+		for i := 0; i < int(utils.GetStatefulSetReplicas(db)); i++ {
+			names = append(names, utils.GetStatefulSetPvcName(db, i))
+		}
+	} else {
+		names = append(names, db.Name)
+		for i := 0; i < utils.GetStandbySize(db); i++ {
+			names = append(names, utils.GetStandbyName(db, i))
+		}
+	}
+
+	var pvcs []*corev1.PersistentVolumeClaim
+	for _, name := range names {
+		pvc, err := service.FetchPersistentVolumeClaim(name, db.Namespace, r.client)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		pvcs = append(pvcs, pvc)
+	}
+	return pvcs, nil
+}
+
+// isVolumeExpansionAllowed returns true when the StorageClass of the PersistentVolumeClaim has allowVolumeExpansion
+func (r *ReconcileDatabase) isVolumeExpansionAllowed(pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+	sc, err := service.FetchStorageClass(*pvc.Spec.StorageClassName, r.client)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+}
+
+// isPvcConditionTrue returns true when the PersistentVolumeClaim has the condition informed with the status True
+func isPvcConditionTrue(pvc *corev1.PersistentVolumeClaim, conditionType corev1.PersistentVolumeClaimConditionType) bool {
+	for _, c := range pvc.Status.Conditions {
+		if c.Type == conditionType && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestEnsurePvcsStorage(t *testing.T) {
+	tests := []struct {
+		name string
+		db   *v1alpha1.Database
+		objs []runtime.Object
+		want string
+	}{
+		{
+			name: "should expand the pvc when the storage class allows it",
+			db:   &dbInstanceWithStorageRequest,
+			objs: []runtime.Object{&dbInstanceWithStorageRequest, pvcDatabaseBound.DeepCopy(), &storageClassExpandable},
+			want: "2Gi",
+		},
+		{
+			name: "should not expand the pvc when the storage class does not allow it",
+			db:   &dbInstanceWithStorageRequest,
+			objs: []runtime.Object{&dbInstanceWithStorageRequest, pvcDatabaseBound.DeepCopy(), &storageClassNotExpandable},
+			want: "1Gi",
+		},
+		{
+			name: "should not shrink the pvc",
+			db:   &dbInstanceWithStorageRequest,
+			objs: []runtime.Object{&dbInstanceWithStorageRequest, pvcDatabaseLarger.DeepCopy(), &storageClassExpandable},
+			want: "5Gi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildReconcileWithFakeClientWithMocks(tt.objs)
+			db := tt.db.DeepCopy()
+			utils.AddDatabaseMandatorySpecs(db)
+
+			if err := r.ensurePvcsStorage(db); err != nil {
+				t.Fatalf("ensurePvcsStorage() error = %v", err)
+			}
+
+			pvc, err := service.FetchPersistentVolumeClaim(db.Name, db.Namespace, r.client)
+			if err != nil {
+				t.Fatalf("get pvc: (%v)", err)
+			}
+			got := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+			if got.Cmp(resource.MustParse(tt.want)) != 0 {
+				t.Errorf("PVC storage request got (%v), when is expected (%v)", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildStorageCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		db         *v1alpha1.Database
+		objs       []runtime.Object
+		wantStatus corev1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name:       "should be resized when the pvc has the storage of the spec",
+			db:         &dbInstanceWithStorageRequest,
+			objs:       []runtime.Object{&dbInstanceWithStorageRequest, &storageClassExpandable},
+			wantStatus: corev1.ConditionTrue,
+			wantReason: "AsExpected",
+		},
+		{
+			name:       "should report the expansion not allowed by the storage class",
+			db:         &dbInstanceWithStorageRequest,
+			objs:       []runtime.Object{&dbInstanceWithStorageRequest, pvcDatabaseBound.DeepCopy(), &storageClassNotExpandable},
+			wantStatus: corev1.ConditionFalse,
+			wantReason: "ExpansionNotAllowed",
+		},
+		{
+			name:       "should refuse to shrink the pvc",
+			db:         &dbInstanceWithStorageRequest,
+			objs:       []runtime.Object{&dbInstanceWithStorageRequest, pvcDatabaseLarger.DeepCopy(), &storageClassExpandable},
+			wantStatus: corev1.ConditionFalse,
+			wantReason: "ShrinkNotSupported",
+		},
+		{
+			name:       "should track the file system resize pending",
+			db:         &dbInstanceWithStorageRequest,
+			objs:       []runtime.Object{&dbInstanceWithStorageRequest, pvcDatabaseResizePending.DeepCopy(), &storageClassExpandable},
+			wantStatus: corev1.ConditionFalse,
+			wantReason: "FileSystemResizePending",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildReconcileWithFakeClientWithMocks(tt.objs)
+			db := tt.db.DeepCopy()
+			utils.AddDatabaseMandatorySpecs(db)
+
+			got, err := r.buildStorageCondition(db)
+			if err != nil {
+				t.Fatalf("buildStorageCondition() error = %v", err)
+			}
+			if got.Status != tt.wantStatus || got.Reason != tt.wantReason {
+				t.Errorf("buildStorageCondition() got (%v, %v), when is expected (%v, %v): %v",
+					got.Status, got.Reason, tt.wantStatus, tt.wantReason, got.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/resource"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Intervals used to check the server certificate in order to renew it or load it when it is created
+const (
+	tlsCheckInterval = time.Hour
+	tlsRetryInterval = 30 * time.Second
+)
+
+// manageTLS will ensure the server certificate of the Database and store its state in the status
+// NOTE: When the tls is invalid or the certificate is not available the tls of the Database informed, which is used to
+// create and manage the workloads, is replaced by the last one applied to the pods. It is only removed before the first
+// certificate be applied, so the pods are not blocked by a Secret which does not exist and are never redeployed
+// without TLS once it was applied. The hash of the certificate in the pod template restarts the pods when it is renewed.
+func (r *ReconcileDatabase) manageTLS(db *v1alpha1.Database) error {
+	if !utils.IsTLSEnabled(db) {
+		if db.Status.TLS == nil {
+			return nil
+		}
+		db.Status.TLS = nil
+		return r.updateStatus(db)
+	}
+	if utils.ValidateTLS(db) != nil {
+		keepAppliedTLS(db)
+		return nil
+	}
+
+	status := &v1alpha1.TLSStatus{
+		SecretName: utils.GetTLSSecretName(db),
+		Source:     utils.GetTLSSource(db),
+	}
+	if db.Status.TLS != nil {
+		status.LastRenewalTime = db.Status.TLS.LastRenewalTime
+	}
+
+	var secret *corev1.Secret
+	var err error
+	switch status.Source {
+	case utils.TLSSourceSelfSigned:
+		secret, err = r.ensureSelfSignedCertificate(db, status)
+	case utils.TLSSourceCertManager:
+		secret, err = r.ensureCertManagerCertificate(db, status)
+	default:
+		secret, err = r.fetchTLSSecret(db, status)
+	}
+	if err != nil {
+		return err
+	}
+	if secret != nil {
+		setCertificateStatus(db, secret, status)
+		status.AppliedSpec = db.Spec.TLS.DeepCopy()
+	} else if applied := db.Status.TLS; applied != nil && applied.Hash != "" && applied.AppliedSpec != nil {
+		status.SecretName = applied.SecretName
+		status.Source = applied.Source
+		status.NotAfter = applied.NotAfter
+		status.Hash = applied.Hash
+		status.AppliedSpec = applied.AppliedSpec
+	}
+
+	if !reflect.DeepEqual(status, db.Status.TLS) {
+		db.Status.TLS = status
+		if err := r.updateStatus(db); err != nil {
+			return err
+		}
+	}
+	if secret == nil {
+		keepAppliedTLS(db)
+	}
+	return nil
+}
+
+// keepAppliedTLS replaces the tls of the Database informed by the last one applied to the pods, or removes it when none
+// certificate was applied yet
+func keepAppliedTLS(db *v1alpha1.Database) {
+	applied := db.Status.TLS
+	if applied == nil || applied.Hash == "" || applied.AppliedSpec == nil {
+		db.Spec.TLS = nil
+		return
+	}
+	db.Spec.TLS = applied.AppliedSpec.DeepCopy()
+}
+
+// ensureSelfSignedCertificate will create the CA of the Database and the server certificate signed by it, renewing
+// the certificate before its expiry or when the DNS names of the Services change
+func (r *ReconcileDatabase) ensureSelfSignedCertificate(db *v1alpha1.Database, status *v1alpha1.TLSStatus) (*corev1.Secret, error) {
+	ca, err := r.ensureTLSCA(db)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := service.FetchSecret(db.Namespace, utils.GetTLSSecretName(db), r.client)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	found := err == nil
+	bundle := utils.BuildCABundle(ca.Data[utils.TLSCAKey], ca.Data[utils.TLSPreviousCAKey])
+	if found && !isCertificateRenewalRequired(db, secret, ca) {
+		if bytes.Equal(secret.Data[utils.TLSCAKey], bundle) {
+			return secret, nil
+		}
+		secret.Data[utils.TLSCAKey] = bundle
+		return secret, r.client.Update(context.TODO(), secret)
+	}
+
+	cert, key, err := utils.GenerateServerCertificate(ca.Data[utils.TLSCAKey], ca.Data[utils.TLSCAKeyKey], db.Name,
+		utils.GetTLSDNSNames(db), utils.GetTLSValidity(db))
+	if err != nil {
+		return nil, err
+	}
+	desired := resource.NewDatabaseTLSSecret(db, cert, key, bundle, r.scheme)
+	reason, message := "CertificateIssued", "Server certificate issued in the Secret %v."
+	if found {
+		secret.Data = desired.Data
+		err = r.client.Update(context.TODO(), secret)
+		reason, message = "CertificateRenewed", "Server certificate renewed in the Secret %v."
+	} else {
+		secret = desired
+		err = r.client.Create(context.TODO(), secret)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r.recorder != nil {
+		r.recorder.Eventf(db, corev1.EventTypeNormal, reason, message, secret.Name)
+	}
+	now := metav1.Now()
+	status.LastRenewalTime = &now
+	return secret, nil
+}
+
+// ensureTLSCA will create the Secret with the CA of the Database, generating it again before its expiry
+// NOTE: The previous CA is kept in the Secret, so it is still in the bundle ca.crt of the server certificate until it
+// expires. The server certificate signed by it is only renewed by the new CA in the renewBeforeDays before its expiry,
+// which gives the time to the clients trust the new CA.
+func (r *ReconcileDatabase) ensureTLSCA(db *v1alpha1.Database) (*corev1.Secret, error) {
+	secret, err := service.FetchSecret(db.Namespace, utils.GetTLSCASecretName(db), r.client)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	found := err == nil
+	if found {
+		cert, err := utils.ParseCertificate(secret.Data[utils.TLSCAKey])
+		if err == nil && !utils.IsCertificateRenewalRequired(cert, utils.GetTLSCARenewBefore(db), cert.DNSNames) {
+			return secret, nil
+		}
+	}
+
+	cert, key, err := utils.GenerateCA(db.Name+"-ca", utils.TLSCAValidity)
+	if err != nil {
+		return nil, err
+	}
+	desired := resource.NewDatabaseTLSCASecret(db, cert, key, r.scheme)
+	if !found {
+		return desired, r.client.Create(context.TODO(), desired)
+	}
+	if _, err := utils.ParseCertificate(secret.Data[utils.TLSCAKey]); err == nil {
+		desired.Data[utils.TLSPreviousCAKey] = secret.Data[utils.TLSCAKey]
+	}
+	secret.Data = desired.Data
+	if err := r.client.Update(context.TODO(), secret); err != nil {
+		return nil, err
+	}
+
+	if r.recorder != nil {
+		r.recorder.Eventf(db, corev1.EventTypeWarning, "CARenewed",
+			"CA renewed in the Secret %v. The clients should trust the new CA from the ca.crt of the Secret %v before the server certificate be renewed, since the previous CA is only kept until it expires.",
+			secret.Name, utils.GetTLSSecretName(db))
+	}
+	return secret, nil
+}
+
+// ensureCertManagerCertificate will create the Certificate of cert-manager and return the Secret issued by it
+// NOTE: cert-manager renews the certificate according to the renewBefore of the Certificate
+func (r *ReconcileDatabase) ensureCertManagerCertificate(db *v1alpha1.Database, status *v1alpha1.TLSStatus) (*corev1.Secret, error) {
+	desired := resource.NewDatabaseCertificate(db, r.scheme)
+	cert := &unstructured.Unstructured{}
+	cert.SetGroupVersionKind(desired.GroupVersionKind())
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: desired.GetName(), Namespace: desired.GetNamespace()}, cert)
+	switch {
+	case meta.IsNoMatchError(err):
+		status.Message = fmt.Sprintf("Error: The Certificate of cert-manager (%v) is not supported by the cluster.", utils.CertManagerAPIVersion)
+		return nil, nil
+	case errors.IsNotFound(err):
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case !reflect.DeepEqual(cert.Object["spec"], desired.Object["spec"]):
+		cert.Object["spec"] = desired.Object["spec"]
+		if err := r.client.Update(context.TODO(), cert); err != nil {
+			return nil, err
+		}
+	}
+	return r.fetchTLSSecret(db, status)
+}
+
+// fetchTLSSecret returns the Secret with the server certificate or nil with the message in the status when it is not
+// found or invalid
+func (r *ReconcileDatabase) fetchTLSSecret(db *v1alpha1.Database, status *v1alpha1.TLSStatus) (*corev1.Secret, error) {
+	secret, err := service.FetchSecret(db.Namespace, status.SecretName, r.client)
+	if errors.IsNotFound(err) {
+		status.Message = fmt.Sprintf("Waiting for the Secret %v with the server certificate.", status.SecretName)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := utils.ParseCertificate(secret.Data[utils.TLSCertKey]); err != nil || len(secret.Data[utils.TLSKeyKey]) == 0 {
+		status.Message = fmt.Sprintf("Error: The Secret %v does not have a valid %v and %v.", status.SecretName, utils.TLSCertKey, utils.TLSKeyKey)
+		return nil, nil
+	}
+	return secret, nil
+}
+
+// setCertificateStatus stores the expiry and the hash of the server certificate of the Secret in the status
+func setCertificateStatus(db *v1alpha1.Database, secret *corev1.Secret, status *v1alpha1.TLSStatus) {
+	cert, _ := utils.ParseCertificate(secret.Data[utils.TLSCertKey])
+	notAfter := metav1.NewTime(cert.NotAfter)
+	status.NotAfter = &notAfter
+	status.Hash = utils.GetHash(string(secret.Data[utils.TLSCertKey]) + string(secret.Data[utils.TLSKeyKey]))
+	if time.Now().Add(utils.GetTLSRenewBefore(db)).After(cert.NotAfter) {
+		status.Message = fmt.Sprintf("The server certificate expires at %v.", cert.NotAfter.Format(time.RFC3339))
+	}
+}
+
+// isCertificateRenewalRequired returns true when the certificate generated by the operator is invalid, expires soon,
+// does not have the DNS names of the Services or was not signed by the CA of the Database
+// NOTE: The certificate signed by the previous CA is kept until the previous CA expires soon
+func isCertificateRenewalRequired(db *v1alpha1.Database, secret *corev1.Secret, ca *corev1.Secret) bool {
+	cert, err := utils.ParseCertificate(secret.Data[utils.TLSCertKey])
+	if err != nil {
+		return true
+	}
+	if utils.IsCertificateRenewalRequired(cert, utils.GetTLSRenewBefore(db), utils.GetTLSDNSNames(db)) {
+		return true
+	}
+	if utils.IsCertificateSignedBy(cert, ca.Data[utils.TLSCAKey]) {
+		return false
+	}
+	previous, err := utils.ParseCertificate(ca.Data[utils.TLSPreviousCAKey])
+	return err != nil || !utils.IsCertificateSignedBy(cert, ca.Data[utils.TLSPreviousCAKey]) ||
+		utils.IsCertificateRenewalRequired(previous, utils.GetTLSRenewBefore(db), previous.DNSNames)
+}
+
+// isTLSPending returns true when the server certificate is not available yet or the status tls has an error
+func isTLSPending(db *v1alpha1.Database) bool {
+	return db.Status.TLS != nil && (db.Status.TLS.Hash == "" || db.Status.TLS.Message != "")
+}
@@ -0,0 +1,475 @@
+package database
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileDatabase_TLSSelfSigned(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		dbInstanceWithTLS.DeepCopy(),
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithTLS.Name,
+			Namespace: dbInstanceWithTLS.Namespace,
+		},
+	}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if res.RequeueAfter != tlsCheckInterval {
+		t.Errorf("Reconcile requeue got (%v), when is expected (%v)", res.RequeueAfter, tlsCheckInterval)
+	}
+
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.TLS == nil || db.Status.TLS.Source != utils.TLSSourceSelfSigned || db.Status.TLS.Hash == "" ||
+		db.Status.TLS.NotAfter == nil || db.Status.TLS.LastRenewalTime == nil {
+		t.Fatalf("Status tls got (%v), when is expected the certificate issued by the operator", db.Status.TLS)
+	}
+
+	// The server certificate is signed by the CA and has the DNS names of the Services
+	ca, err := service.FetchSecret(req.Namespace, utils.GetTLSCASecretName(db), r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	secret, err := service.FetchSecret(req.Namespace, utils.GetTLSSecretName(db), r.client)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	cert, err := utils.ParseCertificate(secret.Data[utils.TLSCertKey])
+	if err != nil {
+		t.Fatalf("parse certificate: (%v)", err)
+	}
+	caCert, err := utils.ParseCertificate(ca.Data[utils.TLSCAKey])
+	if err != nil {
+		t.Fatalf("parse certificate: (%v)", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	for _, name := range []string{"database", "database.postgresql-operator.svc", "database.postgresql-operator.svc.cluster.local"} {
+		if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, DNSName: name}); err != nil {
+			t.Errorf("Certificate verify with the DNS name (%v) got error (%v)", name, err)
+		}
+	}
+
+	// The pods mount the certificate and only accept the connections with TLS
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	container := dep.Spec.Template.Spec.Containers[0]
+	args := strings.Join(container.Args, " ")
+	for _, arg := range []string{"ssl=on", "ssl_cert_file=" + utils.TLSCertPath, "hba_file=" + utils.HbaConfPath} {
+		if !strings.Contains(args, arg) {
+			t.Errorf("Container args got (%v), when is expected (%v)", container.Args, arg)
+		}
+	}
+	if !hasVolume(dep.Spec.Template.Spec.Volumes, secret.Name) || !hasVolume(dep.Spec.Template.Spec.Volumes, utils.GetHbaConfigMapName(db)) {
+		t.Errorf("Deployment volumes got (%v), when is expected the Secret and the pg_hba.conf", dep.Spec.Template.Spec.Volumes)
+	}
+	if dep.Spec.Template.Annotations[utils.TLSHashAnnotation] != db.Status.TLS.Hash {
+		t.Errorf("Deployment annotations got (%v), when is expected the hash (%v)", dep.Spec.Template.Annotations, db.Status.TLS.Hash)
+	}
+
+	cm, err := service.FetchConfigMap(utils.GetHbaConfigMapName(db), req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get configmap: (%v)", err)
+	}
+	if !strings.Contains(cm.Data[utils.HbaConfKey], "hostssl all all all md5") {
+		t.Errorf("ConfigMap pg_hba.conf got (%v), when is expected only hostssl from the network", cm.Data[utils.HbaConfKey])
+	}
+}
+
+func TestReconcileDatabase_TLSRenewal(t *testing.T) {
+	db := dbInstanceWithTLS.DeepCopy()
+	utils.AddDatabaseMandatorySpecs(db)
+
+	// The certificate expires before the renewBeforeDays
+	caCert, caKey, err := utils.GenerateCA("database-ca", utils.TLSCAValidity)
+	if err != nil {
+		t.Fatalf("generate ca: (%v)", err)
+	}
+	cert, key, err := utils.GenerateServerCertificate(caCert, caKey, db.Name, utils.GetTLSDNSNames(db), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("generate certificate: (%v)", err)
+	}
+	oldHash := utils.GetHash(string(cert) + string(key))
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		dbInstanceWithTLS.DeepCopy(),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: utils.GetTLSCASecretName(db), Namespace: db.Namespace},
+			Data:       map[string][]byte{utils.TLSCAKey: caCert, utils.TLSCAKeyKey: caKey},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: utils.GetTLSSecretName(db), Namespace: db.Namespace},
+			Data:       map[string][]byte{utils.TLSCertKey: cert, utils.TLSKeyKey: key, utils.TLSCAKey: caCert},
+		},
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      db.Name,
+			Namespace: db.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	got, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if got.Status.TLS == nil || got.Status.TLS.Hash == oldHash || got.Status.TLS.NotAfter.Time.Before(time.Now().Add(300*24*time.Hour)) {
+		t.Fatalf("Status tls got (%v), when is expected the certificate renewed", got.Status.TLS)
+	}
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if dep.Spec.Template.Annotations[utils.TLSHashAnnotation] != got.Status.TLS.Hash {
+		t.Errorf("Deployment annotations got (%v), when is expected the hash (%v) of the renewed certificate", dep.Spec.Template.Annotations, got.Status.TLS.Hash)
+	}
+}
+
+func TestReconcileDatabase_TLSCARenewal(t *testing.T) {
+	tests := []struct {
+		name            string
+		caValidity      time.Duration
+		wantCertRenewed bool
+	}{
+		{
+			name:            "should keep the certificate signed by the previous CA and trust both CAs",
+			caValidity:      40 * 24 * time.Hour,
+			wantCertRenewed: false,
+		},
+		{
+			name:            "should renew the certificate by the new CA when the previous CA expires soon",
+			caValidity:      20 * 24 * time.Hour,
+			wantCertRenewed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbInstanceWithTLS.DeepCopy()
+			utils.AddDatabaseMandatorySpecs(db)
+
+			// The CA expires before twice the renewBeforeDays
+			caCert, caKey, err := utils.GenerateCA("database-ca", tt.caValidity)
+			if err != nil {
+				t.Fatalf("generate ca: (%v)", err)
+			}
+			cert, key, err := utils.GenerateServerCertificate(caCert, caKey, db.Name, utils.GetTLSDNSNames(db), 300*24*time.Hour)
+			if err != nil {
+				t.Fatalf("generate certificate: (%v)", err)
+			}
+
+			// objects to track in the fake client
+			objs := []runtime.Object{
+				dbInstanceWithTLS.DeepCopy(),
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: utils.GetTLSCASecretName(db), Namespace: db.Namespace},
+					Data:       map[string][]byte{utils.TLSCAKey: caCert, utils.TLSCAKeyKey: caKey},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: utils.GetTLSSecretName(db), Namespace: db.Namespace},
+					Data:       map[string][]byte{utils.TLSCertKey: cert, utils.TLSKeyKey: key, utils.TLSCAKey: caCert},
+				},
+			}
+
+			r := buildReconcileWithFakeClientWithMocks(objs)
+
+			// mock request to simulate Reconcile() being called on an event for a watched resource
+			req := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      db.Name,
+					Namespace: db.Namespace,
+				},
+			}
+
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+
+			ca, err := service.FetchSecret(req.Namespace, utils.GetTLSCASecretName(db), r.client)
+			if err != nil {
+				t.Fatalf("get ca secret: (%v)", err)
+			}
+			if string(ca.Data[utils.TLSCAKey]) == string(caCert) || string(ca.Data[utils.TLSPreviousCAKey]) != string(caCert) {
+				t.Fatalf("CA Secret got (%v), when is expected a new CA with the previous one", ca.Data)
+			}
+
+			secret, err := service.FetchSecret(req.Namespace, utils.GetTLSSecretName(db), r.client)
+			if err != nil {
+				t.Fatalf("get tls secret: (%v)", err)
+			}
+			bundle := string(secret.Data[utils.TLSCAKey])
+			if !strings.Contains(bundle, string(ca.Data[utils.TLSCAKey])) || !strings.Contains(bundle, string(caCert)) {
+				t.Errorf("TLS Secret ca.crt got (%v), when is expected the new and the previous CA", bundle)
+			}
+			if renewed := string(secret.Data[utils.TLSCertKey]) != string(cert); renewed != tt.wantCertRenewed {
+				t.Errorf("TLS Secret certificate renewed got (%v), when is expected (%v)", renewed, tt.wantCertRenewed)
+			}
+
+			recorder := r.recorder.(*record.FakeRecorder)
+			found := false
+			for len(recorder.Events) > 0 {
+				if strings.Contains(<-recorder.Events, "CARenewed") {
+					found = true
+				}
+			}
+			if !found {
+				t.Error("Event CARenewed was not recorded")
+			}
+		})
+	}
+}
+
+func TestReconcileDatabase_TLSSecret(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		dbInstanceWithTLSSecret.DeepCopy(),
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithTLSSecret.Name,
+			Namespace: dbInstanceWithTLSSecret.Namespace,
+		},
+	}
+
+	// The Secret does not exist so the pods are created without the TLS
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if res.RequeueAfter != tlsRetryInterval {
+		t.Errorf("Reconcile requeue got (%v), when is expected (%v)", res.RequeueAfter, tlsRetryInterval)
+	}
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.TLS == nil || db.Status.TLS.Source != utils.TLSSourceSecret || db.Status.TLS.Message == "" {
+		t.Fatalf("Status tls got (%v), when is expected the message waiting for the Secret", db.Status.TLS)
+	}
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if hasVolume(dep.Spec.Template.Spec.Volumes, dbInstanceWithTLSSecret.Spec.TLS.SecretName) {
+		t.Errorf("Deployment volumes got (%v), when is expected without the Secret which does not exist", dep.Spec.Template.Spec.Volumes)
+	}
+
+	// The Secret is created with the certificate
+	caCert, caKey, err := utils.GenerateCA("ca", utils.TLSCAValidity)
+	if err != nil {
+		t.Fatalf("generate ca: (%v)", err)
+	}
+	cert, key, err := utils.GenerateServerCertificate(caCert, caKey, "database", []string{"database"}, 90*24*time.Hour)
+	if err != nil {
+		t.Fatalf("generate certificate: (%v)", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: dbInstanceWithTLSSecret.Spec.TLS.SecretName, Namespace: req.Namespace},
+		Data:       map[string][]byte{utils.TLSCertKey: cert, utils.TLSKeyKey: key},
+	}
+	if err := r.client.Create(context.TODO(), secret); err != nil {
+		t.Fatalf("create secret: (%v)", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.TLS == nil || db.Status.TLS.Message != "" || db.Status.TLS.NotAfter == nil || db.Status.TLS.LastRenewalTime != nil {
+		t.Fatalf("Status tls got (%v), when is expected the expiry of the certificate of the Secret", db.Status.TLS)
+	}
+	dep, err = service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if !hasVolume(dep.Spec.Template.Spec.Volumes, secret.Name) || !strings.Contains(strings.Join(dep.Spec.Template.Spec.Containers[0].Args, " "), "ssl=on") {
+		t.Errorf("Deployment got volumes (%v) and args (%v), when is expected the certificate of the Secret",
+			dep.Spec.Template.Spec.Volumes, dep.Spec.Template.Spec.Containers[0].Args)
+	}
+	hash := db.Status.TLS.Hash
+
+	// The Secret informed is changed to one which does not exist and then to an invalid tls, so the TLS applied is kept
+	db.Spec.TLS.SecretName = "missing"
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("update database: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	db.Spec.TLS.CertManager = &v1alpha1.DatabaseTLSCertManager{IssuerName: "ca"}
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("update database: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	db, err = service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.TLS == nil || db.Status.TLS.Hash != hash || db.Status.TLS.Message == "" {
+		t.Fatalf("Status tls got (%v), when is expected the hash (%v) applied with the message of the missing Secret", db.Status.TLS, hash)
+	}
+	dep, err = service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if !hasVolume(dep.Spec.Template.Spec.Volumes, secret.Name) || !strings.Contains(strings.Join(dep.Spec.Template.Spec.Containers[0].Args, " "), "ssl=on") ||
+		dep.Spec.Template.Annotations[utils.TLSHashAnnotation] != hash {
+		t.Errorf("Deployment got volumes (%v), args (%v) and annotations (%v), when is expected the certificate applied",
+			dep.Spec.Template.Spec.Volumes, dep.Spec.Template.Spec.Containers[0].Args, dep.Spec.Template.Annotations)
+	}
+}
+
+func TestReconcileDatabase_TLSCertManager(t *testing.T) {
+
+	// objects to track in the fake client
+	objs := []runtime.Object{
+		dbInstanceWithTLSCertManager.DeepCopy(),
+	}
+
+	r := buildReconcileWithFakeClientWithMocks(objs)
+
+	// mock request to simulate Reconcile() being called on an event for a watched resource
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithTLSCertManager.Name,
+			Namespace: dbInstanceWithTLSCertManager.Namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	cert := &unstructured.Unstructured{}
+	cert.SetAPIVersion(utils.CertManagerAPIVersion)
+	cert.SetKind("Certificate")
+	if err := r.client.Get(context.TODO(), req.NamespacedName, cert); err != nil {
+		t.Fatalf("get certificate: (%v)", err)
+	}
+	secretName, _, _ := unstructured.NestedString(cert.Object, "spec", "secretName")
+	issuerKind, _, _ := unstructured.NestedString(cert.Object, "spec", "issuerRef", "kind")
+	if secretName != "database-tls" || issuerKind != "Issuer" {
+		t.Errorf("Certificate spec got (%v), when is expected the Secret database-tls issued by the Issuer", cert.Object["spec"])
+	}
+
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	if db.Status.TLS == nil || db.Status.TLS.Source != utils.TLSSourceCertManager || db.Status.TLS.Message == "" {
+		t.Errorf("Status tls got (%v), when is expected the message waiting for the Secret issued by cert-manager", db.Status.TLS)
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		tls     *v1alpha1.DatabaseTLS
+		wantErr bool
+	}{
+		{
+			name: "should accept the certificate generated by the operator",
+			tls:  &v1alpha1.DatabaseTLS{Enabled: true},
+		},
+		{
+			name: "should accept the cert-manager ClusterIssuer",
+			tls:  &v1alpha1.DatabaseTLS{Enabled: true, CertManager: &v1alpha1.DatabaseTLSCertManager{IssuerName: "ca", IssuerKind: "ClusterIssuer"}},
+		},
+		{
+			name:    "should not accept the secretName with the certManager",
+			tls:     &v1alpha1.DatabaseTLS{Enabled: true, SecretName: "cert", CertManager: &v1alpha1.DatabaseTLSCertManager{IssuerName: "ca"}},
+			wantErr: true,
+		},
+		{
+			name:    "should not accept the certManager without the issuerName",
+			tls:     &v1alpha1.DatabaseTLS{Enabled: true, CertManager: &v1alpha1.DatabaseTLSCertManager{}},
+			wantErr: true,
+		},
+		{
+			name:    "should not accept an unknown issuerKind",
+			tls:     &v1alpha1.DatabaseTLS{Enabled: true, CertManager: &v1alpha1.DatabaseTLSCertManager{IssuerName: "ca", IssuerKind: "Vault"}},
+			wantErr: true,
+		},
+		{
+			name:    "should not accept the renewBeforeDays greater than the validityDays",
+			tls:     &v1alpha1.DatabaseTLS{Enabled: true, ValidityDays: 30, RenewBeforeDays: 60},
+			wantErr: true,
+		},
+		{
+			name: "should accept anything when it is disabled",
+			tls:  &v1alpha1.DatabaseTLS{SecretName: "cert", CertManager: &v1alpha1.DatabaseTLSCertManager{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbInstanceWithoutSpec.DeepCopy()
+			db.Spec.TLS = tt.tls
+			utils.AddDatabaseMandatorySpecs(db)
+			if err := utils.ValidateTLS(db); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTLS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// hasVolume returns true when the volume with the name informed is found
+func hasVolume(volumes []corev1.Volume, name string) bool {
+	for _, v := range volumes {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
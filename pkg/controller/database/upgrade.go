@@ -0,0 +1,440 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/resource"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Phases of the upgrade shown in the status
+const (
+	upgradeBackingUp   = "BackingUp"
+	upgradeDumping     = "Dumping"
+	upgradeUpgrading   = "Upgrading"
+	upgradeSwapping    = "Swapping"
+	upgradeRollingBack = "RollingBack"
+	upgradeSucceeded   = "Succeeded"
+	upgradeFailed      = "Failed"
+	upgradeCancelled   = "Cancelled"
+)
+
+// Steps of the upgrade and their phases shown in the status
+const (
+	upgradeBackupStep   = "Backup"
+	upgradeDumpStep     = "Dump"
+	upgradeLoadStep     = "Upgrade"
+	upgradeSwapStep     = "Swap"
+	upgradeRollbackStep = "Rollback"
+
+	stepRunning   = "Running"
+	stepSucceeded = "Succeeded"
+	stepFailed    = "Failed"
+	stepSkipped   = "Skipped"
+)
+
+const (
+	// upgradeCheckInterval is the interval used to check the steps of the upgrade while it is in progress
+	upgradeCheckInterval = 10 * time.Second
+	// upgradeSwapTimeout is the time which the Deployment has to be available with the new image before the rollback
+	upgradeSwapTimeout = 10 * time.Minute
+)
+
+// manageUpgrade will upgrade the data of the Database when the spec.postgresVersion is changed to a new major version
+// NOTE: The workloads keep running the previous image until the data be upgraded, so the image of the spec is replaced
+// in the Database informed which is used to create and manage them
+func (r *ReconcileDatabase) manageUpgrade(db *v1alpha1.Database) error {
+	if db.Spec.PostgresVersion == "" || utils.ValidatePostgresVersion(db) != nil {
+		return nil
+	}
+
+	// The version informed for the first time is the one of the data
+	if db.Status.PostgresVersion == "" {
+		db.Status.PostgresVersion = db.Spec.PostgresVersion
+		return r.updateStatus(db)
+	}
+
+	switch {
+	case isUpgradeInProgress(db):
+		if err := r.runUpgradeStep(db); err != nil {
+			return err
+		}
+	case isUpgradeRequested(db):
+		if err := r.startUpgrade(db); err != nil {
+			return err
+		}
+	case db.Status.Upgrade != nil && db.Status.Upgrade.Phase == upgradeFailed && !isVersionChanged(db):
+		// The spec was reverted to the version of the data so the upgrade can be requested again
+		db.Status.Upgrade.Phase = upgradeCancelled
+		if err := r.updateStatus(db); err != nil {
+			return err
+		}
+	}
+
+	if isPreviousImageRequired(db) {
+		db.Spec.Image = db.Status.Upgrade.FromImage
+	}
+	return nil
+}
+
+// startUpgrade records the upgrade requested in the status or the reason why it was refused
+func (r *ReconcileDatabase) startUpgrade(db *v1alpha1.Database) error {
+	fromImage, err := r.fetchRunningImage(db)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// The Database has no data yet so the new version can be used directly
+			db.Status.PostgresVersion = db.Spec.PostgresVersion
+			return r.updateStatus(db)
+		}
+		return err
+	}
+
+	upgrade := &v1alpha1.UpgradeStatus{
+		FromVersion: db.Status.PostgresVersion,
+		ToVersion:   db.Spec.PostgresVersion,
+		FromImage:   fromImage,
+		ToImage:     db.Spec.Image,
+		Generation:  db.Generation,
+		Phase:       upgradeBackingUp,
+		StartTime:   metav1.Now(),
+	}
+	db.Status.Upgrade = upgrade
+
+	from, err := utils.GetPostgresMajorVersion(upgrade.FromVersion)
+	if err != nil {
+		return r.failUpgrade(db, err.Error())
+	}
+	to, _ := utils.GetPostgresMajorVersion(upgrade.ToVersion)
+	switch {
+	case to < from:
+		return r.failUpgrade(db, fmt.Sprintf("Error: The downgrade from the version %v to %v is not supported.", upgrade.FromVersion, upgrade.ToVersion))
+	case utils.IsStatefulSet(db):
+		return r.failUpgrade(db, "Error: The upgrade of the major version is not supported with the StatefulSet.")
+	case upgrade.FromImage == upgrade.ToImage:
+		return r.failUpgrade(db, fmt.Sprintf("Error: The image %v should be changed together with the postgresVersion.", upgrade.FromImage))
+	}
+
+	if r.recorder != nil {
+		r.recorder.Eventf(db, corev1.EventTypeNormal, "UpgradeStarted", "Upgrade of the data from the version %v to %v started.",
+			upgrade.FromVersion, upgrade.ToVersion)
+	}
+	return r.updateStatus(db)
+}
+
+// runUpgradeStep checks the step of the upgrade in progress and starts the next one when it is finished
+func (r *ReconcileDatabase) runUpgradeStep(db *v1alpha1.Database) error {
+	upgrade := db.Status.Upgrade
+	switch upgrade.Phase {
+	case upgradeBackingUp:
+		return r.backupBeforeUpgrade(db)
+	case upgradeDumping:
+		return r.runUpgradeJob(db, upgradeDumpStep, utils.UpgradeDumpStep, func() error {
+			upgrade.Phase = upgradeUpgrading
+			return nil
+		}, func(msg string) error {
+			return r.failUpgrade(db, msg)
+		})
+	case upgradeUpgrading:
+		return r.runUpgradeJob(db, upgradeLoadStep, utils.UpgradeLoadStep, func() error {
+			upgrade.Phase = upgradeSwapping
+			// The standbys are created again from the upgraded primary
+			return r.deleteStandbysForUpgrade(db)
+		}, func(msg string) error {
+			upgrade.Phase = upgradeRollingBack
+			upgrade.Message = msg
+			return r.updateStatus(db)
+		})
+	case upgradeSwapping:
+		return r.swapAfterUpgrade(db)
+	case upgradeRollingBack:
+		return r.runUpgradeJob(db, upgradeRollbackStep, utils.UpgradeRollbackStep, func() error {
+			return r.failUpgrade(db, upgrade.Message)
+		}, func(msg string) error {
+			return r.failUpgrade(db, upgrade.Message+" "+msg)
+		})
+	}
+	return nil
+}
+
+// backupBeforeUpgrade requests an on-demand backup to the Backup CR of the Database and waits for it
+// NOTE: The step is skipped when none Backup CR refers to the Database since the dump of the upgrade is kept in the PVC
+func (r *ReconcileDatabase) backupBeforeUpgrade(db *v1alpha1.Database) error {
+	upgrade := db.Status.Upgrade
+	step := startUpgradeStep(upgrade, upgradeBackupStep, "")
+
+	bkp, err := r.fetchDatabaseBackup(db)
+	if err != nil {
+		return err
+	}
+	if bkp == nil || bkp.Spec.Trigger != "" {
+		step.Message = "None Backup CR refers to the Database. The dump of the upgrade is kept in the PVC."
+		if bkp != nil {
+			step.Message = fmt.Sprintf("The spec trigger of the Backup %v has precedence over the annotation %v. The dump of the upgrade is kept in the PVC.",
+				bkp.Name, utils.BackupTriggerAnnotation)
+		}
+		finishUpgradeStep(step, stepSkipped)
+		upgrade.Phase = upgradeDumping
+		return r.updateStatus(db)
+	}
+
+	trigger := utils.GetUpgradeBackupTrigger(db)
+	if bkp.Annotations[utils.BackupTriggerAnnotation] != trigger {
+		if bkp.Annotations == nil {
+			bkp.Annotations = map[string]string{}
+		}
+		bkp.Annotations[utils.BackupTriggerAnnotation] = trigger
+		if err := r.client.Update(context.TODO(), bkp); err != nil {
+			return err
+		}
+		step.Message = fmt.Sprintf("On-demand backup %v requested to the Backup %v", trigger, bkp.Name)
+		return r.updateStatus(db)
+	}
+
+	backup := bkp.Status.OnDemandBackup
+	if backup == nil || backup.Trigger != trigger {
+		return nil
+	}
+	step.JobName = backup.JobName
+	switch backup.Phase {
+	case stepSucceeded:
+		finishUpgradeStep(step, stepSucceeded)
+		upgrade.Phase = upgradeDumping
+		return r.updateStatus(db)
+	case stepFailed:
+		step.Message = backup.Error
+		finishUpgradeStep(step, stepFailed)
+		return r.failUpgrade(db, fmt.Sprintf("Error: The backup before the upgrade failed. Check the logs of the Job %v.", backup.JobName))
+	}
+	return nil
+}
+
+// runUpgradeJob creates the Job of the step when the Deployment is scaled down and calls next or fail when it is finished
+func (r *ReconcileDatabase) runUpgradeJob(db *v1alpha1.Database, stepName, jobStep string, next func() error, fail func(msg string) error) error {
+	upgrade := db.Status.Upgrade
+
+	// The data cannot be changed while the database is running
+	dep, err := service.FetchDeployment(db.Name, db.Namespace, r.client)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && (dep.Spec.Replicas == nil || *dep.Spec.Replicas != 0 || dep.Status.Replicas != 0) {
+		return nil
+	}
+
+	step := startUpgradeStep(upgrade, stepName, utils.GetUpgradeJobName(db, jobStep))
+	job, err := service.FetchJob(step.JobName, db.Namespace, r.client)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if err := r.client.Create(context.TODO(), resource.NewDatabaseUpgradeJob(db, jobStep, r.scheme)); err != nil {
+			return err
+		}
+		return r.updateStatus(db)
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+		finishUpgradeStep(step, stepSucceeded)
+		if err := next(); err != nil {
+			return err
+		}
+		return r.updateStatus(db)
+	case isJobFailed(job):
+		step.Message = fmt.Sprintf("Check the logs of the Job %v", job.Name)
+		finishUpgradeStep(step, stepFailed)
+		return fail(fmt.Sprintf("Error: The step %v of the upgrade failed. Check the logs of the Job %v.", stepName, job.Name))
+	}
+	return nil
+}
+
+// swapAfterUpgrade waits for the Deployment be available with the new image and rolls back when it does not happen in time
+// NOTE: The Deployment is updated with the new image by the manageResources since the previous one is no longer required
+func (r *ReconcileDatabase) swapAfterUpgrade(db *v1alpha1.Database) error {
+	upgrade := db.Status.Upgrade
+	step := startUpgradeStep(upgrade, upgradeSwapStep, "")
+
+	dep, err := service.FetchDeployment(db.Name, db.Namespace, r.client)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && len(dep.Spec.Template.Spec.Containers) > 0 && dep.Spec.Template.Spec.Containers[0].Image == upgrade.ToImage {
+		rolledOut, err := r.isWorkloadRolledOut(db, dep.Name)
+		if err != nil {
+			return err
+		}
+		if rolledOut && dep.Status.AvailableReplicas > 0 {
+			finishUpgradeStep(step, stepSucceeded)
+			now := metav1.Now()
+			upgrade.Phase = upgradeSucceeded
+			upgrade.CompletionTime = &now
+			db.Status.PostgresVersion = upgrade.ToVersion
+			if r.recorder != nil {
+				r.recorder.Eventf(db, corev1.EventTypeNormal, "UpgradeSucceeded", "Data upgraded from the version %v to %v.",
+					upgrade.FromVersion, upgrade.ToVersion)
+			}
+			return r.updateStatus(db)
+		}
+	}
+
+	if time.Since(step.StartTime.Time) < upgradeSwapTimeout {
+		return nil
+	}
+	step.Message = fmt.Sprintf("The Deployment %v was not available with the image %v after %v", db.Name, upgrade.ToImage, upgradeSwapTimeout)
+	finishUpgradeStep(step, stepFailed)
+	upgrade.Phase = upgradeRollingBack
+	upgrade.Message = "Error: " + step.Message + "."
+	return r.updateStatus(db)
+}
+
+// failUpgrade finishes the upgrade with the error informed. The Database keeps running the previous image
+func (r *ReconcileDatabase) failUpgrade(db *v1alpha1.Database, msg string) error {
+	now := metav1.Now()
+	db.Status.Upgrade.Phase = upgradeFailed
+	db.Status.Upgrade.CompletionTime = &now
+	db.Status.Upgrade.Message = msg
+	if r.recorder != nil {
+		r.recorder.Eventf(db, corev1.EventTypeWarning, "UpgradeFailed", "%v", msg)
+	}
+	return r.updateStatus(db)
+}
+
+// deleteStandbysForUpgrade will delete the Deployments and PersistentVolumeClaims of the standbys which have the data of
+// the previous version. They are created again by the createStandbys
+func (r *ReconcileDatabase) deleteStandbysForUpgrade(db *v1alpha1.Database) error {
+	for i := 0; i < utils.GetStandbySize(db); i++ {
+		name := utils.GetStandbyName(db, i)
+		if dep, err := service.FetchDeployment(name, db.Namespace, r.client); err == nil {
+			if err := r.client.Delete(context.TODO(), dep); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		if pvc, err := service.FetchPersistentVolumeClaim(name, db.Namespace, r.client); err == nil {
+			if err := r.client.Delete(context.TODO(), pvc); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fetchRunningImage returns the image of the container of the database which is running in the primary workload
+func (r *ReconcileDatabase) fetchRunningImage(db *v1alpha1.Database) (string, error) {
+	var containers []corev1.Container
+	if utils.IsStatefulSet(db) {
+		sts, err := service.FetchStatefulSet(db.Name, db.Namespace, r.client)
+		if err != nil {
+			return "", err
+		}
+		containers = sts.Spec.Template.Spec.Containers
+	} else {
+		dep, err := service.FetchDeployment(db.Name, db.Namespace, r.client)
+		if err != nil {
+			return "", err
+		}
+		containers = dep.Spec.Template.Spec.Containers
+	}
+	if len(containers) == 0 {
+		return db.Spec.Image, nil
+	}
+	return containers[0].Image, nil
+}
+
+// startUpgradeStep returns the step in progress with the name informed or appends a new one
+func startUpgradeStep(upgrade *v1alpha1.UpgradeStatus, name, jobName string) *v1alpha1.UpgradeStep {
+	if n := len(upgrade.Steps); n > 0 && upgrade.Steps[n-1].Name == name && upgrade.Steps[n-1].Phase == stepRunning {
+		return &upgrade.Steps[n-1]
+	}
+	upgrade.Steps = append(upgrade.Steps, v1alpha1.UpgradeStep{
+		Name:      name,
+		Phase:     stepRunning,
+		StartTime: metav1.Now(),
+		JobName:   jobName,
+	})
+	return &upgrade.Steps[len(upgrade.Steps)-1]
+}
+
+// finishUpgradeStep sets the phase and completion time of the step
+func finishUpgradeStep(step *v1alpha1.UpgradeStep, phase string) {
+	now := metav1.Now()
+	step.Phase = phase
+	step.CompletionTime = &now
+}
+
+// isJobFailed returns true when the Job has the condition Failed
+func isJobFailed(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return job.Spec.BackoffLimit != nil && job.Status.Failed > *job.Spec.BackoffLimit
+}
+
+// isVersionChanged returns true when the major version of the spec is not the one of the data
+func isVersionChanged(db *v1alpha1.Database) bool {
+	spec, err := utils.GetPostgresMajorVersion(db.Spec.PostgresVersion)
+	if err != nil {
+		return false
+	}
+	status, err := utils.GetPostgresMajorVersion(db.Status.PostgresVersion)
+	return err != nil || spec != status
+}
+
+// isUpgradeRequested returns true when the version of the spec changed and it was not upgraded or refused before
+func isUpgradeRequested(db *v1alpha1.Database) bool {
+	if !isVersionChanged(db) {
+		return false
+	}
+	upgrade := db.Status.Upgrade
+	return upgrade == nil || upgrade.Phase == upgradeSucceeded || upgrade.Phase == upgradeCancelled ||
+		upgrade.ToVersion != db.Spec.PostgresVersion || upgrade.ToImage != db.Spec.Image
+}
+
+// isUpgradeInProgress returns true when the upgrade of the Database is not finished
+func isUpgradeInProgress(db *v1alpha1.Database) bool {
+	if db.Status.Upgrade == nil {
+		return false
+	}
+	switch db.Status.Upgrade.Phase {
+	case upgradeSucceeded, upgradeFailed, upgradeCancelled:
+		return false
+	}
+	return true
+}
+
+// isDatabaseStoppedByUpgrade returns true when the Deployment should be scaled down since the data is being changed
+func isDatabaseStoppedByUpgrade(db *v1alpha1.Database) bool {
+	if db.Status.Upgrade == nil {
+		return false
+	}
+	switch db.Status.Upgrade.Phase {
+	case upgradeDumping, upgradeUpgrading, upgradeRollingBack:
+		return true
+	}
+	return false
+}
+
+// isPreviousImageRequired returns true when the workloads should keep running the image of the data before the upgrade
+// NOTE: It is the case while the upgrade is in progress, except when swapping, and after it fails until the spec change
+func isPreviousImageRequired(db *v1alpha1.Database) bool {
+	upgrade := db.Status.Upgrade
+	if upgrade == nil {
+		return false
+	}
+	switch upgrade.Phase {
+	case upgradeSucceeded, upgradeCancelled, upgradeSwapping:
+		return false
+	case upgradeFailed:
+		return upgrade.ToImage == db.Spec.Image && upgrade.ToVersion == db.Spec.PostgresVersion
+	}
+	return true
+}
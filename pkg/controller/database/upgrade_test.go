@@ -0,0 +1,261 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	upgradeFromImage = "centos/postgresql-96-centos7"
+	upgradeToImage   = "centos/postgresql-12-centos7"
+)
+
+// requestUpgrade reconciles the Database created with the version 9.6 and changes its spec to the version 12
+func requestUpgrade(t *testing.T) (*ReconcileDatabase, reconcile.Request) {
+	objs := []runtime.Object{
+		dbInstanceWithPostgresVersion.DeepCopy(),
+	}
+	r := buildReconcileWithStatusSubresource(objs)
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithPostgresVersion.Name,
+			Namespace: dbInstanceWithPostgresVersion.Namespace,
+		},
+	}
+
+	reconcileUpgrade(t, r, req)
+	db := fetchUpgradeDatabase(t, r, req)
+	if db.Status.PostgresVersion != "9.6" {
+		t.Fatalf("Status postgresVersion got (%v), when is expected (%v)", db.Status.PostgresVersion, "9.6")
+	}
+
+	db.Spec.PostgresVersion = "12"
+	db.Spec.Image = upgradeToImage
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	return r, req
+}
+
+func reconcileUpgrade(t *testing.T, r *ReconcileDatabase, req reconcile.Request) {
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+}
+
+func fetchUpgradeDatabase(t *testing.T, r *ReconcileDatabase, req reconcile.Request) *v1alpha1.Database {
+	db, err := service.FetchDatabaseCR(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get database: (%v)", err)
+	}
+	return db
+}
+
+// finishUpgradeJob checks the image of the Job of the step and simulates its outcome
+func finishUpgradeJob(t *testing.T, r *ReconcileDatabase, req reconcile.Request, step, image string, succeeded bool) {
+	db := fetchUpgradeDatabase(t, r, req)
+	job, err := service.FetchJob(utils.GetUpgradeJobName(db, step), req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get job of the step %v: (%v)", step, err)
+	}
+	if got := job.Spec.Template.Spec.Containers[0].Image; got != image {
+		t.Errorf("Job %v image got (%v), when is expected (%v)", job.Name, got, image)
+	}
+	if succeeded {
+		job.Status.Succeeded = 1
+	} else {
+		job.Status.Failed = 1
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	}
+	if err := r.client.Status().Update(context.TODO(), job); err != nil {
+		t.Fatalf("fails when try to update the job status: (%v)", err)
+	}
+}
+
+// checkUpgradePhase reconciles the Database and checks the phase of the upgrade
+func checkUpgradePhase(t *testing.T, r *ReconcileDatabase, req reconcile.Request, phase string) *v1alpha1.Database {
+	reconcileUpgrade(t, r, req)
+	db := fetchUpgradeDatabase(t, r, req)
+	if db.Status.Upgrade == nil || db.Status.Upgrade.Phase != phase {
+		t.Fatalf("Upgrade got (%v), when is expected the phase (%v)", db.Status.Upgrade, phase)
+	}
+	return db
+}
+
+func checkDeploymentImage(t *testing.T, r *ReconcileDatabase, req reconcile.Request, image string, replicas int32) {
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if got := dep.Spec.Template.Spec.Containers[0].Image; got != image {
+		t.Errorf("Deployment image got (%v), when is expected (%v)", got, image)
+	}
+	if *dep.Spec.Replicas != replicas {
+		t.Errorf("Deployment replicas got (%v), when is expected (%v)", *dep.Spec.Replicas, replicas)
+	}
+}
+
+// The status postgresVersion is stored before the resources be created, so the default values must not be lost
+func TestReconcileDatabase_UpgradeKeepsDefaultSpecs(t *testing.T) {
+	objs := []runtime.Object{
+		dbInstanceWithPostgresVersion.DeepCopy(),
+	}
+	r := buildReconcileWithStatusSubresource(objs)
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      dbInstanceWithPostgresVersion.Name,
+			Namespace: dbInstanceWithPostgresVersion.Namespace,
+		},
+	}
+
+	reconcileUpgrade(t, r, req)
+	checkDeploymentImage(t, r, req, upgradeFromImage, 1)
+
+	db := fetchUpgradeDatabase(t, r, req)
+	if db.Status.PostgresVersion != "9.6" {
+		t.Errorf("Status postgresVersion got (%v), when is expected (%v)", db.Status.PostgresVersion, "9.6")
+	}
+	// The default values are not stored in the CR by the reconcile
+	if db.Spec.DatabaseMemoryLimit != "" {
+		t.Errorf("Spec databaseMemoryLimit got (%v), when is expected to not be stored", db.Spec.DatabaseMemoryLimit)
+	}
+}
+
+func TestReconcileDatabase_Upgrade(t *testing.T) {
+	r, req := requestUpgrade(t)
+
+	db := checkUpgradePhase(t, r, req, upgradeBackingUp)
+	if db.Status.Upgrade.FromImage != upgradeFromImage || db.Status.Upgrade.ToImage != upgradeToImage {
+		t.Errorf("Upgrade images got (%v, %v), when is expected (%v, %v)",
+			db.Status.Upgrade.FromImage, db.Status.Upgrade.ToImage, upgradeFromImage, upgradeToImage)
+	}
+	checkDeploymentImage(t, r, req, upgradeFromImage, 1)
+
+	// None Backup CR refers to the Database
+	db = checkUpgradePhase(t, r, req, upgradeDumping)
+	if step := db.Status.Upgrade.Steps[0]; step.Name != upgradeBackupStep || step.Phase != stepSkipped {
+		t.Errorf("Upgrade step got (%v), when is expected (%v) (%v)", step, upgradeBackupStep, stepSkipped)
+	}
+	checkDeploymentImage(t, r, req, upgradeFromImage, 0)
+
+	checkUpgradePhase(t, r, req, upgradeDumping)
+	finishUpgradeJob(t, r, req, utils.UpgradeDumpStep, upgradeFromImage, true)
+	checkUpgradePhase(t, r, req, upgradeUpgrading)
+
+	checkUpgradePhase(t, r, req, upgradeUpgrading)
+	finishUpgradeJob(t, r, req, utils.UpgradeLoadStep, upgradeToImage, true)
+	checkUpgradePhase(t, r, req, upgradeSwapping)
+	checkDeploymentImage(t, r, req, upgradeToImage, 1)
+
+	// Simulate the pod of the Deployment available with the new image
+	dep, err := service.FetchDeployment(req.Name, req.Namespace, r.client)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	dep.Status.Replicas = 1
+	dep.Status.UpdatedReplicas = 1
+	dep.Status.AvailableReplicas = 1
+	if err := r.client.Status().Update(context.TODO(), dep); err != nil {
+		t.Fatalf("fails when try to update the deployment status: (%v)", err)
+	}
+
+	db = checkUpgradePhase(t, r, req, upgradeSucceeded)
+	if db.Status.PostgresVersion != "12" || db.Status.Upgrade.CompletionTime == nil {
+		t.Errorf("Status postgresVersion got (%v), when is expected (%v) with the completion time", db.Status.PostgresVersion, "12")
+	}
+	var steps []string
+	for _, s := range db.Status.Upgrade.Steps {
+		steps = append(steps, s.Name+":"+s.Phase)
+	}
+	expected := "[Backup:Skipped Dump:Succeeded Upgrade:Succeeded Swap:Succeeded]"
+	if got := fmt.Sprint(steps); got != expected {
+		t.Errorf("Upgrade steps got (%v), when is expected (%v)", got, expected)
+	}
+}
+
+func TestReconcileDatabase_UpgradeRollback(t *testing.T) {
+	r, req := requestUpgrade(t)
+
+	checkUpgradePhase(t, r, req, upgradeBackingUp)
+	checkUpgradePhase(t, r, req, upgradeDumping)
+	checkUpgradePhase(t, r, req, upgradeDumping)
+	finishUpgradeJob(t, r, req, utils.UpgradeDumpStep, upgradeFromImage, true)
+	checkUpgradePhase(t, r, req, upgradeUpgrading)
+	checkUpgradePhase(t, r, req, upgradeUpgrading)
+	finishUpgradeJob(t, r, req, utils.UpgradeLoadStep, upgradeToImage, false)
+
+	checkUpgradePhase(t, r, req, upgradeRollingBack)
+	checkDeploymentImage(t, r, req, upgradeFromImage, 0)
+	checkUpgradePhase(t, r, req, upgradeRollingBack)
+	finishUpgradeJob(t, r, req, utils.UpgradeRollbackStep, upgradeFromImage, true)
+
+	db := checkUpgradePhase(t, r, req, upgradeFailed)
+	if db.Status.PostgresVersion != "9.6" || db.Status.Upgrade.Message == "" {
+		t.Errorf("Status postgresVersion got (%v), when is expected (%v) with the message of the failure", db.Status.PostgresVersion, "9.6")
+	}
+	// The Database keeps running the previous image
+	checkUpgradePhase(t, r, req, upgradeFailed)
+	checkDeploymentImage(t, r, req, upgradeFromImage, 1)
+
+	// The upgrade is cancelled when the spec is reverted
+	db.Spec.PostgresVersion = "9.6"
+	db.Spec.Image = upgradeFromImage
+	if err := r.client.Update(context.TODO(), db); err != nil {
+		t.Fatalf("fails when try to update the database: (%v)", err)
+	}
+	checkUpgradePhase(t, r, req, upgradeCancelled)
+}
+
+func TestIsUpgradeRequested(t *testing.T) {
+	tests := []struct {
+		name    string
+		upgrade *v1alpha1.UpgradeStatus
+		version string
+		want    bool
+	}{
+		{
+			name:    "should not upgrade the same major version",
+			version: "9.6",
+			want:    false,
+		},
+		{
+			name:    "should upgrade a new major version",
+			version: "12",
+			want:    true,
+		},
+		{
+			name:    "should not upgrade again the version which failed",
+			upgrade: &v1alpha1.UpgradeStatus{ToVersion: "12", ToImage: upgradeToImage, Phase: upgradeFailed},
+			version: "12",
+			want:    false,
+		},
+		{
+			name:    "should upgrade another version after the failure",
+			upgrade: &v1alpha1.UpgradeStatus{ToVersion: "11", ToImage: upgradeToImage, Phase: upgradeFailed},
+			version: "12",
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbInstanceWithPostgresVersion.DeepCopy()
+			db.Spec.Image = upgradeToImage
+			db.Spec.PostgresVersion = tt.version
+			db.Status.PostgresVersion = "9.6"
+			db.Status.Upgrade = tt.upgrade
+			if got := isUpgradeRequested(db); got != tt.want {
+				t.Errorf("isUpgradeRequested() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
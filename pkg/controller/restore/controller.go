@@ -0,0 +1,171 @@
+package restore
+
+import (
+	"fmt"
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Add creates a new Restore Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileRestore{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(utils.RestoreControllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource Restore
+	err = c.Watch(&source.Kind{Type: &v1alpha1.Restore{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch Job resource controlled and created by it
+	if err := service.Watch(c, &batchv1.Job{}, true, &v1alpha1.Restore{}); err != nil {
+		return err
+	}
+
+	// Watch Secret resource controlled and created by it
+	if err := service.Watch(c, &corev1.Secret{}, true, &v1alpha1.Restore{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// blank assignment to verify that ReconcileRestore implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileRestore{}
+
+// ReconcileRestore reconciles a Restore object
+type ReconcileRestore struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// Reconcile reads that state of the cluster for a Restore object and makes changes based on the state read
+// and what is in the Restore.Spec
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileRestore) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := utils.GetLoggerByRequestAndController(request, utils.RestoreControllerName)
+	reqLogger.Info("Reconciling Restore ...")
+
+	rst, err := service.FetchRestoreCR(request.Name, request.Namespace, r.client)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			reqLogger.Info("Restore resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		reqLogger.Error(err, "Failed to get Restore.")
+		return reconcile.Result{}, err
+	}
+
+	// The restore is done just once. To do it again a new CR should be applied
+	if isRestoreFinished(rst) {
+		reqLogger.Info("Restore is finished. Ignoring since it is done just once.", "Phase", rst.Status.Phase)
+		return reconcile.Result{}, nil
+	}
+
+	// Add const values for mandatory specs
+	reqLogger.Info("Adding restore mandatory specs")
+	utils.AddRestoreMandatorySpecs(rst)
+
+	// The target is checked before creating the Job since it is written in the recovery.conf and the database server
+	// only refuses it after downloading the base backup and the WAL segments
+	if err := utils.ValidatePointInTimeTarget(rst); err != nil {
+		reqLogger.Error(err, "Invalid target of the point-in-time recovery")
+		return reconcile.Result{}, r.updateFailedStatus(request, err)
+	}
+
+	// Create mandatory objects for the Restore
+	if err := r.createResources(rst, request); err != nil {
+		reqLogger.Error(err, "Failed to create the secondary resource required for the Restore CR")
+		if err := r.updateErrorStatus(request, err); err != nil {
+			reqLogger.Error(err, "Failed to update the error in the Restore CR status")
+		}
+		return reconcile.Result{}, err
+	}
+
+	// Update the CR status for the primary resource
+	if err := r.updateRestoreStatus(request); err != nil {
+		reqLogger.Error(err, "Failed to create and update the status in the Restore CR")
+		return reconcile.Result{}, err
+	}
+
+	reqLogger.Info("Stop Reconciling Restore ...")
+	return reconcile.Result{}, nil
+}
+
+//createResources will create the secondary resource which are required in order to do the restore
+func (r *ReconcileRestore) createResources(rst *v1alpha1.Restore, request reconcile.Request) error {
+	reqLogger := utils.GetLoggerByRequestAndController(request, utils.RestoreControllerName)
+	reqLogger.Info("Creating secondary Restore resources ...")
+
+	// Check if the database instance was created
+	db, err := service.FetchDatabaseCR(rst.Spec.DatabaseCRName, request.Namespace, r.client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to fetch Database instance/cr")
+		return err
+	}
+	service.AddDatabaseMandatorySpecs(db, r.client)
+
+	// Use the data of the Backup which did the backup when it is referenced
+	if rst.Spec.BackupCRName != "" {
+		bkp, err := service.FetchBackupCR(rst.Spec.BackupCRName, request.Namespace, r.client)
+		if err != nil {
+			reqLogger.Error(err, "Failed to fetch Backup instance/cr")
+			return err
+		}
+		utils.AddBackupMandatorySpecs(bkp)
+		utils.AddRestoreSpecsFromBackup(rst, bkp)
+
+		// The dumps of the Backup cannot be decrypted without the private key
+		if utils.IsBackupEncrypted(bkp) && rst.Spec.DecryptKeySecretName == "" {
+			err := fmt.Errorf("Error: The backups of the Backup %v are encrypted. Inform the decryptKeySecretName with the GPG_PRIVATE_KEY.", bkp.Name)
+			reqLogger.Error(err, "Failed to find the secret to decrypt the backup")
+			return err
+		}
+	}
+
+	// Check if the secret with the storage and GPG data is created, if not create one
+	if err := r.createRestoreSecret(rst); err != nil {
+		reqLogger.Error(err, "Failed to create the Restore secret")
+		return err
+	}
+
+	// Check if the job is created, if not create one
+	if err := r.createJob(rst, db); err != nil {
+		reqLogger.Error(err, "Failed to create the Job")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,426 @@
+package restore
+
+import (
+	"context"
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+)
+
+func TestReconcileRestore(t *testing.T) {
+	type fields struct {
+		objs []runtime.Object
+	}
+	type args struct {
+		rstInstance v1alpha1.Restore
+	}
+	tests := []struct {
+		name          string
+		fields        fields
+		args          args
+		wantErr       bool
+		wantSecret    bool
+		wantGpgKey    bool
+		wantJob       bool
+		wantPhase     string
+		wantObjectKey string
+		wantError     bool
+	}{
+		{
+			name: "Should create the secret and the job with the data of the Backup CR",
+			fields: fields{
+				objs: []runtime.Object{&rstInstanceWithBackup, &bkpInstance, &dbInstance, &awsSecretFromBackup},
+			},
+			args: args{
+				rstInstance: rstInstanceWithBackup,
+			},
+			wantErr:    false,
+			wantSecret: true,
+			wantGpgKey: false,
+			wantJob:    true,
+			wantPhase:  phaseRunning,
+		},
+		{
+			name: "Should create the secret with the GPG data and the job with the object key informed",
+			fields: fields{
+				objs: []runtime.Object{&rstInstanceWithSecretNames, &dbInstance, &awsSecretWithSecretNames, &decryptSecretWithSecretNames},
+			},
+			args: args{
+				rstInstance: rstInstanceWithSecretNames,
+			},
+			wantErr:       false,
+			wantSecret:    true,
+			wantGpgKey:    true,
+			wantJob:       true,
+			wantPhase:     phaseRunning,
+			wantObjectKey: rstInstanceWithSecretNames.Spec.ObjectKey,
+		},
+		{
+			name: "Should fail when the decrypt key secret informed does not exist",
+			fields: fields{
+				objs: []runtime.Object{&rstInstanceWithSecretNames, &dbInstance, &awsSecretWithSecretNames},
+			},
+			args: args{
+				rstInstance: rstInstanceWithSecretNames,
+			},
+			wantErr:   true,
+			wantPhase: phasePending,
+			wantError: true,
+		},
+		{
+			name: "Should fail when the decrypt key secret informed has not the private key",
+			fields: fields{
+				objs: []runtime.Object{&rstInstanceWithSecretNames, &dbInstance, &awsSecretWithSecretNames, &decryptSecretWithoutPrivateKey},
+			},
+			args: args{
+				rstInstance: rstInstanceWithSecretNames,
+			},
+			wantErr:   true,
+			wantPhase: phasePending,
+			wantError: true,
+		},
+		{
+			name: "Should fail when the Backup CR encrypts the backups and the decrypt key secret is not informed",
+			fields: fields{
+				objs: []runtime.Object{&rstInstanceWithEncryptedBackup, &bkpInstanceEncrypted, &dbInstance, &awsSecretFromBackup},
+			},
+			args: args{
+				rstInstance: rstInstanceWithEncryptedBackup,
+			},
+			wantErr:   true,
+			wantPhase: phasePending,
+			wantError: true,
+		},
+		{
+			name: "Should fail when the AWS secret is not informed and none Backup CR is referenced",
+			fields: fields{
+				objs: []runtime.Object{&rstInstanceWithoutSecrets, &dbInstance},
+			},
+			args: args{
+				rstInstance: rstInstanceWithoutSecrets,
+			},
+			wantErr:   true,
+			wantPhase: phasePending,
+			wantError: true,
+		},
+		{
+			name: "Should fail when the Backup CR referenced was not applied",
+			fields: fields{
+				objs: []runtime.Object{&rstInstanceWithBackup, &dbInstance, &awsSecretFromBackup},
+			},
+			args: args{
+				rstInstance: rstInstanceWithBackup,
+			},
+			wantErr:   true,
+			wantPhase: phasePending,
+			wantError: true,
+		},
+		{
+			name: "Should fail when the Database CR was not applied",
+			fields: fields{
+				objs: []runtime.Object{&rstInstanceWithBackup, &bkpInstance, &awsSecretFromBackup},
+			},
+			args: args{
+				rstInstance: rstInstanceWithBackup,
+			},
+			wantErr:   true,
+			wantPhase: phasePending,
+			wantError: true,
+		},
+		{
+			name: "Should be running while the job is active",
+			fields: fields{
+				objs: []runtime.Object{&rstInstanceWithBackup, &bkpInstance, &dbInstance, &awsSecretFromBackup, &jobRunning},
+			},
+			args: args{
+				rstInstance: rstInstanceWithBackup,
+			},
+			wantErr:    false,
+			wantSecret: true,
+			wantJob:    true,
+			wantPhase:  phaseRunning,
+		},
+		{
+			name: "Should succeed with the object key restored by the job",
+			fields: fields{
+				objs: []runtime.Object{&rstInstanceWithBackup, &bkpInstance, &dbInstance, &awsSecretFromBackup, &jobSucceeded, &podJobSucceeded},
+			},
+			args: args{
+				rstInstance: rstInstanceWithBackup,
+			},
+			wantErr:       false,
+			wantSecret:    true,
+			wantJob:       true,
+			wantPhase:     phaseSucceeded,
+			wantObjectKey: podJobSucceeded.Status.ContainerStatuses[0].State.Terminated.Message,
+		},
+		{
+			name: "Should fail with the error of the job",
+			fields: fields{
+				objs: []runtime.Object{&rstInstanceWithBackup, &bkpInstance, &dbInstance, &awsSecretFromBackup, &jobFailed, &podJobFailed},
+			},
+			args: args{
+				rstInstance: rstInstanceWithBackup,
+			},
+			wantErr:    false,
+			wantSecret: true,
+			wantJob:    true,
+			wantPhase:  phaseFailed,
+			wantError:  true,
+		},
+		{
+			name: "Should not create the job again when the restore is finished",
+			fields: fields{
+				objs: []runtime.Object{&rstInstanceSucceeded, &bkpInstance, &dbInstance, &awsSecretFromBackup},
+			},
+			args: args{
+				rstInstance: rstInstanceSucceeded,
+			},
+			wantErr:    false,
+			wantSecret: false,
+			wantJob:    false,
+			wantPhase:  phaseSucceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := buildReconcileWithFakeClientWithMocks(tt.fields.objs)
+
+			// mock request to simulate Reconcile() being called on an event for a watched resource
+			req := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      tt.args.rstInstance.Name,
+					Namespace: tt.args.rstInstance.Namespace,
+				},
+			}
+
+			_, err := r.Reconcile(req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TestReconcileRestore reconcile: error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			secret := &corev1.Secret{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: utils.RestoreSecretPrefix + tt.args.rstInstance.Name, Namespace: tt.args.rstInstance.Namespace}, secret)
+			if (err == nil) != tt.wantSecret {
+				t.Errorf("TestReconcileRestore to get restore secret error = %v, wantSecret %v", err, tt.wantSecret)
+				return
+			}
+
+			if _, ok := secret.Data["GPG_PRIVATE_KEY"]; ok != tt.wantGpgKey {
+				t.Errorf("TestReconcileRestore restore secret has GPG_PRIVATE_KEY = %v, wantGpgKey %v", ok, tt.wantGpgKey)
+				return
+			}
+
+			job := &batchv1.Job{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: tt.args.rstInstance.Name, Namespace: tt.args.rstInstance.Namespace}, job)
+			if (err == nil) != tt.wantJob {
+				t.Errorf("TestReconcileRestore to get job error = %v, wantJob %v", err, tt.wantJob)
+				return
+			}
+
+			rst := &v1alpha1.Restore{}
+			if err := r.client.Get(context.TODO(), req.NamespacedName, rst); err != nil {
+				t.Errorf("TestReconcileRestore to get restore error = %v", err)
+				return
+			}
+
+			if rst.Status.Phase != tt.wantPhase {
+				t.Errorf("TestReconcileRestore status.phase = %v, wantPhase %v", rst.Status.Phase, tt.wantPhase)
+			}
+
+			if rst.Status.ObjectKey != tt.wantObjectKey {
+				t.Errorf("TestReconcileRestore status.objectKey = %v, wantObjectKey %v", rst.Status.ObjectKey, tt.wantObjectKey)
+			}
+
+			if (rst.Status.Error != "") != tt.wantError {
+				t.Errorf("TestReconcileRestore status.error = %v, wantError %v", rst.Status.Error, tt.wantError)
+			}
+
+			if tt.wantPhase == phaseSucceeded && tt.wantJob && (rst.Status.StartTime == nil || rst.Status.CompletionTime == nil) {
+				t.Errorf("TestReconcileRestore status.startTime = %v, status.completionTime = %v, want both", rst.Status.StartTime, rst.Status.CompletionTime)
+			}
+		})
+	}
+}
+
+func TestReconcileRestore_PointInTime(t *testing.T) {
+	tests := []struct {
+		name               string
+		rstInstance        v1alpha1.Restore
+		targetTime         string
+		targetLSN          string
+		wantInitContainers int
+		wantFailed         bool
+	}{
+		{
+			name:               "Should create the job which fetches the base backup and replays the WAL segments",
+			rstInstance:        rstInstancePointInTime,
+			wantInitContainers: 1,
+		},
+		{
+			name:               "Should create the job which loads the dump without target",
+			rstInstance:        rstInstanceWithBackup,
+			wantInitContainers: 0,
+		},
+		{
+			name:               "Should create the job which replays the WAL segments until the target LSN",
+			rstInstance:        rstInstancePointInTime,
+			targetLSN:          "0/3000060",
+			wantInitContainers: 1,
+		},
+		{
+			name:        "Should fail the restore when the target time is not RFC3339",
+			rstInstance: rstInstancePointInTime,
+			targetTime:  "2019-06-26 22:30:00+00'\nrecovery_target_action = 'shutdown",
+			wantFailed:  true,
+		},
+		{
+			name:        "Should fail the restore when the target LSN is invalid",
+			rstInstance: rstInstancePointInTime,
+			targetLSN:   "0/3000060'\nrestore_command = 'true",
+			wantFailed:  true,
+		},
+		{
+			name:        "Should fail the restore when both targets are informed",
+			rstInstance: rstInstancePointInTime,
+			targetTime:  "2019-06-26T22:30:00Z",
+			targetLSN:   "0/3000060",
+			wantFailed:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			rst := tt.rstInstance.DeepCopy()
+			if tt.targetTime != "" || tt.targetLSN != "" {
+				rst.Spec.TargetTime = tt.targetTime
+				rst.Spec.TargetLSN = tt.targetLSN
+			}
+			r := buildReconcileWithFakeClientWithMocks([]runtime.Object{rst, &bkpInstance, &dbInstance, &awsSecretFromBackup})
+
+			// mock request to simulate Reconcile() being called on an event for a watched resource
+			req := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      tt.rstInstance.Name,
+					Namespace: tt.rstInstance.Namespace,
+				},
+			}
+
+			if _, err := r.Reconcile(req); err != nil {
+				t.Errorf("TestReconcileRestore_PointInTime reconcile: error = %v", err)
+				return
+			}
+
+			if tt.wantFailed {
+				restore, err := service.FetchRestoreCR(req.Name, req.Namespace, r.client)
+				if err != nil {
+					t.Fatalf("TestReconcileRestore_PointInTime to get restore error = %v", err)
+				}
+				if restore.Status.Phase != phaseFailed || restore.Status.Error == "" {
+					t.Errorf("TestReconcileRestore_PointInTime status = %v, when is expected the phase %v with the error", restore.Status, phaseFailed)
+				}
+				if err := r.client.Get(context.TODO(), req.NamespacedName, &batchv1.Job{}); !errors.IsNotFound(err) {
+					t.Errorf("TestReconcileRestore_PointInTime to get job error = %v, when is expected not found", err)
+				}
+				return
+			}
+
+			job := &batchv1.Job{}
+			if err := r.client.Get(context.TODO(), req.NamespacedName, job); err != nil {
+				t.Errorf("TestReconcileRestore_PointInTime to get job error = %v", err)
+				return
+			}
+
+			podSpec := job.Spec.Template.Spec
+			if len(podSpec.InitContainers) != tt.wantInitContainers {
+				t.Errorf("TestReconcileRestore_PointInTime job init containers = %v, wantInitContainers %v", len(podSpec.InitContainers), tt.wantInitContainers)
+			}
+		})
+	}
+}
+
+func TestReconcileRestore_Storage(t *testing.T) {
+	tests := []struct {
+		name        string
+		objs        []runtime.Object
+		rstInstance v1alpha1.Restore
+		wantData    map[string]string
+		wantVolume  bool
+	}{
+		{
+			name:        "Should create the secret and the job with the volume storage of the Backup CR",
+			objs:        []runtime.Object{&rstInstanceWithVolumeStorage, &bkpInstanceWithVolumeStorage, &dbInstance},
+			rstInstance: rstInstanceWithVolumeStorage,
+			wantData: map[string]string{
+				"STORAGE_TYPE": utils.VolumeStorage,
+				"STORAGE_PATH": utils.StorageVolumePath,
+			},
+			wantVolume: true,
+		},
+		{
+			name:        "Should create the secret with the data of the GCS storage informed",
+			objs:        []runtime.Object{&rstInstanceWithGCSStorage, &gcsSecret, &dbInstance},
+			rstInstance: rstInstanceWithGCSStorage,
+			wantData: map[string]string{
+				"STORAGE_TYPE":         utils.GCSStorage,
+				"STORAGE_BUCKET":       "example-bucket",
+				"STORAGE_ENDPOINT_URL": "https://storage.googleapis.com",
+			},
+			wantVolume: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := buildReconcileWithFakeClientWithMocks(tt.objs)
+
+			// mock request to simulate Reconcile() being called on an event for a watched resource
+			req := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      tt.rstInstance.Name,
+					Namespace: tt.rstInstance.Namespace,
+				},
+			}
+
+			if _, err := r.Reconcile(req); err != nil {
+				t.Errorf("TestReconcileRestore_Storage reconcile: error = %v", err)
+				return
+			}
+
+			secret := &corev1.Secret{}
+			if err := r.client.Get(context.TODO(), types.NamespacedName{Name: utils.RestoreSecretPrefix + tt.rstInstance.Name, Namespace: tt.rstInstance.Namespace}, secret); err != nil {
+				t.Errorf("TestReconcileRestore_Storage to get secret error = %v", err)
+				return
+			}
+			for key, value := range tt.wantData {
+				if string(secret.Data[key]) != value {
+					t.Errorf("TestReconcileRestore_Storage secret %v = %v, want %v", key, string(secret.Data[key]), value)
+				}
+			}
+
+			job := &batchv1.Job{}
+			if err := r.client.Get(context.TODO(), req.NamespacedName, job); err != nil {
+				t.Errorf("TestReconcileRestore_Storage to get job error = %v", err)
+				return
+			}
+			hasVolume := false
+			for _, v := range job.Spec.Template.Spec.Volumes {
+				if v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == "backups" {
+					hasVolume = true
+				}
+			}
+			if hasVolume != tt.wantVolume {
+				t.Errorf("TestReconcileRestore_Storage job has the PVC of the storage = %v, wantVolume %v", hasVolume, tt.wantVolume)
+			}
+		})
+	}
+}
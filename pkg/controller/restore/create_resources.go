@@ -0,0 +1,39 @@
+package restore
+
+import (
+	"context"
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/resource"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+)
+
+// createRestoreSecret checks if the secret with the storage and GPG data is created, if not create one
+// NOTE: The data is copied from the pre-existing secrets since they can be in another namespace
+func (r *ReconcileRestore) createRestoreSecret(rst *v1alpha1.Restore) error {
+	if _, err := service.FetchSecret(rst.Namespace, utils.RestoreSecretPrefix+rst.Name, r.client); err != nil {
+		secretData, err := r.buildRestoreSecretData(rst)
+		if err != nil {
+			return err
+		}
+		if err := r.client.Create(context.TODO(), resource.NewRestoreSecret(rst, secretData, r.scheme)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createJob checks if the job is created, if not create one
+// NOTE: The job of the point-in-time recovery is created when a target is informed
+func (r *ReconcileRestore) createJob(rst *v1alpha1.Restore, db *v1alpha1.Database) error {
+	if _, err := service.FetchJob(rst.Name, rst.Namespace, r.client); err != nil {
+		job := resource.NewRestoreJob(rst, db, r.scheme)
+		if utils.IsPointInTimeRestore(rst) {
+			job = resource.NewPointInTimeRestoreJob(rst, db, r.scheme)
+		}
+		if err := r.client.Create(context.TODO(), job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package restore
+
+import (
+	"fmt"
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// gpgSecretKeys are the keys of the secret with the GPG data which are used to decrypt the dump
+// NOTE: It is the layout of the secret used to encrypt the backup with the private key and its passphrase
+var gpgSecretKeys = []string{gpgPrivateKey, "GPG_PASSPHRASE", "GPG_RECIPIENT", "GPG_TRUST_MODEL"}
+
+// gpgPrivateKey is the key of the secret with the private key which is required to decrypt the dump
+const gpgPrivateKey = "GPG_PRIVATE_KEY"
+
+// buildRestoreSecretData returns the data of the secret used by the Job with the values of the storage and GPG secrets
+// NOTE: The data of the storage is built with the same keys used by the Backup in order to allow the scripts access it
+func (r *ReconcileRestore) buildRestoreSecretData(rst *v1alpha1.Restore) (map[string][]byte, error) {
+	storage := utils.GetRestoreStorage(rst)
+	if storage.Type == utils.S3Storage && storage.SecretName == "" {
+		return nil, fmt.Errorf("Error: AWS Secret is not informed and none Backup CR is referenced")
+	}
+
+	secret := &corev1.Secret{}
+	if !utils.IsVolumeStorage(storage) && storage.SecretName != "" {
+		var err error
+		if secret, err = service.FetchSecret(storage.SecretNamespace, storage.SecretName, r.client); err != nil {
+			return nil, fmt.Errorf("Error: Storage Secret is missing. (name:%v,namespace:%v)", storage.SecretName, storage.SecretNamespace)
+		}
+	}
+
+	data, err := utils.BuildStorageData(storage, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// The GPG data is only required when the dump is encrypted
+	if rst.Spec.DecryptKeySecretName != "" {
+		gpg, err := service.FetchSecret(rst.Spec.DecryptKeySecretNamespace, rst.Spec.DecryptKeySecretName, r.client)
+		if err != nil {
+			return nil, fmt.Errorf("Error: Decrypt Key Secret is missing. (name:%v,namespace:%v)", rst.Spec.DecryptKeySecretName, rst.Spec.DecryptKeySecretNamespace)
+		}
+		if len(gpg.Data[gpgPrivateKey]) == 0 {
+			return nil, fmt.Errorf("Error: Decrypt Key Secret has not the key %v. (name:%v,namespace:%v)", gpgPrivateKey, rst.Spec.DecryptKeySecretName, rst.Spec.DecryptKeySecretNamespace)
+		}
+		for _, key := range gpgSecretKeys {
+			if value, ok := gpg.Data[key]; ok {
+				data[key] = value
+			}
+		}
+	}
+	return data, nil
+}
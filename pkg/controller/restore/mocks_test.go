@@ -0,0 +1,309 @@
+package restore
+
+import (
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+// Centralized mock objects for use in tests
+var (
+	dbInstance = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+	}
+
+	bkpInstance = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.BackupSpec{
+			ProductName:        "postgresql",
+			AwsS3BucketName:    "example-awsS3BucketName",
+			AwsAccessKeyId:     "example-awsAccessKeyId",
+			AwsSecretAccessKey: "example-awsSecretAccessKey",
+		},
+	}
+
+	awsSecretFromBackup = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.GetAWSSecretName(&bkpInstance),
+			Namespace: utils.GetAwsSecretNamespace(&bkpInstance),
+		},
+		Data: map[string][]byte{
+			"AWS_S3_BUCKET_NAME":    []byte("example-awsS3BucketName"),
+			"AWS_ACCESS_KEY_ID":     []byte("example-awsAccessKeyId"),
+			"AWS_SECRET_ACCESS_KEY": []byte("example-awsSecretAccessKey"),
+		},
+	}
+
+	/**
+	Restore CR which uses the data of the Backup CR
+	*/
+	rstInstanceWithBackup = v1alpha1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "restore",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.RestoreSpec{
+			BackupCRName: bkpInstance.Name,
+		},
+	}
+
+	/**
+	Backup CR and Restore CRs with the storage backends
+	*/
+	bkpInstanceWithVolumeStorage = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup-volume",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.BackupSpec{
+			ProductName: "postgresql",
+			Storage: &v1alpha1.BackupStorage{
+				Type: utils.VolumeStorage,
+				Volume: &v1alpha1.BackupStorageVolume{
+					ClaimName: "backups",
+				},
+			},
+		},
+	}
+
+	rstInstanceWithVolumeStorage = v1alpha1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "restore",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.RestoreSpec{
+			BackupCRName: bkpInstanceWithVolumeStorage.Name,
+		},
+	}
+
+	rstInstanceWithGCSStorage = v1alpha1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "restore",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.RestoreSpec{
+			Storage: &v1alpha1.BackupStorage{
+				Type:       utils.GCSStorage,
+				SecretName: "gcs",
+			},
+		},
+	}
+
+	gcsSecret = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gcs",
+			Namespace: "postgresql-operator",
+		},
+		Data: map[string][]byte{
+			"GCS_BUCKET_NAME":       []byte("example-bucket"),
+			"GCS_ACCESS_KEY_ID":     []byte("example-accessKeyId"),
+			"GCS_SECRET_ACCESS_KEY": []byte("example-secretAccessKey"),
+		},
+	}
+
+	/**
+	Restore CR which replays the WAL segments archived until the target time
+	*/
+	rstInstancePointInTime = v1alpha1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "restore",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.RestoreSpec{
+			BackupCRName: bkpInstance.Name,
+			TargetTime:   "2019-06-26T22:30:00Z",
+		},
+	}
+
+	/**
+	Restore CR with the object key and the secrets pre-existing in another namespace
+	*/
+	rstInstanceWithSecretNames = v1alpha1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "restore",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.RestoreSpec{
+			ObjectKey:                 "backups/postgresql/postgres/2020/07/06/postgresql.example-00_00_00.pg_dump.gz.gpg",
+			AwsSecretName:             "aws-secret-test",
+			AwsSecretNamespace:        "secrets",
+			DecryptKeySecretName:      "gpg-secret-test",
+			DecryptKeySecretNamespace: "secrets",
+		},
+	}
+
+	awsSecretWithSecretNames = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rstInstanceWithSecretNames.Spec.AwsSecretName,
+			Namespace: rstInstanceWithSecretNames.Spec.AwsSecretNamespace,
+		},
+		Data: awsSecretFromBackup.Data,
+	}
+
+	decryptSecretWithSecretNames = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rstInstanceWithSecretNames.Spec.DecryptKeySecretName,
+			Namespace: rstInstanceWithSecretNames.Spec.DecryptKeySecretNamespace,
+		},
+		Data: map[string][]byte{
+			"GPG_PUBLIC_KEY":  []byte("example-gpgPublicKey"),
+			"GPG_PRIVATE_KEY": []byte("example-gpgPrivateKey"),
+			"GPG_RECIPIENT":   []byte("email@gmail.com"),
+			"GPG_TRUST_MODEL": []byte("always"),
+		},
+	}
+
+	decryptSecretWithoutPrivateKey = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rstInstanceWithSecretNames.Spec.DecryptKeySecretName,
+			Namespace: rstInstanceWithSecretNames.Spec.DecryptKeySecretNamespace,
+		},
+		Data: map[string][]byte{
+			"GPG_PUBLIC_KEY":  []byte("example-gpgPublicKey"),
+			"GPG_RECIPIENT":   []byte("email@gmail.com"),
+			"GPG_TRUST_MODEL": []byte("always"),
+		},
+	}
+
+	/**
+	Backup CR which encrypts the backups and Restore CR which refers to it
+	*/
+	bkpInstanceEncrypted = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.BackupSpec{
+			ProductName:        "postgresql",
+			AwsS3BucketName:    "example-awsS3BucketName",
+			AwsAccessKeyId:     "example-awsAccessKeyId",
+			AwsSecretAccessKey: "example-awsSecretAccessKey",
+			GpgPublicKey:       "example-gpgPublicKey",
+			GpgEmail:           "email@gmail.com",
+			GpgTrustModel:      "always",
+		},
+	}
+
+	rstInstanceWithEncryptedBackup = v1alpha1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "restore",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.RestoreSpec{
+			BackupCRName: bkpInstanceEncrypted.Name,
+		},
+	}
+
+	/**
+	Restore CR without Backup CR and secrets
+	*/
+	rstInstanceWithoutSecrets = v1alpha1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "restore",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.RestoreSpec{
+			ObjectKey: "backups/postgresql/postgres/2020/07/06/postgresql.example-00_00_00.pg_dump.gz",
+		},
+	}
+
+	/**
+	Restore CR finished
+	*/
+	rstInstanceSucceeded = v1alpha1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "restore",
+			Namespace: "postgresql-operator",
+		},
+		Spec: v1alpha1.RestoreSpec{
+			BackupCRName: bkpInstance.Name,
+		},
+		Status: v1alpha1.RestoreStatus{
+			Phase: phaseSucceeded,
+		},
+	}
+
+	/**
+	Jobs and pods of the Restore
+	*/
+	jobStartTime      = metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	jobCompletionTime = metav1.NewTime(time.Now().Truncate(time.Second))
+
+	jobRunning = batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rstInstanceWithBackup.Name,
+			Namespace: rstInstanceWithBackup.Namespace,
+		},
+		Status: batchv1.JobStatus{
+			Active:    1,
+			StartTime: &jobStartTime,
+		},
+	}
+
+	jobSucceeded = batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rstInstanceWithBackup.Name,
+			Namespace: rstInstanceWithBackup.Namespace,
+		},
+		Status: batchv1.JobStatus{
+			Succeeded:      1,
+			StartTime:      &jobStartTime,
+			CompletionTime: &jobCompletionTime,
+		},
+	}
+
+	jobFailed = batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rstInstanceWithBackup.Name,
+			Namespace: rstInstanceWithBackup.Namespace,
+		},
+		Status: batchv1.JobStatus{
+			Failed:    1,
+			StartTime: &jobStartTime,
+			Conditions: []batchv1.JobCondition{
+				{
+					Type:               batchv1.JobFailed,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: jobCompletionTime,
+					Message:            "Job has reached the specified backoff limit",
+				},
+			},
+		},
+	}
+
+	podJobSucceeded = buildJobPod("backups/postgresql/postgres/2020/07/06/postgresql.example-00_00_00.pg_dump.gz")
+
+	podJobFailed = buildJobPod("No dump of the database example was found in the storage s3")
+)
+
+// buildJobPod returns the pod of the Job of the Restore terminated with the message informed
+func buildJobPod(msg string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rstInstanceWithBackup.Name + "-abcde",
+			Namespace: rstInstanceWithBackup.Namespace,
+			Labels:    map[string]string{"job-name": rstInstanceWithBackup.Name},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: rstInstanceWithBackup.Name,
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							Message: msg,
+						},
+					},
+				},
+			},
+		},
+	}
+}
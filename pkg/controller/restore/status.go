@@ -0,0 +1,151 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strings"
+)
+
+const (
+	phasePending   = "Pending"
+	phaseRunning   = "Running"
+	phaseSucceeded = "Succeeded"
+	phaseFailed    = "Failed"
+)
+
+// updateRestoreStatus returns error when was not possible update the status with the state of the Job
+func (r *ReconcileRestore) updateRestoreStatus(request reconcile.Request) error {
+	rst, err := service.FetchRestoreCR(request.Name, request.Namespace, r.client)
+	if err != nil {
+		return err
+	}
+
+	job, err := service.FetchJob(rst.Name, rst.Namespace, r.client)
+	if err != nil {
+		return err
+	}
+
+	status, err := r.buildRestoreStatus(rst, job)
+	if err != nil {
+		return err
+	}
+
+	// Check if the RestoreStatus was changed, if yes update it
+	return r.insertUpdateRestoreStatus(rst, status)
+}
+
+// updateErrorStatus will store the error which did not allow create the Job in the status
+func (r *ReconcileRestore) updateErrorStatus(request reconcile.Request, reason error) error {
+	rst, err := service.FetchRestoreCR(request.Name, request.Namespace, r.client)
+	if err != nil {
+		return err
+	}
+
+	status := rst.Status.DeepCopy()
+	status.Phase = phasePending
+	status.Error = reason.Error()
+	return r.insertUpdateRestoreStatus(rst, status)
+}
+
+// updateFailedStatus will store the error which does not allow to do the restore in the status, so it is not retried
+func (r *ReconcileRestore) updateFailedStatus(request reconcile.Request, reason error) error {
+	rst, err := service.FetchRestoreCR(request.Name, request.Namespace, r.client)
+	if err != nil {
+		return err
+	}
+
+	status := rst.Status.DeepCopy()
+	status.Phase = phaseFailed
+	status.Error = reason.Error()
+	return r.insertUpdateRestoreStatus(rst, status)
+}
+
+// insertUpdateRestoreStatus will check if the RestoreStatus was changed, if yes update it
+func (r *ReconcileRestore) insertUpdateRestoreStatus(rst *v1alpha1.Restore, status *v1alpha1.RestoreStatus) error {
+	if !reflect.DeepEqual(*status, rst.Status) {
+		rst.Status = *status
+		if err := r.client.Status().Update(context.TODO(), rst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildRestoreStatus returns the status of the Restore according to the state of its Job
+func (r *ReconcileRestore) buildRestoreStatus(rst *v1alpha1.Restore, job *batchv1.Job) (*v1alpha1.RestoreStatus, error) {
+	status := &v1alpha1.RestoreStatus{
+		Phase:     phaseRunning,
+		JobName:   job.Name,
+		ObjectKey: rst.Spec.ObjectKey,
+		StartTime: job.Status.StartTime,
+	}
+
+	failed := getJobFailedCondition(job)
+	if job.Status.Succeeded == 0 && failed == nil {
+		return status, nil
+	}
+
+	// The Job writes the key of the dump restored or the last lines of its logs when it fails in the termination message
+	msg, err := r.fetchTerminationMessage(job)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status.Succeeded > 0 {
+		status.Phase = phaseSucceeded
+		status.CompletionTime = job.Status.CompletionTime
+		if msg != "" {
+			status.ObjectKey = msg
+		}
+		return status, nil
+	}
+
+	status.Phase = phaseFailed
+	status.CompletionTime = &failed.LastTransitionTime
+	status.Error = strings.TrimSpace(fmt.Sprintf("%v %v", failed.Message, msg))
+	return status, nil
+}
+
+// fetchTerminationMessage returns the termination message of the last container of the Job which was terminated
+// NOTE: The init containers are checked first since the point-in-time recovery downloads the data in an init container
+func (r *ReconcileRestore) fetchTerminationMessage(job *batchv1.Job) (string, error) {
+	podList := &corev1.PodList{}
+	listOps := &client.ListOptions{Namespace: job.Namespace, LabelSelector: labels.SelectorFromSet(map[string]string{"job-name": job.Name})}
+	if err := r.client.List(context.TODO(), podList, listOps); err != nil && !errors.IsNotFound(err) {
+		return "", err
+	}
+
+	msg := ""
+	for _, pod := range podList.Items {
+		for _, c := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if c.State.Terminated != nil && c.State.Terminated.Message != "" {
+				msg = strings.TrimSpace(c.State.Terminated.Message)
+			}
+		}
+	}
+	return msg, nil
+}
+
+// getJobFailedCondition returns the condition of the Job when it failed
+func getJobFailedCondition(job *batchv1.Job) *batchv1.JobCondition {
+	for i, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return &job.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// isRestoreFinished returns true when the Job of the Restore succeeded or failed
+func isRestoreFinished(rst *v1alpha1.Restore) bool {
+	return rst.Status.Phase == phaseSucceeded || rst.Status.Phase == phaseFailed
+}
@@ -0,0 +1,43 @@
+package resource
+
+import (
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+//NewDatabaseCertificate returns the Certificate of cert-manager which issues the server certificate of the Database
+//NOTE: It is unstructured in order to not require the API of cert-manager to build the operator
+func NewDatabaseCertificate(db *v1alpha1.Database, scheme *runtime.Scheme) *unstructured.Unstructured {
+	var dnsNames []interface{}
+	for _, name := range utils.GetTLSDNSNames(db) {
+		dnsNames = append(dnsNames, name)
+	}
+	cert := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": utils.CertManagerAPIVersion,
+			"kind":       "Certificate",
+			"metadata": map[string]interface{}{
+				"name":      db.Name,
+				"namespace": db.Namespace,
+			},
+			"spec": map[string]interface{}{
+				"secretName":  utils.GetTLSSecretName(db),
+				"commonName":  db.Name,
+				"dnsNames":    dnsNames,
+				"duration":    utils.GetTLSValidity(db).String(),
+				"renewBefore": utils.GetTLSRenewBefore(db).String(),
+				"issuerRef": map[string]interface{}{
+					"name":  db.Spec.TLS.CertManager.IssuerName,
+					"kind":  db.Spec.TLS.CertManager.IssuerKind,
+					"group": "cert-manager.io",
+				},
+			},
+		},
+	}
+	cert.SetLabels(utils.GetLabels(db.Name))
+	controllerutil.SetControllerReference(db, cert, scheme)
+	return cert
+}
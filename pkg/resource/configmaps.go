@@ -0,0 +1,90 @@
+package resource
+
+import (
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+//NewDatabaseParametersConfigMap returns the ConfigMap with the file of the spec.parameters included in the postgresql.conf
+func NewDatabaseParametersConfigMap(db *v1alpha1.Database, scheme *runtime.Scheme) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.GetParametersConfigMapName(db),
+			Namespace: db.Namespace,
+			Labels:    utils.GetLabels(db.Name),
+		},
+		Data: map[string]string{
+			utils.ParametersConfKey: utils.BuildParametersConf(db.Spec.Parameters),
+		},
+	}
+	controllerutil.SetControllerReference(db, cm, scheme)
+	return cm
+}
+
+//NewDatabaseHbaConfigMap returns the ConfigMap with the pg_hba.conf built from the spec.hba and tls
+func NewDatabaseHbaConfigMap(db *v1alpha1.Database, scheme *runtime.Scheme) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.GetHbaConfigMapName(db),
+			Namespace: db.Namespace,
+			Labels:    utils.GetLabels(db.Name),
+		},
+		Data: map[string]string{
+			utils.HbaConfKey: utils.BuildHbaConf(db),
+		},
+	}
+	controllerutil.SetControllerReference(db, cm, scheme)
+	return cm
+}
+
+//buildParametersVolumes returns the volume of the ConfigMap with the spec.parameters when they are informed
+//NOTE: It is mounted in the directory where the image looks for the files included in the postgresql.conf
+func buildParametersVolumes(db *v1alpha1.Database) []corev1.Volume {
+	if !utils.HasParameters(db) {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name: utils.GetParametersConfigMapName(db),
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: utils.GetParametersConfigMapName(db),
+					},
+				},
+			},
+		},
+	}
+}
+
+//buildParametersAnnotations returns the annotations of the pod template which restart the pods when some parameter
+//which requires the restart changes
+func buildParametersAnnotations(db *v1alpha1.Database) map[string]string {
+	if !utils.HasParameters(db) {
+		return nil
+	}
+	return map[string]string{utils.RestartHashAnnotation: utils.GetRestartParametersHash(db)}
+}
+
+//buildHbaVolumes returns the volume of the ConfigMap with the pg_hba.conf when it is managed by the operator
+func buildHbaVolumes(db *v1alpha1.Database) []corev1.Volume {
+	if !utils.IsHbaManaged(db) {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name: utils.GetHbaConfigMapName(db),
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: utils.GetHbaConfigMapName(db),
+					},
+				},
+			},
+		},
+	}
+}
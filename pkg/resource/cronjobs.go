@@ -9,10 +9,103 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"strconv"
+)
+
+//pruneScript lists the dumps of the database in the storage from the newest to the oldest and deletes the ones which do
+//not match the retention policy. The quantity of dumps kept and deleted is written in the first line of the termination
+//message in order to be shown in the status of the Backup.
+const pruneScript = `set -eo pipefail
+RULES=$((RETENTION_KEEP_LAST + RETENTION_KEEP_DAILY + RETENTION_KEEP_WEEKLY + RETENTION_KEEP_MONTHLY))
+NOW=$(date +%s)
+declare -A DAYS WEEKS MONTHS
+COUNT=0
+KEPT=0
+DELETED=0
+while read -r DAY TIME KEY; do
+  COUNT=$((COUNT + 1))
+  KEEP=0
+  if [ "${RULES}" -eq 0 ] || [ "${COUNT}" -le "${RETENTION_KEEP_LAST}" ]; then
+    KEEP=1
+  fi
+  WEEK=$(date -d "${DAY}" +%G-%V)
+  MONTH=${DAY:0:7}
+  if [ -z "${DAYS[${DAY}]}" ] && [ "${#DAYS[@]}" -lt "${RETENTION_KEEP_DAILY}" ]; then
+    DAYS[${DAY}]=1
+    KEEP=1
+  fi
+  if [ -z "${WEEKS[${WEEK}]}" ] && [ "${#WEEKS[@]}" -lt "${RETENTION_KEEP_WEEKLY}" ]; then
+    WEEKS[${WEEK}]=1
+    KEEP=1
+  fi
+  if [ -z "${MONTHS[${MONTH}]}" ] && [ "${#MONTHS[@]}" -lt "${RETENTION_KEEP_MONTHLY}" ]; then
+    MONTHS[${MONTH}]=1
+    KEEP=1
+  fi
+  if [ "${RETENTION_MAX_AGE_DAYS}" -gt 0 ] && [ $(((NOW - $(date -d "${DAY} ${TIME}" +%s)) / 86400)) -ge "${RETENTION_MAX_AGE_DAYS}" ]; then
+    KEEP=0
+  fi
+  if [ "${KEEP}" -eq 1 ]; then
+    KEPT=$((KEPT + 1))
+  else
+    storage_del "${KEY}"
+    DELETED=$((DELETED + 1))
+  fi
+done < <(storage_ls "backups/${PRODUCT_NAME}/" | grep "\.${POSTGRES_DATABASE}-" | sort -r)
+echo "kept=${KEPT} deleted=${DELETED}" > /dev/termination-log
+`
+
+//baseBackupUploadScript uploads the base backup taken by the init container to the directory of the WAL archive
+//NOTE: The key of the base backup has the time when it was taken in order to allow find the one before a target time.
+//It is written in the termination message in order to be shown in the status of the Backup.
+const baseBackupUploadScript = `set -eo pipefail
+KEY="${WAL_ARCHIVE_PATH}/base/$(date -u +%Y%m%dT%H%M%SZ)/base.tar.gz"
+storage_put "${BASE_BACKUP_PATH}/data/base.tar.gz" "${KEY}"
+echo -n "${KEY}" > /dev/termination-log
+`
+
+//dumpScript dumps the database with the pg_dump of the image of the Database in order to be uploaded by the backup image
+const dumpScript = `set -eo pipefail
+if [ "${COMPRESSION}" = "none" ]; then
+  pg_dump > "${DUMP_PATH}/${DUMP_FILE}"
+else
+  pg_dump | gzip > "${DUMP_PATH}/${DUMP_FILE}"
+fi
+`
+
+//backupUploadScript encrypts the dump when the GPG public key is informed and uploads it to the storage with the same key
+//format used by the backup image. The extension of the key is the one of the file of the dump (.pg_dump or .pg_dump.gz).
+//The key and the size in bytes of the file uploaded are written in the termination message.
+const backupUploadScript = `set -eo pipefail
+DUMP="${DUMP_PATH}/${DUMP_FILE}"
+KEY="backups/${PRODUCT_NAME}/postgres/$(date -u +%Y/%m/%d)/${POSTGRES_HOST}.${POSTGRES_DATABASE}-$(date -u +%H_%M_%S)${DUMP_FILE#dump}"
+if [ -n "${GPG_PUBLIC_KEY}" ]; then
+  export GNUPGHOME="${DUMP_PATH}/.gnupg"
+  mkdir -m 700 -p "${GNUPGHOME}"
+  echo "${GPG_PUBLIC_KEY}" | base64 -d | gpg --batch --import
+  gpg --batch --yes --trust-model "${GPG_TRUST_MODEL:-always}" --recipient "${GPG_RECIPIENT}" --output "${DUMP}.gpg" --encrypt "${DUMP}"
+  DUMP="${DUMP}.gpg"
+  KEY="${KEY}.gpg"
+fi
+storage_put "${DUMP}" "${KEY}"
+echo "${KEY} $(stat -c %s "${DUMP}")" > /dev/termination-log
+`
+
+//dumpsListScript appends in the termination message the newest dumps of the database found in the storage in order to
+//be shown in the status of the Backup
+//NOTE: Just 30 dumps are listed since the termination message is limited to 4096 bytes
+const dumpsListScript = `storage_ls "backups/${PRODUCT_NAME}/" | { grep "\.${POSTGRES_DATABASE}-" || true; } | sort -r | awk 'NR <= 30' >> /dev/termination-log
+`
+
+const (
+	baseBackupPath = "/basebackup"
+	dumpPath       = "/dump"
 )
 
 //Returns the NewBackupCronJob object for the Database Backup
-func NewBackupCronJob(bkp *v1alpha1.Backup, scheme *runtime.Scheme) *v1beta1.CronJob {
+//NOTE: The hash of the job template is kept in an annotation in order to allow update it when the Backup changes. The
+//Jobs have the label of the Backup in order to be found when their runs are recorded.
+func NewBackupCronJob(bkp *v1alpha1.Backup, db *v1alpha1.Database, scheme *runtime.Scheme) *v1beta1.CronJob {
 	cron := &v1beta1.CronJob{
 		ObjectMeta: v1.ObjectMeta{
 			Name:      bkp.Name,
@@ -21,46 +114,301 @@ func NewBackupCronJob(bkp *v1alpha1.Backup, scheme *runtime.Scheme) *v1beta1.Cro
 		},
 		Spec: v1beta1.CronJobSpec{
 			Schedule: bkp.Spec.Schedule,
+			JobTemplate: v1beta1.JobTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{
+					Labels: utils.GetBackupJobLabels(bkp),
+				},
+				Spec: buildBackupJobSpec(bkp, db),
+			},
+		},
+	}
+	cron.Annotations = map[string]string{utils.TemplateHashAnnotation: utils.GetHash(cron.Spec.JobTemplate)}
+	controllerutil.SetControllerReference(bkp, cron, scheme)
+	return cron
+}
+
+//buildBackupJobSpec returns the spec of the Job which does the backup
+//NOTE: It is used by the CronJob and by the Jobs of the on-demand backups. When the retention is enabled the backup
+//runs as init container in order to delete the expired dumps only after a successful backup. When the storage is
+//informed the dump is done by an init container with the image of the Database and uploaded by the backup image.
+func buildBackupJobSpec(bkp *v1alpha1.Backup, db *v1alpha1.Database) batchv1.JobSpec {
+	spec := batchv1.JobSpec{
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: v1.ObjectMeta{
+				Labels: utils.GetBackupPodLabels(bkp),
+			},
+			Spec: corev1.PodSpec{
+				ServiceAccountName: "postgresql-operator",
+				Containers:         []corev1.Container{buildBackupContainer(bkp)},
+				RestartPolicy:      corev1.RestartPolicyOnFailure,
+			},
+		},
+	}
+	if utils.IsStorageInformed(bkp) {
+		spec.Template.Spec.InitContainers = []corev1.Container{buildDumpContainer(bkp, db)}
+		spec.Template.Spec.Containers = []corev1.Container{buildUploadContainer(bkp)}
+		spec.Template.Spec.Volumes = append(buildStorageVolumes(bkp.Spec.Storage), corev1.Volume{
+			Name: "dump",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+	if utils.IsRetentionEnabled(bkp) {
+		spec.Template.Spec.InitContainers = append(spec.Template.Spec.InitContainers, spec.Template.Spec.Containers...)
+		spec.Template.Spec.Containers = []corev1.Container{buildPruneContainer(bkp)}
+	}
+	return spec
+}
+
+//buildDumpContainer returns the container which dumps the database with the image of the Database
+func buildDumpContainer(bkp *v1alpha1.Backup, db *v1alpha1.Database) corev1.Container {
+	return corev1.Container{
+		Name:            bkp.Name + utils.DumpContainerSuffix,
+		Image:           db.Spec.Image,
+		ImagePullPolicy: db.Spec.ContainerImagePullPolicy,
+		Command:         []string{"/bin/bash", "-c", dumpScript},
+		Env: []corev1.EnvVar{
+			buildBackupSecretEnvVar(bkp, "PGHOST", "POSTGRES_HOST"),
+			{
+				Name:  "PGPORT",
+				Value: strconv.Itoa(int(db.Spec.DatabasePort)),
+			},
+			buildBackupSecretEnvVar(bkp, "PGUSER", "POSTGRES_USERNAME"),
+			buildBackupSecretEnvVar(bkp, "PGPASSWORD", "POSTGRES_PASSWORD"),
+			buildBackupSecretEnvVar(bkp, "PGDATABASE", "POSTGRES_DATABASE"),
+			{
+				Name:  "DUMP_PATH",
+				Value: dumpPath,
+			},
+			{
+				Name:  "DUMP_FILE",
+				Value: utils.GetDumpFileName(bkp.Spec.Storage),
+			},
+			{
+				Name:  "COMPRESSION",
+				Value: bkp.Spec.Storage.Compression,
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "dump",
+				MountPath: dumpPath,
+			},
+		},
+	}
+}
+
+//buildUploadContainer returns the container which uploads the dump to the storage with the backup image
+//NOTE: The data of the storage and the GPG public key are in the storage Secret
+func buildUploadContainer(bkp *v1alpha1.Backup) corev1.Container {
+	return corev1.Container{
+		Name:                     bkp.Name,
+		Image:                    bkp.Spec.Image,
+		Command:                  []string{"/bin/bash", "-c", buildStorageScript(backupUploadScript + dumpsListScript)},
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		EnvFrom: []corev1.EnvFromSource{
+			{
+				SecretRef: &corev1.SecretEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: utils.StorageSecretPrefix + bkp.Name,
+					},
+				},
+			},
+		},
+		Env: []corev1.EnvVar{
+			buildBackupSecretEnvVar(bkp, "POSTGRES_HOST", "POSTGRES_HOST"),
+			buildBackupSecretEnvVar(bkp, "POSTGRES_DATABASE", "POSTGRES_DATABASE"),
+			{
+				Name:  "PRODUCT_NAME",
+				Value: bkp.Spec.ProductName,
+			},
+			{
+				Name:  "DUMP_PATH",
+				Value: dumpPath,
+			},
+			{
+				Name:  "DUMP_FILE",
+				Value: utils.GetDumpFileName(bkp.Spec.Storage),
+			},
+		},
+		VolumeMounts: append(buildStorageVolumeMounts(bkp.Spec.Storage), corev1.VolumeMount{
+			Name:      "dump",
+			MountPath: dumpPath,
+		}),
+	}
+}
+
+//buildBackupContainer returns the container which does the backup with the backup image
+func buildBackupContainer(bkp *v1alpha1.Backup) corev1.Container {
+	return corev1.Container{
+		Name:    bkp.Name,
+		Image:   bkp.Spec.Image,
+		Command: []string{"/opt/intly/tools/entrypoint.sh", "-c", "postgres", "-n", bkp.Namespace, "-b", "s3", "-e", ""},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "BACKEND_SECRET_NAME",
+				Value: utils.GetAWSSecretName(bkp),
+			},
+			{
+				Name:  "BACKEND_SECRET_NAMESPACE",
+				Value: utils.GetAwsSecretNamespace(bkp),
+			},
+			{
+				Name:  "ENCRYPTION_SECRET_NAME",
+				Value: utils.GetEncSecretName(bkp),
+			},
+			{
+				Name:  "ENCRYPTION_SECRET_NAMESPACE",
+				Value: utils.GetEncSecretNamespace(bkp),
+			},
+			{
+				Name:  "COMPONENT_SECRET_NAME",
+				Value: utils.DbSecretPrefix + bkp.Name,
+			},
+			{
+				Name:  "COMPONENT_SECRET_NAMESPACE",
+				Value: bkp.Namespace,
+			},
+			{
+				Name:  "PRODUCT_NAME",
+				Value: bkp.Spec.ProductName,
+			},
+		},
+	}
+}
+
+//buildPruneContainer returns the container which deletes the expired dumps according to the retention policy
+//NOTE: The data of the storage is in the storage Secret since the secret informed can be in another namespace
+func buildPruneContainer(bkp *v1alpha1.Backup) corev1.Container {
+	rt := bkp.Spec.Retention
+	return corev1.Container{
+		Name:                     utils.GetPruneContainerName(bkp),
+		Image:                    bkp.Spec.Image,
+		Command:                  []string{"/bin/bash", "-c", buildStorageScript(pruneScript + dumpsListScript)},
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		EnvFrom: []corev1.EnvFromSource{
+			{
+				SecretRef: &corev1.SecretEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: utils.StorageSecretPrefix + bkp.Name,
+					},
+				},
+			},
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name: "POSTGRES_DATABASE",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: utils.DbSecretPrefix + bkp.Name,
+						},
+						Key: "POSTGRES_DATABASE",
+					},
+				},
+			},
+			{
+				Name:  "PRODUCT_NAME",
+				Value: bkp.Spec.ProductName,
+			},
+			{
+				Name:  "RETENTION_KEEP_LAST",
+				Value: strconv.Itoa(int(rt.KeepLast)),
+			},
+			{
+				Name:  "RETENTION_KEEP_DAILY",
+				Value: strconv.Itoa(int(rt.KeepDaily)),
+			},
+			{
+				Name:  "RETENTION_KEEP_WEEKLY",
+				Value: strconv.Itoa(int(rt.KeepWeekly)),
+			},
+			{
+				Name:  "RETENTION_KEEP_MONTHLY",
+				Value: strconv.Itoa(int(rt.KeepMonthly)),
+			},
+			{
+				Name:  "RETENTION_MAX_AGE_DAYS",
+				Value: strconv.Itoa(int(rt.MaxAgeDays)),
+			},
+		},
+		VolumeMounts: buildStorageVolumeMounts(bkp.Spec.Storage),
+	}
+}
+
+//Returns the CronJob object which takes the base backups of the Database used by the point-in-time recovery
+//NOTE: The base backup is taken with pg_basebackup by an init container with the database image and uploaded by the
+//main container with the backup image. The WAL segments required by it are restored from the ones shipped by the Database.
+func NewBaseBackupCronJob(bkp *v1alpha1.Backup, db *v1alpha1.Database, scheme *runtime.Scheme) *v1beta1.CronJob {
+	name := utils.GetBaseBackupName(bkp)
+	volume := "basebackup"
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      volume,
+			MountPath: baseBackupPath,
+		},
+	}
+	cron := &v1beta1.CronJob{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: bkp.Namespace,
+			Labels:    utils.GetLabels(bkp.Name),
+		},
+		Spec: v1beta1.CronJobSpec{
+			Schedule:          bkp.Spec.WalArchiving.BaseBackupSchedule,
+			ConcurrencyPolicy: v1beta1.ForbidConcurrent,
 			JobTemplate: v1beta1.JobTemplateSpec{
 				Spec: batchv1.JobSpec{
 					Template: corev1.PodTemplateSpec{
+						ObjectMeta: v1.ObjectMeta{
+							Labels: utils.GetBackupPodLabels(bkp),
+						},
 						Spec: corev1.PodSpec{
-							ServiceAccountName: "postgresql-operator",
+							InitContainers: []corev1.Container{
+								{
+									Name:            volume,
+									Image:           db.Spec.Image,
+									ImagePullPolicy: db.Spec.ContainerImagePullPolicy,
+									Command:         []string{"/bin/bash", "-c", "pg_basebackup -D " + baseBackupPath + "/data -Ft -z -c fast"},
+									Env:             buildBaseBackupEnvVars(bkp, db),
+									VolumeMounts:    volumeMounts,
+								},
+							},
 							Containers: []corev1.Container{
 								{
-									Name:    bkp.Name,
-									Image:   bkp.Spec.Image,
-									Command: []string{"/opt/intly/tools/entrypoint.sh", "-c", "postgres", "-n", bkp.Namespace, "-b", "s3", "-e", ""},
-									Env: []corev1.EnvVar{
-										{
-											Name:  "BACKEND_SECRET_NAME",
-											Value: utils.GetAWSSecretName(bkp),
-										},
-										{
-											Name:  "BACKEND_SECRET_NAMESPACE",
-											Value: utils.GetAwsSecretNamespace(bkp),
-										},
-										{
-											Name:  "ENCRYPTION_SECRET_NAME",
-											Value: utils.GetEncSecretName(bkp),
-										},
-										{
-											Name:  "ENCRYPTION_SECRET_NAMESPACE",
-											Value: utils.GetEncSecretNamespace(bkp),
-										},
+									Name:                     name,
+									Image:                    bkp.Spec.Image,
+									Command:                  []string{"/bin/bash", "-c", buildStorageScript(baseBackupUploadScript)},
+									TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+									EnvFrom: []corev1.EnvFromSource{
 										{
-											Name:  "COMPONENT_SECRET_NAME",
-											Value: utils.DbSecretPrefix + bkp.Name,
+											SecretRef: &corev1.SecretEnvSource{
+												LocalObjectReference: corev1.LocalObjectReference{
+													Name: utils.StorageSecretPrefix + bkp.Name,
+												},
+											},
 										},
+									},
+									Env: []corev1.EnvVar{
 										{
-											Name:  "COMPONENT_SECRET_NAMESPACE",
-											Value: bkp.Namespace,
+											Name:  "WAL_ARCHIVE_PATH",
+											Value: utils.GetWalArchivePath(bkp.Namespace, bkp.Spec.DatabaseCRName),
 										},
 										{
-											Name:  "PRODUCT_NAME",
-											Value: bkp.Spec.ProductName,
+											Name:  "BASE_BACKUP_PATH",
+											Value: baseBackupPath,
 										},
 									},
+									VolumeMounts: volumeMounts,
+								},
+							},
+							Volumes: []corev1.Volume{
+								{
+									Name: volume,
+									VolumeSource: corev1.VolumeSource{
+										EmptyDir: &corev1.EmptyDirVolumeSource{},
+									},
 								},
 							},
 							RestartPolicy: corev1.RestartPolicyOnFailure,
@@ -70,6 +418,42 @@ func NewBackupCronJob(bkp *v1alpha1.Backup, scheme *runtime.Scheme) *v1beta1.Cro
 			},
 		},
 	}
+	cron.Annotations = map[string]string{utils.TemplateHashAnnotation: utils.GetHash(cron.Spec)}
 	controllerutil.SetControllerReference(bkp, cron, scheme)
 	return cron
 }
+
+//buildBaseBackupEnvVars returns the env vars used by pg_basebackup to connect in the primary of the Database
+//NOTE: The replication user is used when the replication is enabled since it has the REPLICATION privilege. Otherwise,
+//the database user of the secret of the Backup is used and it should have this privilege.
+func buildBaseBackupEnvVars(bkp *v1alpha1.Backup, db *v1alpha1.Database) []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{
+			Name:  "PGHOST",
+			Value: db.Name + "." + db.Namespace + ".svc",
+		},
+		{
+			Name:  "PGPORT",
+			Value: strconv.Itoa(int(db.Spec.DatabasePort)),
+		},
+	}
+	if utils.IsReplicationEnabled(db) {
+		return append(env, utils.BuildReplicationCredentialsEnvVars(db, "PGUSER", "PGPASSWORD")...)
+	}
+	return append(env, buildBackupSecretEnvVar(bkp, "PGUSER", "POSTGRES_USERNAME"), buildBackupSecretEnvVar(bkp, "PGPASSWORD", "POSTGRES_PASSWORD"))
+}
+
+//buildBackupSecretEnvVar returns the env var with the value of the key of the database secret of the Backup
+func buildBackupSecretEnvVar(bkp *v1alpha1.Backup, name, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: utils.DbSecretPrefix + bkp.Name,
+				},
+				Key: key,
+			},
+		},
+	}
+}
@@ -11,14 +11,57 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-//buildDBDeployment returns the deployment object for the Database
+//walArchiverScript uploads the WAL segments found in the spool directory and removes them when the upload succeeds.
+//NOTE: When the upload fails the segment is renamed with the suffix .failed in order to make the archive_command fail.
+const walArchiverScript = `while true; do
+  for FILE in $(ls "${WAL_SPOOL_PATH}" 2>/dev/null | grep -v -e '\.part$' -e '\.failed$'); do
+    if storage_put "${WAL_SPOOL_PATH}/${FILE}" "${WAL_ARCHIVE_PATH}/segments/${FILE}"; then
+      rm -f "${WAL_SPOOL_PATH}/${FILE}"
+    else
+      mv "${WAL_SPOOL_PATH}/${FILE}" "${WAL_SPOOL_PATH}/${FILE}.failed"
+    fi
+  done
+  sleep 1
+done
+`
+
+//NewDatabaseDeployment returns the deployment object for the Database
+//NOTE: When the replication is enabled it is the deployment of the primary unless a failover promoted a standby. The
+//member label is in its selector since the pods of the standbys have the labels of the Database as well.
 func NewDatabaseDeployment(db *v1alpha1.Database, scheme *runtime.Scheme) *appsv1.Deployment {
-	ls := utils.GetLabels(db.Name)
+	ls := utils.GetMemberLabels(db.Name, db.Name)
+	podLabels := ls
+	role := ""
+	if utils.IsReplicationEnabled(db) {
+		role = utils.GetMemberRole(db, db.Name)
+		podLabels = utils.GetPodLabels(db.Name, db.Name, role)
+	}
+	dep := buildDatabaseDeployment(db, db.Name, ls, podLabels, role)
+	dep.Annotations = map[string]string{utils.TemplateHashAnnotation: utils.GetHash(dep.Spec.Template)}
+	controllerutil.SetControllerReference(db, dep, scheme)
+	return dep
+}
+
+//NewDatabaseStandbyDeployment returns the deployment object for the standby of the Database with the index informed
+//NOTE: Each standby has its own PersistentVolumeClaim with the same name of its deployment
+func NewDatabaseStandbyDeployment(db *v1alpha1.Database, index int, scheme *runtime.Scheme) *appsv1.Deployment {
+	name := utils.GetStandbyName(db, index)
+	ls := utils.GetMemberLabels(db.Name, name)
+	role := utils.GetMemberRole(db, name)
+	podLabels := utils.GetPodLabels(db.Name, name, role)
+	dep := buildDatabaseDeployment(db, name, ls, podLabels, role)
+	dep.Annotations = map[string]string{utils.TemplateHashAnnotation: utils.GetHash(dep.Spec.Template)}
+	controllerutil.SetControllerReference(db, dep, scheme)
+	return dep
+}
+
+//buildDatabaseDeployment returns the deployment object with 1 instance of the Database which uses the PVC with its name
+func buildDatabaseDeployment(db *v1alpha1.Database, name string, ls, podLabels map[string]string, role string) *appsv1.Deployment {
 	auto := true
-	replicas := db.Spec.Size
-	dep := &appsv1.Deployment{
+	replicas := int32(1)
+	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      db.Name,
+			Name:      name,
 			Namespace: db.Namespace,
 			Labels:    ls,
 		},
@@ -32,90 +75,219 @@ func NewDatabaseDeployment(db *v1alpha1.Database, scheme *runtime.Scheme) *appsv
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: ls,
+					Labels:      podLabels,
+					Annotations: buildPodAnnotations(db),
 				},
 				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{{
-						Image:           db.Spec.Image,
-						Name:            db.Spec.ContainerName,
-						ImagePullPolicy: db.Spec.ContainerImagePullPolicy,
-						Ports: []corev1.ContainerPort{{
-							ContainerPort: db.Spec.DatabasePort,
-							Protocol:      "TCP",
-						}},
-						Env: []corev1.EnvVar{
-							utils.BuildDatabaseNameEnvVar(db),
-							utils.BuildDatabaseUserEnvVar(db),
-							utils.BuildDatabasePasswordEnvVar(db),
-							{
-								Name:  "PGDATA",
-								Value: "/var/lib/pgsql/data",
-							},
-						},
-						VolumeMounts: []corev1.VolumeMount{
-							{
-								Name:      db.Name,
-								MountPath: "/var/lib/pgsql/data",
-							},
-						},
-						LivenessProbe: &corev1.Probe{
-							Handler: corev1.Handler{
-								Exec: &corev1.ExecAction{
-									Command: []string{
-										"/usr/libexec/check-container",
-										"'--live'",
-									},
-								},
-							},
-							FailureThreshold:    3,
-							InitialDelaySeconds: 120,
-							PeriodSeconds:       10,
-							TimeoutSeconds:      10,
-							SuccessThreshold:    1,
-						},
-						ReadinessProbe: &corev1.Probe{
-							Handler: corev1.Handler{
-								Exec: &corev1.ExecAction{
-									Command: []string{
-										"/usr/libexec/check-container",
-									},
-								},
-							},
-							FailureThreshold:    3,
-							InitialDelaySeconds: 5,
-							PeriodSeconds:       10,
-							TimeoutSeconds:      1,
-							SuccessThreshold:    1,
-						},
-						Resources: corev1.ResourceRequirements{
-							Limits: corev1.ResourceList{
-								corev1.ResourceMemory: resource.MustParse(db.Spec.DatabaseMemoryLimit),
-								corev1.ResourceCPU:    resource.MustParse(db.Spec.DatabaseCpuLimit),
-							},
-							Requests: corev1.ResourceList{
-								corev1.ResourceMemory: resource.MustParse(db.Spec.DatabaseMemoryRequest),
-								corev1.ResourceCPU:    resource.MustParse(db.Spec.DatabaseCpu),
-							},
-						},
-						TerminationMessagePath: "/dev/termination-log",
-					}},
+					Containers:    buildDatabasePodContainers(db, buildDatabaseContainer(db, name, role), name),
 					DNSPolicy:     corev1.DNSClusterFirst,
 					RestartPolicy: corev1.RestartPolicyAlways,
-					Volumes: []corev1.Volume{
+					Volumes: append([]corev1.Volume{
 						{
-							Name: db.Name,
+							Name: name,
 							VolumeSource: corev1.VolumeSource{
 								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-									ClaimName: db.Name,
+									ClaimName: name,
 								},
 							},
 						},
-					},
+					}, buildPodVolumes(db)...),
 					AutomountServiceAccountToken: &auto,
+					NodeSelector:                 db.Spec.NodeSelector,
+					Tolerations:                  db.Spec.Tolerations,
+					Affinity:                     db.Spec.Affinity,
 				},
 			},
 		},
 	}
-	controllerutil.SetControllerReference(db, dep, scheme)
-	return dep
+}
+
+//buildDatabaseContainer returns the container of the Database
+//NOTE: The role is empty when the replication is not enabled
+func buildDatabaseContainer(db *v1alpha1.Database, volumeName, role string) corev1.Container {
+	env := []corev1.EnvVar{
+		utils.BuildDatabaseNameEnvVar(db),
+		utils.BuildDatabaseUserEnvVar(db),
+		utils.BuildDatabasePasswordEnvVar(db),
+		{
+			Name:  "PGDATA",
+			Value: "/var/lib/pgsql/data",
+		},
+	}
+
+	var args []string
+	switch role {
+	case utils.PrimaryRole:
+		env = append(env, utils.BuildReplicationEnvVars(db, role)...)
+		args = []string{db.Spec.Replication.PrimaryCommand}
+	case utils.StandbyRole:
+		env = append(env, utils.BuildReplicationEnvVars(db, role)...)
+		args = []string{db.Spec.Replication.StandbyCommand}
+	}
+
+	// The run commands of the image pass the arguments to the database server
+	if utils.IsWalArchivingEnabled(db) {
+		if args == nil {
+			args = []string{utils.RunPostgresqlCommand}
+		}
+		args = append(args, utils.BuildWalArchivingArgs(db)...)
+	}
+	if utils.IsTLSEnabled(db) {
+		if args == nil {
+			args = []string{utils.RunPostgresqlCommand}
+		}
+		args = append(args, utils.BuildTLSArgs()...)
+	}
+	if utils.IsHbaManaged(db) {
+		if args == nil {
+			args = []string{utils.RunPostgresqlCommand}
+		}
+		args = append(args, utils.BuildHbaArgs()...)
+	}
+
+	mounts := []corev1.VolumeMount{
+		{
+			Name:      volumeName,
+			MountPath: "/var/lib/pgsql/data",
+		},
+	}
+	if utils.HasParameters(db) {
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      utils.GetParametersConfigMapName(db),
+			MountPath: utils.ParametersConfPath,
+			ReadOnly:  true,
+		})
+	}
+	if utils.IsTLSEnabled(db) {
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      utils.GetTLSSecretName(db),
+			MountPath: utils.TLSCertPath,
+			ReadOnly:  true,
+		})
+	}
+	if utils.IsHbaManaged(db) {
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      utils.GetHbaConfigMapName(db),
+			MountPath: utils.HbaConfPath,
+			ReadOnly:  true,
+		})
+	}
+
+	return corev1.Container{
+		Image:           db.Spec.Image,
+		Name:            db.Spec.ContainerName,
+		ImagePullPolicy: db.Spec.ContainerImagePullPolicy,
+		Args:            args,
+		Ports: []corev1.ContainerPort{{
+			ContainerPort: db.Spec.DatabasePort,
+			Protocol:      "TCP",
+		}},
+		Env:          env,
+		VolumeMounts: mounts,
+		LivenessProbe: &corev1.Probe{
+			Handler: corev1.Handler{
+				Exec: &corev1.ExecAction{
+					Command: []string{
+						"/usr/libexec/check-container",
+						"'--live'",
+					},
+				},
+			},
+			FailureThreshold:    3,
+			InitialDelaySeconds: 120,
+			PeriodSeconds:       10,
+			TimeoutSeconds:      10,
+			SuccessThreshold:    1,
+		},
+		ReadinessProbe: &corev1.Probe{
+			Handler: corev1.Handler{
+				Exec: &corev1.ExecAction{
+					Command: []string{
+						"/usr/libexec/check-container",
+					},
+				},
+			},
+			FailureThreshold:    3,
+			InitialDelaySeconds: 5,
+			PeriodSeconds:       10,
+			TimeoutSeconds:      1,
+			SuccessThreshold:    1,
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse(db.Spec.DatabaseMemoryLimit),
+				corev1.ResourceCPU:    resource.MustParse(db.Spec.DatabaseCpuLimit),
+			},
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse(db.Spec.DatabaseMemoryRequest),
+				corev1.ResourceCPU:    resource.MustParse(db.Spec.DatabaseCpu),
+			},
+		},
+		TerminationMessagePath: "/dev/termination-log",
+	}
+}
+
+//buildPodVolumes returns the volumes of the pod of the Database besides its data
+func buildPodVolumes(db *v1alpha1.Database) []corev1.Volume {
+	volumes := append(buildParametersVolumes(db), buildTLSVolumes(db)...)
+	return append(volumes, buildHbaVolumes(db)...)
+}
+
+//buildPodAnnotations returns the annotations of the pod template which restart the pods when some parameter which
+//requires the restart or the server certificate changes
+func buildPodAnnotations(db *v1alpha1.Database) map[string]string {
+	annotations := buildParametersAnnotations(db)
+	if utils.IsTLSEnabled(db) && db.Status.TLS != nil && db.Status.TLS.Hash != "" {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[utils.TLSHashAnnotation] = db.Status.TLS.Hash
+	}
+	return annotations
+}
+
+//buildDatabasePodContainers returns the containers of the pod of the Database
+//NOTE: The sidecar which uploads the WAL segments is added when the WAL archiving is enabled
+func buildDatabasePodContainers(db *v1alpha1.Database, container corev1.Container, volumeName string) []corev1.Container {
+	containers := []corev1.Container{container}
+	if utils.IsWalArchivingEnabled(db) {
+		containers = append(containers, buildWalArchiverContainer(db, volumeName))
+	}
+	return containers
+}
+
+//buildWalArchiverContainer returns the sidecar which uploads the WAL segments handed over by the archive_command
+//NOTE: The AWS data is in the storage Secret of the Backup CR informed in the walArchiving
+func buildWalArchiverContainer(db *v1alpha1.Database, volumeName string) corev1.Container {
+	return corev1.Container{
+		Name:            utils.WalArchiverName,
+		Image:           db.Spec.WalArchiving.Image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", buildStorageScript(walArchiverScript)},
+		EnvFrom: []corev1.EnvFromSource{
+			{
+				SecretRef: &corev1.SecretEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: utils.StorageSecretPrefix + db.Spec.WalArchiving.BackupCRName,
+					},
+				},
+			},
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "WAL_ARCHIVE_PATH",
+				Value: utils.GetWalArchivePath(db.Namespace, db.Name),
+			},
+			{
+				Name:  "WAL_SPOOL_PATH",
+				Value: utils.WalSpoolPath,
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      volumeName,
+				MountPath: "/var/lib/pgsql/data",
+			},
+		},
+	}
 }
@@ -0,0 +1,460 @@
+package resource
+
+import (
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"strconv"
+)
+
+const (
+	legacyDataPath = "/var/lib/pgsql/legacy"
+	dataPath       = "/var/lib/pgsql/data"
+	restorePath    = "/restore"
+	upgradePath    = "/var/lib/pgsql/data/upgrade"
+	pgDataPath     = "/var/lib/pgsql/data/userdata"
+)
+
+//upgradeServerScript starts a temporary server with the data of the Database which only accepts local connections
+//NOTE: The configuration of the data is not used since it includes the files generated by the image when it starts
+const upgradeServerScript = `: > /tmp/postgresql.conf
+echo "local all all trust" > /tmp/pg_hba.conf
+: > /tmp/pg_ident.conf
+postgres -D "${PGDATA}" -p 5433 -c config_file=/tmp/postgresql.conf -c hba_file=/tmp/pg_hba.conf \
+  -c ident_file=/tmp/pg_ident.conf -c listen_addresses= -c unix_socket_directories=/tmp &
+PID=$!
+until pg_isready -h /tmp -p 5433 >/dev/null 2>&1; do
+  if ! kill -0 ${PID} 2>/dev/null; then
+    echo "The temporary server of the version ${PG_VERSION} did not start"
+    exit 1
+  fi
+  sleep 2
+done
+`
+
+//upgradeDumpScript dumps all databases and roles of the previous major version into the PVC of the Database
+const upgradeDumpScript = `set -eo pipefail
+mkdir -p "${UPGRADE_PATH}"
+` + upgradeServerScript + `pg_dumpall -h /tmp -p 5433 -f "${UPGRADE_PATH}/dumpall.sql"
+kill -INT ${PID}
+wait ${PID} || true
+`
+
+//upgradeLoadScript moves the data of the previous major version aside, creates the data of the new one and loads the dump
+//NOTE: The errors of the objects which already exist in the new data (E.g. the role postgres) are ignored. The marker
+//file allows the rollback to know that the previous data was moved.
+const upgradeLoadScript = `set -eo pipefail
+OLD_DATA="${PGDATA}-${FROM_VERSION}"
+if [ -e "${OLD_DATA}" ]; then
+  echo "The directory ${OLD_DATA} of a previous upgrade was found. Remove it in order to upgrade the data."
+  exit 1
+fi
+mv "${PGDATA}" "${OLD_DATA}"
+touch "${UPGRADE_PATH}/data-moved"
+initdb --username=postgres -D "${PGDATA}"
+cp "${OLD_DATA}/pg_hba.conf" "${PGDATA}/pg_hba.conf"
+grep "^include" "${OLD_DATA}/postgresql.conf" >> "${PGDATA}/postgresql.conf" || true
+` + upgradeServerScript + `psql -h /tmp -p 5433 -d postgres -f "${UPGRADE_PATH}/dumpall.sql" > /dev/null 2> "${UPGRADE_PATH}/load.log"
+kill -INT ${PID}
+wait ${PID} || true
+if grep "ERROR" "${UPGRADE_PATH}/load.log" | grep -v "already exists"; then
+  echo "The dump could not be loaded into the version ${PG_VERSION}"
+  exit 1
+fi
+rm -f "${UPGRADE_PATH}/data-moved"
+`
+
+//upgradeRollbackScript moves back the data of the previous major version when it was moved by the upgrade
+const upgradeRollbackScript = `set -eo pipefail
+OLD_DATA="${PGDATA}-${FROM_VERSION}"
+if [ ! -e "${UPGRADE_PATH}/data-moved" ]; then
+  echo "The data of the version ${FROM_VERSION} was not moved"
+  exit 0
+fi
+rm -rf "${PGDATA}"
+mv "${OLD_DATA}" "${PGDATA}"
+rm -f "${UPGRADE_PATH}/data-moved"
+`
+
+//restoreScript downloads the dump from the storage, decrypts it when it is encrypted and loads it into the database
+//uncompressing it when its extension is .gz
+//NOTE: When the object key is not informed the latest dump of the database in the directory of the product is used.
+//The key restored is written in the termination message in order to be shown in the status of the Restore.
+const restoreScript = `set -eo pipefail
+KEY="${OBJECT_KEY}"
+if [ -z "${KEY}" ]; then
+  KEY=$(storage_ls "backups/${PRODUCT_NAME}/" | grep "\.${PGDATABASE}-" | sort | tail -n 1 | awk '{print $3}')
+fi
+if [ -z "${KEY}" ]; then
+  echo "No dump of the database ${PGDATABASE} was found in the storage ${STORAGE_TYPE}"
+  exit 1
+fi
+DUMP="${RESTORE_PATH}/$(basename "${KEY}")"
+storage_get "${KEY}" "${DUMP}"
+if [ "${DUMP%.gpg}" != "${DUMP}" ]; then
+  if [ -z "${GPG_PRIVATE_KEY}" ]; then
+    echo "The dump ${KEY} is encrypted and the GPG_PRIVATE_KEY was not found"
+    exit 1
+  fi
+  export GNUPGHOME="${RESTORE_PATH}/.gnupg"
+  mkdir -m 700 -p "${GNUPGHOME}"
+  echo "${GPG_PRIVATE_KEY}" | base64 -d | gpg --batch --import
+  GPG_OPTS=(--batch --yes --trust-model "${GPG_TRUST_MODEL:-always}")
+  if [ -n "${GPG_PASSPHRASE}" ]; then
+    GPG_OPTS+=(--passphrase "${GPG_PASSPHRASE}")
+  fi
+  gpg "${GPG_OPTS[@]}" --output "${DUMP%.gpg}" --decrypt "${DUMP}"
+  DUMP="${DUMP%.gpg}"
+fi
+if [ "${DUMP%.gz}" != "${DUMP}" ]; then
+  gunzip -c "${DUMP}" | psql -v ON_ERROR_STOP=1
+else
+  psql -v ON_ERROR_STOP=1 -f "${DUMP}"
+fi
+echo -n "${KEY}" > /dev/termination-log
+`
+
+//pitrFetchScript downloads the base backup and the WAL segments of the Database in order to do the point-in-time recovery
+//NOTE: When the object key is not informed the latest base backup taken before the target time is used.
+const pitrFetchScript = `set -eo pipefail
+KEY="${OBJECT_KEY}"
+if [ -z "${KEY}" ]; then
+  LIMIT="99999999T999999Z"
+  if [ -n "${TARGET_TIME}" ]; then
+    LIMIT=$(date -u -d "${TARGET_TIME}" +%Y%m%dT%H%M%SZ)
+  fi
+  KEY=$(storage_ls "${WAL_ARCHIVE_PATH}/base/" | awk '{print $3}' | sort | awk -F/ -v limit="${LIMIT}" '$(NF-1) <= limit' | tail -n 1)
+fi
+if [ -z "${KEY}" ]; then
+  echo "No base backup of the database was found in ${WAL_ARCHIVE_PATH} of the storage ${STORAGE_TYPE}"
+  exit 1
+fi
+mkdir -p "${RESTORE_PATH}/wal"
+storage_get "${KEY}" "${RESTORE_PATH}/base.tar.gz"
+storage_sync "${WAL_ARCHIVE_PATH}/segments/" "${RESTORE_PATH}/wal"
+chmod -R a+rwX "${RESTORE_PATH}"
+echo -n "${KEY}" > "${RESTORE_PATH}/key"
+`
+
+//pitrRecoverScript starts a temporary server with the base backup which replays the WAL segments until the target and
+//then loads its dump into the database.
+//NOTE: The temporary server only accepts local connections and uses the default configuration in order to not depend
+//on the files of the image which took the base backup. The key of the base backup is written in the termination message.
+const pitrRecoverScript = `set -eo pipefail
+DATA="${RESTORE_PATH}/pgdata"
+mkdir -p "${DATA}"
+tar -xzf "${RESTORE_PATH}/base.tar.gz" -C "${DATA}"
+chmod 700 "${DATA}"
+: > "${DATA}/postgresql.conf"
+echo "local all all trust" > "${DATA}/pg_hba.conf"
+if [ "$(cut -d. -f1 "${DATA}/PG_VERSION")" -ge 12 ]; then
+  CONF="${DATA}/postgresql.auto.conf"
+  touch "${DATA}/recovery.signal"
+else
+  CONF="${DATA}/recovery.conf"
+fi
+echo "restore_command = 'cp ${RESTORE_PATH}/wal/%f %p'" >> "${CONF}"
+if [ -n "${TARGET_TIME}" ]; then
+  echo "recovery_target_time = '${TARGET_TIME}'" >> "${CONF}"
+fi
+if [ -n "${TARGET_LSN}" ]; then
+  echo "recovery_target_lsn = '${TARGET_LSN}'" >> "${CONF}"
+fi
+echo "recovery_target_action = 'promote'" >> "${CONF}"
+postgres -D "${DATA}" -p 5433 -c listen_addresses= -c unix_socket_directories=/tmp -c hot_standby=off &
+PID=$!
+until [ "$(psql -h /tmp -p 5433 -d postgres -tAc 'SELECT pg_is_in_recovery()' 2>/dev/null)" = "f" ]; do
+  if ! kill -0 ${PID} 2>/dev/null; then
+    echo "The recovery of the base backup did not reach the target"
+    exit 1
+  fi
+  sleep 5
+done
+pg_dump -h /tmp -p 5433 --clean --if-exists "${PGDATABASE}" | psql -v ON_ERROR_STOP=1
+kill -INT ${PID}
+wait ${PID} || true
+cat "${RESTORE_PATH}/key" > /dev/termination-log
+`
+
+//Returns the Job object which copies the data of the Deployment PVC to the PVC of the first pod of the StatefulSet
+//NOTE: The Deployment should be scaled down before since both volumes are ReadWriteOnce
+func NewDatabaseMigrationJob(db *v1alpha1.Database, scheme *runtime.Scheme) *batchv1.Job {
+	backoffLimit := int32(3)
+	legacyVolume := "legacy"
+	volume := "data"
+	job := &batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      utils.GetMigrationJobName(db),
+			Namespace: db.Namespace,
+			Labels:    utils.GetLabels(db.Name),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            utils.GetMigrationJobName(db),
+							Image:           db.Spec.Image,
+							ImagePullPolicy: db.Spec.ContainerImagePullPolicy,
+							Command:         []string{"/bin/bash", "-c", "cp -a " + legacyDataPath + "/. " + dataPath + "/"},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      legacyVolume,
+									MountPath: legacyDataPath,
+								},
+								{
+									Name:      volume,
+									MountPath: dataPath,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: legacyVolume,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: db.Name,
+								},
+							},
+						},
+						{
+							Name: volume,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: utils.GetStatefulSetPvcName(db, 0),
+								},
+							},
+						},
+					},
+					RestartPolicy: corev1.RestartPolicyOnFailure,
+					// The pod mounts the PVC of the Database so it has the same scheduling constraints
+					NodeSelector: db.Spec.NodeSelector,
+					Tolerations:  db.Spec.Tolerations,
+					Affinity:     db.Spec.Affinity,
+				},
+			},
+		},
+	}
+	controllerutil.SetControllerReference(db, job, scheme)
+	return job
+}
+
+//Returns the Job object which runs the step (dump, upgrade or rollback) of the upgrade of the major version of the Database
+//NOTE: The dump and rollback run with the previous image and the upgrade with the new one. The Deployment should be
+//scaled down before since the volume is ReadWriteOnce and the data cannot be changed by the server.
+func NewDatabaseUpgradeJob(db *v1alpha1.Database, step string, scheme *runtime.Scheme) *batchv1.Job {
+	upgrade := db.Status.Upgrade
+	backoffLimit := int32(0)
+	volume := "data"
+	image, version, script := upgrade.FromImage, upgrade.FromVersion, upgradeDumpScript
+	switch step {
+	case utils.UpgradeLoadStep:
+		image, version, script = upgrade.ToImage, upgrade.ToVersion, upgradeLoadScript
+	case utils.UpgradeRollbackStep:
+		script = upgradeRollbackScript
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      utils.GetUpgradeJobName(db, step),
+			Namespace: db.Namespace,
+			Labels:    utils.GetLabels(db.Name),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:                     utils.GetUpgradeJobName(db, step),
+							Image:                    image,
+							ImagePullPolicy:          db.Spec.ContainerImagePullPolicy,
+							Command:                  []string{"/bin/bash", "-c", script},
+							TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+							Env: []corev1.EnvVar{
+								{Name: "PGDATA", Value: pgDataPath},
+								{Name: "PGUSER", Value: "postgres"},
+								{Name: "PG_VERSION", Value: version},
+								{Name: "FROM_VERSION", Value: upgrade.FromVersion},
+								{Name: "UPGRADE_PATH", Value: upgradePath},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      volume,
+									MountPath: dataPath,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: volume,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: db.Name,
+								},
+							},
+						},
+					},
+					RestartPolicy: corev1.RestartPolicyNever,
+					// The pod mounts the PVC of the Database so it has the same scheduling constraints
+					NodeSelector: db.Spec.NodeSelector,
+					Tolerations:  db.Spec.Tolerations,
+					Affinity:     db.Spec.Affinity,
+				},
+			},
+		},
+	}
+	controllerutil.SetControllerReference(db, job, scheme)
+	return job
+}
+
+//Returns the Job object which downloads the dump, decrypts and loads it into the Database
+//NOTE: The storage and GPG data are in the Secret of the Restore and the database credentials are the same used by the Database.
+//The PVC of the storage is mounted when the backups are written in a volume.
+func NewRestoreJob(rst *v1alpha1.Restore, db *v1alpha1.Database, scheme *runtime.Scheme) *batchv1.Job {
+	backoffLimit := int32(0)
+	volume := "restore"
+	job := &batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      rst.Name,
+			Namespace: rst.Namespace,
+			Labels:    utils.GetLabels(rst.Name),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:                     rst.Name,
+							Image:                    rst.Spec.Image,
+							ImagePullPolicy:          corev1.PullAlways,
+							Command:                  []string{"/bin/bash", "-c", buildStorageScript(restoreScript)},
+							Env:                      buildRestoreEnvVars(rst, db),
+							TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+							EnvFrom: []corev1.EnvFromSource{
+								{
+									SecretRef: &corev1.SecretEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: utils.RestoreSecretPrefix + rst.Name,
+										},
+									},
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      volume,
+									MountPath: restorePath,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: volume,
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+					RestartPolicy: corev1.RestartPolicyNever,
+				},
+			},
+		},
+	}
+	storage := utils.GetRestoreStorage(rst)
+	podSpec := &job.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, buildStorageVolumes(storage)...)
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, buildStorageVolumeMounts(storage)...)
+	controllerutil.SetControllerReference(rst, job, scheme)
+	return job
+}
+
+//Returns the Job object which recovers the Database until the target time or LSN and loads the data recovered into it
+//NOTE: The init container downloads the base backup and WAL segments with the restore image and the recovery is done by
+//a temporary server with the image of the Database in the Job. The running Database is not stopped.
+func NewPointInTimeRestoreJob(rst *v1alpha1.Restore, db *v1alpha1.Database, scheme *runtime.Scheme) *batchv1.Job {
+	job := NewRestoreJob(rst, db, scheme)
+	podSpec := &job.Spec.Template.Spec
+	fetch := podSpec.Containers[0]
+	fetch.Name = rst.Name + "-fetch"
+	fetch.Command = []string{"/bin/bash", "-c", buildStorageScript(pitrFetchScript)}
+	fetch.Env = buildPointInTimeRestoreEnvVars(rst, db)
+	fetch.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+
+	recovery := podSpec.Containers[0]
+	recovery.Image = db.Spec.Image
+	recovery.ImagePullPolicy = db.Spec.ContainerImagePullPolicy
+	recovery.Command = []string{"/bin/bash", "-c", pitrRecoverScript}
+	recovery.Env = buildPointInTimeRestoreEnvVars(rst, db)
+	recovery.EnvFrom = nil
+
+	podSpec.InitContainers = []corev1.Container{fetch}
+	podSpec.Containers = []corev1.Container{recovery}
+	return job
+}
+
+//buildPointInTimeRestoreEnvVars returns the env vars of the restore with the target and the directory of the WAL archive
+func buildPointInTimeRestoreEnvVars(rst *v1alpha1.Restore, db *v1alpha1.Database) []corev1.EnvVar {
+	return append(buildRestoreEnvVars(rst, db),
+		corev1.EnvVar{Name: "TARGET_TIME", Value: rst.Spec.TargetTime},
+		corev1.EnvVar{Name: "TARGET_LSN", Value: rst.Spec.TargetLSN},
+		corev1.EnvVar{Name: "WAL_ARCHIVE_PATH", Value: utils.GetWalArchivePath(db.Namespace, db.Name)},
+	)
+}
+
+//buildRestoreEnvVars returns the env vars used by psql to connect in the Database and the data to find the dump
+func buildRestoreEnvVars(rst *v1alpha1.Restore, db *v1alpha1.Database) []corev1.EnvVar {
+	dbName := utils.BuildDatabaseNameEnvVar(db)
+	dbName.Name = "PGDATABASE"
+	user := utils.BuildDatabaseUserEnvVar(db)
+	user.Name = "PGUSER"
+	pwd := utils.BuildDatabasePasswordEnvVar(db)
+	pwd.Name = "PGPASSWORD"
+	return []corev1.EnvVar{
+		dbName,
+		user,
+		pwd,
+		{
+			Name:  "PGHOST",
+			Value: db.Name + "." + db.Namespace + ".svc",
+		},
+		{
+			Name:  "PGPORT",
+			Value: strconv.Itoa(int(db.Spec.DatabasePort)),
+		},
+		{
+			Name:  "PRODUCT_NAME",
+			Value: rst.Spec.ProductName,
+		},
+		{
+			Name:  "OBJECT_KEY",
+			Value: rst.Spec.ObjectKey,
+		},
+		{
+			Name:  "RESTORE_PATH",
+			Value: restorePath,
+		},
+	}
+}
+
+//Returns the Job object of the on-demand backup with the same template used by the CronJob of the Backup
+func NewBackupJob(bkp *v1alpha1.Backup, db *v1alpha1.Database, name string, scheme *runtime.Scheme) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: bkp.Namespace,
+			Labels:    utils.GetBackupJobLabels(bkp),
+		},
+		Spec: buildBackupJobSpec(bkp, db),
+	}
+	controllerutil.SetControllerReference(bkp, job, scheme)
+	return job
+}
@@ -10,12 +10,33 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-//Returns the deployment object for the Database
+//Returns the PersistentVolumeClaim object for the Database
 func NewDatabasePvc(db *v1alpha1.Database, scheme *runtime.Scheme) *corev1.PersistentVolumeClaim {
-	ls := utils.GetLabels(db.Name)
-	pv := &corev1.PersistentVolumeClaim{
+	pv := buildDatabasePvc(db, db.Name, utils.GetLabels(db.Name))
+	controllerutil.SetControllerReference(db, pv, scheme)
+	return pv
+}
+
+//Returns the PersistentVolumeClaim object for the standby of the Database with the index informed
+func NewDatabaseStandbyPvc(db *v1alpha1.Database, index int, scheme *runtime.Scheme) *corev1.PersistentVolumeClaim {
+	name := utils.GetStandbyName(db, index)
+	pv := buildDatabasePvc(db, name, utils.GetMemberLabels(db.Name, name))
+	controllerutil.SetControllerReference(db, pv, scheme)
+	return pv
+}
+
+//Returns the PersistentVolumeClaim object for the first pod of the StatefulSet of the Database
+//NOTE: It is created only to migrate the data from the Deployment. It has no owner in order to behave as the
+//PersistentVolumeClaims created by the volumeClaimTemplates of the StatefulSet which will adopt it
+func NewDatabaseStatefulSetPvc(db *v1alpha1.Database) *corev1.PersistentVolumeClaim {
+	return buildDatabasePvc(db, utils.GetStatefulSetPvcName(db, 0), utils.GetLabels(db.Name))
+}
+
+//buildDatabasePvc returns the PersistentVolumeClaim object with the storage setup of the Database
+func buildDatabasePvc(db *v1alpha1.Database, name string, ls map[string]string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      db.Name,
+			Name:      name,
 			Namespace: db.Namespace,
 			Labels:    ls,
 		},
@@ -31,6 +52,4 @@ func NewDatabasePvc(db *v1alpha1.Database, scheme *runtime.Scheme) *corev1.Persi
 			StorageClassName: &db.Spec.DatabaseStorageClassName,
 		},
 	}
-	controllerutil.SetControllerReference(db, pv, scheme)
-	return pv
 }
@@ -1,6 +1,8 @@
 package resource
 
 import (
+	"strconv"
+
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
 	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
@@ -30,3 +32,122 @@ func NewBackupSecret(bkp *v1alpha1.Backup, prefix string, secretData map[string]
 	controllerutil.SetControllerReference(bkp, secret, scheme)
 	return secret
 }
+
+//Returns the Secret object with the AWS and GPG data used by the Job of the Restore
+func NewRestoreSecret(rst *v1alpha1.Restore, secretData map[string][]byte, scheme *runtime.Scheme) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      utils.RestoreSecretPrefix + rst.Name,
+			Namespace: rst.Namespace,
+			Labels:    utils.GetLabels(rst.Name),
+		},
+		Data: secretData,
+		Type: "Opaque",
+	}
+	controllerutil.SetControllerReference(rst, secret, scheme)
+	return secret
+}
+
+//Returns the Secret object with the credentials of the role of the Database
+func NewDatabaseRoleSecret(db *v1alpha1.Database, role, password string, scheme *runtime.Scheme) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      utils.GetRoleSecretName(db, role),
+			Namespace: db.Namespace,
+			Labels:    utils.GetLabels(db.Name),
+		},
+		Data: map[string][]byte{
+			"username": []byte(role),
+			"password": []byte(password),
+			"host":     []byte(db.Name),
+			"port":     []byte(strconv.Itoa(int(db.Spec.DatabasePort))),
+		},
+		Type: "Opaque",
+	}
+	controllerutil.SetControllerReference(db, secret, scheme)
+	return secret
+}
+
+//Returns the Secret object with the database name, user and the password generated for the Database
+//NOTE: The keys are the ones expected by the env vars when the credentialsSecretName is informed. The database name,
+//user and password are only added when the credentials are generated, and the replication user and its password when
+//the replication is enabled.
+func NewDatabaseCredentialsSecret(db *v1alpha1.Database, password, replicationPassword string, scheme *runtime.Scheme) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      utils.GetGeneratedCredentialsSecretName(db),
+			Namespace: db.Namespace,
+			Labels:    utils.GetLabels(db.Name),
+		},
+		Data: map[string][]byte{},
+		Type: "Opaque",
+	}
+	if db.Spec.CredentialsSecretName == secret.Name {
+		secret.Data[utils.GetEnvVarKey(db.Spec.CredentialsSecretDatabaseNameKey, db.Spec.DatabaseNameKeyEnvVar)] = []byte(db.Spec.DatabaseName)
+		secret.Data[utils.GetEnvVarKey(db.Spec.CredentialsSecretUserKey, db.Spec.DatabaseUserKeyEnvVar)] = []byte(db.Spec.DatabaseUser)
+		secret.Data[utils.GetEnvVarKey(db.Spec.CredentialsSecretPasswordKey, db.Spec.DatabasePasswordKeyEnvVar)] = []byte(password)
+	}
+	if utils.IsReplicationEnabled(db) {
+		secret.Data[utils.GetReplicationUserKey(db)] = []byte(db.Spec.Replication.ReplicationUser)
+		secret.Data[utils.GetReplicationPasswordKey(db)] = []byte(replicationPassword)
+	}
+	controllerutil.SetControllerReference(db, secret, scheme)
+	return secret
+}
+
+//Returns the Secret object with the CA generated by the operator to sign the server certificate of the Database
+func NewDatabaseTLSCASecret(db *v1alpha1.Database, cert, key []byte, scheme *runtime.Scheme) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      utils.GetTLSCASecretName(db),
+			Namespace: db.Namespace,
+			Labels:    utils.GetLabels(db.Name),
+		},
+		Data: map[string][]byte{
+			utils.TLSCAKey:    cert,
+			utils.TLSCAKeyKey: key,
+		},
+		Type: "Opaque",
+	}
+	controllerutil.SetControllerReference(db, secret, scheme)
+	return secret
+}
+
+//Returns the Secret object with the server certificate generated by the operator for the Database
+func NewDatabaseTLSSecret(db *v1alpha1.Database, cert, key, ca []byte, scheme *runtime.Scheme) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      utils.GetTLSSecretName(db),
+			Namespace: db.Namespace,
+			Labels:    utils.GetLabels(db.Name),
+		},
+		Data: map[string][]byte{
+			utils.TLSCertKey: cert,
+			utils.TLSKeyKey:  key,
+			utils.TLSCAKey:   ca,
+		},
+		Type: corev1.SecretTypeTLS,
+	}
+	controllerutil.SetControllerReference(db, secret, scheme)
+	return secret
+}
+
+//buildTLSVolumes returns the volume of the Secret with the server certificate when the tls is enabled
+//NOTE: The database server refuses a private key which can be read by others
+func buildTLSVolumes(db *v1alpha1.Database) []corev1.Volume {
+	if !utils.IsTLSEnabled(db) {
+		return nil
+	}
+	mode := int32(0640)
+	return []corev1.Volume{
+		{
+			Name: utils.GetTLSSecretName(db),
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  utils.GetTLSSecretName(db),
+					DefaultMode: &mode,
+				},
+			},
+		},
+	}
+}
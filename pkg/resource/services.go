@@ -12,20 +12,54 @@ import (
 )
 
 // Returns the service object for the Database
+// NOTE: When the replication is enabled it will select only the primary (read-write)
 func NewDatabaseService(db *v1alpha1.Database, scheme *runtime.Scheme) *corev1.Service {
-	ls := utils.GetLabels(db.Name)
-	ser := &corev1.Service{
+	ser := buildDatabaseService(db, db.Name, GetDatabaseServiceSelector(db))
+	// Set Database db as the owner and controller
+	controllerutil.SetControllerReference(db, ser, scheme)
+	return ser
+}
+
+// Returns the read-only service object for the Database which selects only its standbys
+func NewDatabaseReadOnlyService(db *v1alpha1.Database, scheme *runtime.Scheme) *corev1.Service {
+	ser := buildDatabaseService(db, utils.GetReadOnlyServiceName(db), utils.GetRoleLabels(db.Name, utils.StandbyRole))
+	// Set Database db as the owner and controller
+	controllerutil.SetControllerReference(db, ser, scheme)
+	return ser
+}
+
+// Returns the headless service object for the Database which gives the stable DNS names for the pods of the StatefulSet
+func NewDatabaseHeadlessService(db *v1alpha1.Database, scheme *runtime.Scheme) *corev1.Service {
+	ser := buildDatabaseService(db, utils.GetHeadlessServiceName(db), utils.GetLabels(db.Name))
+	ser.Spec.ClusterIP = corev1.ClusterIPNone
+	ser.Spec.PublishNotReadyAddresses = true
+	// Set Database db as the owner and controller
+	controllerutil.SetControllerReference(db, ser, scheme)
+	return ser
+}
+
+// GetDatabaseServiceSelector returns the selector of the service for the Database according to its replication setup
+func GetDatabaseServiceSelector(db *v1alpha1.Database) map[string]string {
+	if utils.IsReplicationEnabled(db) {
+		return utils.GetRoleLabels(db.Name, utils.PrimaryRole)
+	}
+	return utils.GetLabels(db.Name)
+}
+
+// buildDatabaseService returns the service object with the port of the Database
+func buildDatabaseService(db *v1alpha1.Database, name string, selector map[string]string) *corev1.Service {
+	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      db.Name,
+			Name:      name,
 			Namespace: db.Namespace,
-			Labels:    ls,
+			Labels:    utils.GetLabels(db.Name),
 		},
 		Spec: corev1.ServiceSpec{
-			Selector: ls,
+			Selector: selector,
 			Type:     corev1.ServiceTypeClusterIP,
 			Ports: []corev1.ServicePort{
 				{
-					Name: db.Name,
+					Name: name,
 					TargetPort: intstr.IntOrString{
 						Type:   intstr.Int,
 						IntVal: db.Spec.DatabasePort,
@@ -36,7 +70,4 @@ func NewDatabaseService(db *v1alpha1.Database, scheme *runtime.Scheme) *corev1.S
 			},
 		},
 	}
-	// Set Database db as the owner and controller
-	controllerutil.SetControllerReference(db, ser, scheme)
-	return ser
 }
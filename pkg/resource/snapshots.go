@@ -0,0 +1,34 @@
+package resource
+
+import (
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+//NewDatabaseVolumeSnapshot returns the VolumeSnapshot of the PVC taken when the Database is deleted
+//NOTE: It is unstructured in order to not require the API of the CSI snapshots to build the operator. It is not
+//controlled by the Database so it is kept after the deletion.
+func NewDatabaseVolumeSnapshot(db *v1alpha1.Database, pvcName string) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": pvcName,
+		},
+	}
+	if db.Spec.VolumeSnapshotClassName != "" {
+		spec["volumeSnapshotClassName"] = db.Spec.VolumeSnapshotClassName
+	}
+	snapshot := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": utils.VolumeSnapshotAPIVersion,
+			"kind":       "VolumeSnapshot",
+			"metadata": map[string]interface{}{
+				"name":      utils.GetDeletionSnapshotName(db, pvcName),
+				"namespace": db.Namespace,
+			},
+			"spec": spec,
+		},
+	}
+	snapshot.SetLabels(utils.GetLabels(db.Name))
+	return snapshot
+}
@@ -0,0 +1,90 @@
+package resource
+
+import (
+	"fmt"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+//NewDatabaseStatefulSet returns the StatefulSet object for the Database
+//NOTE: Each pod has its own PersistentVolumeClaim created by the volumeClaimTemplates and a stable DNS name by the headless service
+func NewDatabaseStatefulSet(db *v1alpha1.Database, scheme *runtime.Scheme) *appsv1.StatefulSet {
+	auto := true
+	ls := utils.GetLabels(db.Name)
+	replicas := utils.GetStatefulSetReplicas(db)
+	pvc := buildDatabasePvc(db, db.Name, ls)
+	pvc.Namespace = ""
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      db.Name,
+			Namespace: db.Namespace,
+			Labels:    ls,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:            &replicas,
+			ServiceName:         utils.GetHeadlessServiceName(db),
+			PodManagementPolicy: appsv1.OrderedReadyPodManagement,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.RollingUpdateStatefulSetStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: ls,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      ls,
+					Annotations: buildPodAnnotations(db),
+				},
+				Spec: corev1.PodSpec{
+					Containers:                   buildDatabasePodContainers(db, buildDatabaseStatefulSetContainer(db), db.Name),
+					Volumes:                      buildPodVolumes(db),
+					DNSPolicy:                    corev1.DNSClusterFirst,
+					RestartPolicy:                corev1.RestartPolicyAlways,
+					AutomountServiceAccountToken: &auto,
+					NodeSelector:                 db.Spec.NodeSelector,
+					Tolerations:                  db.Spec.Tolerations,
+					Affinity:                     db.Spec.Affinity,
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{*pvc},
+		},
+	}
+	sts.Annotations = map[string]string{utils.TemplateHashAnnotation: utils.GetHash(sts.Spec.Template)}
+	controllerutil.SetControllerReference(db, sts, scheme)
+	return sts
+}
+
+//buildDatabaseStatefulSetContainer returns the container of the Database for the StatefulSet
+//NOTE: All pods share the same template. When the replication is enabled the pod with the name of the primary starts
+//as primary and the others as standbys. The arguments of the database server (E.g. WAL archiving) are passed to both commands.
+func buildDatabaseStatefulSetContainer(db *v1alpha1.Database) corev1.Container {
+	container := buildDatabaseContainer(db, db.Name, "")
+	if utils.IsReplicationEnabled(db) {
+		primaryCommand, standbyCommand := db.Spec.Replication.PrimaryCommand, db.Spec.Replication.StandbyCommand
+		var serverArgs []string
+		if len(container.Args) > 1 {
+			serverArgs = append([]string{"bash"}, container.Args[1:]...)
+			primaryCommand += " \"$@\""
+			standbyCommand += " \"$@\""
+		}
+		container.Env = append(container.Env, utils.BuildReplicationEnvVars(db, utils.StandbyRole)...)
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  utils.PrimaryPodEnvVar,
+			Value: utils.GetPrimaryPodName(db),
+		})
+		container.Args = []string{
+			"/bin/bash",
+			"-c",
+			fmt.Sprintf("if [ \"$HOSTNAME\" = \"$%v\" ]; then exec %v; else exec %v; fi",
+				utils.PrimaryPodEnvVar, primaryCommand, standbyCommand),
+		}
+		container.Args = append(container.Args, serverArgs...)
+	}
+	return container
+}
@@ -0,0 +1,116 @@
+package resource
+
+import (
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const storageVolume = "storage"
+
+//storageFunctions defines the functions used by the scripts to access the storage according to the STORAGE_TYPE of the
+//storage Secret. The keys are relative to the bucket, container or directory of the volume.
+//NOTE: storage_ls prints the date, time and key of each object found with the prefix informed.
+//The s3 and gcs types use s3cmd, azure uses its REST API with the SAS token and volume the files of the PVC.
+const storageFunctions = `case "${STORAGE_TYPE}" in
+s3|gcs)
+  S3CMD=(s3cmd --access_key="${STORAGE_ACCESS_KEY_ID}" --secret_key="${STORAGE_SECRET_ACCESS_KEY}")
+  if [ -n "${STORAGE_ENDPOINT_URL}" ]; then
+    STORAGE_HOST="${STORAGE_ENDPOINT_URL#*://}"
+    STORAGE_HOST="${STORAGE_HOST%%/*}"
+    if [ "${STORAGE_PATH_STYLE}" = "true" ]; then
+      S3CMD+=(--host="${STORAGE_HOST}" --host-bucket="${STORAGE_HOST}")
+    else
+      S3CMD+=(--host="${STORAGE_HOST}" --host-bucket="%(bucket)s.${STORAGE_HOST}")
+    fi
+    if [ "${STORAGE_ENDPOINT_URL#http://}" != "${STORAGE_ENDPOINT_URL}" ]; then
+      S3CMD+=(--no-ssl)
+    fi
+  fi
+  if [ -n "${STORAGE_REGION}" ]; then
+    S3CMD+=(--region="${STORAGE_REGION}")
+  fi
+  if [ -n "${STORAGE_CA_BUNDLE}" ]; then
+    echo "${STORAGE_CA_BUNDLE}" > /tmp/storage-ca.pem
+    S3CMD+=(--ca-certs=/tmp/storage-ca.pem)
+  fi
+  storage_put() { "${S3CMD[@]}" put "$1" "s3://${STORAGE_BUCKET}/$2" > /dev/null; }
+  storage_get() { "${S3CMD[@]}" get --force "s3://${STORAGE_BUCKET}/$1" "$2" > /dev/null; }
+  storage_del() { "${S3CMD[@]}" del "s3://${STORAGE_BUCKET}/$1" > /dev/null; }
+  storage_ls() {
+    "${S3CMD[@]}" ls --recursive "s3://${STORAGE_BUCKET}/$1" | awk -v p="s3://${STORAGE_BUCKET}/" 'index($4, p) == 1 {print $1, $2, substr($4, length(p) + 1)}'
+  }
+  ;;
+azure)
+  STORAGE_URL="https://${STORAGE_ACCOUNT}.blob.core.windows.net/${STORAGE_BUCKET}"
+  AZCURL=(curl -sSf -H "x-ms-version: 2019-12-12")
+  storage_put() { "${AZCURL[@]}" -X PUT -H "x-ms-blob-type: BlockBlob" --upload-file "$1" "${STORAGE_URL}/$2?${STORAGE_SAS_TOKEN}" > /dev/null; }
+  storage_get() { "${AZCURL[@]}" -o "$2" "${STORAGE_URL}/$1?${STORAGE_SAS_TOKEN}"; }
+  storage_del() { "${AZCURL[@]}" -X DELETE "${STORAGE_URL}/$1?${STORAGE_SAS_TOKEN}" > /dev/null; }
+  storage_ls() {
+    local MARKER="" OUT
+    while true; do
+      OUT=$("${AZCURL[@]}" "${STORAGE_URL}?restype=container&comp=list&prefix=$1&marker=${MARKER}&${STORAGE_SAS_TOKEN}")
+      echo "${OUT}" | sed 's|<Blob>|\n|g' | sed -n 's|^<Name>\([^<]*\)</Name>.*<Last-Modified>\([^<]*\)</Last-Modified>.*|\2\t\1|p' |
+        while IFS=$'\t' read -r MODIFIED KEY; do echo "$(date -u -d "${MODIFIED}" '+%Y-%m-%d %H:%M') ${KEY}"; done
+      MARKER=$(echo "${OUT}" | sed -n 's|.*<NextMarker>\([^<]*\)</NextMarker>.*|\1|p')
+      [ -n "${MARKER}" ] || break
+    done
+  }
+  ;;
+volume)
+  storage_put() { mkdir -p "$(dirname "${STORAGE_PATH}/$2")" && cp "$1" "${STORAGE_PATH}/$2"; }
+  storage_get() { cp "${STORAGE_PATH}/$1" "$2"; }
+  storage_del() { rm -f "${STORAGE_PATH}/$1"; }
+  storage_ls() {
+    if [ -d "${STORAGE_PATH}/$1" ]; then
+      (cd "${STORAGE_PATH}" && find "$1" -type f -printf '%TY-%Tm-%Td %TH:%TM %p\n')
+    fi
+  }
+  ;;
+*)
+  echo "Invalid storage type (${STORAGE_TYPE})"
+  exit 1
+  ;;
+esac
+storage_sync() {
+  storage_ls "$1" | while read -r DAY TIME KEY; do
+    storage_get "${KEY}" "$2/$(basename "${KEY}")"
+  done
+}
+`
+
+//buildStorageScript returns the script informed with the functions to access the storage defined before it
+func buildStorageScript(script string) string {
+	return storageFunctions + script
+}
+
+//buildStorageVolumes returns the volume with the PVC used as storage when the backups are written in a volume
+func buildStorageVolumes(storage *v1alpha1.BackupStorage) []corev1.Volume {
+	if !utils.IsVolumeStorage(storage) {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name: storageVolume,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: storage.Volume.ClaimName,
+				},
+			},
+		},
+	}
+}
+
+//buildStorageVolumeMounts returns the mount of the PVC used as storage when the backups are written in a volume
+func buildStorageVolumeMounts(storage *v1alpha1.BackupStorage) []corev1.VolumeMount {
+	if !utils.IsVolumeStorage(storage) {
+		return nil
+	}
+	return []corev1.VolumeMount{
+		{
+			Name:      storageVolume,
+			MountPath: utils.StorageVolumePath,
+		},
+	}
+}
@@ -20,3 +20,16 @@ func FetchBackupCR(name, namespace string, client client.Client) (*v1alpha1.Back
 	err := client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, bkp)
 	return bkp, err
 }
+
+func FetchRestoreCR(name, namespace string, client client.Client) (*v1alpha1.Restore, error) {
+	rst := &v1alpha1.Restore{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, rst)
+	return rst, err
+}
+
+// FetchDatabaseClassCR returns the DatabaseClass, which is cluster-scoped
+func FetchDatabaseClassCR(name string, client client.Client) (*v1alpha1.DatabaseClass, error) {
+	class := &v1alpha1.DatabaseClass{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: name}, class)
+	return class, err
+}
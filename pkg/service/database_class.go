@@ -0,0 +1,70 @@
+package service
+
+import (
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/config"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AddDatabaseClassSpecs adds the values of the DatabaseClass of the Database which are not informed in its spec and
+// returns the names of the fields which use them
+// NOTE: It returns the error of the fetch when the DatabaseClass informed is not found
+func AddDatabaseClassSpecs(db *v1alpha1.Database, client client.Client) ([]string, error) {
+	if db.Spec.ClassName == "" {
+		return nil, nil
+	}
+	class, err := FetchDatabaseClassCR(db.Spec.ClassName, client)
+	if err != nil {
+		return nil, err
+	}
+	return utils.AddDatabaseClassSpecs(db, class), nil
+}
+
+// AddBackupClassSpecs adds the backup policy of the DatabaseClass of the Database of the Backup which is not informed
+// in its spec
+// NOTE: The Backup is kept as it is when the Database or its DatabaseClass is not found
+func AddBackupClassSpecs(bkp *v1alpha1.Backup, client client.Client) error {
+	class, err := FetchBackupDatabaseClass(bkp, client)
+	if err != nil || class == nil {
+		return err
+	}
+	utils.AddBackupClassSpecs(bkp, class)
+	return nil
+}
+
+// FetchBackupDatabaseClass returns the DatabaseClass of the Database of the Backup or nil when the Database does not
+// inform the className or when the Database or its DatabaseClass is not found
+func FetchBackupDatabaseClass(bkp *v1alpha1.Backup, client client.Client) (*v1alpha1.DatabaseClass, error) {
+	name := bkp.Spec.DatabaseCRName
+	if name == "" {
+		name = config.GetBackupConfig(bkp.Namespace).DatabaseCRName
+	}
+	db, err := FetchDatabaseCR(name, bkp.Namespace, client)
+	if err != nil || db.Spec.ClassName == "" {
+		return nil, ignoreNotFound(err)
+	}
+	class, err := FetchDatabaseClassCR(db.Spec.ClassName, client)
+	if err != nil {
+		return nil, ignoreNotFound(err)
+	}
+	return class, nil
+}
+
+// ignoreNotFound returns nil when the error is of an object not found
+func ignoreNotFound(err error) error {
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// AddDatabaseMandatorySpecs adds the values of the DatabaseClass and then the default values which are not informed in
+// the spec of the Database and returns the names of the fields which use the values of the class
+// NOTE: Only the default values are added when the DatabaseClass is not found, which is returned as error
+func AddDatabaseMandatorySpecs(db *v1alpha1.Database, client client.Client) ([]string, error) {
+	fields, err := AddDatabaseClassSpecs(db, client)
+	utils.AddDatabaseMandatorySpecs(db)
+	return fields, err
+}
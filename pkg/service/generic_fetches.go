@@ -3,8 +3,10 @@ package service
 import (
 	"context"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -30,6 +32,13 @@ func FetchPersistentVolumeClaim(name, namespace string, client client.Client) (*
 	return pvc, err
 }
 
+//FetchStorageClass returns the StorageClass resource with the name
+func FetchStorageClass(name string, client client.Client) (*storagev1.StorageClass, error) {
+	storageClass := &storagev1.StorageClass{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: name}, storageClass)
+	return storageClass, err
+}
+
 //FetchCronJob returns the CronJob resource with the name in the namespace
 func FetchCronJob(name, namespace string, client client.Client) (*v1beta1.CronJob, error) {
 	cronJob := &v1beta1.CronJob{}
@@ -50,3 +59,17 @@ func FetchConfigMap(name, namespace string, client client.Client) (*corev1.Confi
 	err := client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, cfg)
 	return cfg, err
 }
+
+//FetchStatefulSet returns the StatefulSet resource with the name in the namespace
+func FetchStatefulSet(name, namespace string, client client.Client) (*appsv1.StatefulSet, error) {
+	sts := &appsv1.StatefulSet{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, sts)
+	return sts, err
+}
+
+//FetchJob returns the Job resource with the name in the namespace
+func FetchJob(name, namespace string, client client.Client) (*batchv1.Job, error) {
+	job := &batchv1.Job{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, job)
+	return job, err
+}
@@ -7,6 +7,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
 )
 
 // FetchDatabasePod search in the cluster for 1 Pod managed by the Database Controller
@@ -22,6 +23,18 @@ func FetchDatabasePod(bkp *v1alpha1.Backup, db *v1alpha1.Database, client client
 		return nil, err
 	}
 
+	// The primary is returned when it is found in order to make the result predictable
+	primaryPodName := utils.GetPrimaryPodName(db)
+	for i := range dbPodList.Items {
+		if dbPodList.Items[i].Labels[utils.RoleLabelKey] == utils.PrimaryRole || dbPodList.Items[i].Name == primaryPodName {
+			return &dbPodList.Items[i], nil
+		}
+	}
+
+	// Otherwise, the first pod sorted by the name is returned
+	sort.Slice(dbPodList.Items, func(i, j int) bool {
+		return dbPodList.Items[i].Name < dbPodList.Items[j].Name
+	})
 	pod := dbPodList.Items[0]
 	return &pod, nil
 }
@@ -39,6 +52,13 @@ func FetchDatabaseService(bkp *v1alpha1.Backup, db *v1alpha1.Database, client cl
 		return nil, err
 	}
 
+	// The Service with the name of the Database is the one which selects the primary when the replication is enabled
+	for i := range dbServiceList.Items {
+		if dbServiceList.Items[i].Name == db.Name {
+			return &dbServiceList.Items[i], nil
+		}
+	}
+
 	srv := dbServiceList.Items[0]
 	return &srv, nil
 }
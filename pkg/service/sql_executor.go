@@ -0,0 +1,163 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// SQLExecutor runs commands in the containers of the Database pods. It allows check the health of the instances and
+// run SQL statements with psql.
+// NOTE: It is an interface in order to allow mock it in the tests since the fake client cannot exec in pods
+type SQLExecutor interface {
+	// Exec runs the command in the container of the pod and returns its stdout
+	Exec(pod *corev1.Pod, container string, command []string) (string, error)
+}
+
+// PodExecutor is the SQLExecutor which uses the pods/exec subresource of the cluster
+type PodExecutor struct {
+	config    *rest.Config
+	clientset kubernetes.Interface
+}
+
+// NewPodExecutor returns the SQLExecutor which uses the pods/exec subresource of the cluster
+func NewPodExecutor(config *rest.Config) (*PodExecutor, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &PodExecutor{config: config, clientset: clientset}, nil
+}
+
+// Exec runs the command in the container of the pod and returns its stdout
+func (e *PodExecutor) Exec(pod *corev1.Pod, container string, command []string) (string, error) {
+	req := e.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(e.config, "POST", req.URL())
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := exec.Stream(remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		return "", fmt.Errorf("%v: %v", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Query runs the SQL statement with psql in the container of the pod and returns its output without alignment
+func Query(executor SQLExecutor, pod *corev1.Pod, container, sql string) (string, error) {
+	return executor.Exec(pod, container, []string{"psql", "-tAc", sql})
+}
+
+// IsHostReady returns error when the database in the host:port is not accepting connections. The check runs from
+// the container of the pod informed in order to use the cluster network. E.g. to check the primary through its Service.
+func IsHostReady(executor SQLExecutor, pod *corev1.Pod, container, host string, port int32) error {
+	_, err := executor.Exec(pod, container, []string{"pg_isready", "-h", host, "-p", strconv.Itoa(int(port)), "-t", "5"})
+	return err
+}
+
+// FetchServerVersionNum returns the server_version_num of the database running in the pod. (E.g. 90624 or 120003)
+func FetchServerVersionNum(executor SQLExecutor, pod *corev1.Pod, container string) (int, error) {
+	out, err := Query(executor, pod, container, "SHOW server_version_num")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(out)
+}
+
+// FetchReceiveLSN returns the last WAL location received by the standby running in the pod
+func FetchReceiveLSN(executor SQLExecutor, pod *corev1.Pod, container string) (string, error) {
+	version, err := FetchServerVersionNum(executor, pod, container)
+	if err != nil {
+		return "", err
+	}
+
+	// The xlog functions were renamed to wal in PostgreSQL 10
+	sql := "SELECT pg_last_wal_receive_lsn()"
+	if version < 100000 {
+		sql = "SELECT pg_last_xlog_receive_location()"
+	}
+	return Query(executor, pod, container, sql)
+}
+
+// Promote promotes the standby running in the pod to primary
+func Promote(executor SQLExecutor, pod *corev1.Pod, container string) error {
+	version, err := FetchServerVersionNum(executor, pod, container)
+	if err != nil {
+		return err
+	}
+
+	// The function pg_promote is available since PostgreSQL 12
+	if version >= 120000 {
+		_, err := Query(executor, pod, container, "SELECT pg_promote()")
+		return err
+	}
+
+	dataDir, err := Query(executor, pod, container, "SHOW data_directory")
+	if err != nil {
+		return err
+	}
+	_, err = executor.Exec(pod, container, []string{"pg_ctl", "promote", "-D", dataDir})
+	return err
+}
+
+// ArchiverStats is the state of the WAL archiving of the database from the pg_stat_archiver
+type ArchiverStats struct {
+	LastArchivedWal  string
+	LastArchivedTime int64
+	LastFailedWal    string
+	LastFailedTime   int64
+	CurrentWal       string
+	WalSegmentSize   string
+}
+
+// FetchArchiverStats returns the state of the WAL archiving of the primary running in the pod
+// NOTE: The times are in seconds since the epoch and 0 when none segment was archived or failed
+func FetchArchiverStats(executor SQLExecutor, pod *corev1.Pod, container string) (*ArchiverStats, error) {
+	version, err := FetchServerVersionNum(executor, pod, container)
+	if err != nil {
+		return nil, err
+	}
+
+	// The xlog functions were renamed to wal in PostgreSQL 10
+	current := "pg_walfile_name(pg_current_wal_lsn())"
+	if version < 100000 {
+		current = "pg_xlogfile_name(pg_current_xlog_location())"
+	}
+	out, err := Query(executor, pod, container, "SELECT coalesce(last_archived_wal, ''), coalesce(extract(epoch FROM last_archived_time)::bigint, 0), "+
+		"coalesce(last_failed_wal, ''), coalesce(extract(epoch FROM last_failed_time)::bigint, 0), "+current+", current_setting('wal_segment_size') FROM pg_stat_archiver")
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Split(out, "|")
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("Error: Unable to parse the pg_stat_archiver (%v).", out)
+	}
+	stats := &ArchiverStats{LastArchivedWal: fields[0], LastFailedWal: fields[2], CurrentWal: fields[4], WalSegmentSize: fields[5]}
+	if stats.LastArchivedTime, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+		return nil, err
+	}
+	if stats.LastFailedTime, err = strconv.ParseInt(fields[3], 10, 64); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
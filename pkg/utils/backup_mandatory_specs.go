@@ -5,11 +5,11 @@ import (
 	"github.com/dev4devs-com/postgresql-operator/pkg/config"
 )
 
-var defaultBackupConfig = config.NewDefaultBackupConfig()
-
 // AddBackupMandatorySpecs will add the specs which are mandatory for Backup CR in the case them
 // not be applied
+// NOTE: The default values are the ones of the configuration of the operator for the namespace of the CR
 func AddBackupMandatorySpecs(bkp *v1alpha1.Backup) {
+	defaultConfig := config.GetBackupConfig(bkp.Namespace)
 
 	/*
 		 Backup Container
@@ -18,18 +18,49 @@ func AddBackupMandatorySpecs(bkp *v1alpha1.Backup) {
 	*/
 
 	if bkp.Spec.Schedule == "" {
-		bkp.Spec.Schedule = defaultBackupConfig.Schedule
+		bkp.Spec.Schedule = defaultConfig.Schedule
 	}
 
 	if bkp.Spec.DatabaseCRName == "" {
-		bkp.Spec.DatabaseCRName = defaultBackupConfig.DatabaseCRName
+		bkp.Spec.DatabaseCRName = defaultConfig.DatabaseCRName
 	}
 
 	if bkp.Spec.Image == "" {
-		bkp.Spec.Image = defaultBackupConfig.Image
+		bkp.Spec.Image = defaultConfig.Image
 	}
 
 	if bkp.Spec.DatabaseVersion == "" {
-		bkp.Spec.DatabaseVersion = defaultBackupConfig.DatabaseVersion
+		bkp.Spec.DatabaseVersion = defaultConfig.DatabaseVersion
+	}
+
+	if bkp.Spec.WalArchiving != nil && bkp.Spec.WalArchiving.BaseBackupSchedule == "" {
+		bkp.Spec.WalArchiving.BaseBackupSchedule = defaultConfig.BaseBackupSchedule
+	}
+
+	addStorageMandatorySpecs(bkp, defaultConfig)
+}
+
+// addStorageMandatorySpecs will add the type of the storage, its secret and the compression when they are not informed
+// NOTE: The AWS secret is used by the s3 type in order to allow move to the storage spec without change the secret
+func addStorageMandatorySpecs(bkp *v1alpha1.Backup, defaultConfig *config.DefaultBackupConfig) {
+	if bkp.Spec.Storage == nil {
+		return
+	}
+
+	if bkp.Spec.Storage.Type == "" {
+		bkp.Spec.Storage.Type = defaultConfig.StorageType
+	}
+
+	if bkp.Spec.Storage.Type == S3Storage && bkp.Spec.Storage.SecretName == "" {
+		bkp.Spec.Storage.SecretName = GetAWSSecretName(bkp)
+		bkp.Spec.Storage.SecretNamespace = GetAwsSecretNamespace(bkp)
+	}
+
+	if bkp.Spec.Storage.SecretNamespace == "" {
+		bkp.Spec.Storage.SecretNamespace = bkp.Namespace
+	}
+
+	if bkp.Spec.Storage.Compression == "" {
+		bkp.Spec.Storage.Compression = defaultConfig.Compression
 	}
 }
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+// GetBackupTrigger returns the value which requests an on-demand backup
+// NOTE: The spec has precedence over the annotation
+func GetBackupTrigger(bkp *v1alpha1.Backup) string {
+	if bkp.Spec.Trigger != "" {
+		return bkp.Spec.Trigger
+	}
+	return bkp.Annotations[BackupTriggerAnnotation]
+}
+
+// IsOnDemandBackupRequested returns true when the trigger was changed since the latest on-demand backup
+func IsOnDemandBackupRequested(bkp *v1alpha1.Backup) bool {
+	trigger := GetBackupTrigger(bkp)
+	if trigger == "" {
+		return false
+	}
+	return bkp.Status.OnDemandBackup == nil || bkp.Status.OnDemandBackup.Trigger != trigger
+}
+
+// GetOnDemandJobName returns the name of the Job of the on-demand backup requested by the trigger informed
+// NOTE: The trigger is hashed since it can have chars which are not allowed in the names of the objects
+func GetOnDemandJobName(bkp *v1alpha1.Backup, trigger string) string {
+	h := fnv.New32a()
+	h.Write([]byte(trigger))
+	return fmt.Sprintf("%v%v%08x", bkp.Name, OnDemandJobSuffix, h.Sum32())
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+// IsRetentionEnabled returns true when the expired dumps should be deleted after each successful backup
+func IsRetentionEnabled(bkp *v1alpha1.Backup) bool {
+	return bkp.Spec.Retention != nil
+}
+
+// GetBackupPodLabels returns the labels of the pods which do the backup
+// NOTE: It allows find the pods of the Jobs created by the CronJob which are not controlled by the Backup
+func GetBackupPodLabels(bkp *v1alpha1.Backup) map[string]string {
+	return map[string]string{BackupLabelKey: bkp.Name}
+}
+
+// GetPruneContainerName returns the name of the container which deletes the expired dumps
+func GetPruneContainerName(bkp *v1alpha1.Backup) string {
+	return bkp.Name + PruneContainerSuffix
+}
+
+// ParsePruneMessage returns the quantity of dumps kept and deleted written by the prune container in its termination message
+// (E.g. "kept=7 deleted=2")
+func ParsePruneMessage(msg string) (int32, int32, error) {
+	var kept, deleted int32
+	if _, err := fmt.Sscanf(msg, "kept=%d deleted=%d", &kept, &deleted); err != nil {
+		return 0, 0, fmt.Errorf("Error: Unable to parse the result of the pruning (%v): %v", msg, err)
+	}
+	return kept, deleted, nil
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetBackupJobLabels returns the labels of the Jobs which do the backup (scheduled by the CronJob or on-demand)
+// NOTE: It allows find the Jobs created by the CronJob which are not controlled by the Backup
+func GetBackupJobLabels(bkp *v1alpha1.Backup) map[string]string {
+	ls := GetLabels(bkp.Name)
+	ls[BackupLabelKey] = bkp.Name
+	return ls
+}
+
+// ParseUploadMessage returns the key and the size in bytes of the dump written in the first line of the termination
+// message of the container which uploads it (E.g. "backups/postgresql/postgres/2020/07/06/postgresql.example-00_00_00.pg_dump.gz 1024")
+// NOTE: The size is 0 when it is not informed
+func ParseUploadMessage(msg string) (string, int64) {
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	fields := strings.Fields(lines[0])
+	if len(fields) == 0 {
+		return "", 0
+	}
+	if len(fields) < 2 {
+		return fields[0], 0
+	}
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return fields[0], 0
+	}
+	return fields[0], size
+}
+
+// GetDuration returns the time between the start and the completion rounded to seconds (E.g. 1m30s)
+// NOTE: It is empty when the start or the completion is not informed
+func GetDuration(start, completion *metav1.Time) string {
+	if start == nil || completion == nil {
+		return ""
+	}
+	return completion.Sub(start.Time).Round(time.Second).String()
+}
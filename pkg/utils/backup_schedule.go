@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+// cronField defines the values allowed in a field of the cron expression
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+// cronFields are the fields of the standard cron expression used by the schedule of the CronJobs
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 6, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+var cronMacros = []string{"@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly"}
+
+// ValidateCronSchedule returns error when the schedule is not a cron expression supported by the CronJobs
+// (E.g. "0 0 * * *", "*/15 * * * 1-5" or "@daily")
+func ValidateCronSchedule(schedule string) error {
+	if strings.HasPrefix(schedule, "@every ") {
+		if d, err := time.ParseDuration(strings.TrimPrefix(schedule, "@every ")); err != nil || d <= 0 {
+			return fmt.Errorf("Error: The duration of the schedule (%v) is invalid.", schedule)
+		}
+		return nil
+	}
+	if strings.HasPrefix(schedule, "@") {
+		if !isValueAllowed(cronMacros, schedule) {
+			return fmt.Errorf("Error: The schedule (%v) is invalid. Expected one of %v.", schedule, strings.Join(cronMacros, ", "))
+		}
+		return nil
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != len(cronFields) {
+		return fmt.Errorf("Error: The schedule (%v) should have %v fields: minute, hour, day of month, month and day of week.", schedule, len(cronFields))
+	}
+	for i, value := range fields {
+		if err := validateCronField(cronFields[i], value); err != nil {
+			return fmt.Errorf("Error: The %v (%v) of the schedule (%v) is invalid. %v", cronFields[i].name, value, schedule, err)
+		}
+	}
+	return nil
+}
+
+// ValidateBackupSchedules returns error when the schedule of the backup or of the base backups is invalid
+func ValidateBackupSchedules(bkp *v1alpha1.Backup) error {
+	if err := ValidateCronSchedule(bkp.Spec.Schedule); err != nil {
+		return err
+	}
+	if bkp.Spec.WalArchiving != nil && bkp.Spec.WalArchiving.BaseBackupSchedule != "" {
+		return ValidateCronSchedule(bkp.Spec.WalArchiving.BaseBackupSchedule)
+	}
+	return nil
+}
+
+// validateCronField returns error when some item of the list of the field is not a value, range or step allowed
+func validateCronField(field cronField, value string) error {
+	for _, item := range strings.Split(value, ",") {
+		rangePart, step := item, ""
+		if i := strings.Index(item, "/"); i >= 0 {
+			rangePart, step = item[:i], item[i+1:]
+			if n, err := strconv.Atoi(step); err != nil || n <= 0 {
+				return fmt.Errorf("The step (%v) should be a positive number.", step)
+			}
+		}
+		if rangePart == "*" || (rangePart == "?" && (field.name == "day of month" || field.name == "day of week")) {
+			continue
+		}
+		bounds := strings.SplitN(rangePart, "-", 2)
+		var values []int
+		for _, bound := range bounds {
+			n, err := parseCronValue(field, bound)
+			if err != nil {
+				return err
+			}
+			values = append(values, n)
+		}
+		if len(values) == 2 && values[0] > values[1] {
+			return fmt.Errorf("The range (%v) should start with the lower value.", rangePart)
+		}
+	}
+	return nil
+}
+
+// parseCronValue returns the number of the value or of its name (E.g. jan or mon) checking if it is in the bounds
+func parseCronValue(field cronField, value string) (int, error) {
+	for i, name := range field.names {
+		if strings.EqualFold(name, value) {
+			return field.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < field.min || n > field.max {
+		return 0, fmt.Errorf("Expected values from %v to %v.", field.min, field.max)
+	}
+	return n, nil
+}
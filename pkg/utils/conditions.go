@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FindCondition returns the condition of the type informed or nil when it is not found
+func FindCondition(conditions []v1alpha1.Condition, conditionType string) *v1alpha1.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue returns true when the condition of the type informed is found with the status True
+func IsConditionTrue(conditions []v1alpha1.Condition, conditionType string) bool {
+	c := FindCondition(conditions, conditionType)
+	return c != nil && c.Status == corev1.ConditionTrue
+}
+
+// NewCondition returns the condition with the status True or False
+func NewCondition(conditionType string, isTrue bool, reason, message string, generation int64) v1alpha1.Condition {
+	status := corev1.ConditionFalse
+	if isTrue {
+		status = corev1.ConditionTrue
+	}
+	return v1alpha1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// SetCondition adds or updates the condition of its type and returns true when something was changed
+// NOTE: The LastTransitionTime is just changed when the status changes
+func SetCondition(conditions *[]v1alpha1.Condition, condition v1alpha1.Condition) bool {
+	existing := FindCondition(*conditions, condition.Type)
+	if existing == nil {
+		if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = metav1.Now()
+		}
+		*conditions = append(*conditions, condition)
+		return true
+	}
+	if existing.Status == condition.Status && existing.Reason == condition.Reason &&
+		existing.Message == condition.Message && existing.ObservedGeneration == condition.ObservedGeneration {
+		return false
+	}
+	if existing.Status != condition.Status {
+		existing.LastTransitionTime = metav1.Now()
+	}
+	existing.Status = condition.Status
+	existing.Reason = condition.Reason
+	existing.Message = condition.Message
+	existing.ObservedGeneration = condition.ObservedGeneration
+	return true
+}
@@ -1,9 +1,61 @@
 package utils
 
 const (
-	AwsSecretPrefix        = "aws-"
-	DbSecretPrefix         = "db-"
-	EncSecretPrefix        = "encryption-"
-	BackupControllerName   = "controller_backup"
-	DatabaseControllerName = "controller_database"
+	AwsSecretPrefix         = "aws-"
+	DbSecretPrefix          = "db-"
+	EncSecretPrefix         = "encryption-"
+	BackupControllerName    = "controller_backup"
+	DatabaseControllerName  = "controller_database"
+	StandbySuffix           = "-standby-"
+	ReadOnlyServiceSuffix   = "-ro"
+	RoleLabelKey            = "role"
+	MemberLabelKey          = "member"
+	PrimaryRole             = "primary"
+	StandbyRole             = "standby"
+	DeploymentWorkload      = "Deployment"
+	StatefulSetWorkload     = "StatefulSet"
+	HeadlessServiceSuffix   = "-headless"
+	MigrationJobSuffix      = "-migration"
+	PrimaryPodEnvVar        = "POSTGRESQL_PRIMARY_POD_NAME"
+	RestoreControllerName   = "controller_restore"
+	RestoreSecretPrefix     = "restore-"
+	BackupTriggerAnnotation = "postgresql.dev4devs.com/backup-trigger"
+	OnDemandJobSuffix       = "-ondemand-"
+	StorageSecretPrefix     = "storage-"
+	PruneContainerSuffix    = "-prune"
+	BackupLabelKey          = "backup"
+	TemplateHashAnnotation  = "postgresql.dev4devs.com/template-hash"
+	BaseBackupSuffix        = "-basebackup"
+	WalArchiverName         = "wal-archiver"
+	WalArchiveDir           = "wal"
+	WalSpoolPath            = "/var/lib/pgsql/data/wal-spool"
+	RunPostgresqlCommand    = "run-postgresql"
+	S3Storage               = "s3"
+	GCSStorage              = "gcs"
+	AzureStorage            = "azure"
+	VolumeStorage           = "volume"
+	StorageVolumePath       = "/backup-storage"
+	DumpContainerSuffix     = "-dump"
+	GzipCompression         = "gzip"
+	NoneCompression         = "none"
+	UpgradeJobSuffix        = "-upgrade-"
+	ParametersSuffix        = "-parameters"
+	ParametersConfKey       = "postgresql-operator.conf"
+	ParametersConfPath      = "/opt/app-root/src/postgresql-cfg"
+	RoleSecretSuffix        = "-role-"
+	CredentialsSecretSuffix = "-credentials"
+	TLSSecretSuffix         = "-tls"
+	TLSCASecretSuffix       = "-tls-ca"
+	TLSCertPath             = "/opt/app-root/tls"
+	TLSHashAnnotation       = "postgresql.dev4devs.com/tls-certificate-hash"
+	HbaSuffix               = "-hba"
+	HbaConfKey              = "pg_hba.conf"
+	HbaConfPath             = "/opt/app-root/hba"
+	RestartHashAnnotation   = "postgresql.dev4devs.com/restart-parameters-hash"
+	DeletionFinalizer       = "postgresql.dev4devs.com/deletion-policy"
+	RetainedFromAnnotation  = "postgresql.dev4devs.com/retained-from"
+	DeletePolicy            = "Delete"
+	RetainPolicy            = "Retain"
+	SnapshotPolicy          = "Snapshot"
+	BackupThenDeletePolicy  = "BackupThenDelete"
 )
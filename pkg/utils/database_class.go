@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+// AddDatabaseClassSpecs adds the values of the DatabaseClass which are not informed in the spec of the Database and
+// returns the names of the fields which use the values of the class
+// NOTE: The parameters of the class are added to the ones of the spec which have priority over them
+func AddDatabaseClassSpecs(db *v1alpha1.Database, class *v1alpha1.DatabaseClass) []string {
+	var fields []string
+	values := []struct {
+		name  string
+		spec  *string
+		class string
+	}{
+		{"image", &db.Spec.Image, class.Spec.Image},
+		{"databaseMemoryLimit", &db.Spec.DatabaseMemoryLimit, class.Spec.DatabaseMemoryLimit},
+		{"databaseMemoryRequest", &db.Spec.DatabaseMemoryRequest, class.Spec.DatabaseMemoryRequest},
+		{"databaseCpuLimit", &db.Spec.DatabaseCpuLimit, class.Spec.DatabaseCpuLimit},
+		{"databaseCpu", &db.Spec.DatabaseCpu, class.Spec.DatabaseCpu},
+		{"databaseStorageRequest", &db.Spec.DatabaseStorageRequest, class.Spec.DatabaseStorageRequest},
+		{"databaseStorageClassName", &db.Spec.DatabaseStorageClassName, class.Spec.DatabaseStorageClassName},
+	}
+	for _, f := range values {
+		if *f.spec == "" && f.class != "" {
+			*f.spec = f.class
+			fields = append(fields, f.name)
+		}
+	}
+
+	added := false
+	for name, value := range class.Spec.Parameters {
+		if _, ok := db.Spec.Parameters[name]; ok {
+			continue
+		}
+		if db.Spec.Parameters == nil {
+			db.Spec.Parameters = map[string]string{}
+		}
+		db.Spec.Parameters[name] = value
+		added = true
+	}
+	if added {
+		fields = append(fields, "parameters")
+	}
+
+	if db.Spec.NodeSelector == nil && class.Spec.NodeSelector != nil {
+		db.Spec.NodeSelector = class.Spec.NodeSelector
+		fields = append(fields, "nodeSelector")
+	}
+	if db.Spec.Tolerations == nil && class.Spec.Tolerations != nil {
+		db.Spec.Tolerations = class.Spec.Tolerations
+		fields = append(fields, "tolerations")
+	}
+	if db.Spec.Affinity == nil && class.Spec.Affinity != nil {
+		db.Spec.Affinity = class.Spec.Affinity
+		fields = append(fields, "affinity")
+	}
+	return fields
+}
+
+// AddBackupClassSpecs adds the backup policy of the DatabaseClass of the Database which is not informed in the spec of
+// the Backup
+func AddBackupClassSpecs(bkp *v1alpha1.Backup, class *v1alpha1.DatabaseClass) {
+	if class.Spec.Backup == nil {
+		return
+	}
+	if bkp.Spec.Schedule == "" {
+		bkp.Spec.Schedule = class.Spec.Backup.Schedule
+	}
+	if bkp.Spec.Retention == nil && class.Spec.Backup.Retention != nil {
+		bkp.Spec.Retention = class.Spec.Backup.Retention.DeepCopy()
+	}
+}
+
+// ResetDatabaseClassSpecs sets the fields of the spec which can be informed by the DatabaseClass with the values of the
+// original Database when it informs the className
+// NOTE: It is used to not store the default values of the operator in these fields, which would replace the ones of
+// the class
+func ResetDatabaseClassSpecs(db, original *v1alpha1.Database) {
+	if original.Spec.ClassName == "" {
+		return
+	}
+	db.Spec.Image = original.Spec.Image
+	db.Spec.DatabaseMemoryLimit = original.Spec.DatabaseMemoryLimit
+	db.Spec.DatabaseMemoryRequest = original.Spec.DatabaseMemoryRequest
+	db.Spec.DatabaseCpuLimit = original.Spec.DatabaseCpuLimit
+	db.Spec.DatabaseCpu = original.Spec.DatabaseCpu
+	db.Spec.DatabaseStorageRequest = original.Spec.DatabaseStorageRequest
+	db.Spec.DatabaseStorageClassName = original.Spec.DatabaseStorageClassName
+}
+
+// ResetBackupClassSpecs sets the fields of the spec which are informed by the backup policy of the DatabaseClass with
+// the values of the original Backup
+// NOTE: It is used to not store the default values of the operator in these fields, which would replace the ones of
+// the class
+func ResetBackupClassSpecs(bkp, original *v1alpha1.Backup, class *v1alpha1.DatabaseClass) {
+	if class.Spec.Backup == nil {
+		return
+	}
+	if class.Spec.Backup.Schedule != "" {
+		bkp.Spec.Schedule = original.Spec.Schedule
+	}
+	if class.Spec.Backup.Retention != nil {
+		bkp.Spec.Retention = original.Spec.Retention.DeepCopy()
+	}
+}
+
+// BuildClassStatus returns the status with the values of the Database with the class and default values
+func BuildClassStatus(db *v1alpha1.Database, fields []string) *v1alpha1.ClassStatus {
+	return &v1alpha1.ClassStatus{
+		Name:                     db.Spec.ClassName,
+		Fields:                   fields,
+		Image:                    db.Spec.Image,
+		DatabaseMemoryLimit:      db.Spec.DatabaseMemoryLimit,
+		DatabaseMemoryRequest:    db.Spec.DatabaseMemoryRequest,
+		DatabaseCpuLimit:         db.Spec.DatabaseCpuLimit,
+		DatabaseCpu:              db.Spec.DatabaseCpu,
+		DatabaseStorageRequest:   db.Spec.DatabaseStorageRequest,
+		DatabaseStorageClassName: db.Spec.DatabaseStorageClassName,
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+// VolumeSnapshotAPIVersion is the API version of the VolumeSnapshots taken when the deletionPolicy is Snapshot
+const VolumeSnapshotAPIVersion = "snapshot.storage.k8s.io/v1beta1"
+
+// ValidateDeletionPolicy returns error when the spec.deletionPolicy informed is not supported
+func ValidateDeletionPolicy(db *v1alpha1.Database) error {
+	switch db.Spec.DeletionPolicy {
+	case "", DeletePolicy, RetainPolicy, SnapshotPolicy, BackupThenDeletePolicy:
+		return nil
+	}
+	return fmt.Errorf("Error: Deletion policy (%v) is not supported. Options: %v, %v, %v or %v.",
+		db.Spec.DeletionPolicy, DeletePolicy, RetainPolicy, SnapshotPolicy, BackupThenDeletePolicy)
+}
+
+// IsDeletionFinalizerRequired returns true when the deletionPolicy needs the operator to do something before the PVCs
+// of the Database are deleted by the garbage collector
+// NOTE: The finalizer is only not required by the policy Delete, so the data is not lost when the policy is invalid
+func IsDeletionFinalizerRequired(db *v1alpha1.Database) bool {
+	return db.Spec.DeletionPolicy != DeletePolicy
+}
+
+// HasDeletionFinalizer returns true when the Database has the finalizer which applies the deletionPolicy
+func HasDeletionFinalizer(db *v1alpha1.Database) bool {
+	for _, f := range db.Finalizers {
+		if f == DeletionFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveDeletionFinalizer removes the finalizer which applies the deletionPolicy from the Database
+func RemoveDeletionFinalizer(db *v1alpha1.Database) {
+	var finalizers []string
+	for _, f := range db.Finalizers {
+		if f != DeletionFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	db.Finalizers = finalizers
+}
+
+// GetDeletionSnapshotName returns the name of the VolumeSnapshot of the PVC taken when the Database is deleted
+// NOTE: The time of the deletion is used in order to not conflict with the snapshots of a Database with the same name
+// deleted before
+func GetDeletionSnapshotName(db *v1alpha1.Database, pvcName string) string {
+	return fmt.Sprintf("%v-%v", pvcName, db.DeletionTimestamp.Unix())
+}
+
+// GetDeletionBackupTrigger returns the trigger of the on-demand backup taken before the Database is deleted
+func GetDeletionBackupTrigger(db *v1alpha1.Database) string {
+	return fmt.Sprintf("deletion-%v", db.DeletionTimestamp.Unix())
+}
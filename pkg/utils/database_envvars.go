@@ -7,74 +7,133 @@ import (
 
 //BuildDatabaseNameEnvVar return the corev1.EnvVar object wth the key:value for the database name
 func BuildDatabaseNameEnvVar(db *v1alpha1.Database) corev1.EnvVar {
-	if len(db.Spec.ConfigMapName) > 0 {
-		return corev1.EnvVar{
-			Name: db.Spec.DatabaseNameKeyEnvVar,
-			ValueFrom: &corev1.EnvVarSource{
-				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: db.Spec.ConfigMapName,
-					},
-					Key: GetEnvVarKey(db.Spec.ConfigMapDatabaseNameKey, db.Spec.DatabaseNameKeyEnvVar),
-				},
-			},
-		}
-	}
-
-	return corev1.EnvVar{
-		Name:  db.Spec.DatabaseNameKeyEnvVar,
-		Value: db.Spec.DatabaseName,
-	}
+	return buildCredentialEnvVar(db, db.Spec.DatabaseNameKeyEnvVar, db.Spec.DatabaseName,
+		db.Spec.ConfigMapDatabaseNameKey, db.Spec.CredentialsSecretDatabaseNameKey)
 }
 
 //BuildDatabaseUserEnvVar return the corev1.EnvVar object wth the key:value for the database user
 func BuildDatabaseUserEnvVar(db *v1alpha1.Database) corev1.EnvVar {
-	if len(db.Spec.ConfigMapName) > 0 {
-		return corev1.EnvVar{
-			Name: db.Spec.DatabaseUserKeyEnvVar,
-			ValueFrom: &corev1.EnvVarSource{
-				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: db.Spec.ConfigMapName,
-					},
-					Key: GetEnvVarKey(db.Spec.ConfigMapDatabaseUserKey, db.Spec.DatabaseUserKeyEnvVar),
-				},
-			},
+	return buildCredentialEnvVar(db, db.Spec.DatabaseUserKeyEnvVar, db.Spec.DatabaseUser,
+		db.Spec.ConfigMapDatabaseUserKey, db.Spec.CredentialsSecretUserKey)
+}
+
+//BuildDatabasePasswordEnvVar return the corev1.EnvVar object wth the key:value for the database pwd
+func BuildDatabasePasswordEnvVar(db *v1alpha1.Database) corev1.EnvVar {
+	return buildCredentialEnvVar(db, db.Spec.DatabasePasswordKeyEnvVar, db.Spec.DatabasePassword,
+		db.Spec.ConfigMapDatabasePasswordKey, db.Spec.CredentialsSecretPasswordKey)
+}
+
+//BuildReplicationEnvVars return the corev1.EnvVar objects with the key:value required for the replication
+//NOTE: The primary service is only informed for the standbys which will use it to build the primary_conninfo
+func BuildReplicationEnvVars(db *v1alpha1.Database, role string) []corev1.EnvVar {
+	rep := db.Spec.Replication
+	envs := BuildReplicationCredentialsEnvVars(db, rep.ReplicationUserKeyEnvVar, rep.ReplicationPasswordKeyEnvVar)
+
+	if role == StandbyRole {
+		envs = append(envs, corev1.EnvVar{
+			Name:  rep.PrimaryServiceKeyEnvVar,
+			Value: db.Name,
+		})
+	}
+	return envs
+}
+
+//BuildReplicationCredentialsEnvVars return the corev1.EnvVar objects with the names informed for the replication user
+//and password. They refer to the Secret of the credentials when it is informed or generated, since the password of
+//the replication should not be in the spec of the workloads.
+func BuildReplicationCredentialsEnvVars(db *v1alpha1.Database, userEnvVar, passwordEnvVar string) []corev1.EnvVar {
+	rep := db.Spec.Replication
+	if secretName := GetReplicationSecretName(db); len(secretName) > 0 {
+		return []corev1.EnvVar{
+			buildSecretEnvVar(secretName, userEnvVar, GetReplicationUserKey(db)),
+			buildSecretEnvVar(secretName, passwordEnvVar, GetReplicationPasswordKey(db)),
 		}
 	}
+	return []corev1.EnvVar{
+		{
+			Name:  userEnvVar,
+			Value: rep.ReplicationUser,
+		},
+		{
+			Name:  passwordEnvVar,
+			Value: rep.ReplicationPassword,
+		},
+	}
+}
 
-	return corev1.EnvVar{
-		Name:  db.Spec.DatabaseUserKeyEnvVar,
-		Value: db.Spec.DatabaseUser,
+//GetReplicationSecretName returns the name of the Secret with the replication user and password, which is the Secret
+//of the credentials or the one generated by the operator when the replicationPassword is not informed
+func GetReplicationSecretName(db *v1alpha1.Database) string {
+	if len(db.Spec.CredentialsSecretName) > 0 {
+		return db.Spec.CredentialsSecretName
+	}
+	if db.Spec.Replication.ReplicationPassword == "" {
+		return GetGeneratedCredentialsSecretName(db)
 	}
+	return ""
 }
 
-//BuildDatabasePasswordEnvVar return the corev1.EnvVar object wth the key:value for the database pwd
-func BuildDatabasePasswordEnvVar(db *v1alpha1.Database) corev1.EnvVar {
+//GetReplicationUserKey returns the key of the replication user in the Secret of the credentials
+func GetReplicationUserKey(db *v1alpha1.Database) string {
+	return db.Spec.Replication.ReplicationUserKeyEnvVar
+}
+
+//GetReplicationPasswordKey returns the key of the replication password in the Secret of the credentials
+func GetReplicationPasswordKey(db *v1alpha1.Database) string {
+	return db.Spec.Replication.ReplicationPasswordKeyEnvVar
+}
+
+//GetEnvVarKey check if the customized key is in place for the configMap or Secret and returned the valid key
+func GetEnvVarKey(cgfKey, defaultKey string) string {
+	if len(cgfKey) > 0 {
+		return cgfKey
+	}
+	return defaultKey
+}
+
+//GetGeneratedCredentialsSecretName returns the name of the Secret with the password generated by the operator
+func GetGeneratedCredentialsSecretName(db *v1alpha1.Database) string {
+	return db.Name + CredentialsSecretSuffix
+}
+
+//buildCredentialEnvVar returns the env var with the value informed in the spec or the reference to its key in the
+//Secret or ConfigMap of the credentials. The Secret has priority over the ConfigMap
+func buildCredentialEnvVar(db *v1alpha1.Database, envVar, value, cfgKey, secretKey string) corev1.EnvVar {
+	if len(db.Spec.CredentialsSecretName) > 0 {
+		return buildSecretEnvVar(db.Spec.CredentialsSecretName, envVar, GetEnvVarKey(secretKey, envVar))
+	}
+
 	if len(db.Spec.ConfigMapName) > 0 {
 		return corev1.EnvVar{
-			Name: db.Spec.DatabasePasswordKeyEnvVar,
+			Name: envVar,
 			ValueFrom: &corev1.EnvVarSource{
 				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
 					LocalObjectReference: corev1.LocalObjectReference{
 						Name: db.Spec.ConfigMapName,
 					},
-					Key: GetEnvVarKey(db.Spec.ConfigMapDatabasePasswordKey, db.Spec.DatabasePasswordKeyEnvVar),
+					Key: GetEnvVarKey(cfgKey, envVar),
 				},
 			},
 		}
 	}
 
 	return corev1.EnvVar{
-		Name:  db.Spec.DatabasePasswordKeyEnvVar,
-		Value: db.Spec.DatabasePassword,
+		Name:  envVar,
+		Value: value,
 	}
 }
 
-//GetEnvVarKey check if the customized key is in place for the configMap and returned the valid key
-func GetEnvVarKey(cgfKey, defaultKey string) string {
-	if len(cgfKey) > 0 {
-		return cgfKey
+//buildSecretEnvVar returns the env var with the reference to the key of the Secret
+func buildSecretEnvVar(secretName, envVar, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: envVar,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+				Key: key,
+			},
+		},
 	}
-	return defaultKey
 }
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+// Markers of the rules of the spec.hba in the pg_hba.conf built by the operator
+const (
+	hbaRulesBegin = "# Rules of the spec.hba"
+	hbaRulesEnd   = "# End of the rules of the spec.hba"
+)
+
+// hbaTypes are the types of the rules. The hostgssenc and hostnogssenc are not allowed since they require PostgreSQL 12
+var hbaTypes = []string{"local", "host", "hostssl", "hostnossl"}
+
+// hbaMethods are the authentication methods of the rules. The ones which require options (E.g. ldap, radius and cert)
+// are not allowed since the rules have no options, as is sspi which is only supported on Windows
+var hbaMethods = []string{"trust", "reject", "scram-sha-256", "md5", "password", "gss", "ident", "peer", "pam"}
+
+var hbaAddressKeywords = []string{"all", "samehost", "samenet"}
+
+// hbaNameListRegex matches the databases and users of a rule. The files (@file) and quoted names are not supported
+var hbaNameListRegex = regexp.MustCompile(`^\+?[a-zA-Z0-9_.-]+(,\+?[a-zA-Z0-9_.-]+)*$`)
+
+// HasHbaRules returns true when the spec.hba is informed
+func HasHbaRules(db *v1alpha1.Database) bool {
+	return len(db.Spec.Hba) > 0
+}
+
+// IsHbaManaged returns true when the pg_hba.conf of the ConfigMap is used instead of the one of the image
+func IsHbaManaged(db *v1alpha1.Database) bool {
+	return HasHbaRules(db) || IsTLSEnforced(db)
+}
+
+// GetHbaConfigMapName returns the name of the ConfigMap with the pg_hba.conf of the Database
+func GetHbaConfigMapName(db *v1alpha1.Database) string {
+	return db.Name + HbaSuffix
+}
+
+// ValidateHba returns error when some rule of the spec.hba is invalid
+func ValidateHba(db *v1alpha1.Database) error {
+	for i, rule := range db.Spec.Hba {
+		if !isValueAllowed(hbaTypes, rule.Type) {
+			return fmt.Errorf("Error: The type (%v) of the hba rule %v is invalid. Expected one of %v.", rule.Type, i, strings.Join(hbaTypes, ", "))
+		}
+		if rule.Database != "" && !hbaNameListRegex.MatchString(rule.Database) {
+			return fmt.Errorf("Error: The database (%v) of the hba rule %v is invalid.", rule.Database, i)
+		}
+		if rule.User != "" && !hbaNameListRegex.MatchString(rule.User) {
+			return fmt.Errorf("Error: The user (%v) of the hba rule %v is invalid.", rule.User, i)
+		}
+		if err := validateHbaAddress(rule, i); err != nil {
+			return err
+		}
+		if !isValueAllowed(hbaMethods, rule.Method) {
+			return fmt.Errorf("Error: The method (%v) of the hba rule %v is invalid. Expected one of %v.", rule.Method, i, strings.Join(hbaMethods, ", "))
+		}
+		if rule.Method == "peer" && rule.Type != "local" {
+			return fmt.Errorf("Error: The method peer of the hba rule %v is only allowed with the type local.", i)
+		}
+	}
+	return nil
+}
+
+// validateHbaAddress returns error when the address is informed for the type local or is not a CIDR or keyword
+func validateHbaAddress(rule v1alpha1.DatabaseHbaRule, index int) error {
+	if rule.Type == "local" {
+		if rule.Address != "" {
+			return fmt.Errorf("Error: The address of the hba rule %v cannot be informed with the type local.", index)
+		}
+		return nil
+	}
+	if isValueAllowed(hbaAddressKeywords, rule.Address) {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(rule.Address); err != nil {
+		return fmt.Errorf("Error: The address (%v) of the hba rule %v is invalid. Expected a CIDR or one of %v.",
+			rule.Address, index, strings.Join(hbaAddressKeywords, ", "))
+	}
+	return nil
+}
+
+// BuildHbaConf returns the content of the pg_hba.conf with the rules of the spec.hba between the rules which trust the
+// local connections and allow the replication
+// NOTE: The local connections are used by the operator and the health checks of the image. When only the connections
+// with TLS are accepted the rules of the type host become hostssl, and without rules all users can connect by password.
+func BuildHbaConf(db *v1alpha1.Database) string {
+	host := "host"
+	if IsTLSEnforced(db) {
+		host = "hostssl"
+	}
+
+	var b strings.Builder
+	b.WriteString("# Generated by the postgresql-operator from the spec of the Database\n")
+	b.WriteString("local all all trust\n")
+	b.WriteString("host all all 127.0.0.1/32 trust\n")
+	b.WriteString("host all all ::1/128 trust\n")
+	b.WriteString(hbaRulesBegin + "\n")
+	for _, rule := range db.Spec.Hba {
+		if rule.Type == "host" {
+			rule.Type = host
+		}
+		b.WriteString(buildHbaLine(rule) + "\n")
+	}
+	b.WriteString(hbaRulesEnd + "\n")
+	fmt.Fprintf(&b, "%v replication all all md5\n", host)
+	if !HasHbaRules(db) {
+		fmt.Fprintf(&b, "%v all all all md5\n", host)
+	}
+	return b.String()
+}
+
+// ParseHbaConf returns the rules of the spec.hba of the content built by the BuildHbaConf
+func ParseHbaConf(conf string) []v1alpha1.DatabaseHbaRule {
+	var rules []v1alpha1.DatabaseHbaRule
+	inRules := false
+	for _, line := range strings.Split(conf, "\n") {
+		switch {
+		case line == hbaRulesBegin:
+			inRules = true
+		case line == hbaRulesEnd:
+			return rules
+		case inRules:
+			fields := strings.Fields(line)
+			if len(fields) == 4 && fields[0] == "local" {
+				rules = append(rules, v1alpha1.DatabaseHbaRule{Type: fields[0], Database: fields[1], User: fields[2], Method: fields[3]})
+			} else if len(fields) == 5 {
+				rules = append(rules, v1alpha1.DatabaseHbaRule{Type: fields[0], Database: fields[1], User: fields[2], Address: fields[3], Method: fields[4]})
+			}
+		}
+	}
+	return rules
+}
+
+// BuildHbaArgs returns the arguments of the database server which use the pg_hba.conf of the ConfigMap mounted
+func BuildHbaArgs() []string {
+	return []string{"-c", "hba_file=" + HbaConfPath + "/" + HbaConfKey}
+}
+
+func buildHbaLine(rule v1alpha1.DatabaseHbaRule) string {
+	fields := []string{rule.Type, getValueOrAll(rule.Database), getValueOrAll(rule.User)}
+	if rule.Type != "local" {
+		fields = append(fields, rule.Address)
+	}
+	return strings.Join(append(fields, rule.Method), " ")
+}
+
+func getValueOrAll(value string) string {
+	if value == "" {
+		return "all"
+	}
+	return value
+}
@@ -5,11 +5,11 @@ import (
 	"github.com/dev4devs-com/postgresql-operator/pkg/config"
 )
 
-var defaulDatabaseConfig = config.NewDatabaseConfig()
-
 // AddDatabaseMandatorySpecs will add the specs which are mandatory for Database CR in the case them
 // not be applied
+// NOTE: The default values are the ones of the configuration of the operator for the namespace of the CR
 func AddDatabaseMandatorySpecs(db *v1alpha1.Database) {
+	defaultConfig := config.GetDatabaseConfig(db.Namespace)
 
 	/*
 	   CR DB Resource
@@ -17,7 +17,7 @@ func AddDatabaseMandatorySpecs(db *v1alpha1.Database) {
 	*/
 
 	if db.Spec.Size == 0 {
-		db.Spec.Size = defaulDatabaseConfig.Size
+		db.Spec.Size = defaultConfig.Size
 	}
 
 	/*
@@ -27,15 +27,16 @@ func AddDatabaseMandatorySpecs(db *v1alpha1.Database) {
 	*/
 
 	if db.Spec.DatabaseName == "" {
-		db.Spec.DatabaseName = defaulDatabaseConfig.DatabaseName
+		db.Spec.DatabaseName = defaultConfig.DatabaseName
 	}
 
-	if db.Spec.DatabasePassword == "" {
-		db.Spec.DatabasePassword = defaulDatabaseConfig.DatabasePassword
+	// The password is generated in a Secret when it is not informed by the spec, ConfigMap or Secret
+	if db.Spec.DatabasePassword == "" && db.Spec.ConfigMapName == "" && db.Spec.CredentialsSecretName == "" {
+		db.Spec.CredentialsSecretName = GetGeneratedCredentialsSecretName(db)
 	}
 
 	if db.Spec.DatabaseUser == "" {
-		db.Spec.DatabaseUser = defaulDatabaseConfig.DatabaseUser
+		db.Spec.DatabaseUser = defaultConfig.DatabaseUser
 	}
 
 	/*
@@ -45,50 +46,170 @@ func AddDatabaseMandatorySpecs(db *v1alpha1.Database) {
 
 	//Following are the values which will be used as the key label for the environment variable of the database image.
 	if db.Spec.DatabaseNameKeyEnvVar == "" {
-		db.Spec.DatabaseNameKeyEnvVar = defaulDatabaseConfig.DatabaseNameKeyEnvVar
+		db.Spec.DatabaseNameKeyEnvVar = defaultConfig.DatabaseNameKeyEnvVar
 	}
 
 	if db.Spec.DatabasePasswordKeyEnvVar == "" {
-		db.Spec.DatabasePasswordKeyEnvVar = defaulDatabaseConfig.DatabasePasswordKeyEnvVar
+		db.Spec.DatabasePasswordKeyEnvVar = defaultConfig.DatabasePasswordKeyEnvVar
 	}
 
 	if db.Spec.DatabaseUserKeyEnvVar == "" {
-		db.Spec.DatabaseUserKeyEnvVar = defaulDatabaseConfig.DatabaseUserKeyEnvVar
+		db.Spec.DatabaseUserKeyEnvVar = defaultConfig.DatabaseUserKeyEnvVar
 	}
 
 	if db.Spec.Image == "" {
-		db.Spec.Image = defaulDatabaseConfig.Image
+		db.Spec.Image = defaultConfig.Image
 	}
 
 	if db.Spec.ContainerName == "" {
-		db.Spec.ContainerName = defaulDatabaseConfig.ContainerName
+		db.Spec.ContainerName = defaultConfig.ContainerName
 	}
 
 	if db.Spec.DatabaseMemoryLimit == "" {
-		db.Spec.DatabaseMemoryLimit = defaulDatabaseConfig.DatabaseMemoryLimit
+		db.Spec.DatabaseMemoryLimit = defaultConfig.DatabaseMemoryLimit
 	}
 
 	if db.Spec.DatabaseMemoryRequest == "" {
-		db.Spec.DatabaseMemoryRequest = defaulDatabaseConfig.DatabaseMemoryRequest
+		db.Spec.DatabaseMemoryRequest = defaultConfig.DatabaseMemoryRequest
 	}
 
 	if db.Spec.DatabaseStorageRequest == "" {
-		db.Spec.DatabaseStorageRequest = defaulDatabaseConfig.DatabaseStorageRequest
+		db.Spec.DatabaseStorageRequest = defaultConfig.DatabaseStorageRequest
 	}
 
 	if db.Spec.DatabaseCpu == "" {
-		db.Spec.DatabaseCpu = defaulDatabaseConfig.DatabaseCpu
+		db.Spec.DatabaseCpu = defaultConfig.DatabaseCpu
 	}
 
 	if db.Spec.DatabaseCpuLimit == "" {
-		db.Spec.DatabaseCpuLimit = defaulDatabaseConfig.DatabaseCpuLimit
+		db.Spec.DatabaseCpuLimit = defaultConfig.DatabaseCpuLimit
 	}
 
 	if db.Spec.DatabasePort == 0 {
-		db.Spec.DatabasePort = defaulDatabaseConfig.DatabasePort
+		db.Spec.DatabasePort = defaultConfig.DatabasePort
 	}
 
 	if len(db.Spec.DatabaseStorageClassName) < 1 {
-		db.Spec.DatabaseStorageClassName = defaulDatabaseConfig.DatabaseStorageClassName
+		db.Spec.DatabaseStorageClassName = defaultConfig.DatabaseStorageClassName
+	}
+
+	if db.Spec.WorkloadType == "" {
+		db.Spec.WorkloadType = defaultConfig.WorkloadType
+	}
+
+	if db.Spec.DeletionPolicy == "" {
+		db.Spec.DeletionPolicy = defaultConfig.DeletionPolicy
+	}
+
+	/*
+	   Replication
+	   ---------------------------------
+	*/
+
+	// The replication is kept while a standby promoted by a failover is the primary. See IsReplicationEnabled.
+	if db.Spec.Replication == nil && IsStandbyPrimary(db) {
+		db.Spec.Replication = &v1alpha1.DatabaseReplication{}
+	}
+
+	if db.Spec.Replication != nil {
+		addReplicationMandatorySpecs(db.Spec.Replication, defaultConfig)
+	}
+
+	/*
+	   WAL Archiving
+	   ---------------------------------
+	*/
+
+	if db.Spec.WalArchiving != nil {
+		addWalArchivingMandatorySpecs(db.Spec.WalArchiving, defaultConfig)
+	}
+
+	/*
+	   Password Rotation
+	   ---------------------------------
+	*/
+
+	if db.Spec.PasswordRotation != nil {
+		addPasswordRotationMandatorySpecs(db.Spec.PasswordRotation, defaultConfig)
+	}
+
+	/*
+	   TLS
+	   ---------------------------------
+	*/
+
+	if db.Spec.TLS != nil {
+		addTLSMandatorySpecs(db.Spec.TLS, defaultConfig)
+	}
+}
+
+// addTLSMandatorySpecs will add the specs which are mandatory for the TLS in the case them not be applied
+func addTLSMandatorySpecs(tls *v1alpha1.DatabaseTLS, defaultConfig *config.DefaultDatabaseConfig) {
+	if tls.ValidityDays == 0 {
+		tls.ValidityDays = defaultConfig.TLSValidityDays
+	}
+
+	if tls.RenewBeforeDays == 0 {
+		tls.RenewBeforeDays = defaultConfig.TLSRenewBeforeDays
+	}
+
+	if tls.CertManager != nil && tls.CertManager.IssuerKind == "" {
+		tls.CertManager.IssuerKind = defaultConfig.CertManagerIssuerKind
+	}
+}
+
+// addPasswordRotationMandatorySpecs will add the specs which are mandatory for the password rotation in the case them
+// not be applied
+func addPasswordRotationMandatorySpecs(rotation *v1alpha1.DatabasePasswordRotation, defaultConfig *config.DefaultDatabaseConfig) {
+	if rotation.IntervalDays == 0 {
+		rotation.IntervalDays = defaultConfig.RotationIntervalDays
+	}
+
+	if rotation.GracePeriodMinutes == 0 {
+		rotation.GracePeriodMinutes = defaultConfig.RotationGracePeriodMinutes
+	}
+}
+
+// addWalArchivingMandatorySpecs will add the specs which are mandatory for the WAL archiving in the case them
+// not be applied
+func addWalArchivingMandatorySpecs(wal *v1alpha1.DatabaseWalArchiving, defaultConfig *config.DefaultDatabaseConfig) {
+	if wal.BackupCRName == "" {
+		wal.BackupCRName = defaultConfig.WalArchivingBackupCRName
+	}
+
+	if wal.ArchiveTimeout == 0 {
+		wal.ArchiveTimeout = defaultConfig.ArchiveTimeout
+	}
+
+	if wal.Image == "" {
+		wal.Image = defaultConfig.WalArchiverImage
+	}
+}
+
+// addReplicationMandatorySpecs will add the specs which are mandatory for the replication setup in the case them
+// not be applied
+func addReplicationMandatorySpecs(rep *v1alpha1.DatabaseReplication, defaultConfig *config.DefaultDatabaseConfig) {
+	if rep.ReplicationUser == "" {
+		rep.ReplicationUser = defaultConfig.ReplicationUser
+	}
+
+	if rep.ReplicationUserKeyEnvVar == "" {
+		rep.ReplicationUserKeyEnvVar = defaultConfig.ReplicationUserKeyEnvVar
+	}
+
+	if rep.ReplicationPasswordKeyEnvVar == "" {
+		rep.ReplicationPasswordKeyEnvVar = defaultConfig.ReplicationPasswordKeyEnvVar
+	}
+
+	if rep.PrimaryServiceKeyEnvVar == "" {
+		rep.PrimaryServiceKeyEnvVar = defaultConfig.PrimaryServiceKeyEnvVar
+	}
+
+	if rep.PrimaryCommand == "" {
+		rep.PrimaryCommand = defaultConfig.PrimaryCommand
+	}
+
+	if rep.StandbyCommand == "" {
+		rep.StandbyCommand = defaultConfig.StandbyCommand
 	}
 }
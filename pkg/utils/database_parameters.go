@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+// Contexts of the parameters which define how a change is applied by the database
+const (
+	ReloadParameter  = "reload"
+	RestartParameter = "restart"
+)
+
+// Kinds of the values of the parameters
+const (
+	integerValue = "integer"
+	realValue    = "real"
+	boolValue    = "bool"
+	memoryValue  = "memory"
+	timeValue    = "time"
+	enumValue    = "enum"
+	stringValue  = "string"
+)
+
+var (
+	memoryValueRegex = regexp.MustCompile(`^-?[0-9]+\s*(B|kB|MB|GB|TB)?$`)
+	timeValueRegex   = regexp.MustCompile(`^-?[0-9]+\s*(us|ms|s|min|h|d)?$`)
+	boolValues       = []string{"on", "off", "true", "false", "yes", "no", "1", "0"}
+)
+
+// parameterDefinition defines how a parameter of the postgresql.conf is validated and applied
+type parameterDefinition struct {
+	context string
+	kind    string
+	// values allowed when the kind is enum
+	values []string
+}
+
+// knownParameters are the parameters of the postgresql.conf which can be informed in the spec.parameters
+// NOTE: The context is the one of the pg_settings. The parameters with the context postmaster require the restart.
+var knownParameters = map[string]parameterDefinition{
+	// Connections and memory
+	"max_connections":                 {context: RestartParameter, kind: integerValue},
+	"superuser_reserved_connections":  {context: RestartParameter, kind: integerValue},
+	"shared_buffers":                  {context: RestartParameter, kind: memoryValue},
+	"huge_pages":                      {context: RestartParameter, kind: enumValue, values: []string{"on", "off", "try"}},
+	"max_prepared_transactions":       {context: RestartParameter, kind: integerValue},
+	"max_locks_per_transaction":       {context: RestartParameter, kind: integerValue},
+	"max_worker_processes":            {context: RestartParameter, kind: integerValue},
+	"shared_preload_libraries":        {context: RestartParameter, kind: stringValue},
+	"track_activity_query_size":       {context: RestartParameter, kind: memoryValue},
+	"autovacuum_max_workers":          {context: RestartParameter, kind: integerValue},
+	"max_wal_senders":                 {context: RestartParameter, kind: integerValue},
+	"max_replication_slots":           {context: RestartParameter, kind: integerValue},
+	"wal_buffers":                     {context: RestartParameter, kind: memoryValue},
+	"work_mem":                        {context: ReloadParameter, kind: memoryValue},
+	"maintenance_work_mem":            {context: ReloadParameter, kind: memoryValue},
+	"temp_buffers":                    {context: ReloadParameter, kind: memoryValue},
+	"effective_cache_size":            {context: ReloadParameter, kind: memoryValue},
+	"max_parallel_workers_per_gather": {context: ReloadParameter, kind: integerValue},
+	// Query planning
+	"random_page_cost":          {context: ReloadParameter, kind: realValue},
+	"seq_page_cost":             {context: ReloadParameter, kind: realValue},
+	"effective_io_concurrency":  {context: ReloadParameter, kind: integerValue},
+	"default_statistics_target": {context: ReloadParameter, kind: integerValue},
+	// WAL and checkpoints
+	"checkpoint_timeout":           {context: ReloadParameter, kind: timeValue},
+	"checkpoint_completion_target": {context: ReloadParameter, kind: realValue},
+	"max_wal_size":                 {context: ReloadParameter, kind: memoryValue},
+	"min_wal_size":                 {context: ReloadParameter, kind: memoryValue},
+	"wal_compression":              {context: ReloadParameter, kind: boolValue},
+	"wal_keep_segments":            {context: ReloadParameter, kind: integerValue},
+	"synchronous_commit":           {context: ReloadParameter, kind: enumValue, values: []string{"on", "off", "local", "remote_write", "remote_apply"}},
+	"hot_standby_feedback":         {context: ReloadParameter, kind: boolValue},
+	"max_standby_streaming_delay":  {context: ReloadParameter, kind: timeValue},
+	"max_standby_archive_delay":    {context: ReloadParameter, kind: timeValue},
+	// Autovacuum
+	"autovacuum":                      {context: ReloadParameter, kind: boolValue},
+	"autovacuum_naptime":              {context: ReloadParameter, kind: timeValue},
+	"autovacuum_vacuum_scale_factor":  {context: ReloadParameter, kind: realValue},
+	"autovacuum_analyze_scale_factor": {context: ReloadParameter, kind: realValue},
+	"autovacuum_vacuum_cost_limit":    {context: ReloadParameter, kind: integerValue},
+	// Logging
+	"log_min_duration_statement":  {context: ReloadParameter, kind: timeValue},
+	"log_autovacuum_min_duration": {context: ReloadParameter, kind: timeValue},
+	"log_statement":               {context: ReloadParameter, kind: enumValue, values: []string{"none", "ddl", "mod", "all"}},
+	"log_connections":             {context: ReloadParameter, kind: boolValue},
+	"log_disconnections":          {context: ReloadParameter, kind: boolValue},
+	"log_lock_waits":              {context: ReloadParameter, kind: boolValue},
+	"log_checkpoints":             {context: ReloadParameter, kind: boolValue},
+	"log_temp_files":              {context: ReloadParameter, kind: memoryValue},
+	// Authentication
+	"password_encryption": {context: ReloadParameter, kind: enumValue, values: []string{"md5", "scram-sha-256"}},
+	// Client connection defaults
+	"statement_timeout":                   {context: ReloadParameter, kind: timeValue},
+	"lock_timeout":                        {context: ReloadParameter, kind: timeValue},
+	"idle_in_transaction_session_timeout": {context: ReloadParameter, kind: timeValue},
+	"default_transaction_isolation":       {context: ReloadParameter, kind: enumValue, values: []string{"serializable", "repeatable read", "read committed", "read uncommitted"}},
+	"timezone":                            {context: ReloadParameter, kind: stringValue},
+}
+
+// managedParameters are set by the operator or the image so they cannot be informed in the spec.parameters
+var managedParameters = []string{
+	"listen_addresses", "port", "data_directory", "config_file", "hba_file", "ident_file", "external_pid_file",
+	"wal_level", "hot_standby", "archive_mode", "archive_command", "archive_timeout",
+	"include", "include_dir", "include_if_exists",
+	"ssl", "ssl_cert_file", "ssl_key_file", "ssl_ca_file",
+}
+
+// ValidateParameters returns error when some parameter of the spec is not supported or its value is invalid
+func ValidateParameters(db *v1alpha1.Database) error {
+	for _, name := range getSortedParameterNames(db.Spec.Parameters) {
+		value := db.Spec.Parameters[name]
+		for _, managed := range managedParameters {
+			if name == managed {
+				return fmt.Errorf("Error: The parameter (%v) is managed by the operator and cannot be informed in the spec.parameters.", name)
+			}
+		}
+		def, found := knownParameters[name]
+		if !found {
+			return fmt.Errorf("Error: The parameter (%v) is not supported by the spec.parameters.", name)
+		}
+		if !isParameterValueValid(def, value) {
+			return fmt.Errorf("Error: The value (%v) of the parameter (%v) is invalid. Expected %v.", value, name, getParameterValueHint(def))
+		}
+	}
+	return nil
+}
+
+// GetParameterContext returns if the change of the parameter is applied by reload or restart
+// NOTE: The unknown parameters are considered restart since they cannot be informed in the spec.parameters
+func GetParameterContext(name string) string {
+	if def, found := knownParameters[name]; found {
+		return def.context
+	}
+	return RestartParameter
+}
+
+// GetParametersConfigMapName returns the name of the ConfigMap with the parameters of the Database
+func GetParametersConfigMapName(db *v1alpha1.Database) string {
+	return db.Name + ParametersSuffix
+}
+
+// BuildParametersConf returns the content of the file included in the postgresql.conf with the parameters informed
+// sorted by the name. The single quotes of the values are escaped.
+func BuildParametersConf(parameters map[string]string) string {
+	var b strings.Builder
+	b.WriteString("# Generated by the postgresql-operator from the spec.parameters of the Database\n")
+	for _, name := range getSortedParameterNames(parameters) {
+		fmt.Fprintf(&b, "%v = '%v'\n", name, strings.Replace(parameters[name], "'", "''", -1))
+	}
+	return b.String()
+}
+
+// ParseParametersConf returns the parameters of the content built by the BuildParametersConf
+func ParseParametersConf(conf string) map[string]string {
+	parameters := map[string]string{}
+	for _, line := range strings.Split(conf, "\n") {
+		parts := strings.SplitN(line, " = ", 2)
+		if strings.HasPrefix(line, "#") || len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSuffix(strings.TrimPrefix(parts[1], "'"), "'")
+		parameters[parts[0]] = strings.Replace(value, "''", "'", -1)
+	}
+	return parameters
+}
+
+// GetChangedParameters returns the names of the parameters added, changed or removed which are applied by reload and
+// the ones which require the restart
+func GetChangedParameters(current, desired map[string]string) ([]string, []string) {
+	var reload, restart []string
+	names := getSortedParameterNames(desired)
+	for name := range current {
+		if _, found := desired[name]; !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value, found := current[name]; found && value == desired[name] {
+			continue
+		}
+		if GetParameterContext(name) == RestartParameter {
+			restart = append(restart, name)
+		} else {
+			reload = append(reload, name)
+		}
+	}
+	return reload, restart
+}
+
+// GetRestartParametersHash returns the hash of the parameters which require the restart. It is added to the pod
+// template so the pods are only restarted when some of them changes
+func GetRestartParametersHash(db *v1alpha1.Database) string {
+	restart := map[string]string{}
+	for name, value := range db.Spec.Parameters {
+		if GetParameterContext(name) == RestartParameter {
+			restart[name] = value
+		}
+	}
+	return GetHash(restart)
+}
+
+// HasParameters returns true when the spec.parameters is informed
+func HasParameters(db *v1alpha1.Database) bool {
+	return len(db.Spec.Parameters) > 0
+}
+
+func getSortedParameterNames(parameters map[string]string) []string {
+	var names []string
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isParameterValueValid(def parameterDefinition, value string) bool {
+	if strings.ContainsAny(value, "\n\r") {
+		return false
+	}
+	switch def.kind {
+	case integerValue:
+		_, err := strconv.Atoi(value)
+		return err == nil
+	case realValue:
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	case boolValue:
+		return isValueAllowed(boolValues, value)
+	case memoryValue:
+		return memoryValueRegex.MatchString(value)
+	case timeValue:
+		return timeValueRegex.MatchString(value)
+	case enumValue:
+		return isValueAllowed(def.values, value)
+	}
+	return true
+}
+
+func isValueAllowed(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func getParameterValueHint(def parameterDefinition) string {
+	switch def.kind {
+	case memoryValue:
+		return "a number with the unit B, kB, MB, GB or TB. E.g. 8MB"
+	case timeValue:
+		return "a number with the unit us, ms, s, min, h or d. E.g. 30s"
+	case boolValue:
+		return "on or off"
+	case enumValue:
+		return "one of " + strings.Join(def.values, ", ")
+	}
+	return "a value of the kind " + def.kind
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+// IsReplicationEnabled returns true when the Database should run as one primary and hot standbys
+// NOTE: It is kept enabled while a standby promoted by a failover is the primary since the single instance would run
+// with the stale data of the Deployment of the Database
+func IsReplicationEnabled(db *v1alpha1.Database) bool {
+	return db.Spec.Replication != nil && (db.Spec.Replication.Enabled || IsStandbyPrimary(db))
+}
+
+// IsStandbyPrimary returns true when the Deployment of a standby was promoted by a failover and is the current primary
+func IsStandbyPrimary(db *v1alpha1.Database) bool {
+	return !IsStatefulSet(db) && GetStandbyIndex(db, db.Status.CurrentPrimary) >= 0
+}
+
+// ValidateReplication returns error when the replication is disabled while a standby promoted by a failover is the
+// primary
+func ValidateReplication(db *v1alpha1.Database) error {
+	if IsStandbyPrimary(db) && (db.Spec.Replication == nil || !db.Spec.Replication.Enabled) {
+		return fmt.Errorf("Error: The replication cannot be disabled while the standby (%v) promoted by a failover is the primary. It is kept enabled.",
+			db.Status.CurrentPrimary)
+	}
+	return nil
+}
+
+// GetStandbySize returns the quantity of standbys which should exist for the Database
+// NOTE: The spec.size is the total of instances (primary + standbys)
+func GetStandbySize(db *v1alpha1.Database) int {
+	if !IsReplicationEnabled(db) || db.Spec.Size <= 1 {
+		return 0
+	}
+	return int(db.Spec.Size) - 1
+}
+
+// GetStandbyName returns the name used for the Deployment and PersistentVolumeClaim of the standby
+func GetStandbyName(db *v1alpha1.Database, index int) string {
+	return db.Name + StandbySuffix + strconv.Itoa(index)
+}
+
+// GetStandbyIndex returns the index of the standby by its name or -1 when the name is not from a standby of the Database
+func GetStandbyIndex(db *v1alpha1.Database, name string) int {
+	prefix := db.Name + StandbySuffix
+	if !strings.HasPrefix(name, prefix) {
+		return -1
+	}
+	index, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+	if err != nil {
+		return -1
+	}
+	return index
+}
+
+// GetReadOnlyServiceName returns the name of the Service which balances the connections between the standbys
+func GetReadOnlyServiceName(db *v1alpha1.Database) string {
+	return db.Name + ReadOnlyServiceSuffix
+}
+
+// ValidateSize returns error when the size informed cannot be respected with the current setup
+func ValidateSize(db *v1alpha1.Database) error {
+	if db.Spec.Size > 1 && !IsReplicationEnabled(db) {
+		return fmt.Errorf("Error: Size (%v) greater than 1 requires the replication enabled. Only 1 instance is running.", db.Spec.Size)
+	}
+	return nil
+}
+
+// GetPrimaryMember returns the name of the member (Deployment or pod of the StatefulSet) which should run as primary
+// NOTE: It is the one stored in the status after a failover or the default one (the Deployment with the name of the
+// Database or the first pod of the StatefulSet)
+func GetPrimaryMember(db *v1alpha1.Database) string {
+	current := db.Status.CurrentPrimary
+	if IsStatefulSet(db) {
+		if current != "" && GetStatefulSetOrdinal(db, current) >= 0 {
+			return current
+		}
+		return GetStatefulSetPodName(db, 0)
+	}
+	if current != "" && GetStandbyIndex(db, current) >= 0 {
+		return current
+	}
+	return db.Name
+}
+
+// GetMemberRole returns the role (primary or standby) of the member (Deployment or pod of the StatefulSet)
+func GetMemberRole(db *v1alpha1.Database, member string) string {
+	if member == GetPrimaryMember(db) {
+		return PrimaryRole
+	}
+	return StandbyRole
+}
+
+// ParseLSN returns the numeric value of the WAL location (E.g. 0/3000060) in order to allow compare them
+func ParseLSN(lsn string) (uint64, error) {
+	parts := strings.Split(strings.TrimSpace(lsn), "/")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("Error: Invalid WAL location (%v).", lsn)
+	}
+	high, err := strconv.ParseUint(parts[0], 16, 32)
+	if err != nil {
+		return 0, err
+	}
+	low, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return 0, err
+	}
+	return high<<32 | low, nil
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+const (
+	// DefaultDatabaseOwner is the owner of the databases of the spec which do not inform it
+	DefaultDatabaseOwner = "postgres"
+	// RolePasswordLength is the length of the passwords generated for the roles
+	RolePasswordLength = 24
+	passwordChars      = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+var (
+	// identifierRegex only allows the identifiers which do not require to be escaped
+	identifierRegex    = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+	databasePrivileges = []string{"CONNECT", "CREATE", "TEMPORARY", "ALL"}
+	reservedDatabases  = []string{"postgres", "template0", "template1"}
+)
+
+// ValidateRoles returns error when some role or database of the spec cannot be managed by the operator
+// NOTE: The roles created by the image (E.g. the databaseUser) cannot be managed since their password is in the env vars
+func ValidateRoles(db *v1alpha1.Database) error {
+	roles := map[string]bool{}
+	for _, role := range db.Spec.Roles {
+		if err := validateIdentifier("role", role.Name); err != nil {
+			return err
+		}
+		if role.Name == DefaultDatabaseOwner || strings.HasPrefix(role.Name, "pg_") || role.Name == db.Spec.DatabaseUser ||
+			(db.Spec.Replication != nil && role.Name == db.Spec.Replication.ReplicationUser) {
+			return fmt.Errorf("Error: The role (%v) is reserved and cannot be managed by the spec.roles.", role.Name)
+		}
+		if roles[role.Name] {
+			return fmt.Errorf("Error: The role (%v) is duplicated in the spec.roles.", role.Name)
+		}
+		roles[role.Name] = true
+		for _, member := range role.InRoles {
+			if err := validateIdentifier("role", member); err != nil {
+				return err
+			}
+		}
+		if role.ConnectionLimit != nil && *role.ConnectionLimit < -1 {
+			return fmt.Errorf("Error: The connectionLimit (%v) of the role (%v) is invalid. Use -1 for no limit.", *role.ConnectionLimit, role.Name)
+		}
+	}
+
+	databases := map[string]bool{}
+	for _, database := range db.Spec.Databases {
+		if err := validateIdentifier("database", database.Name); err != nil {
+			return err
+		}
+		if isValueAllowed(reservedDatabases, database.Name) {
+			return fmt.Errorf("Error: The database (%v) is reserved and cannot be managed by the spec.databases.", database.Name)
+		}
+		if databases[database.Name] {
+			return fmt.Errorf("Error: The database (%v) is duplicated in the spec.databases.", database.Name)
+		}
+		databases[database.Name] = true
+		if database.Owner != "" {
+			if err := validateIdentifier("role", database.Owner); err != nil {
+				return err
+			}
+		}
+		for _, grant := range database.Grants {
+			if err := validateIdentifier("role", grant.Role); err != nil {
+				return err
+			}
+			if len(grant.Privileges) == 0 {
+				return fmt.Errorf("Error: The grant of the role (%v) on the database (%v) has no privileges.", grant.Role, database.Name)
+			}
+			for _, privilege := range grant.Privileges {
+				if !isValueAllowed(databasePrivileges, privilege) {
+					return fmt.Errorf("Error: The privilege (%v) on the database (%v) is invalid. Expected one of %v.",
+						privilege, database.Name, strings.Join(databasePrivileges, ", "))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// IsRoleLogin returns true when the role can log in so it has a Secret with its credentials
+func IsRoleLogin(role v1alpha1.DatabaseRole) bool {
+	return role.Login == nil || *role.Login
+}
+
+// GetRoleSecretName returns the name of the Secret with the credentials of the role
+func GetRoleSecretName(db *v1alpha1.Database, role string) string {
+	return db.Name + RoleSecretSuffix + strings.Replace(role, "_", "-", -1)
+}
+
+// GetDatabaseOwner returns the owner of the database of the spec
+func GetDatabaseOwner(database v1alpha1.LogicalDatabase) string {
+	if database.Owner == "" {
+		return DefaultDatabaseOwner
+	}
+	return database.Owner
+}
+
+// GeneratePassword returns a random password with letters and digits
+func GeneratePassword(length int) (string, error) {
+	max := big.NewInt(int64(len(passwordChars)))
+	password := make([]byte, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		password[i] = passwordChars[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// BuildRoleSQL returns the statements which create the role when it does not exist and set its attributes and password
+// NOTE: The password is only informed when the role can log in, and it is not changed when it is empty
+func BuildRoleSQL(role v1alpha1.DatabaseRole, password string) string {
+	attributes := []string{"NOLOGIN", "NOCREATEDB", "NOCREATEROLE"}
+	if IsRoleLogin(role) {
+		attributes[0] = "LOGIN"
+	}
+	if role.CreateDB {
+		attributes[1] = "CREATEDB"
+	}
+	if role.CreateRole {
+		attributes[2] = "CREATEROLE"
+	}
+	limit := int32(-1)
+	if role.ConnectionLimit != nil {
+		limit = *role.ConnectionLimit
+	}
+	attributes = append(attributes, fmt.Sprintf("CONNECTION LIMIT %v", limit))
+	if !IsRoleLogin(role) {
+		attributes = append(attributes, "PASSWORD NULL")
+	} else if password != "" {
+		attributes = append(attributes, fmt.Sprintf("PASSWORD '%v'", strings.Replace(password, "'", "''", -1)))
+	}
+
+	return fmt.Sprintf(`DO $$ BEGIN IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = '%v') THEN CREATE ROLE "%v"; END IF; END $$; `+
+		`ALTER ROLE "%v" WITH %v;`, role.Name, role.Name, role.Name, strings.Join(attributes, " "))
+}
+
+// BuildRoleMembershipSQL returns the statements which grant the roles informed in the inRoles to the role
+// NOTE: The memberships removed from the spec are not revoked
+func BuildRoleMembershipSQL(role v1alpha1.DatabaseRole) string {
+	var statements []string
+	for _, member := range role.InRoles {
+		statements = append(statements, fmt.Sprintf(`GRANT "%v" TO "%v";`, member, role.Name))
+	}
+	return strings.Join(statements, " ")
+}
+
+// BuildDatabaseExistsSQL returns the query which returns 1 when the database exists
+func BuildDatabaseExistsSQL(database v1alpha1.LogicalDatabase) string {
+	return fmt.Sprintf("SELECT 1 FROM pg_database WHERE datname = '%v'", database.Name)
+}
+
+// BuildCreateDatabaseSQL returns the statement which creates the database
+// NOTE: It cannot run in a transaction so it is not sent with other statements
+func BuildCreateDatabaseSQL(database v1alpha1.LogicalDatabase) string {
+	return fmt.Sprintf(`CREATE DATABASE "%v" OWNER "%v"`, database.Name, GetDatabaseOwner(database))
+}
+
+// BuildDatabaseSQL returns the statements which set the owner and grant the privileges of the database
+// NOTE: The privileges removed from the spec are not revoked
+func BuildDatabaseSQL(database v1alpha1.LogicalDatabase) string {
+	statements := []string{fmt.Sprintf(`ALTER DATABASE "%v" OWNER TO "%v";`, database.Name, GetDatabaseOwner(database))}
+	for _, grant := range database.Grants {
+		statements = append(statements, fmt.Sprintf(`GRANT %v ON DATABASE "%v" TO "%v";`,
+			strings.ToUpper(strings.Join(grant.Privileges, ", ")), database.Name, grant.Role))
+	}
+	return strings.Join(statements, " ")
+}
+
+func validateIdentifier(kind, name string) error {
+	if !identifierRegex.MatchString(name) {
+		return fmt.Errorf("Error: The %v name (%v) is invalid. Only lowercase letters, digits and underscores are allowed.", kind, name)
+	}
+	return nil
+}
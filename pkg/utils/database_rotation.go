@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+const (
+	// RotatedAtAnnotation is the annotation of the Secrets with the time which their password was rotated
+	RotatedAtAnnotation = "postgresql.dev4devs.com/rotated-at"
+	// PreviousRoleAnnotation is the annotation of the Secrets with the role which can still log in with the previous
+	// password until the end of the grace period
+	PreviousRoleAnnotation = "postgresql.dev4devs.com/previous-role"
+	// AlternateRoleSuffix is the suffix of the login role which alternates with the role in the rotations
+	AlternateRoleSuffix = "_alt"
+	// maxRoleLength is the maximum length of the names of the roles in the database server
+	maxRoleLength = 63
+)
+
+// IsPasswordRotationEnabled returns true when the passwords generated by the operator should be rotated
+func IsPasswordRotationEnabled(db *v1alpha1.Database) bool {
+	return db.Spec.PasswordRotation != nil && db.Spec.PasswordRotation.Enabled
+}
+
+// ValidatePasswordRotation returns error when the interval or the grace period of the password rotation is invalid,
+// or when the alternate login role of some role rotated cannot be created
+func ValidatePasswordRotation(db *v1alpha1.Database) error {
+	if !IsPasswordRotationEnabled(db) {
+		return nil
+	}
+	if db.Spec.PasswordRotation.IntervalDays < 0 || db.Spec.PasswordRotation.GracePeriodMinutes < 0 {
+		return fmt.Errorf("Error: The intervalDays and gracePeriodMinutes of the passwordRotation cannot be negative.")
+	}
+	if GetPasswordRotationGracePeriod(db) >= GetPasswordRotationInterval(db) {
+		return fmt.Errorf("Error: The gracePeriodMinutes (%v) of the passwordRotation should be lower than its intervalDays (%v).",
+			db.Spec.PasswordRotation.GracePeriodMinutes, db.Spec.PasswordRotation.IntervalDays)
+	}
+
+	roles := []string{db.Spec.DatabaseUser}
+	for _, role := range db.Spec.Roles {
+		if IsRoleLogin(role) {
+			roles = append(roles, role.Name)
+		}
+	}
+	for _, role := range roles {
+		alternate := GetAlternateRole(role)
+		if len(alternate) > maxRoleLength {
+			return fmt.Errorf("Error: The role (%v) cannot be rotated since its alternate role (%v) would have more than %v characters.",
+				role, alternate, maxRoleLength)
+		}
+		for _, other := range db.Spec.Roles {
+			if other.Name == alternate {
+				return fmt.Errorf("Error: The role (%v) is reserved as the alternate role of (%v) by the passwordRotation.", alternate, role)
+			}
+		}
+	}
+	return nil
+}
+
+// GetPasswordRotationInterval returns the duration between the rotations of the passwords
+func GetPasswordRotationInterval(db *v1alpha1.Database) time.Duration {
+	return time.Duration(db.Spec.PasswordRotation.IntervalDays) * 24 * time.Hour
+}
+
+// GetPasswordRotationGracePeriod returns the duration after the rotation which the previous role can still log in
+func GetPasswordRotationGracePeriod(db *v1alpha1.Database) time.Duration {
+	return time.Duration(db.Spec.PasswordRotation.GracePeriodMinutes) * time.Minute
+}
+
+// GetCredentialsUserKey returns the key of the user in the Secret of the credentials
+func GetCredentialsUserKey(db *v1alpha1.Database) string {
+	return GetEnvVarKey(db.Spec.CredentialsSecretUserKey, db.Spec.DatabaseUserKeyEnvVar)
+}
+
+// GetCredentialsPasswordKey returns the key of the password in the Secret of the credentials
+func GetCredentialsPasswordKey(db *v1alpha1.Database) string {
+	return GetEnvVarKey(db.Spec.CredentialsSecretPasswordKey, db.Spec.DatabasePasswordKeyEnvVar)
+}
+
+// GetAlternateRole returns the login role which alternates with the role in the rotations of its password
+func GetAlternateRole(role string) string {
+	return role + AlternateRoleSuffix
+}
+
+// GetNextLoginRole returns the login role which receives the new password in the rotation, which is the one which is
+// not in use by the Secret
+func GetNextLoginRole(role, current string) string {
+	if current == role {
+		return GetAlternateRole(role)
+	}
+	return role
+}
+
+// BuildPasswordSQL returns the statement which changes the password of the role
+func BuildPasswordSQL(role, password string) string {
+	return fmt.Sprintf(`ALTER ROLE "%v" WITH PASSWORD '%v';`, strings.Replace(role, `"`, `""`, -1), strings.Replace(password, "'", "''", -1))
+}
+
+// BuildLoginRoleSQL returns the statements which set the password of the login role of the rotation
+// NOTE: The alternate role is created when it does not exist as a member of the role, and its sessions act as the
+// role, so the objects created by both are owned by the role.
+func BuildLoginRoleSQL(role, login, password string) string {
+	if login == role {
+		return BuildPasswordSQL(role, password)
+	}
+	return fmt.Sprintf(`DO $$ BEGIN IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = '%v') THEN CREATE ROLE "%v"; END IF; END $$; `+
+		`ALTER ROLE "%v" WITH LOGIN PASSWORD '%v'; GRANT "%v" TO "%v"; ALTER ROLE "%v" SET role = '%v';`,
+		login, login, login, strings.Replace(password, "'", "''", -1), role, login, login, role)
+}
+
+// BuildExpiredPasswordSQL returns the statement which removes the password of the role so it can no longer log in
+func BuildExpiredPasswordSQL(role string) string {
+	return fmt.Sprintf(`ALTER ROLE "%v" WITH PASSWORD NULL;`, strings.Replace(role, `"`, `""`, -1))
+}
@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+// Origins of the server certificate
+const (
+	TLSSourceSecret      = "Secret"
+	TLSSourceCertManager = "CertManager"
+	TLSSourceSelfSigned  = "SelfSigned"
+)
+
+// Keys of the Secrets with the certificates
+const (
+	TLSCertKey = "tls.crt"
+	TLSKeyKey  = "tls.key"
+	TLSCAKey   = "ca.crt"
+	// TLSCAKeyKey is the key of the private key of the CA generated by the operator
+	TLSCAKeyKey = "ca.key"
+	// TLSPreviousCAKey is the key of the CA replaced by the operator, which is still trusted until it expires
+	TLSPreviousCAKey = "previous-ca.crt"
+)
+
+// CertManagerAPIVersion is the API version of the Certificate created when the certManager is informed
+const CertManagerAPIVersion = "cert-manager.io/v1"
+
+// TLSCAValidity is the validity of the CA generated by the operator
+const TLSCAValidity = 10 * 365 * 24 * time.Hour
+
+var certManagerIssuerKinds = []string{"Issuer", "ClusterIssuer"}
+
+// IsTLSEnabled returns true when the database server should accept the connections with TLS
+func IsTLSEnabled(db *v1alpha1.Database) bool {
+	return db.Spec.TLS != nil && db.Spec.TLS.Enabled
+}
+
+// IsTLSEnforced returns true when the database server should only accept the connections with TLS
+func IsTLSEnforced(db *v1alpha1.Database) bool {
+	return IsTLSEnabled(db) && db.Spec.TLS.EnforceSSL
+}
+
+// ValidateTLS returns error when the origin of the server certificate is ambiguous or the cert-manager issuer is invalid
+func ValidateTLS(db *v1alpha1.Database) error {
+	if !IsTLSEnabled(db) {
+		return nil
+	}
+	tls := db.Spec.TLS
+	if tls.SecretName != "" && tls.CertManager != nil {
+		return fmt.Errorf("Error: The secretName and certManager of the tls cannot be informed together.")
+	}
+	if tls.CertManager != nil {
+		if tls.CertManager.IssuerName == "" {
+			return fmt.Errorf("Error: The issuerName of the tls.certManager is required.")
+		}
+		if tls.CertManager.IssuerKind != "" && !isValueAllowed(certManagerIssuerKinds, tls.CertManager.IssuerKind) {
+			return fmt.Errorf("Error: The issuerKind (%v) of the tls.certManager is invalid. Expected one of %v.",
+				tls.CertManager.IssuerKind, strings.Join(certManagerIssuerKinds, ", "))
+		}
+	}
+	if tls.ValidityDays < 0 || tls.RenewBeforeDays < 0 || (tls.ValidityDays > 0 && tls.RenewBeforeDays >= tls.ValidityDays) {
+		return fmt.Errorf("Error: The renewBeforeDays (%v) of the tls should be lower than its validityDays (%v).",
+			tls.RenewBeforeDays, tls.ValidityDays)
+	}
+	return nil
+}
+
+// GetTLSSource returns the origin of the server certificate
+func GetTLSSource(db *v1alpha1.Database) string {
+	switch {
+	case db.Spec.TLS.SecretName != "":
+		return TLSSourceSecret
+	case db.Spec.TLS.CertManager != nil:
+		return TLSSourceCertManager
+	}
+	return TLSSourceSelfSigned
+}
+
+// GetTLSSecretName returns the name of the Secret with the server certificate
+func GetTLSSecretName(db *v1alpha1.Database) string {
+	if db.Spec.TLS != nil && db.Spec.TLS.SecretName != "" {
+		return db.Spec.TLS.SecretName
+	}
+	return db.Name + TLSSecretSuffix
+}
+
+// GetTLSCASecretName returns the name of the Secret with the CA generated by the operator
+func GetTLSCASecretName(db *v1alpha1.Database) string {
+	return db.Name + TLSCASecretSuffix
+}
+
+// GetTLSValidity returns the duration which the server certificates are valid
+func GetTLSValidity(db *v1alpha1.Database) time.Duration {
+	return time.Duration(db.Spec.TLS.ValidityDays) * 24 * time.Hour
+}
+
+// GetTLSRenewBefore returns the duration before the expiry which the server certificates are renewed
+func GetTLSRenewBefore(db *v1alpha1.Database) time.Duration {
+	return time.Duration(db.Spec.TLS.RenewBeforeDays) * 24 * time.Hour
+}
+
+// GetTLSCARenewBefore returns the duration before the expiry which the CA generated by the operator is renewed
+// NOTE: It is twice the one of the server certificates, so the new CA is in the bundle ca.crt during the renewBeforeDays
+// before the server certificate be signed by it
+func GetTLSCARenewBefore(db *v1alpha1.Database) time.Duration {
+	return 2 * GetTLSRenewBefore(db)
+}
+
+// BuildCABundle returns the CA with the previous one appended while it is not expired
+func BuildCABundle(ca, previous []byte) []byte {
+	cert, err := ParseCertificate(previous)
+	if err != nil || time.Now().After(cert.NotAfter) {
+		return ca
+	}
+	return append(append([]byte{}, ca...), previous...)
+}
+
+// GetTLSDNSNames returns the DNS names of the Services created for the Database which should be in the certificate
+func GetTLSDNSNames(db *v1alpha1.Database) []string {
+	services := []string{db.Name}
+	if IsReplicationEnabled(db) {
+		services = append(services, GetReadOnlyServiceName(db))
+	}
+	if IsStatefulSet(db) {
+		services = append(services, GetHeadlessServiceName(db))
+	}
+
+	var names []string
+	for _, svc := range services {
+		names = append(names, svc, svc+"."+db.Namespace, svc+"."+db.Namespace+".svc", svc+"."+db.Namespace+".svc.cluster.local")
+	}
+	if IsStatefulSet(db) {
+		// The pods of the StatefulSet have stable DNS records by the headless Service
+		names = append(names, "*."+GetHeadlessServiceName(db)+"."+db.Namespace+".svc")
+	}
+	return append(names, "localhost")
+}
+
+// BuildTLSArgs returns the arguments of the database server which enable the TLS with the certificate mounted
+func BuildTLSArgs() []string {
+	return []string{
+		"-c", "ssl=on",
+		"-c", "ssl_cert_file=" + TLSCertPath + "/" + TLSCertKey,
+		"-c", "ssl_key_file=" + TLSCertPath + "/" + TLSKeyKey,
+	}
+}
+
+// GenerateCA returns the certificate and the private key in the PEM format of a new CA
+func GenerateCA(commonName string, validity time.Duration) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	template, err := newCertificateTemplate(commonName, validity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encodeCertificate(der), encodePrivateKey(key), nil
+}
+
+// GenerateServerCertificate returns the certificate and the private key in the PEM format of a server certificate
+// with the DNS names informed signed by the CA
+func GenerateServerCertificate(caCertPEM, caKeyPEM []byte, commonName string, dnsNames []string, validity time.Duration) ([]byte, []byte, error) {
+	caCert, err := ParseCertificate(caCertPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(caKeyPEM)
+	if block == nil {
+		return nil, nil, fmt.Errorf("Error: Unable to decode the private key of the CA")
+	}
+	caKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	template, err := newCertificateTemplate(commonName, validity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.DNSNames = dnsNames
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encodeCertificate(der), encodePrivateKey(key), nil
+}
+
+// ParseCertificate returns the first certificate of the PEM informed
+func ParseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("Error: Unable to decode the certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// IsCertificateRenewalRequired returns true when the certificate expires before the duration informed or does not
+// have all DNS names
+func IsCertificateRenewalRequired(cert *x509.Certificate, renewBefore time.Duration, dnsNames []string) bool {
+	if time.Now().Add(renewBefore).After(cert.NotAfter) {
+		return true
+	}
+	current := append([]string{}, cert.DNSNames...)
+	desired := append([]string{}, dnsNames...)
+	sort.Strings(current)
+	sort.Strings(desired)
+	return strings.Join(current, ",") != strings.Join(desired, ",")
+}
+
+// IsCertificateSignedBy returns true when the certificate was signed by the CA informed
+func IsCertificateSignedBy(cert *x509.Certificate, caCertPEM []byte) bool {
+	ca, err := ParseCertificate(caCertPEM)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(cert.RawIssuer, ca.RawSubject) && cert.CheckSignatureFrom(ca) == nil
+}
+
+func newCertificateTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"postgresql-operator"}},
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+func encodeCertificate(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func encodePrivateKey(key *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
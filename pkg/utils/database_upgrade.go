@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+// Steps of the upgrade of the major version which run in Jobs
+const (
+	UpgradeDumpStep     = "dump"
+	UpgradeLoadStep     = "upgrade"
+	UpgradeRollbackStep = "rollback"
+)
+
+// GetPostgresMajorVersion returns the major version informed as a number which can be compared. E.g. 906 for 9.6 and
+// 1200 for 12
+// NOTE: Before the version 10 the major version has two numbers
+func GetPostgresMajorVersion(version string) (int, error) {
+	parts := strings.Split(version, ".")
+	major, err := strconv.Atoi(parts[0])
+	if err != nil || major < 1 {
+		return 0, fmt.Errorf("Error: PostgreSQL version (%v) is invalid. E.g. 9.6 or 12.", version)
+	}
+	if major >= 10 {
+		return major * 100, nil
+	}
+	if len(parts) < 2 {
+		return 0, fmt.Errorf("Error: PostgreSQL version (%v) is invalid. The versions before 10 have two numbers. E.g. 9.6.", version)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("Error: PostgreSQL version (%v) is invalid. E.g. 9.6 or 12.", version)
+	}
+	return major*100 + minor, nil
+}
+
+// ValidatePostgresVersion returns error when the spec.postgresVersion informed is not a valid major version
+func ValidatePostgresVersion(db *v1alpha1.Database) error {
+	if db.Spec.PostgresVersion == "" {
+		return nil
+	}
+	_, err := GetPostgresMajorVersion(db.Spec.PostgresVersion)
+	return err
+}
+
+// GetUpgradeJobName returns the name of the Job which runs the step of the current upgrade
+func GetUpgradeJobName(db *v1alpha1.Database, step string) string {
+	return fmt.Sprintf("%v%v%v-%v", db.Name, UpgradeJobSuffix, step, db.Status.Upgrade.Generation)
+}
+
+// GetUpgradeBackupTrigger returns the trigger of the on-demand backup taken before the current upgrade
+func GetUpgradeBackupTrigger(db *v1alpha1.Database) string {
+	return fmt.Sprintf("pre-upgrade-%v-%v", strings.Replace(db.Status.Upgrade.ToVersion, ".", "-", -1), db.Status.Upgrade.Generation)
+}
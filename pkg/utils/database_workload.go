@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// IsStatefulSet returns true when the Database should run in a StatefulSet instead of Deployments
+func IsStatefulSet(db *v1alpha1.Database) bool {
+	return db.Spec.WorkloadType == StatefulSetWorkload
+}
+
+// GetStatefulSetReplicas returns the quantity of pods of the StatefulSet
+// NOTE: Only with the replication enabled it is possible to run more than one instance
+func GetStatefulSetReplicas(db *v1alpha1.Database) int32 {
+	if !IsReplicationEnabled(db) || db.Spec.Size < 1 {
+		return 1
+	}
+	return db.Spec.Size
+}
+
+// GetHeadlessServiceName returns the name of the headless Service which gives the stable DNS names for the pods of the StatefulSet
+func GetHeadlessServiceName(db *v1alpha1.Database) string {
+	return db.Name + HeadlessServiceSuffix
+}
+
+// GetStatefulSetPodName returns the stable name of the pod of the StatefulSet with the ordinal informed
+func GetStatefulSetPodName(db *v1alpha1.Database, ordinal int) string {
+	return db.Name + "-" + strconv.Itoa(ordinal)
+}
+
+// GetStatefulSetOrdinal returns the ordinal of the pod by its name or -1 when the name is not from a pod of the StatefulSet
+func GetStatefulSetOrdinal(db *v1alpha1.Database, name string) int {
+	prefix := db.Name + "-"
+	if !strings.HasPrefix(name, prefix) {
+		return -1
+	}
+	ordinal, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+	if err != nil {
+		return -1
+	}
+	return ordinal
+}
+
+// GetStatefulSetPvcName returns the name of the PersistentVolumeClaim created by the volumeClaimTemplates for the pod with the ordinal informed
+// NOTE: The volumeClaimTemplates has the name of the Database
+func GetStatefulSetPvcName(db *v1alpha1.Database, ordinal int) string {
+	return db.Name + "-" + GetStatefulSetPodName(db, ordinal)
+}
+
+// GetDatabasePvcName returns the name of the PersistentVolumeClaim of the primary according to the workload type
+func GetDatabasePvcName(db *v1alpha1.Database) string {
+	if IsStatefulSet(db) {
+		return GetStatefulSetPvcName(db, 0)
+	}
+	return db.Name
+}
+
+// GetPrimaryPodName returns the name of the pod of the StatefulSet which should run as primary
+// NOTE: It is empty when the workload type is Deployment since its pods has not stable names
+func GetPrimaryPodName(db *v1alpha1.Database) string {
+	if !IsStatefulSet(db) {
+		return ""
+	}
+	return GetPrimaryMember(db)
+}
+
+// GetMigrationJobName returns the name of the Job which copies the data from the Deployment PVC to the StatefulSet PVC
+func GetMigrationJobName(db *v1alpha1.Database) string {
+	return db.Name + MigrationJobSuffix
+}
+
+// ValidateWorkloadType returns error when the workload type informed is not supported
+func ValidateWorkloadType(db *v1alpha1.Database) error {
+	if db.Spec.WorkloadType != "" && db.Spec.WorkloadType != DeploymentWorkload && db.Spec.WorkloadType != StatefulSetWorkload {
+		return fmt.Errorf("Error: Workload type (%v) is not supported. Options: %v or %v.", db.Spec.WorkloadType, DeploymentWorkload, StatefulSetWorkload)
+	}
+	return nil
+}
+
+// GetDatabaseQuantities returns the quantities of the resources informed in the spec by the name of their field
+func GetDatabaseQuantities(db *v1alpha1.Database) map[string]string {
+	return map[string]string{
+		"databaseMemoryLimit":    db.Spec.DatabaseMemoryLimit,
+		"databaseMemoryRequest":  db.Spec.DatabaseMemoryRequest,
+		"databaseStorageRequest": db.Spec.DatabaseStorageRequest,
+		"databaseCpu":            db.Spec.DatabaseCpu,
+		"databaseCpuLimit":       db.Spec.DatabaseCpuLimit,
+	}
+}
+
+// ValidateQuantities returns error when some quantity of the resources informed is invalid (E.g. 512MB instead of 512Mi)
+// NOTE: The workloads and PVCs cannot be built with an invalid quantity
+func ValidateQuantities(db *v1alpha1.Database) error {
+	quantities := GetDatabaseQuantities(db)
+	for _, name := range getSortedParameterNames(quantities) {
+		if quantities[name] == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(quantities[name]); err != nil {
+			return fmt.Errorf("Error: The %v (%v) is not a valid quantity. E.g. 512Mi, 1Gi or 500m.", name, quantities[name])
+		}
+	}
+	return nil
+}
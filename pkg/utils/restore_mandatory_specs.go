@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/config"
+)
+
+// AddRestoreMandatorySpecs will add the specs which are mandatory for Restore CR in the case them
+// not be applied
+// NOTE: The default values are the ones of the configuration of the operator for the namespace of the CR
+func AddRestoreMandatorySpecs(rst *v1alpha1.Restore) {
+	defaultConfig := config.GetRestoreConfig(rst.Namespace)
+	if rst.Spec.DatabaseCRName == "" {
+		rst.Spec.DatabaseCRName = defaultConfig.DatabaseCRName
+	}
+
+	if rst.Spec.Image == "" {
+		rst.Spec.Image = defaultConfig.Image
+	}
+
+	// The pre-existing secrets are searched in the same namespace where the CR is applied when it is not informed
+	if rst.Spec.AwsSecretName != "" && rst.Spec.AwsSecretNamespace == "" {
+		rst.Spec.AwsSecretNamespace = rst.Namespace
+	}
+
+	if rst.Spec.DecryptKeySecretName != "" && rst.Spec.DecryptKeySecretNamespace == "" {
+		rst.Spec.DecryptKeySecretNamespace = rst.Namespace
+	}
+}
+
+// AddRestoreSpecsFromBackup will add the specs which are not informed in the Restore CR with the values used
+// by the Backup CR which did the backup
+// NOTE: The secret used to encrypt the backup has only the public key, so the decryptKeySecretName is never defaulted
+func AddRestoreSpecsFromBackup(rst *v1alpha1.Restore, bkp *v1alpha1.Backup) {
+	if rst.Spec.ProductName == "" {
+		rst.Spec.ProductName = bkp.Spec.ProductName
+	}
+
+	if rst.Spec.Storage == nil && IsStorageInformed(bkp) {
+		rst.Spec.Storage = bkp.Spec.Storage.DeepCopy()
+	}
+
+	if rst.Spec.AwsSecretName == "" {
+		rst.Spec.AwsSecretName = GetAWSSecretName(bkp)
+		rst.Spec.AwsSecretNamespace = GetAwsSecretNamespace(bkp)
+	}
+}
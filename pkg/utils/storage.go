@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// gcsEndpointURL is the URL of the XML API of Google Cloud Storage which is compatible with S3 when HMAC keys are used
+const gcsEndpointURL = "https://storage.googleapis.com"
+
+// storageSecretKeys are the keys required in the secret with the credentials of each type of storage
+var storageSecretKeys = map[string][]string{
+	S3Storage:    {"AWS_S3_BUCKET_NAME", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"},
+	GCSStorage:   {"GCS_BUCKET_NAME", "GCS_ACCESS_KEY_ID", "GCS_SECRET_ACCESS_KEY"},
+	AzureStorage: {"AZURE_STORAGE_ACCOUNT", "AZURE_STORAGE_CONTAINER", "AZURE_STORAGE_SAS_TOKEN"},
+}
+
+// IsStorageInformed returns true when the Backup uploads the dumps with the storage spec instead of the backup image
+func IsStorageInformed(bkp *v1alpha1.Backup) bool {
+	return bkp.Spec.Storage != nil
+}
+
+// IsVolumeStorage returns true when the backups are written in a PVC
+func IsVolumeStorage(storage *v1alpha1.BackupStorage) bool {
+	return storage != nil && storage.Type == VolumeStorage
+}
+
+// GetBackupStorage returns the storage of the Backup
+// NOTE: The AWS secret is used as s3 storage when it is not informed
+func GetBackupStorage(bkp *v1alpha1.Backup) *v1alpha1.BackupStorage {
+	if IsStorageInformed(bkp) {
+		return bkp.Spec.Storage
+	}
+	return &v1alpha1.BackupStorage{
+		Type:            S3Storage,
+		SecretName:      GetAWSSecretName(bkp),
+		SecretNamespace: GetAwsSecretNamespace(bkp),
+	}
+}
+
+// IsAwsSecretUsed returns true when the AWS secret of the Backup is the secret of its storage
+func IsAwsSecretUsed(bkp *v1alpha1.Backup) bool {
+	storage := GetBackupStorage(bkp)
+	return storage.Type == S3Storage && storage.SecretName == GetAWSSecretName(bkp) && storage.SecretNamespace == GetAwsSecretNamespace(bkp)
+}
+
+// GetRestoreStorage returns the storage where the Restore will find the backups
+// NOTE: The AWS secret is used as s3 storage when it is not informed
+func GetRestoreStorage(rst *v1alpha1.Restore) *v1alpha1.BackupStorage {
+	storage := &v1alpha1.BackupStorage{}
+	if rst.Spec.Storage != nil {
+		storage = rst.Spec.Storage.DeepCopy()
+	}
+	if storage.Type == "" {
+		storage.Type = config.GetBackupConfig(rst.Namespace).StorageType
+	}
+	if storage.Type == S3Storage && storage.SecretName == "" {
+		storage.SecretName = rst.Spec.AwsSecretName
+		storage.SecretNamespace = rst.Spec.AwsSecretNamespace
+	}
+	if storage.SecretNamespace == "" {
+		storage.SecretNamespace = rst.Namespace
+	}
+	return storage
+}
+
+// ValidateStorage returns error when the type of the storage is unknown or the data required by it is not informed
+func ValidateStorage(storage *v1alpha1.BackupStorage) error {
+	switch storage.Type {
+	case S3Storage, GCSStorage, AzureStorage:
+		if storage.SecretName == "" {
+			return fmt.Errorf("Error: The secret of the storage %v is not informed.", storage.Type)
+		}
+	case VolumeStorage:
+		if storage.Volume == nil || storage.Volume.ClaimName == "" {
+			return fmt.Errorf("Error: The claimName of the storage %v is not informed.", storage.Type)
+		}
+	default:
+		return fmt.Errorf("Error: Invalid storage type (%v). Options: %v, %v, %v or %v.", storage.Type, S3Storage, GCSStorage, AzureStorage, VolumeStorage)
+	}
+	if storage.Compression != "" && storage.Compression != GzipCompression && storage.Compression != NoneCompression {
+		return fmt.Errorf("Error: Invalid compression (%v) of the storage. Options: %v or %v.", storage.Compression, GzipCompression, NoneCompression)
+	}
+	return nil
+}
+
+// GetDumpFileName returns the name of the file of the dump according to the compression of the storage
+// NOTE: Its extension is used in the key of the dump in order to allow the restore know if it is compressed
+func GetDumpFileName(storage *v1alpha1.BackupStorage) string {
+	if storage.Compression == NoneCompression {
+		return "dump.pg_dump"
+	}
+	return "dump.pg_dump.gz"
+}
+
+// ParseDumpsMessage returns the dumps listed after the first line of the termination message of the containers which
+// access the storage (E.g. "2020-07-06 00:00 backups/postgresql/postgres/2020/07/06/postgresql.example-00_00_00.pg_dump.gz")
+func ParseDumpsMessage(msg string) []v1alpha1.BackupDump {
+	var dumps []v1alpha1.BackupDump
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		dumps = append(dumps, v1alpha1.BackupDump{Key: fields[2], LastModified: fields[0] + " " + fields[1]})
+	}
+	return dumps
+}
+
+// BuildStorageData returns the data used by the scripts which access the storage
+// NOTE: The keys of the secret are validated according to the type of the storage and their values are copied with the
+// keys expected by the scripts. The secret is not used by the volume storage.
+func BuildStorageData(storage *v1alpha1.BackupStorage, secret *corev1.Secret) (map[string][]byte, error) {
+	if err := ValidateStorage(storage); err != nil {
+		return nil, err
+	}
+
+	data := map[string][]byte{"STORAGE_TYPE": []byte(storage.Type)}
+	if storage.Type == VolumeStorage {
+		data["STORAGE_PATH"] = []byte(StorageVolumePath)
+		return data, nil
+	}
+
+	for _, key := range storageSecretKeys[storage.Type] {
+		if len(secret.Data[key]) == 0 {
+			return nil, fmt.Errorf("Error: Storage Secret has not the key %v required by the storage %v. (name:%v,namespace:%v)",
+				key, storage.Type, secret.Name, secret.Namespace)
+		}
+	}
+
+	switch storage.Type {
+	case S3Storage:
+		data["STORAGE_BUCKET"] = secret.Data["AWS_S3_BUCKET_NAME"]
+		data["STORAGE_ACCESS_KEY_ID"] = secret.Data["AWS_ACCESS_KEY_ID"]
+		data["STORAGE_SECRET_ACCESS_KEY"] = secret.Data["AWS_SECRET_ACCESS_KEY"]
+		if ca, ok := secret.Data["AWS_CA_BUNDLE"]; ok {
+			if !strings.Contains(string(ca), "-----BEGIN CERTIFICATE-----") {
+				return nil, fmt.Errorf("Error: Storage Secret has the key AWS_CA_BUNDLE without a PEM certificate. (name:%v,namespace:%v)",
+					secret.Name, secret.Namespace)
+			}
+			data["STORAGE_CA_BUNDLE"] = ca
+		}
+		if err := addS3StorageData(storage.S3, data); err != nil {
+			return nil, err
+		}
+	case GCSStorage:
+		data["STORAGE_BUCKET"] = secret.Data["GCS_BUCKET_NAME"]
+		data["STORAGE_ACCESS_KEY_ID"] = secret.Data["GCS_ACCESS_KEY_ID"]
+		data["STORAGE_SECRET_ACCESS_KEY"] = secret.Data["GCS_SECRET_ACCESS_KEY"]
+		data["STORAGE_ENDPOINT_URL"] = []byte(gcsEndpointURL)
+	case AzureStorage:
+		data["STORAGE_ACCOUNT"] = secret.Data["AZURE_STORAGE_ACCOUNT"]
+		data["STORAGE_BUCKET"] = secret.Data["AZURE_STORAGE_CONTAINER"]
+		data["STORAGE_SAS_TOKEN"] = []byte(strings.TrimPrefix(string(secret.Data["AZURE_STORAGE_SAS_TOKEN"]), "?"))
+	}
+	return data, nil
+}
+
+// addS3StorageData adds in the data of the storage the options of the S3-compatible services
+func addS3StorageData(s3 *v1alpha1.BackupStorageS3, data map[string][]byte) error {
+	if s3 == nil {
+		return nil
+	}
+	if s3.EndpointURL != "" {
+		endpoint, err := url.Parse(s3.EndpointURL)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("Error: Invalid endpointURL (%v) of the storage %v. (E.g. https://minio.example.com:9000)", s3.EndpointURL, S3Storage)
+		}
+		data["STORAGE_ENDPOINT_URL"] = []byte(s3.EndpointURL)
+	}
+	if s3.Region != "" {
+		data["STORAGE_REGION"] = []byte(s3.Region)
+	}
+	if s3.ForcePathStyle {
+		data["STORAGE_PATH_STYLE"] = []byte("true")
+	}
+	return nil
+}
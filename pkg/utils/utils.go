@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
 	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
 	"github.com/go-logr/logr"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -11,6 +15,27 @@ func GetLabels(name string) map[string]string {
 	return map[string]string{"owner": "postgresqloperator", "cr": name}
 }
 
+// GetRoleLabels returns the labels used to select the Database pods by the role (primary or standby) in the replication
+func GetRoleLabels(name, role string) map[string]string {
+	ls := GetLabels(name)
+	ls[RoleLabelKey] = role
+	return ls
+}
+
+// GetMemberLabels returns the labels used to select the pods of one instance (primary or standby) of the Database
+func GetMemberLabels(name, member string) map[string]string {
+	ls := GetLabels(name)
+	ls[MemberLabelKey] = member
+	return ls
+}
+
+// GetPodLabels returns the labels of the pods of one instance of the Database with the role which it has in the replication
+func GetPodLabels(name, member, role string) map[string]string {
+	ls := GetMemberLabels(name, member)
+	ls[RoleLabelKey] = role
+	return ls
+}
+
 // GetAWSSecretName returns the name of the secret
 // NOTE: The user can just inform the name and namespace of the Secret which is already applied in the cluster OR
 // the data required for the operator be able to create one in the same namespace where the backup is applied
@@ -57,6 +82,12 @@ func IsEncryptionKeyOptionConfig(bkp *v1alpha1.Backup) bool {
 		(bkp.Spec.GpgTrustModel != "" && bkp.Spec.GpgEmail != "" && bkp.Spec.GpgPublicKey != "")
 }
 
+// IsBackupEncrypted returns true when the dumps of the Backup are encrypted with the GPG public key of its secret
+func IsBackupEncrypted(bkp *v1alpha1.Backup) bool {
+	return bkp.Spec.EncryptKeySecretName != "" ||
+		(bkp.Spec.GpgTrustModel != "" && bkp.Spec.GpgEmail != "" && bkp.Spec.GpgPublicKey != "")
+}
+
 // IsEncKeySetupByName returns true when it is setup to get an pre-existing secret applied in the cluster.
 // NOTE: The user can just inform the name of the Secret which is already applied in the cluster OR
 // the data required for the operator be able to create one
@@ -81,3 +112,12 @@ func GetLoggerByRequestAndController(request reconcile.Request, controllerName s
 	var log = logf.Log.WithName(controllerName)
 	return log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 }
+
+// GetHash returns the hash of the object informed. It allows check if the object built by the operator was changed
+// without compare the default values which are added by the cluster
+func GetHash(obj interface{}) string {
+	data, _ := json.Marshal(obj)
+	h := fnv.New32a()
+	h.Write(data)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
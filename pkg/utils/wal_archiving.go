@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+)
+
+// walSegmentNameLen is the length of the name of a WAL segment (TTTTTTTTXXXXXXXXYYYYYYYY) where T is the timeline,
+// X the log and Y the segment of the log
+const walSegmentNameLen = 24
+
+// IsWalArchivingEnabled returns true when the Database should ship its WAL segments to the AWS S3 bucket
+func IsWalArchivingEnabled(db *v1alpha1.Database) bool {
+	return db.Spec.WalArchiving != nil && db.Spec.WalArchiving.Enabled
+}
+
+// IsBackupWalArchivingEnabled returns true when the Backup should take the base backups for the point-in-time recovery
+func IsBackupWalArchivingEnabled(bkp *v1alpha1.Backup) bool {
+	return bkp.Spec.WalArchiving != nil && bkp.Spec.WalArchiving.Enabled
+}
+
+// IsStorageSecretRequired returns true when some container accesses the storage without the backup image
+// NOTE: It is the upload of the dumps when the storage is informed, the prune container of the retention, the base
+// backups and the sidecar which uploads the WAL segments
+func IsStorageSecretRequired(bkp *v1alpha1.Backup) bool {
+	return IsStorageInformed(bkp) || IsRetentionEnabled(bkp) || IsBackupWalArchivingEnabled(bkp)
+}
+
+// IsPointInTimeRestore returns true when the Restore should replay the WAL segments until a target instead of loading a dump
+func IsPointInTimeRestore(rst *v1alpha1.Restore) bool {
+	return rst.Spec.TargetTime != "" || rst.Spec.TargetLSN != ""
+}
+
+// ValidatePointInTimeTarget returns error when the target of the point-in-time recovery is invalid
+// NOTE: The target is written in the recovery.conf of the Job, so only a RFC3339 time or a valid WAL location are
+// accepted, and the database server does not allow more than one target.
+func ValidatePointInTimeTarget(rst *v1alpha1.Restore) error {
+	if rst.Spec.TargetTime != "" && rst.Spec.TargetLSN != "" {
+		return fmt.Errorf("Error: Only one of the targetTime and targetLSN can be informed.")
+	}
+	if err := ValidateTargetTime(rst.Spec.TargetTime); err != nil {
+		return err
+	}
+	return ValidateTargetLSN(rst.Spec.TargetLSN)
+}
+
+// ValidateTargetTime returns error when the target time informed is not in the RFC3339 format
+func ValidateTargetTime(target string) error {
+	if target == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, target); err != nil {
+		return fmt.Errorf("Error: The targetTime (%v) is invalid. Use the RFC3339 format (E.g. 2020-07-06T15:04:05Z).", target)
+	}
+	return nil
+}
+
+// ValidateTargetLSN returns error when the target LSN informed is not a WAL location
+func ValidateTargetLSN(target string) error {
+	if target == "" {
+		return nil
+	}
+	if _, err := ParseLSN(target); err != nil || strings.TrimSpace(target) != target {
+		return fmt.Errorf("Error: The targetLSN (%v) is invalid. Use a WAL location (E.g. 0/3000060).", target)
+	}
+	return nil
+}
+
+// GetWalArchivePath returns the directory of the AWS S3 bucket where the WAL segments and base backups of the Database are stored
+func GetWalArchivePath(namespace, databaseCRName string) string {
+	return WalArchiveDir + "/" + namespace + "/" + databaseCRName
+}
+
+// GetBaseBackupName returns the name of the CronJob and container which take the base backups
+func GetBaseBackupName(bkp *v1alpha1.Backup) string {
+	return bkp.Name + BaseBackupSuffix
+}
+
+// BuildWalArchivingArgs returns the arguments of the database server which enable the WAL archiving
+// NOTE: The archive_command hands over the segment to the sidecar by the spool directory in the data volume and waits
+// until it is uploaded. The segment is archived again by the database when the upload fails or takes more than 5 minutes.
+func BuildWalArchivingArgs(db *v1alpha1.Database) []string {
+	spool := WalSpoolPath
+	command := fmt.Sprintf("f=%v/%%f; mkdir -p -m 777 %v && cp %%p $f.part && chmod 666 $f.part && mv $f.part $f"+
+		" && for i in $(seq 300); do [ -f $f ] || break; sleep 1; done;"+
+		" if [ -f $f ] || [ -f $f.failed ]; then rm -f $f $f.failed; exit 1; fi", spool, spool)
+	return []string{
+		"-c", "wal_level=replica",
+		"-c", "archive_mode=on",
+		"-c", "archive_timeout=" + strconv.Itoa(int(db.Spec.WalArchiving.ArchiveTimeout)),
+		"-c", "archive_command=" + command,
+	}
+}
+
+// GetWalSegmentLag returns the quantity of WAL segments completed after the last archived and before the current one
+// NOTE: It returns 0 when none segment was archived yet or the last archived is a history file
+func GetWalSegmentLag(current, lastArchived, segmentSize string) (int64, error) {
+	if len(lastArchived) < walSegmentNameLen {
+		return 0, nil
+	}
+	size, err := parseWalSegmentSize(segmentSize)
+	if err != nil {
+		return 0, err
+	}
+	cur, err := parseWalSegmentNumber(current, size)
+	if err != nil {
+		return 0, err
+	}
+	last, err := parseWalSegmentNumber(lastArchived[:walSegmentNameLen], size)
+	if err != nil {
+		return 0, err
+	}
+	if lag := int64(cur) - int64(last) - 1; lag > 0 {
+		return lag, nil
+	}
+	return 0, nil
+}
+
+// parseWalSegmentNumber returns the sequential number of the WAL segment without the timeline
+func parseWalSegmentNumber(name string, segmentSize uint64) (uint64, error) {
+	if len(name) != walSegmentNameLen {
+		return 0, fmt.Errorf("Error: Invalid WAL segment name (%v).", name)
+	}
+	log, err := strconv.ParseUint(name[8:16], 16, 32)
+	if err != nil {
+		return 0, err
+	}
+	seg, err := strconv.ParseUint(name[16:24], 16, 32)
+	if err != nil {
+		return 0, err
+	}
+	return log*(0x100000000/segmentSize) + seg, nil
+}
+
+// parseWalSegmentSize returns the size in bytes of the WAL segments shown by the database (E.g. 16MB or 1GB)
+func parseWalSegmentSize(value string) (uint64, error) {
+	units := map[string]uint64{"kB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30}
+	for unit, factor := range units {
+		if strings.HasSuffix(value, unit) {
+			size, err := strconv.ParseUint(strings.TrimSuffix(value, unit), 10, 64)
+			if err != nil || size == 0 {
+				return 0, fmt.Errorf("Error: Invalid WAL segment size (%v).", value)
+			}
+			return size * factor, nil
+		}
+	}
+	return 0, fmt.Errorf("Error: Invalid WAL segment size (%v).", value)
+}
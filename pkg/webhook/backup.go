@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// BackupDefaulter adds the default values of the operator to the spec of the Backup CRs, so the persisted CRs have
+// the values applied by the operator
+type BackupDefaulter struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// Handle adds the default values to the Backup created or updated
+func (d *BackupDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	bkp := &v1alpha1.Backup{}
+	if err := d.decoder.Decode(req, bkp); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if bkp.Namespace == "" {
+		bkp.Namespace = req.Namespace
+	}
+	bkpWithSpecs := defaultBackup(bkp)
+
+	// The backup policy of the DatabaseClass of the Database is not replaced by the default values, so the changes of
+	// the class are applied in the Backup
+	class, err := service.FetchBackupDatabaseClass(bkp, d.client)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if class != nil {
+		utils.ResetBackupClassSpecs(bkpWithSpecs, bkp, class)
+	}
+	return defaultingResponse(req, bkpWithSpecs.Spec)
+}
+
+// defaultBackup returns a copy of the Backup with the default values in the fields of the spec not informed
+// NOTE: The storage is not defaulted when the name is generated by the API (generateName) since its Secret can have
+// the name of the Backup. In this case, it is added in the reconcile.
+func defaultBackup(bkp *v1alpha1.Backup) *v1alpha1.Backup {
+	bkpWithSpecs := bkp.DeepCopy()
+	utils.AddBackupMandatorySpecs(bkpWithSpecs)
+	if bkp.Name == "" {
+		bkpWithSpecs.Spec.Storage = bkp.Spec.Storage.DeepCopy()
+	}
+	return bkpWithSpecs
+}
+
+// BackupValidator rejects the Backup CRs which the operator is not able to manage
+type BackupValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// Handle validates the Backup created or updated
+func (v *BackupValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	bkp := &v1alpha1.Backup{}
+	if err := v.decoder.Decode(req, bkp); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	var old *v1alpha1.Backup
+	if req.Operation == admissionv1beta1.Update {
+		old = &v1alpha1.Backup{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+	// The changes of the metadata (E.g. finalizers and labels) are allowed even when the spec is invalid. The default
+	// values are added to both since the old Backup can be created before the defaulting webhook be enabled.
+	if old != nil && reflect.DeepEqual(defaultBackup(old).Spec, defaultBackup(bkp).Spec) {
+		return admission.Allowed("")
+	}
+	errs, err := v.validateBackup(bkp, old)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return validationResponse("Backup", bkp.Name, errs)
+}
+
+// validateBackup returns the errors of the fields of the spec and the immutable fields changed when the old Backup is
+// informed
+// NOTE: The fields which are not informed are validated with their default values
+func (v *BackupValidator) validateBackup(bkp, old *v1alpha1.Backup) (field.ErrorList, error) {
+	spec := field.NewPath("spec")
+	bkpWithSpecs := bkp.DeepCopy()
+	utils.AddBackupMandatorySpecs(bkpWithSpecs)
+
+	var errs field.ErrorList
+	if err := utils.ValidateCronSchedule(bkpWithSpecs.Spec.Schedule); err != nil {
+		errs = append(errs, invalid(spec.Child("schedule"), bkpWithSpecs.Spec.Schedule, err))
+	}
+	if bkpWithSpecs.Spec.WalArchiving != nil {
+		schedule := bkpWithSpecs.Spec.WalArchiving.BaseBackupSchedule
+		if err := utils.ValidateCronSchedule(schedule); err != nil {
+			errs = append(errs, invalid(spec.Child("walArchiving", "baseBackupSchedule"), schedule, err))
+		}
+	}
+
+	// The reference is only checked when the Backup is created in order to not block the updates when the Database
+	// was deleted
+	if old == nil {
+		if _, err := service.FetchDatabaseCR(bkpWithSpecs.Spec.DatabaseCRName, bkp.Namespace, v.client); err != nil {
+			if !errors.IsNotFound(err) {
+				return nil, err
+			}
+			errs = append(errs, field.NotFound(spec.Child("databaseCRName"), bkpWithSpecs.Spec.DatabaseCRName))
+		}
+	}
+
+	errs = append(errs, validateBackupEncryption(bkp)...)
+
+	if bkp.Spec.Storage != nil {
+		if err := utils.ValidateStorage(utils.GetBackupStorage(bkpWithSpecs)); err != nil {
+			errs = append(errs, invalid(spec.Child("storage"), bkp.Spec.Storage, err))
+		}
+	}
+
+	if old != nil {
+		oldWithSpecs := old.DeepCopy()
+		utils.AddBackupMandatorySpecs(oldWithSpecs)
+		if bkpWithSpecs.Spec.DatabaseCRName != oldWithSpecs.Spec.DatabaseCRName {
+			errs = append(errs, field.Forbidden(spec.Child("databaseCRName"), "is immutable since the runs and Secrets of the Backup belong to the Database"))
+		}
+	}
+	return errs, nil
+}
+
+// validateBackupEncryption returns the errors when the GPG data required to create the encryption Secret is incomplete
+// or the namespace of the Secret is informed without its name
+func validateBackupEncryption(bkp *v1alpha1.Backup) field.ErrorList {
+	spec := field.NewPath("spec")
+	var errs field.ErrorList
+	gpg := map[string]string{
+		"gpgPublicKey":  bkp.Spec.GpgPublicKey,
+		"gpgEmail":      bkp.Spec.GpgEmail,
+		"gpgTrustModel": bkp.Spec.GpgTrustModel,
+	}
+	if bkp.Spec.GpgPublicKey != "" || bkp.Spec.GpgEmail != "" || bkp.Spec.GpgTrustModel != "" {
+		for _, name := range []string{"gpgPublicKey", "gpgEmail", "gpgTrustModel"} {
+			if gpg[name] == "" {
+				errs = append(errs, field.Required(spec.Child(name), "the gpgPublicKey, gpgEmail and gpgTrustModel are required together"))
+			}
+		}
+	}
+	if bkp.Spec.EncryptKeySecretNamespace != "" && bkp.Spec.EncryptKeySecretName == "" {
+		errs = append(errs, field.Required(spec.Child("encryptKeySecretName"), "is required with the encryptKeySecretNamespace"))
+	}
+	return errs
+}
@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/service"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// DatabaseDefaulter adds the default values of the operator to the spec of the Database CRs, so the persisted CRs
+// have the values applied by the operator
+type DatabaseDefaulter struct {
+	decoder *admission.Decoder
+}
+
+// Handle adds the default values to the Database created or updated
+func (d *DatabaseDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	db := &v1alpha1.Database{}
+	if err := d.decoder.Decode(req, db); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if db.Namespace == "" {
+		db.Namespace = req.Namespace
+	}
+	return defaultingResponse(req, defaultDatabase(db).Spec)
+}
+
+// defaultDatabase returns a copy of the Database with the default values in the fields of the spec not informed
+// NOTE: The Secret of the generated password is not informed when the name is generated by the API (generateName)
+// since it is only known after the admission. In this case, it is added in the reconcile. The fields which can be
+// informed by the DatabaseClass are not defaulted when the className is informed.
+func defaultDatabase(db *v1alpha1.Database) *v1alpha1.Database {
+	dbWithSpecs := db.DeepCopy()
+	utils.AddDatabaseMandatorySpecs(dbWithSpecs)
+	utils.ResetDatabaseClassSpecs(dbWithSpecs, db)
+	if db.Name == "" {
+		dbWithSpecs.Spec.CredentialsSecretName = db.Spec.CredentialsSecretName
+	}
+	return dbWithSpecs
+}
+
+// DatabaseValidator rejects the Database CRs which the operator is not able to manage
+type DatabaseValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// Handle validates the Database created or updated
+func (v *DatabaseValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	db := &v1alpha1.Database{}
+	if err := v.decoder.Decode(req, db); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	var old *v1alpha1.Database
+	if req.Operation == admissionv1beta1.Update {
+		old = &v1alpha1.Database{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+	// The changes of the metadata (E.g. finalizers and labels) are allowed even when the spec is invalid. The default
+	// values are added to both since the old Database can be created before the defaulting webhook be enabled.
+	if old != nil && reflect.DeepEqual(defaultDatabase(old).Spec, defaultDatabase(db).Spec) {
+		return admission.Allowed("")
+	}
+	errs := validateDatabase(db, old)
+	classErrs, err := v.validateClass(db, old)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return validationResponse("Database", db.Name, append(errs, classErrs...))
+}
+
+// validateClass returns the error of the className when the DatabaseClass informed is not found
+// NOTE: It is only checked when the className is informed or changed in order to not block the updates when the class
+// was deleted
+func (v *DatabaseValidator) validateClass(db, old *v1alpha1.Database) (field.ErrorList, error) {
+	if db.Spec.ClassName == "" || (old != nil && old.Spec.ClassName == db.Spec.ClassName) {
+		return nil, nil
+	}
+	if _, err := service.FetchDatabaseClassCR(db.Spec.ClassName, v.client); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		return field.ErrorList{field.NotFound(field.NewPath("spec", "className"), db.Spec.ClassName)}, nil
+	}
+	return nil, nil
+}
+
+// validateDatabase returns the errors of the fields of the spec and the immutable fields changed when the old
+// Database is informed
+// NOTE: The fields which are not informed are validated with their default values
+func validateDatabase(db, old *v1alpha1.Database) field.ErrorList {
+	spec := field.NewPath("spec")
+	var errs field.ErrorList
+
+	quantities := utils.GetDatabaseQuantities(db)
+	for _, name := range []string{"databaseMemoryLimit", "databaseMemoryRequest", "databaseStorageRequest", "databaseCpu", "databaseCpuLimit"} {
+		if quantities[name] == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(quantities[name]); err != nil {
+			errs = append(errs, field.Invalid(spec.Child(name), quantities[name], "must be a quantity (E.g. 512Mi, 1Gi or 500m)"))
+		}
+	}
+
+	dbWithSpecs := db.DeepCopy()
+	utils.AddDatabaseMandatorySpecs(dbWithSpecs)
+
+	if err := utils.ValidateSize(db); err != nil {
+		errs = append(errs, invalid(spec.Child("size"), db.Spec.Size, err))
+	}
+	if err := utils.ValidateReplication(db); err != nil {
+		errs = append(errs, invalid(spec.Child("replication"), db.Spec.Replication, err))
+	}
+	if err := utils.ValidateWorkloadType(db); err != nil {
+		errs = append(errs, invalid(spec.Child("workloadType"), db.Spec.WorkloadType, err))
+	}
+	if err := utils.ValidatePostgresVersion(db); err != nil {
+		errs = append(errs, invalid(spec.Child("postgresVersion"), db.Spec.PostgresVersion, err))
+	}
+	if err := utils.ValidateParameters(db); err != nil {
+		errs = append(errs, invalid(spec.Child("parameters"), db.Spec.Parameters, err))
+	}
+	if err := utils.ValidateRoles(dbWithSpecs); err != nil {
+		errs = append(errs, invalid(spec.Child("roles"), db.Spec.Roles, err))
+	}
+	if err := utils.ValidatePasswordRotation(dbWithSpecs); err != nil {
+		errs = append(errs, invalid(spec.Child("passwordRotation"), db.Spec.PasswordRotation, err))
+	}
+	if err := utils.ValidateTLS(dbWithSpecs); err != nil {
+		errs = append(errs, invalid(spec.Child("tls"), db.Spec.TLS, err))
+	}
+	if err := utils.ValidateHba(db); err != nil {
+		errs = append(errs, invalid(spec.Child("hba"), db.Spec.Hba, err))
+	}
+	if err := utils.ValidateDeletionPolicy(db); err != nil {
+		errs = append(errs, invalid(spec.Child("deletionPolicy"), db.Spec.DeletionPolicy, err))
+	}
+
+	if old != nil && len(errs) == 0 {
+		errs = append(errs, validateDatabaseUpdate(dbWithSpecs, old)...)
+	}
+	return errs
+}
+
+// validateDatabaseUpdate returns the errors of the fields which cannot be changed after the Database is created
+func validateDatabaseUpdate(db, old *v1alpha1.Database) field.ErrorList {
+	spec := field.NewPath("spec")
+	old = old.DeepCopy()
+	utils.AddDatabaseMandatorySpecs(old)
+
+	var errs field.ErrorList
+	if db.Spec.DatabaseStorageClassName != old.Spec.DatabaseStorageClassName {
+		errs = append(errs, field.Forbidden(spec.Child("databaseStorageClassName"), "is immutable since the storage class of the PVCs cannot be changed"))
+	}
+	if db.Spec.DatabaseName != old.Spec.DatabaseName {
+		errs = append(errs, field.Forbidden(spec.Child("databaseName"), "is immutable since the database is only created when the data is initialized"))
+	}
+	if db.Spec.DatabaseUser != old.Spec.DatabaseUser {
+		errs = append(errs, field.Forbidden(spec.Child("databaseUser"), "is immutable since the user is only created when the data is initialized"))
+	}
+	desired, err := resource.ParseQuantity(db.Spec.DatabaseStorageRequest)
+	current, oldErr := resource.ParseQuantity(old.Spec.DatabaseStorageRequest)
+	if err == nil && oldErr == nil && desired.Cmp(current) < 0 {
+		errs = append(errs, field.Forbidden(spec.Child("databaseStorageRequest"), "cannot be decreased since the PVCs cannot be shrunk"))
+	}
+	return errs
+}
@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/utils"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// RestoreValidator rejects the Restore CRs which the operator is not able to manage
+type RestoreValidator struct {
+	decoder *admission.Decoder
+}
+
+// Handle validates the Restore created or updated
+func (v *RestoreValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	rst := &v1alpha1.Restore{}
+	if err := v.decoder.Decode(req, rst); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	// The changes of the metadata and status are allowed even when the spec is invalid
+	if req.Operation == admissionv1beta1.Update {
+		old := &v1alpha1.Restore{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if reflect.DeepEqual(old.Spec, rst.Spec) {
+			return admission.Allowed("")
+		}
+	}
+	return validationResponse("Restore", rst.Name, validateRestore(rst))
+}
+
+// validateRestore returns the errors of the target of the point-in-time recovery, which is written in the recovery.conf
+// of the Job
+func validateRestore(rst *v1alpha1.Restore) field.ErrorList {
+	spec := field.NewPath("spec")
+	var errs field.ErrorList
+	if rst.Spec.TargetTime != "" && rst.Spec.TargetLSN != "" {
+		errs = append(errs, field.Forbidden(spec.Child("targetLSN"), "cannot be informed with the targetTime"))
+	}
+	if err := utils.ValidateTargetTime(rst.Spec.TargetTime); err != nil {
+		errs = append(errs, invalid(spec.Child("targetTime"), rst.Spec.TargetTime, err))
+	}
+	if err := utils.ValidateTargetLSN(rst.Spec.TargetLSN); err != nil {
+		errs = append(errs, invalid(spec.Child("targetLSN"), rst.Spec.TargetLSN, err))
+	}
+	return errs
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Paths where the mutating and validating webhooks of the CRs are served
+const (
+	DatabaseMutatePath   = "/mutate-postgresql-dev4devs-com-v1alpha1-database"
+	DatabaseValidatePath = "/validate-postgresql-dev4devs-com-v1alpha1-database"
+	BackupMutatePath     = "/mutate-postgresql-dev4devs-com-v1alpha1-backup"
+	BackupValidatePath   = "/validate-postgresql-dev4devs-com-v1alpha1-backup"
+	RestoreValidatePath  = "/validate-postgresql-dev4devs-com-v1alpha1-restore"
+)
+
+// AddToManager registers the mutating and validating webhooks of the CRs in the webhook server of the Manager
+// NOTE: The server requires the certificate and key in the CertDir of the Manager
+func AddToManager(mgr manager.Manager) error {
+	decoder, err := admission.NewDecoder(mgr.GetScheme())
+	if err != nil {
+		return err
+	}
+	server := mgr.GetWebhookServer()
+	server.Register(DatabaseMutatePath, &admission.Webhook{Handler: &DatabaseDefaulter{decoder: decoder}})
+	server.Register(BackupMutatePath, &admission.Webhook{Handler: &BackupDefaulter{client: mgr.GetClient(), decoder: decoder}})
+	server.Register(DatabaseValidatePath, &admission.Webhook{Handler: &DatabaseValidator{client: mgr.GetClient(), decoder: decoder}})
+	server.Register(BackupValidatePath, &admission.Webhook{Handler: &BackupValidator{client: mgr.GetClient(), decoder: decoder}})
+	server.Register(RestoreValidatePath, &admission.Webhook{Handler: &RestoreValidator{decoder: decoder}})
+	return nil
+}
+
+// validationResponse returns the response which allows the CR or rejects it with the errors of its fields
+func validationResponse(kind, name string, errs field.ErrorList) admission.Response {
+	if len(errs) == 0 {
+		return admission.Allowed("")
+	}
+	err := apierrors.NewInvalid(schema.GroupKind{Group: "postgresql.dev4devs.com", Kind: kind}, name, errs)
+	return admission.Response{
+		AdmissionResponse: admissionv1beta1.AdmissionResponse{
+			Allowed: false,
+			Result:  &err.ErrStatus,
+		},
+	}
+}
+
+// invalid returns the error of the field with the message of the validations of the utils
+func invalid(path *field.Path, value interface{}, err error) *field.Error {
+	return field.Invalid(path, value, strings.TrimPrefix(err.Error(), "Error: "))
+}
+
+// defaultingResponse returns the response with the patch which replaces the spec of the CR by the one with the
+// default values
+// NOTE: Only the spec is patched, so the metadata and status of the request are kept as they are
+func defaultingResponse(req admission.Request, spec interface{}) admission.Response {
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(req.Object.Raw, &obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	obj["spec"] = spec
+	current, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, current)
+}
@@ -0,0 +1,466 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dev4devs-com/postgresql-operator/pkg/apis/postgresql/v1alpha1"
+	"github.com/dev4devs-com/postgresql-operator/pkg/config"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var (
+	dbInstance = v1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "postgresql-operator",
+		},
+	}
+
+	bkpInstance = v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "postgresql-operator",
+		},
+	}
+
+	rstInstance = v1alpha1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "restore",
+			Namespace: "postgresql-operator",
+		},
+	}
+
+	classInstance = v1alpha1.DatabaseClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "small",
+		},
+		Spec: v1alpha1.DatabaseClassSpec{
+			Image:  "centos/postgresql-10-centos7",
+			Backup: &v1alpha1.DatabaseClassBackup{Schedule: "@daily"},
+		},
+	}
+)
+
+//buildRequest returns the admission request of the object informed and of the old one when it is an update
+func buildRequest(t *testing.T, obj, old runtime.Object) admission.Request {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("marshal: (%v)", err)
+	}
+	req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+	if old != nil {
+		oldRaw, err := json.Marshal(old)
+		if err != nil {
+			t.Fatalf("marshal: (%v)", err)
+		}
+		req.Operation = admissionv1beta1.Update
+		req.OldObject = runtime.RawExtension{Raw: oldRaw}
+	}
+	return req
+}
+
+//buildDecoderAndScheme returns the decoder and the scheme with the CRs registered
+func buildDecoderAndScheme(t *testing.T) (*admission.Decoder, *runtime.Scheme) {
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.Database{}, &v1alpha1.Backup{}, &v1alpha1.Restore{}, &v1alpha1.DatabaseClass{})
+	decoder, err := admission.NewDecoder(s)
+	if err != nil {
+		t.Fatalf("decoder: (%v)", err)
+	}
+	return decoder, s
+}
+
+func TestDatabaseValidator_Handle(t *testing.T) {
+	tests := []struct {
+		name           string
+		spec           v1alpha1.DatabaseSpec
+		oldSpec        *v1alpha1.DatabaseSpec
+		currentPrimary string
+		objs           []runtime.Object
+		wantAllowed    bool
+		wantField      string
+	}{
+		{
+			name:        "should allow the Database with the default values",
+			wantAllowed: true,
+		},
+		{
+			name:      "should reject an invalid quantity",
+			spec:      v1alpha1.DatabaseSpec{DatabaseMemoryLimit: "512MB"},
+			wantField: "spec.databaseMemoryLimit",
+		},
+		{
+			name:      "should reject an unknown parameter",
+			spec:      v1alpha1.DatabaseSpec{Parameters: map[string]string{"unknown": "1"}},
+			wantField: "spec.parameters",
+		},
+		{
+			name:      "should reject an invalid hba rule",
+			spec:      v1alpha1.DatabaseSpec{Hba: []v1alpha1.DatabaseHbaRule{{Type: "host", Address: "10.0.0.0/33", Method: "md5"}}},
+			wantField: "spec.hba",
+		},
+		{
+			name:      "should reject an unknown deletion policy",
+			spec:      v1alpha1.DatabaseSpec{DeletionPolicy: "Orphan"},
+			wantField: "spec.deletionPolicy",
+		},
+		{
+			name:      "should reject the change of the storage class",
+			spec:      v1alpha1.DatabaseSpec{DatabaseStorageClassName: "fast"},
+			oldSpec:   &v1alpha1.DatabaseSpec{DatabaseStorageClassName: "standard"},
+			wantField: "spec.databaseStorageClassName",
+		},
+		{
+			name:      "should reject the storage decreased",
+			spec:      v1alpha1.DatabaseSpec{DatabaseStorageRequest: "1Gi"},
+			oldSpec:   &v1alpha1.DatabaseSpec{DatabaseStorageRequest: "2Gi"},
+			wantField: "spec.databaseStorageRequest",
+		},
+		{
+			name:           "should reject the replication disabled while a standby is the primary",
+			spec:           v1alpha1.DatabaseSpec{Replication: &v1alpha1.DatabaseReplication{Enabled: false}},
+			oldSpec:        &v1alpha1.DatabaseSpec{Size: 2, Replication: &v1alpha1.DatabaseReplication{Enabled: true}},
+			currentPrimary: "database-standby-0",
+			wantField:      "spec.replication",
+		},
+		{
+			name:           "should allow the replication disabled while the Database is the primary",
+			spec:           v1alpha1.DatabaseSpec{Replication: &v1alpha1.DatabaseReplication{Enabled: false}},
+			oldSpec:        &v1alpha1.DatabaseSpec{Size: 2, Replication: &v1alpha1.DatabaseReplication{Enabled: true}},
+			currentPrimary: "database",
+			wantAllowed:    true,
+		},
+		{
+			name:        "should allow the storage increased",
+			spec:        v1alpha1.DatabaseSpec{DatabaseStorageRequest: "2Gi"},
+			oldSpec:     &v1alpha1.DatabaseSpec{},
+			wantAllowed: true,
+		},
+		{
+			name:        "should allow the update without changes of an invalid spec",
+			spec:        v1alpha1.DatabaseSpec{DatabaseMemoryLimit: "512MB"},
+			oldSpec:     &v1alpha1.DatabaseSpec{DatabaseMemoryLimit: "512MB"},
+			wantAllowed: true,
+		},
+		{
+			name:        "should allow the update which only adds the default values to an invalid spec",
+			spec:        v1alpha1.DatabaseSpec{DatabaseMemoryLimit: "512MB", Image: "centos/postgresql-96-centos7"},
+			oldSpec:     &v1alpha1.DatabaseSpec{DatabaseMemoryLimit: "512MB"},
+			wantAllowed: true,
+		},
+		{
+			name:      "should reject the DatabaseClass not found",
+			spec:      v1alpha1.DatabaseSpec{ClassName: "small"},
+			wantField: "spec.className",
+		},
+		{
+			name:        "should allow the DatabaseClass found",
+			spec:        v1alpha1.DatabaseSpec{ClassName: "small"},
+			objs:        []runtime.Object{classInstance.DeepCopy()},
+			wantAllowed: true,
+		},
+		{
+			name:        "should allow the update of the Database which DatabaseClass was deleted",
+			spec:        v1alpha1.DatabaseSpec{ClassName: "small", DatabaseStorageRequest: "2Gi"},
+			oldSpec:     &v1alpha1.DatabaseSpec{ClassName: "small"},
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder, s := buildDecoderAndScheme(t)
+			v := &DatabaseValidator{client: fake.NewFakeClientWithScheme(s, tt.objs...), decoder: decoder}
+
+			db := dbInstance.DeepCopy()
+			db.Spec = tt.spec
+			db.Status.CurrentPrimary = tt.currentPrimary
+			var old runtime.Object
+			if tt.oldSpec != nil {
+				oldDB := dbInstance.DeepCopy()
+				oldDB.Spec = *tt.oldSpec
+				oldDB.Status.CurrentPrimary = tt.currentPrimary
+				old = oldDB
+			}
+
+			res := v.Handle(context.TODO(), buildRequest(t, db, old))
+			if res.Allowed != tt.wantAllowed {
+				t.Fatalf("Handle() allowed = %v, want %v (%v)", res.Allowed, tt.wantAllowed, res.Result)
+			}
+			if tt.wantField != "" && !strings.Contains(res.Result.Message, tt.wantField) {
+				t.Errorf("Handle() message = %v, when is expected the error of the field (%v)", res.Result.Message, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestBackupValidator_Handle(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        v1alpha1.BackupSpec
+		oldSpec     *v1alpha1.BackupSpec
+		objs        []runtime.Object
+		wantAllowed bool
+		wantField   string
+	}{
+		{
+			name:        "should allow the Backup of an existing Database",
+			spec:        v1alpha1.BackupSpec{Schedule: "*/15 1-5 * jan-jun mon,fri"},
+			objs:        []runtime.Object{dbInstance.DeepCopy()},
+			wantAllowed: true,
+		},
+		{
+			name:      "should reject the Backup of an unknown Database",
+			spec:      v1alpha1.BackupSpec{DatabaseCRName: "unknown"},
+			objs:      []runtime.Object{dbInstance.DeepCopy()},
+			wantField: "spec.databaseCRName",
+		},
+		{
+			name:      "should reject an invalid schedule",
+			spec:      v1alpha1.BackupSpec{Schedule: "0 25 * * *"},
+			objs:      []runtime.Object{dbInstance.DeepCopy()},
+			wantField: "spec.schedule",
+		},
+		{
+			name:      "should reject a schedule without all fields",
+			spec:      v1alpha1.BackupSpec{Schedule: "0 0 * *"},
+			objs:      []runtime.Object{dbInstance.DeepCopy()},
+			wantField: "spec.schedule",
+		},
+		{
+			name:      "should reject the incomplete GPG data",
+			spec:      v1alpha1.BackupSpec{GpgPublicKey: "key", GpgEmail: "email@example.com"},
+			objs:      []runtime.Object{dbInstance.DeepCopy()},
+			wantField: "spec.gpgTrustModel",
+		},
+		{
+			name:      "should reject the change of the Database",
+			spec:      v1alpha1.BackupSpec{DatabaseCRName: "other"},
+			oldSpec:   &v1alpha1.BackupSpec{},
+			wantField: "spec.databaseCRName",
+		},
+		{
+			name:        "should allow the update when the Database was deleted",
+			spec:        v1alpha1.BackupSpec{Schedule: "@daily"},
+			oldSpec:     &v1alpha1.BackupSpec{},
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder, s := buildDecoderAndScheme(t)
+			v := &BackupValidator{client: fake.NewFakeClientWithScheme(s, tt.objs...), decoder: decoder}
+
+			bkp := bkpInstance.DeepCopy()
+			bkp.Spec = tt.spec
+			var old runtime.Object
+			if tt.oldSpec != nil {
+				oldBkp := bkpInstance.DeepCopy()
+				oldBkp.Spec = *tt.oldSpec
+				old = oldBkp
+			}
+
+			res := v.Handle(context.TODO(), buildRequest(t, bkp, old))
+			if res.Allowed != tt.wantAllowed {
+				t.Fatalf("Handle() allowed = %v, want %v (%v)", res.Allowed, tt.wantAllowed, res.Result)
+			}
+			if tt.wantField != "" && !strings.Contains(res.Result.Message, tt.wantField) {
+				t.Errorf("Handle() message = %v, when is expected the error of the field (%v)", res.Result.Message, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestRestoreValidator_Handle(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        v1alpha1.RestoreSpec
+		oldSpec     *v1alpha1.RestoreSpec
+		wantAllowed bool
+		wantField   string
+	}{
+		{
+			name:        "should allow the target time in the RFC3339 format",
+			spec:        v1alpha1.RestoreSpec{TargetTime: "2020-07-06T15:04:05+02:00"},
+			wantAllowed: true,
+		},
+		{
+			name:        "should allow the target LSN",
+			spec:        v1alpha1.RestoreSpec{TargetLSN: "0/3000060"},
+			wantAllowed: true,
+		},
+		{
+			name:      "should reject the target time which is not RFC3339",
+			spec:      v1alpha1.RestoreSpec{TargetTime: "2020-07-06 15:04:05+00"},
+			wantField: "spec.targetTime",
+		},
+		{
+			name:      "should reject the target LSN with other lines of the recovery.conf",
+			spec:      v1alpha1.RestoreSpec{TargetLSN: "0/3000060'\nrestore_command = 'true"},
+			wantField: "spec.targetLSN",
+		},
+		{
+			name:      "should reject both targets",
+			spec:      v1alpha1.RestoreSpec{TargetTime: "2020-07-06T15:04:05Z", TargetLSN: "0/3000060"},
+			wantField: "spec.targetLSN",
+		},
+		{
+			name:        "should allow the update which does not change the spec",
+			spec:        v1alpha1.RestoreSpec{TargetTime: "2020-07-06 15:04:05+00"},
+			oldSpec:     &v1alpha1.RestoreSpec{TargetTime: "2020-07-06 15:04:05+00"},
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder, _ := buildDecoderAndScheme(t)
+			v := &RestoreValidator{decoder: decoder}
+
+			rst := rstInstance.DeepCopy()
+			rst.Spec = tt.spec
+			var old runtime.Object
+			if tt.oldSpec != nil {
+				oldRst := rstInstance.DeepCopy()
+				oldRst.Spec = *tt.oldSpec
+				old = oldRst
+			}
+
+			res := v.Handle(context.TODO(), buildRequest(t, rst, old))
+			if res.Allowed != tt.wantAllowed {
+				t.Fatalf("Handle() allowed = %v, want %v (%v)", res.Allowed, tt.wantAllowed, res.Result)
+			}
+			if tt.wantField != "" && !strings.Contains(res.Result.Message, tt.wantField) {
+				t.Errorf("Handle() message = %v, when is expected the error of the field (%v)", res.Result.Message, tt.wantField)
+			}
+		})
+	}
+}
+
+//patchValues returns the values of the operations of the patch by their path
+func patchValues(res admission.Response) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, p := range res.Patches {
+		values[p.Path] = p.Value
+	}
+	return values
+}
+
+func TestDatabaseDefaulter_Handle(t *testing.T) {
+	decoder, _ := buildDecoderAndScheme(t)
+	d := &DatabaseDefaulter{decoder: decoder}
+
+	db := dbInstance.DeepCopy()
+	db.Spec.DatabaseMemoryLimit = "1Gi"
+	db.Spec.TLS = &v1alpha1.DatabaseTLS{Enabled: true}
+	res := d.Handle(context.TODO(), buildRequest(t, db, nil))
+	if !res.Allowed {
+		t.Fatalf("Handle() is not allowed (%v)", res.Result)
+	}
+
+	values := patchValues(res)
+	expected := map[string]interface{}{
+		"/spec/image":                    "centos/postgresql-96-centos7",
+		"/spec/databaseMemoryRequest":    "128Mi",
+		"/spec/databaseStorageClassName": "standard",
+		"/spec/credentialsSecretName":    "database-credentials",
+		"/spec/tls/validityDays":         float64(365),
+	}
+	for path, value := range expected {
+		if values[path] != value {
+			t.Errorf("Patch of (%v) got (%v), want (%v)", path, values[path], value)
+		}
+	}
+	if _, ok := values["/spec/databaseMemoryLimit"]; ok {
+		t.Errorf("Patch got the databaseMemoryLimit informed in the spec (%v)", values["/spec/databaseMemoryLimit"])
+	}
+	for path := range values {
+		if !strings.HasPrefix(path, "/spec") {
+			t.Errorf("Patch got the path (%v) out of the spec", path)
+		}
+	}
+
+	// The default values of the namespace in the configuration of the operator are used
+	cfg, err := config.ParseOperatorConfig([]byte("namespaces:\n  postgresql-operator:\n    database:\n      image: postgres:12\n"))
+	if err != nil {
+		t.Fatalf("parse config: (%v)", err)
+	}
+	config.SetOperatorConfig(cfg)
+	defer config.SetOperatorConfig(config.NewOperatorConfig())
+	res = d.Handle(context.TODO(), buildRequest(t, dbInstance.DeepCopy(), nil))
+	if image := patchValues(res)["/spec/image"]; image != "postgres:12" {
+		t.Errorf("Patch of the image got (%v), when is expected the one of the namespace (postgres:12)", image)
+	}
+
+	// The Secret of the generated password is not named without the name of the Database
+	db = dbInstance.DeepCopy()
+	db.Name = ""
+	db.GenerateName = "database-"
+	res = d.Handle(context.TODO(), buildRequest(t, db, nil))
+	if _, ok := patchValues(res)["/spec/credentialsSecretName"]; ok {
+		t.Error("Patch got the credentialsSecretName when the name is generated")
+	}
+
+	// The values which can be informed by the DatabaseClass are not defaulted
+	db = dbInstance.DeepCopy()
+	db.Spec.ClassName = "small"
+	values = patchValues(d.Handle(context.TODO(), buildRequest(t, db, nil)))
+	if _, ok := values["/spec/image"]; ok {
+		t.Errorf("Patch got the image when the className is informed (%v)", values["/spec/image"])
+	}
+	if values["/spec/databaseName"] != "solution" {
+		t.Errorf("Patch of the databaseName got (%v), want (solution)", values["/spec/databaseName"])
+	}
+}
+
+func TestBackupDefaulter_Handle(t *testing.T) {
+	decoder, s := buildDecoderAndScheme(t)
+	d := &BackupDefaulter{client: fake.NewFakeClientWithScheme(s), decoder: decoder}
+
+	bkp := bkpInstance.DeepCopy()
+	bkp.Spec.Schedule = "@daily"
+	bkp.Spec.Storage = &v1alpha1.BackupStorage{}
+	res := d.Handle(context.TODO(), buildRequest(t, bkp, nil))
+	if !res.Allowed {
+		t.Fatalf("Handle() is not allowed (%v)", res.Result)
+	}
+
+	values := patchValues(res)
+	expected := map[string]interface{}{
+		"/spec/image":                   "quay.io/integreatly/backup-container:1.0.8",
+		"/spec/databaseCRName":          "database",
+		"/spec/storage/type":            "s3",
+		"/spec/storage/secretName":      "aws-backup",
+		"/spec/storage/secretNamespace": "postgresql-operator",
+		"/spec/storage/compression":     "gzip",
+	}
+	for path, value := range expected {
+		if values[path] != value {
+			t.Errorf("Patch of (%v) got (%v), want (%v)", path, values[path], value)
+		}
+	}
+	if _, ok := values["/spec/schedule"]; ok {
+		t.Errorf("Patch got the schedule informed in the spec (%v)", values["/spec/schedule"])
+	}
+
+	// The schedule of the DatabaseClass of the Database is not replaced by the default value
+	db := dbInstance.DeepCopy()
+	db.Spec.ClassName = "small"
+	d.client = fake.NewFakeClientWithScheme(s, db, classInstance.DeepCopy())
+	values = patchValues(d.Handle(context.TODO(), buildRequest(t, bkpInstance.DeepCopy(), nil)))
+	if _, ok := values["/spec/schedule"]; ok {
+		t.Errorf("Patch got the schedule of the DatabaseClass (%v)", values["/spec/schedule"])
+	}
+	if values["/spec/image"] != "quay.io/integreatly/backup-container:1.0.8" {
+		t.Errorf("Patch of the image got (%v), want (quay.io/integreatly/backup-container:1.0.8)", values["/spec/image"])
+	}
+}